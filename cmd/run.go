@@ -0,0 +1,319 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hashicorp/go-version"
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/runtime"
+	"github.com/runatlantis/atlantis/server/events/terraform"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/spf13/cobra"
+)
+
+// RunCmd runs an Atlantis command on a local repo without a VCS host and
+// prints the comment that Atlantis would have made. It's used to test
+// atlantis.yaml changes and custom workflows before pushing them.
+type RunCmd struct {
+	TerraformClientCreator TerraformClientCreator
+
+	repoDir          string
+	repoFullName     string
+	branch           string
+	baseBranch       string
+	user             string
+	pullNum          int
+	dir              string
+	workspace        string
+	project          string
+	verbose          bool
+	approvedBy       []string
+	dataDir          string
+	defaultTFVersion string
+	atlantisURL      string
+}
+
+// TerraformClientCreator creates terraform clients.
+// It's an abstraction to help us test.
+type TerraformClientCreator interface {
+	NewTerraformClient(log *logging.SimpleLogger, dataDir string, defaultTFVersion string) (TerraformClient, error)
+}
+
+// TerraformClient is what the step runners need from terraform.
+type TerraformClient interface {
+	runtime.TerraformExec
+	runtime.AsyncTFExec
+	runtime.StreamingTFExec
+	Version() *version.Version
+}
+
+// DefaultTerraformClientCreator is the concrete implementation of
+// TerraformClientCreator.
+type DefaultTerraformClientCreator struct{}
+
+// NewTerraformClient returns a client that runs the terraform binary in PATH,
+// downloading defaultTFVersion into dataDir if it isn't there.
+func (d *DefaultTerraformClientCreator) NewTerraformClient(log *logging.SimpleLogger, dataDir string, defaultTFVersion string) (TerraformClient, error) {
+	return terraform.NewClient(log, dataDir, "", defaultTFVersion, DefaultTFVersionFlag, &terraform.DefaultDownloader{})
+}
+
+// Init returns the runnable cobra command.
+func (r *RunCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "run plan|apply [flags] [-- terraform flags]",
+		Short: "Run a command on a local repo without a VCS host",
+		Long: `Run plan or apply on a local git repo as if they had been commented on a pull
+request from --branch into --base-branch, and print the comment Atlantis would
+have made.
+
+The branch's committed changes are cloned into --data-dir so uncommitted
+changes are ignored. Apply requirements that need a VCS host are evaluated
+against the local repo, ex. the pull request is only approved if
+--approved-by is set.`,
+		Args: cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			err := r.run(cmd.OutOrStdout(), args[0], args[1:])
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "\033[31mError: %s\033[39m\n", err.Error()) // nolint: errcheck
+			}
+			return err
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	c.Flags().StringVar(&r.repoDir, "repo-dir", ".", "Path to the local git repo.")
+	c.Flags().StringVar(&r.repoFullName, "repo", "", "Full name of the repo, ex. runatlantis/atlantis. Defaults to local/<name of --repo-dir>.")
+	c.Flags().StringVar(&r.branch, "branch", "", "Head branch of the pull request. Defaults to the checked out branch.")
+	c.Flags().StringVar(&r.baseBranch, "base-branch", "master", "Base branch of the pull request.")
+	c.Flags().StringVar(&r.user, "user", "", "Username of who's running the command. Defaults to $USER.")
+	c.Flags().IntVar(&r.pullNum, "pull-num", 1, "Number of the pull request.")
+	c.Flags().StringVarP(&r.dir, "dir", "d", "", "Which directory to run the command in. Relative to the root of the repo.")
+	c.Flags().StringVarP(&r.workspace, "workspace", "w", "", "Switch to this Terraform workspace before running the command.")
+	c.Flags().StringVarP(&r.project, "project", "p", "", "Which project to run the command for. Refers to the name of the project configured in atlantis.yaml.")
+	c.Flags().BoolVar(&r.verbose, "verbose", false, "Append Atlantis log to the comment.")
+	c.Flags().StringSliceVar(&r.approvedBy, "approved-by", nil, "Comma-separated users that the pull request is treated as approved by.")
+	c.Flags().StringVar(&r.dataDir, "data-dir", "~/.atlantis/run", "Path to the directory where the repo is cloned and plans and locks are kept between runs.")
+	c.Flags().StringVar(&r.defaultTFVersion, DefaultTFVersionFlag, "", "Terraform version to default to. Will download to <data-dir>/bin/terraform<version> if not in PATH.")
+	c.Flags().StringVar(&r.atlantisURL, "atlantis-url", "http://localhost:4141", "URL that Atlantis links to in its comments.")
+	return c
+}
+
+func (r *RunCmd) run(out io.Writer, cmdName string, tfFlags []string) error {
+	var name models.CommandName
+	switch cmdName {
+	case models.PlanCommand.String():
+		name = models.PlanCommand
+	case models.ApplyCommand.String():
+		name = models.ApplyCommand
+	default:
+		return fmt.Errorf("unsupported command %q, only plan and apply are supported", cmdName)
+	}
+	if r.project != "" && (r.dir != "" || r.workspace != "") {
+		return errors.New("cannot use --project at same time as --dir or --workspace")
+	}
+
+	repoDir, err := filepath.Abs(r.repoDir)
+	if err != nil {
+		return err
+	}
+	dataDir, err := homedir.Expand(r.dataDir)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir, 0700); err != nil {
+		return errors.Wrapf(err, "creating data dir %q", dataDir)
+	}
+	cmdCtx, err := r.commandContext(repoDir)
+	if err != nil {
+		return err
+	}
+
+	vcsClient := &vcs.LocalClient{RepoDir: repoDir, Approvers: r.approvedBy}
+	builder, runner, err := r.newBuilderAndRunner(cmdCtx.Log, vcsClient, dataDir)
+	if err != nil {
+		return err
+	}
+
+	commentCmd := events.NewCommentCommand(r.dir, tfFlags, name, r.verbose, r.workspace, r.project)
+	var projectCmds []models.ProjectCommandContext
+	if name == models.PlanCommand {
+		projectCmds, err = builder.BuildPlanCommands(cmdCtx, commentCmd)
+	} else {
+		projectCmds, err = builder.BuildApplyCommands(cmdCtx, commentCmd)
+	}
+
+	var result events.CommandResult
+	if err != nil {
+		result.Error = err
+	}
+	for _, projectCmd := range projectCmds {
+		if name == models.PlanCommand {
+			result.ProjectResults = append(result.ProjectResults, runner.Plan(projectCmd))
+		} else {
+			result.ProjectResults = append(result.ProjectResults, runner.Apply(projectCmd))
+		}
+	}
+
+	renderer := &events.MarkdownRenderer{}
+	comment := renderer.Render(result, name, cmdCtx.Log.History.String(), r.verbose, cmdCtx.BaseRepo.VCSHost.Type, "")
+	_, err = fmt.Fprintln(out, comment)
+	return err
+}
+
+// commandContext returns the context of the pull request from the flags and
+// the local repo.
+func (r *RunCmd) commandContext(repoDir string) (*events.CommandContext, error) {
+	branch := r.branch
+	if branch == "" {
+		var err error
+		branch, err = gitOutput(repoDir, "rev-parse", "--abbrev-ref", "HEAD")
+		if err != nil {
+			return nil, err
+		}
+	}
+	headCommit, err := gitOutput(repoDir, "rev-parse", branch)
+	if err != nil {
+		return nil, err
+	}
+	fullName := r.repoFullName
+	if fullName == "" {
+		fullName = "local/" + filepath.Base(repoDir)
+	}
+	owner := fullName
+	name := fullName
+	if i := strings.LastIndex(fullName, "/"); i != -1 {
+		owner = fullName[:i]
+		name = fullName[i+1:]
+	}
+	username := r.user
+	if username == "" {
+		username = os.Getenv("USER")
+	}
+
+	cloneURL := (&url.URL{Scheme: "file", Path: repoDir}).String()
+	repo := models.Repo{
+		FullName:          fullName,
+		Owner:             owner,
+		Name:              name,
+		CloneURL:          cloneURL,
+		SanitizedCloneURL: cloneURL,
+		VCSHost: models.VCSHost{
+			Hostname: "localhost",
+			Type:     models.Github,
+		},
+	}
+	return &events.CommandContext{
+		BaseRepo: repo,
+		HeadRepo: repo,
+		Pull: models.PullRequest{
+			Num:        r.pullNum,
+			HeadCommit: headCommit,
+			HeadBranch: branch,
+			BaseBranch: r.baseBranch,
+			Author:     username,
+			State:      models.OpenPullState,
+			BaseRepo:   repo,
+		},
+		User:          models.User{Username: username},
+		Log:           logging.NewSimpleLogger(fmt.Sprintf("%s#%d", fullName, r.pullNum), true, logging.Info),
+		PullMergeable: true,
+	}, nil
+}
+
+// newBuilderAndRunner wires up the same project command builder and runner
+// that the server uses, except with vcsClient in place of a VCS host.
+func (r *RunCmd) newBuilderAndRunner(log *logging.SimpleLogger, vcsClient *vcs.LocalClient, dataDir string) (events.ProjectCommandBuilder, events.ProjectCommandRunner, error) {
+	terraformClient, err := r.TerraformClientCreator.NewTerraformClient(log, dataDir, r.defaultTFVersion)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "initializing terraform")
+	}
+	boltdb, err := db.New(dataDir)
+	if err != nil {
+		return nil, nil, err
+	}
+	workingDir := &events.FileWorkspace{DataDir: dataDir}
+	workingDirLocker := events.NewDefaultWorkingDirLocker()
+	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient}
+	defaultTFVersion := terraformClient.Version()
+
+	builder := &events.DefaultProjectCommandBuilder{
+		ParserValidator:   &yaml.ParserValidator{},
+		ProjectFinder:     &events.DefaultProjectFinder{},
+		VCSClient:         vcsClient,
+		WorkingDir:        workingDir,
+		WorkingDirLocker:  workingDirLocker,
+		AllowRepoConfig:   true,
+		PendingPlanFinder: &events.DefaultPendingPlanFinder{},
+		CommentBuilder:    &events.CommentParser{},
+	}
+	runner := &events.DefaultProjectCommandRunner{
+		Locker:           &events.DefaultProjectLocker{Locker: locking.NewClient(boltdb)},
+		LockURLGenerator: &localLockURLGenerator{AtlantisURL: r.atlantisURL},
+		InitStepRunner: &runtime.InitStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTFVersion,
+			StreamingTFExec:   terraformClient,
+		},
+		PlanStepRunner: &runtime.PlanStepRunner{
+			TerraformExecutor:   terraformClient,
+			DefaultTFVersion:    defaultTFVersion,
+			CommitStatusUpdater: commitStatusUpdater,
+			AsyncTFExec:         terraformClient,
+			StreamingTFExec:     terraformClient,
+		},
+		ApplyStepRunner: &runtime.ApplyStepRunner{
+			TerraformExecutor:   terraformClient,
+			CommitStatusUpdater: commitStatusUpdater,
+			AsyncTFExec:         terraformClient,
+			StreamingTFExec:     terraformClient,
+		},
+		RunStepRunner: &runtime.RunStepRunner{
+			DefaultTFVersion: defaultTFVersion,
+		},
+		PullApprovedChecker: vcsClient,
+		WorkingDir:          workingDir,
+		Webhooks:            &webhooks.MultiWebhookSender{},
+		WorkingDirLocker:    workingDirLocker,
+		CodeownersChecker: &events.DefaultCodeownersChecker{
+			VCSClient: vcsClient,
+			Teams:     &events.TeamMembershipCache{VCSClient: vcsClient},
+		},
+		StatusCheckGetter: vcsClient,
+	}
+	return builder, runner, nil
+}
+
+// localLockURLGenerator generates lock URLs in the same format as the server.
+type localLockURLGenerator struct {
+	AtlantisURL string
+}
+
+// GenerateLockURL returns the URL of the lock's page on the server.
+func (l *localLockURLGenerator) GenerateLockURL(lockID string) string {
+	return fmt.Sprintf("%s/lock?id=%s", strings.TrimSuffix(l.AtlantisURL, "/"), url.QueryEscape(lockID))
+}
+
+// gitOutput runs a git command in dir and returns its trimmed output.
+func gitOutput(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...) // nolint: gosec
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), string(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
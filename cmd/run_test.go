@@ -0,0 +1,132 @@
+package cmd_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/cmd"
+	"github.com/runatlantis/atlantis/server/events/terraform"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestRunCmd_UnsupportedCommand(t *testing.T) {
+	c := (&cmd.RunCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"unlock"})
+	err := c.Execute()
+	ErrEquals(t, "unsupported command \"unlock\", only plan and apply are supported", err)
+}
+
+func TestRunCmd_ProjectWithDir(t *testing.T) {
+	c := (&cmd.RunCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"plan", "-p", "project", "-d", "dir"})
+	err := c.Execute()
+	ErrEquals(t, "cannot use --project at same time as --dir or --workspace", err)
+}
+
+func TestRunCmd_RequiresCommand(t *testing.T) {
+	c := (&cmd.RunCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{})
+	Assert(t, c.Execute() != nil, "exp error")
+}
+
+// Runs plan through the real project command builder and runner on a local
+// repo whose workflow only has run steps so terraform is never called.
+func TestRunCmd_PlanRunStepsOnly(t *testing.T) {
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	dataDir, cleanupData := TempDir(t)
+	defer cleanupData()
+
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "--local", "user.email", "atlantisbot@runatlantis.io")
+	runGit(t, repoDir, "config", "--local", "user.name", "atlantisbot")
+	runGit(t, repoDir, "commit", "--allow-empty", "-m", "initial commit")
+	runGit(t, repoDir, "branch", "-M", "master")
+	runGit(t, repoDir, "checkout", "-b", "branch")
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "main.tf"), nil, 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "atlantis.yaml"), []byte(`version: 2
+projects:
+- dir: .
+  workflow: echo
+workflows:
+  echo:
+    plan:
+      steps:
+      - run: echo "planning $WORKSPACE in $PULL_NUM"
+`), 0600))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "add project")
+
+	c := (&cmd.RunCmd{TerraformClientCreator: &fakeTerraformClientCreator{}}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"plan", "--repo-dir", repoDir, "--data-dir", dataDir, "--repo", "owner/repo", "--pull-num", "2"})
+	Ok(t, c.Execute())
+	Equals(t, `Ran Plan for dir: `+"`.`"+` workspace: `+"`default`"+`
+
+`+"```diff"+`
+planning default in 2
+
+`+"```"+`
+
+* :arrow_forward: To **apply** this plan, comment:
+    * `+"`atlantis apply -d .`"+`
+* :put_litter_in_its_place: To **delete** this plan click [here](http://localhost:4141/lock?id=owner%2Frepo%2F.%2Fdefault)
+* :repeat: To **plan** this project again, comment:
+    * `+"`atlantis plan -d .`"+`
+
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * `+"`atlantis apply`"+`
+
+`, out.String())
+}
+
+// fakeTerraformClientCreator creates clients that never run terraform.
+type fakeTerraformClientCreator struct{}
+
+func (f *fakeTerraformClientCreator) NewTerraformClient(_ *logging.SimpleLogger, _ string, _ string) (cmd.TerraformClient, error) {
+	return &fakeTerraformClient{}, nil
+}
+
+type fakeTerraformClient struct{}
+
+func (f *fakeTerraformClient) Version() *version.Version {
+	return version.Must(version.NewVersion("0.11.10"))
+}
+
+func (f *fakeTerraformClient) RunCommandWithVersion(_ *logging.SimpleLogger, _ string, _ []string, _ *version.Version, _ string) (string, error) {
+	return "", errors.New("terraform should not be run")
+}
+
+func (f *fakeTerraformClient) RunCommandWithOutput(_ *logging.SimpleLogger, _ string, _ []string, _ *version.Version, _ string, _ io.Writer) (string, error) {
+	return "", errors.New("terraform should not be run")
+}
+
+func (f *fakeTerraformClient) RunCommandAsync(_ *logging.SimpleLogger, _ string, _ []string, _ *version.Version, _ string) (chan<- string, <-chan terraform.Line) {
+	out := make(chan terraform.Line, 1)
+	out <- terraform.Line{Err: errors.New("terraform should not be run")}
+	close(out)
+	return make(chan string), out
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	c := exec.Command("git", args...)
+	c.Dir = dir
+	out, err := c.CombinedOutput()
+	Assert(t, err == nil, "running git %v: %s", args, out)
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -36,52 +37,123 @@ import (
 // 3. Add your flag's description etc. to the stringFlags, intFlags, or boolFlags slices.
 const (
 	// Flag names.
-	AllowForkPRsFlag           = "allow-fork-prs"
-	AllowRepoConfigFlag        = "allow-repo-config"
-	AtlantisURLFlag            = "atlantis-url"
-	AutomergeFlag              = "automerge"
-	BitbucketBaseURLFlag       = "bitbucket-base-url"
-	BitbucketTokenFlag         = "bitbucket-token"
-	BitbucketUserFlag          = "bitbucket-user"
-	BitbucketWebhookSecretFlag = "bitbucket-webhook-secret"
-	ConfigFlag                 = "config"
-	CheckoutStrategyFlag       = "checkout-strategy"
-	DataDirFlag                = "data-dir"
-	DefaultTFVersionFlag       = "default-tf-version"
-	GHHostnameFlag             = "gh-hostname"
-	GHTokenFlag                = "gh-token"
-	GHUserFlag                 = "gh-user"
-	GHWebhookSecretFlag        = "gh-webhook-secret" // nolint: gosec
-	GitlabHostnameFlag         = "gitlab-hostname"
-	GitlabTokenFlag            = "gitlab-token"
-	GitlabUserFlag             = "gitlab-user"
-	GitlabWebhookSecretFlag    = "gitlab-webhook-secret" // nolint: gosec
-	LogLevelFlag               = "log-level"
-	PortFlag                   = "port"
-	RepoWhitelistFlag          = "repo-whitelist"
-	RequireApprovalFlag        = "require-approval"
-	RequireMergeableFlag       = "require-mergeable"
-	SilenceWhitelistErrorsFlag = "silence-whitelist-errors"
-	SlackTokenFlag             = "slack-token"
-	SSLCertFileFlag            = "ssl-cert-file"
-	SSLKeyFileFlag             = "ssl-key-file"
-	TFETokenFlag               = "tfe-token"
+	AllowForkPRsFlag               = "allow-fork-prs"
+	AllowRepoConfigFlag            = "allow-repo-config"
+	APIUserFlag                    = "api-user"
+	ApplyMinPermissionFlag         = "apply-min-permission"
+	ApplyWindowOverrideUsersFlag   = "apply-window-override-users"
+	AtlantisURLFlag                = "atlantis-url"
+	AutomergeFlag                  = "automerge"
+	AutoplanIgnoreFlag             = "autoplan-ignore"
+	AutoplanModulesFlag            = "autoplan-modules"
+	AutoplanVerboseFlag            = "autoplan-verbose"
+	AzureDevopsBaseURLFlag         = "azuredevops-base-url"
+	AzureDevopsTokenFlag           = "azuredevops-token"
+	AzureDevopsUserFlag            = "azuredevops-user"
+	AzureDevopsWebhookPasswordFlag = "azuredevops-webhook-password" // nolint: gosec
+	AzureDevopsWebhookUserFlag     = "azuredevops-webhook-user"
+	BitbucketBaseURLFlag           = "bitbucket-base-url"
+	BitbucketTokenFlag             = "bitbucket-token"
+	BitbucketUserFlag              = "bitbucket-user"
+	BitbucketWebhookSecretFlag     = "bitbucket-webhook-secret"
+	ConfigFlag                     = "config"
+	CheckoutStrategyFlag           = "checkout-strategy"
+	DataDirFlag                    = "data-dir"
+	DefaultTFVersionFlag           = "default-tf-version"
+	DenyUnmatchedAppliesFlag       = "deny-unmatched-applies"
+	GHHostnameFlag                 = "gh-hostname"
+	GHTokenFlag                    = "gh-token"
+	GHUserFlag                     = "gh-user"
+	GHWebhookSecretFlag            = "gh-webhook-secret" // nolint: gosec
+	GitlabHostnameFlag             = "gitlab-hostname"
+	GitlabTokenFlag                = "gitlab-token"
+	GitlabUserFlag                 = "gitlab-user"
+	GitlabWebhookSecretFlag        = "gitlab-webhook-secret" // nolint: gosec
+	JobOutputRetentionDaysFlag     = "job-output-retention-days"
+	LogLevelFlag                   = "log-level"
+	MaxCommentOutputCharsFlag      = "max-comment-output-chars"
+	PlanMinPermissionFlag          = "plan-min-permission"
+	PortFlag                       = "port"
+	ReadyzMinFreeDiskMBFlag        = "readyz-min-free-disk-mb"
+	RepoConfigFlag                 = "repo-config"
+	RepoWhitelistFlag              = "repo-whitelist"
+	RequireApprovalFlag            = "require-approval"
+	RequireMergeableFlag           = "require-mergeable"
+	SilenceWhitelistErrorsFlag     = "silence-whitelist-errors"
+	SlackTokenFlag                 = "slack-token"
+	SSLCertFileFlag                = "ssl-cert-file"
+	SSLKeyFileFlag                 = "ssl-key-file"
+	TeamCacheTTLMinutesFlag        = "team-cache-ttl-minutes"
+	TFETokenFlag                   = "tfe-token"
 
 	// Flag defaults.
+	DefaultAPIUser          = "atlantis-api"
 	DefaultCheckoutStrategy = "branch"
 	DefaultBitbucketBaseURL = bitbucketcloud.BaseURL
 	DefaultDataDir          = "~/.atlantis"
 	DefaultGHHostname       = "github.com"
 	DefaultGitlabHostname   = "gitlab.com"
+	DefaultJobRetentionDays = 30
 	DefaultLogLevel         = "info"
+	DefaultMinPermission    = "none"
 	DefaultPort             = 4141
+	DefaultReadyzMinFreeMB  = 100
+	DefaultTeamCacheTTL     = 5
 )
 
 var stringFlags = []stringFlag{
+	{
+		name: APIUserFlag,
+		description: "Username that commands run via the API are run as." +
+			" It's recorded as the owner of any locks those commands create." +
+			" Commands can only be run via the API on GitHub and GitLab pull requests.",
+		defaultValue: DefaultAPIUser,
+	},
+	{
+		name: ApplyMinPermissionFlag,
+		description: "Minimum permission on a repo that users need to run apply by commenting on its pull requests." +
+			" One of 'none', 'read', 'write' or 'admin'. If 'none', anyone who can comment can run apply." +
+			" On Bitbucket, other levels need the Atlantis user to be an admin of the repo.",
+		defaultValue: DefaultMinPermission,
+	},
+	{
+		name: ApplyWindowOverrideUsersFlag,
+		description: "Comma-separated list of users who can apply outside of apply windows by commenting with --override-apply-window," +
+			" ex. 'alice,bob'. Apply windows are configured in atlantis.yaml files or the server's default-apply-windows config.",
+	},
 	{
 		name:        AtlantisURLFlag,
 		description: "URL that Atlantis can be reached at. Defaults to http://$(hostname):$port where $port is from --" + PortFlag + ". Supports a base path ex. https://example.com/basepath.",
 	},
+	{
+		name: AutoplanIgnoreFlag,
+		description: "Comma-separated patterns of files, relative to the repo root, whose changes never trigger autoplans, ex. '**/*.md,docs/**'." +
+			" Patterns are evaluated in order and ones starting with ! un-ignore files. Applies whether or not repos have atlantis.yaml files.",
+	},
+	{
+		name: AzureDevopsBaseURLFlag,
+		description: "Collection URL of Azure DevOps Server, ex. 'https://azuredevops.example.com/DefaultCollection'." +
+			" It's used to check that the personal access token works and only repos under it are accepted. If using Azure DevOps (dev.azure.com), do not set.",
+	},
+	{
+		name:        AzureDevopsUserFlag,
+		description: "Azure DevOps username of API user, ex. 'atlantis@example.com'.",
+	},
+	{
+		name:        AzureDevopsTokenFlag,
+		description: "Azure DevOps personal access token of API user. Can also be specified via the ATLANTIS_AZUREDEVOPS_TOKEN environment variable.",
+	},
+	{
+		name:        AzureDevopsWebhookUserFlag,
+		description: "Basic auth username that Azure DevOps service hooks are configured to send.",
+	},
+	{
+		name: AzureDevopsWebhookPasswordFlag,
+		description: "Basic auth password that Azure DevOps service hooks are configured to send." +
+			" SECURITY WARNING: If not specified, Atlantis won't be able to validate that the incoming webhook call came from Azure DevOps. " +
+			"This means that an attacker could spoof calls to Atlantis and cause it to perform malicious actions. " +
+			"Should be specified via the ATLANTIS_AZUREDEVOPS_WEBHOOK_PASSWORD environment variable.",
+	},
 	{
 		name:        BitbucketUserFlag,
 		description: "Bitbucket username of API user.",
@@ -168,12 +240,27 @@ var stringFlags = []stringFlag{
 		description:  "Log level. Either debug, info, warn, or error.",
 		defaultValue: DefaultLogLevel,
 	},
+	{
+		name: PlanMinPermissionFlag,
+		description: "Minimum permission on a repo that users need to run plan by commenting on its pull requests." +
+			" One of 'none', 'read', 'write' or 'admin'. If 'none', anyone who can comment can run plan," +
+			" which can run arbitrary code on the Atlantis server. On Bitbucket, other levels need the Atlantis user" +
+			" to be an admin of the repo.",
+		defaultValue: DefaultMinPermission,
+	},
+	{
+		name: RepoConfigFlag,
+		description: "Path to a YAML file with server-side repo config: defaults for the workflow, apply requirements and" +
+			" Terraform version of repos' projects and which of them the repos' atlantis.yaml files can override." +
+			fmt.Sprintf(" Repos can only use atlantis.yaml files if --%s is also set.", AllowRepoConfigFlag),
+	},
 	{
 		name: RepoWhitelistFlag,
 		description: "Comma separated list of repositories that Atlantis will operate on. " +
 			"The format is {hostname}/{owner}/{repo}, ex. github.com/runatlantis/atlantis. '*' matches any characters until the next comma and can be used for example to whitelist " +
 			"all repos: '*' (not recommended), an entire hostname: 'internalgithub.com/*' or an organization: 'github.com/runatlantis/*'." +
-			" For Bitbucket Server, {hostname} is the domain without scheme and port, {owner} is the name of the project (not the key), and {repo} is the repo name.",
+			" For Bitbucket Server, {hostname} is the domain without scheme and port, {owner} is the name of the project (not the key), and {repo} is the repo name." +
+			" For Azure DevOps, {hostname} is dev.azure.com and {owner} is the organization and project, ex. dev.azure.com/myorg/myproject/myrepo.",
 	},
 	{
 		name:        SlackTokenFlag,
@@ -217,6 +304,23 @@ var boolFlags = []boolFlag{
 		description:  "Automatically merge pull requests when all plans are successfully applied.",
 		defaultValue: false,
 	},
+	{
+		name: AutoplanModulesFlag,
+		description: "Also autoplan projects that use a modified module through a local source, ex. source = \"../modules/vpc\"." +
+			" Modules used by other modules are followed.",
+		defaultValue: false,
+	},
+	{
+		name:         AutoplanVerboseFlag,
+		description:  "Explain in autoplan comments which modified file matched which when_modified pattern of each planned project.",
+		defaultValue: false,
+	},
+	{
+		name: DenyUnmatchedAppliesFlag,
+		description: "Deny applies of projects that no allowed-appliers rule in the config file covers." +
+			" By default, anyone can apply them.",
+		defaultValue: false,
+	},
 	{
 		name:         RequireApprovalFlag,
 		description:  "Require pull requests to be \"Approved\" before allowing the apply command to be run.",
@@ -234,11 +338,34 @@ var boolFlags = []boolFlag{
 	},
 }
 var intFlags = []intFlag{
+	{
+		name: JobOutputRetentionDaysFlag,
+		description: "Number of days to keep the full output of each command for." +
+			" The output is stored in the data dir and can be viewed in the UI. Set to 0 to keep it forever.",
+		defaultValue: DefaultJobRetentionDays,
+	},
+	{
+		name: MaxCommentOutputCharsFlag,
+		description: "Maximum number of characters of a project's output to include in a comment." +
+			" Longer output is truncated with a link to the full output in the UI." +
+			" Defaults to a limit based on the VCS host's maximum comment size.",
+	},
 	{
 		name:         PortFlag,
 		description:  "Port to bind to.",
 		defaultValue: DefaultPort,
 	},
+	{
+		name:         ReadyzMinFreeDiskMBFlag,
+		description:  "Minimum free disk space in megabytes in the data dir for /readyz to report Atlantis as ready.",
+		defaultValue: DefaultReadyzMinFreeMB,
+	},
+	{
+		name: TeamCacheTTLMinutesFlag,
+		description: "Number of minutes to cache VCS team and group membership lookups for." +
+			" Memberships are used to check who can apply projects with allowed appliers.",
+		defaultValue: DefaultTeamCacheTTL,
+	},
 }
 
 type stringFlag struct {
@@ -341,6 +468,11 @@ func (s *ServerCmd) Init() *cobra.Command {
 		}
 		c.Flags().Int(f.name, 0, usage+"\n")
 		s.Viper.BindPFlag(f.name, c.Flags().Lookup(f.name)) // nolint: errcheck
+		// The default is set in Viper so an explicit 0 can be told apart from
+		// the flag not being set, ex. for --job-output-retention-days.
+		if f.defaultValue != 0 {
+			s.Viper.SetDefault(f.name, f.defaultValue)
+		}
 	}
 
 	// Set bool flags.
@@ -365,27 +497,15 @@ func (s *ServerCmd) preRun() error {
 }
 
 func (s *ServerCmd) run() error {
-	var userConfig server.UserConfig
-	if err := s.Viper.Unmarshal(&userConfig); err != nil {
+	userConfig, err := s.loadUserConfig()
+	if err != nil {
 		return err
 	}
-	s.setDefaults(&userConfig)
 
 	// Now that we've parsed the config we can set our local logger to the
 	// right level.
 	s.Logger.SetLevel(userConfig.ToLogLevel())
-
-	if err := s.validate(userConfig); err != nil {
-		return err
-	}
-	if err := s.setAtlantisURL(&userConfig); err != nil {
-		return err
-	}
-	if err := s.setDataDir(&userConfig); err != nil {
-		return err
-	}
 	s.securityWarnings(&userConfig)
-	s.trimAtSymbolFromUsers(&userConfig)
 
 	// Config looks good. Start the server.
 	server, err := s.ServerCreator.NewServer(userConfig, server.Config{
@@ -394,6 +514,7 @@ func (s *ServerCmd) run() error {
 		AtlantisURLFlag:      AtlantisURLFlag,
 		AtlantisVersion:      s.AtlantisVersion,
 		DefaultTFVersionFlag: DefaultTFVersionFlag,
+		LoadUserConfig:       s.reloadUserConfig,
 	})
 	if err != nil {
 		return errors.Wrap(err, "initializing server")
@@ -401,7 +522,40 @@ func (s *ServerCmd) run() error {
 	return server.Start()
 }
 
+// loadUserConfig parses and validates the config from flags, env vars and
+// the config file.
+func (s *ServerCmd) loadUserConfig() (server.UserConfig, error) {
+	var userConfig server.UserConfig
+	if err := s.Viper.Unmarshal(&userConfig); err != nil {
+		return userConfig, err
+	}
+	s.setDefaults(&userConfig)
+	if err := s.validate(userConfig); err != nil {
+		return userConfig, err
+	}
+	if err := s.setAtlantisURL(&userConfig); err != nil {
+		return userConfig, err
+	}
+	if err := s.setDataDir(&userConfig); err != nil {
+		return userConfig, err
+	}
+	s.trimAtSymbolFromUsers(&userConfig)
+	return userConfig, nil
+}
+
+// reloadUserConfig re-reads the config file and returns the new config. It's
+// used by the server to reload its config while it's running.
+func (s *ServerCmd) reloadUserConfig() (server.UserConfig, error) {
+	if err := s.preRun(); err != nil {
+		return server.UserConfig{}, err
+	}
+	return s.loadUserConfig()
+}
+
 func (s *ServerCmd) setDefaults(c *server.UserConfig) {
+	if c.APIUser == "" {
+		c.APIUser = DefaultAPIUser
+	}
 	if c.CheckoutStrategy == "" {
 		c.CheckoutStrategy = DefaultCheckoutStrategy
 	}
@@ -420,9 +574,21 @@ func (s *ServerCmd) setDefaults(c *server.UserConfig) {
 	if c.LogLevel == "" {
 		c.LogLevel = DefaultLogLevel
 	}
+	if c.ApplyMinPermission == "" {
+		c.ApplyMinPermission = DefaultMinPermission
+	}
+	if c.PlanMinPermission == "" {
+		c.PlanMinPermission = DefaultMinPermission
+	}
 	if c.Port == 0 {
 		c.Port = DefaultPort
 	}
+	if c.ReadyzMinFreeDiskMB == 0 {
+		c.ReadyzMinFreeDiskMB = DefaultReadyzMinFreeMB
+	}
+	if c.TeamCacheTTLMinutes == 0 {
+		c.TeamCacheTTLMinutes = DefaultTeamCacheTTL
+	}
 }
 
 func (s *ServerCmd) validate(userConfig server.UserConfig) error {
@@ -435,6 +601,36 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return errors.New("invalid checkout strategy: not one of branch or merge")
 	}
 
+	if _, err := models.ParsePermissionLevel(userConfig.ApplyMinPermission); err != nil {
+		return errors.Wrapf(err, "invalid --%s", ApplyMinPermissionFlag)
+	}
+	if _, err := models.ParsePermissionLevel(userConfig.PlanMinPermission); err != nil {
+		return errors.Wrapf(err, "invalid --%s", PlanMinPermissionFlag)
+	}
+
+	for _, pattern := range strings.Split(userConfig.AutoplanIgnore, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "!" {
+			return fmt.Errorf("invalid --%s: %q is not a valid pattern: nothing to un-ignore", AutoplanIgnoreFlag, pattern)
+		}
+		if _, err := filepath.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return fmt.Errorf("invalid --%s: %q is not a valid pattern", AutoplanIgnoreFlag, pattern)
+		}
+	}
+
+	if userConfig.ReadyzMinFreeDiskMB < 0 {
+		return fmt.Errorf("--%s cannot be negative", ReadyzMinFreeDiskMBFlag)
+	}
+	if userConfig.JobOutputRetentionDays < 0 {
+		return fmt.Errorf("--%s cannot be negative", JobOutputRetentionDaysFlag)
+	}
+	if userConfig.MaxCommentOutputChars < 0 {
+		return fmt.Errorf("--%s cannot be negative", MaxCommentOutputCharsFlag)
+	}
+	if userConfig.TeamCacheTTLMinutes < 0 {
+		return fmt.Errorf("--%s cannot be negative", TeamCacheTTLMinutesFlag)
+	}
+
 	if (userConfig.SSLKeyFile == "") != (userConfig.SSLCertFile == "") {
 		return fmt.Errorf("--%s and --%s are both required for ssl", SSLKeyFileFlag, SSLCertFileFlag)
 	}
@@ -443,14 +639,15 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 	// 1. github user and token set
 	// 2. gitlab user and token set
 	// 3. bitbucket user and token set
-	// 4. any combination of the above
-	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag)
-	if ((userConfig.GithubUser == "") != (userConfig.GithubToken == "")) || ((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) || ((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "")) {
+	// 4. azure devops user and token set
+	// 5. any combination of the above
+	vcsErr := fmt.Errorf("--%s/--%s or --%s/--%s or --%s/--%s or --%s/--%s must be set", GHUserFlag, GHTokenFlag, GitlabUserFlag, GitlabTokenFlag, BitbucketUserFlag, BitbucketTokenFlag, AzureDevopsUserFlag, AzureDevopsTokenFlag)
+	if ((userConfig.GithubUser == "") != (userConfig.GithubToken == "")) || ((userConfig.GitlabUser == "") != (userConfig.GitlabToken == "")) || ((userConfig.BitbucketUser == "") != (userConfig.BitbucketToken == "")) || ((userConfig.AzureDevopsUser == "") != (userConfig.AzureDevopsToken == "")) {
 		return vcsErr
 	}
 	// At this point, we know that there can't be a single user/token without
 	// its partner, but we haven't checked if any user/token is set at all.
-	if userConfig.GithubUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" {
+	if userConfig.GithubUser == "" && userConfig.GitlabUser == "" && userConfig.BitbucketUser == "" && userConfig.AzureDevopsUser == "" {
 		return vcsErr
 	}
 
@@ -461,6 +658,20 @@ func (s *ServerCmd) validate(userConfig server.UserConfig) error {
 		return fmt.Errorf("--%s cannot contain ://, should be hostnames only", RepoWhitelistFlag)
 	}
 
+	if (userConfig.AzureDevopsWebhookUser == "") != (userConfig.AzureDevopsWebhookPassword == "") {
+		return fmt.Errorf("--%s and --%s must be set together", AzureDevopsWebhookUserFlag, AzureDevopsWebhookPasswordFlag)
+	}
+
+	if userConfig.AzureDevopsBaseURL != "" {
+		parsed, err := url.Parse(userConfig.AzureDevopsBaseURL)
+		if err != nil {
+			return fmt.Errorf("error parsing --%s flag value %q: %s", AzureDevopsBaseURLFlag, userConfig.AzureDevopsBaseURL, err)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return fmt.Errorf("--%s must have http:// or https://, got %q", AzureDevopsBaseURLFlag, userConfig.AzureDevopsBaseURL)
+		}
+	}
+
 	if userConfig.BitbucketBaseURL == DefaultBitbucketBaseURL && userConfig.BitbucketWebhookSecret != "" {
 		return fmt.Errorf("--%s cannot be specified for Bitbucket Cloud because it is not supported by Bitbucket", BitbucketWebhookSecretFlag)
 	}
@@ -516,6 +727,7 @@ func (s *ServerCmd) trimAtSymbolFromUsers(userConfig *server.UserConfig) {
 	userConfig.GithubUser = strings.TrimPrefix(userConfig.GithubUser, "@")
 	userConfig.GitlabUser = strings.TrimPrefix(userConfig.GitlabUser, "@")
 	userConfig.BitbucketUser = strings.TrimPrefix(userConfig.BitbucketUser, "@")
+	userConfig.AzureDevopsUser = strings.TrimPrefix(userConfig.AzureDevopsUser, "@")
 }
 
 func (s *ServerCmd) securityWarnings(userConfig *server.UserConfig) {
@@ -531,6 +743,9 @@ func (s *ServerCmd) securityWarnings(userConfig *server.UserConfig) {
 	if userConfig.BitbucketUser != "" && userConfig.BitbucketBaseURL == DefaultBitbucketBaseURL && !s.SilenceOutput {
 		s.Logger.Warn("Bitbucket Cloud does not support webhook secrets. This could allow attackers to spoof requests from Bitbucket. Ensure you are whitelisting Bitbucket IPs")
 	}
+	if userConfig.AzureDevopsUser != "" && userConfig.AzureDevopsWebhookPassword == "" && !s.SilenceOutput {
+		s.Logger.Warn("no Azure DevOps webhook user and password set. This could allow attackers to spoof requests from Azure DevOps")
+	}
 }
 
 // withErrPrint prints out any cmd errors to stderr.
@@ -135,6 +135,72 @@ func TestExecute_ValidateCheckoutStrategy(t *testing.T) {
 	ErrEquals(t, "invalid checkout strategy: not one of branch or merge", err)
 }
 
+func TestExecute_ValidateMinPermission(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.PlanMinPermissionFlag: "maintain",
+	})
+	err := c.Execute()
+	ErrEquals(t, "invalid --plan-min-permission: invalid permission level \"maintain\": not one of none, read, write or admin", err)
+}
+
+func TestExecute_ValidateReadyzMinFreeDiskMB(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.ReadyzMinFreeDiskMBFlag: -1,
+	})
+	err := c.Execute()
+	ErrEquals(t, "--readyz-min-free-disk-mb cannot be negative", err)
+}
+
+func TestExecute_ValidateAutoplanIgnore(t *testing.T) {
+	cases := map[string]string{
+		"*.md,[":  `invalid --autoplan-ignore: "[" is not a valid pattern`,
+		"*.md, !": `invalid --autoplan-ignore: "!" is not a valid pattern: nothing to un-ignore`,
+	}
+	for patterns, expErr := range cases {
+		t.Run(patterns, func(t *testing.T) {
+			c := setupWithDefaults(map[string]interface{}{
+				cmd.AutoplanIgnoreFlag: patterns,
+			})
+			err := c.Execute()
+			ErrEquals(t, expErr, err)
+		})
+	}
+}
+
+func TestExecute_ValidateJobOutputRetentionDays(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.JobOutputRetentionDaysFlag: -1,
+	})
+	err := c.Execute()
+	ErrEquals(t, "--job-output-retention-days cannot be negative", err)
+}
+
+func TestExecute_JobOutputRetentionDaysZero(t *testing.T) {
+	t.Log("an explicit 0 should keep output forever instead of using the default")
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.JobOutputRetentionDaysFlag: 0,
+	})
+	err := c.Execute()
+	Ok(t, err)
+	Equals(t, 0, passedConfig.JobOutputRetentionDays)
+}
+
+func TestExecute_ValidateTeamCacheTTLMinutes(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.TeamCacheTTLMinutesFlag: -1,
+	})
+	err := c.Execute()
+	ErrEquals(t, "--team-cache-ttl-minutes cannot be negative", err)
+}
+
+func TestExecute_ValidateMaxCommentOutputChars(t *testing.T) {
+	c := setupWithDefaults(map[string]interface{}{
+		cmd.MaxCommentOutputCharsFlag: -1,
+	})
+	err := c.Execute()
+	ErrEquals(t, "--max-comment-output-chars cannot be negative", err)
+}
+
 func TestExecute_ValidateSSLConfig(t *testing.T) {
 	expErr := "--ssl-key-file and --ssl-cert-file are both required for ssl"
 	cases := []struct {
@@ -184,7 +250,7 @@ func TestExecute_ValidateSSLConfig(t *testing.T) {
 }
 
 func TestExecute_ValidateVCSConfig(t *testing.T) {
-	expErr := "--gh-user/--gh-token or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token must be set"
+	expErr := "--gh-user/--gh-token or --gitlab-user/--gitlab-token or --bitbucket-user/--bitbucket-token or --azuredevops-user/--azuredevops-token must be set"
 	cases := []struct {
 		description string
 		flags       map[string]interface{}
@@ -216,6 +282,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just azure devops token set",
+			map[string]interface{}{
+				cmd.AzureDevopsTokenFlag: "token",
+			},
+			true,
+		},
 		{
 			"just github user set",
 			map[string]interface{}{
@@ -237,6 +310,13 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			true,
 		},
+		{
+			"just azure devops user set",
+			map[string]interface{}{
+				cmd.AzureDevopsUserFlag: "user",
+			},
+			true,
+		},
 		{
 			"github user and gitlab token set",
 			map[string]interface{}{
@@ -285,15 +365,25 @@ func TestExecute_ValidateVCSConfig(t *testing.T) {
 			},
 			false,
 		},
+		{
+			"azure devops user and azure devops token set and should be successful",
+			map[string]interface{}{
+				cmd.AzureDevopsUserFlag:  "user",
+				cmd.AzureDevopsTokenFlag: "token",
+			},
+			false,
+		},
 		{
 			"all set should be successful",
 			map[string]interface{}{
-				cmd.GHUserFlag:         "user",
-				cmd.GHTokenFlag:        "token",
-				cmd.GitlabUserFlag:     "user",
-				cmd.GitlabTokenFlag:    "token",
-				cmd.BitbucketUserFlag:  "user",
-				cmd.BitbucketTokenFlag: "token",
+				cmd.GHUserFlag:           "user",
+				cmd.GHTokenFlag:          "token",
+				cmd.GitlabUserFlag:       "user",
+				cmd.GitlabTokenFlag:      "token",
+				cmd.BitbucketUserFlag:    "user",
+				cmd.BitbucketTokenFlag:   "token",
+				cmd.AzureDevopsUserFlag:  "user",
+				cmd.AzureDevopsTokenFlag: "token",
 			},
 			false,
 		},
@@ -333,6 +423,8 @@ func TestExecute_Defaults(t *testing.T) {
 	Equals(t, "http://"+hostname+":4141", passedConfig.AtlantisURL)
 	Equals(t, false, passedConfig.AllowForkPRs)
 	Equals(t, false, passedConfig.AllowRepoConfig)
+	Equals(t, "atlantis-api", passedConfig.APIUser)
+	Equals(t, "", passedConfig.ApplyWindowOverrideUsers)
 	Equals(t, false, passedConfig.Automerge)
 
 	// Get our home dir since that's what gets defaulted to
@@ -342,6 +434,7 @@ func TestExecute_Defaults(t *testing.T) {
 
 	Equals(t, "branch", passedConfig.CheckoutStrategy)
 	Equals(t, "", passedConfig.DefaultTFVersion)
+	Equals(t, false, passedConfig.DenyUnmatchedApplies)
 	Equals(t, "github.com", passedConfig.GithubHostname)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "user", passedConfig.GithubUser)
@@ -354,13 +447,20 @@ func TestExecute_Defaults(t *testing.T) {
 	Equals(t, "bitbucket-token", passedConfig.BitbucketToken)
 	Equals(t, "bitbucket-user", passedConfig.BitbucketUser)
 	Equals(t, "", passedConfig.BitbucketWebhookSecret)
+	Equals(t, 30, passedConfig.JobOutputRetentionDays)
 	Equals(t, "info", passedConfig.LogLevel)
+	Equals(t, 0, passedConfig.MaxCommentOutputChars)
+	Equals(t, "none", passedConfig.PlanMinPermission)
+	Equals(t, "none", passedConfig.ApplyMinPermission)
 	Equals(t, 4141, passedConfig.Port)
+	Equals(t, 100, passedConfig.ReadyzMinFreeDiskMB)
+	Equals(t, "", passedConfig.RepoConfig)
 	Equals(t, false, passedConfig.RequireApproval)
 	Equals(t, false, passedConfig.RequireMergeable)
 	Equals(t, "", passedConfig.SlackToken)
 	Equals(t, "", passedConfig.SSLCertFile)
 	Equals(t, "", passedConfig.SSLKeyFile)
+	Equals(t, 5, passedConfig.TeamCacheTTLMinutes)
 	Equals(t, "", passedConfig.TFEToken)
 }
 
@@ -434,37 +534,80 @@ func TestExecute_BitbucketUser(t *testing.T) {
 	Equals(t, "user", passedConfig.BitbucketUser)
 }
 
+func TestExecute_AzureDevopsUser(t *testing.T) {
+	t.Log("Should remove the @ from the azure devops username if it's passed.")
+	c := setup(map[string]interface{}{
+		cmd.AzureDevopsUserFlag:  "@user",
+		cmd.AzureDevopsTokenFlag: "token",
+		cmd.RepoWhitelistFlag:    "*",
+	})
+	err := c.Execute()
+	Ok(t, err)
+
+	Equals(t, "user", passedConfig.AzureDevopsUser)
+}
+
+func TestExecute_AzureDevopsWebhookAuth(t *testing.T) {
+	t.Log("Should error if only one of the azure devops webhook user and password is set.")
+	c := setup(map[string]interface{}{
+		cmd.AzureDevopsUserFlag:        "user",
+		cmd.AzureDevopsTokenFlag:       "token",
+		cmd.AzureDevopsWebhookUserFlag: "webhook-user",
+		cmd.RepoWhitelistFlag:          "*",
+	})
+	err := c.Execute()
+	ErrEquals(t, "--azuredevops-webhook-user and --azuredevops-webhook-password must be set together", err)
+}
+
 func TestExecute_Flags(t *testing.T) {
 	t.Log("Should use all flags that are set.")
 	c := setup(map[string]interface{}{
-		cmd.AtlantisURLFlag:            "url",
-		cmd.AllowForkPRsFlag:           true,
-		cmd.AllowRepoConfigFlag:        true,
-		cmd.AutomergeFlag:              true,
-		cmd.BitbucketBaseURLFlag:       "https://bitbucket-base-url.com",
-		cmd.BitbucketTokenFlag:         "bitbucket-token",
-		cmd.BitbucketUserFlag:          "bitbucket-user",
-		cmd.BitbucketWebhookSecretFlag: "bitbucket-secret",
-		cmd.CheckoutStrategyFlag:       "merge",
-		cmd.DataDirFlag:                "/path",
-		cmd.DefaultTFVersionFlag:       "v0.11.0",
-		cmd.GHHostnameFlag:             "ghhostname",
-		cmd.GHTokenFlag:                "token",
-		cmd.GHUserFlag:                 "user",
-		cmd.GHWebhookSecretFlag:        "secret",
-		cmd.GitlabHostnameFlag:         "gitlab-hostname",
-		cmd.GitlabTokenFlag:            "gitlab-token",
-		cmd.GitlabUserFlag:             "gitlab-user",
-		cmd.GitlabWebhookSecretFlag:    "gitlab-secret",
-		cmd.LogLevelFlag:               "debug",
-		cmd.PortFlag:                   8181,
-		cmd.RepoWhitelistFlag:          "github.com/runatlantis/atlantis",
-		cmd.RequireApprovalFlag:        true,
-		cmd.RequireMergeableFlag:       true,
-		cmd.SlackTokenFlag:             "slack-token",
-		cmd.SSLCertFileFlag:            "cert-file",
-		cmd.SSLKeyFileFlag:             "key-file",
-		cmd.TFETokenFlag:               "my-token",
+		cmd.AtlantisURLFlag:                "url",
+		cmd.AllowForkPRsFlag:               true,
+		cmd.AllowRepoConfigFlag:            true,
+		cmd.APIUserFlag:                    "api-user",
+		cmd.ApplyWindowOverrideUsersFlag:   "alice,bob",
+		cmd.AutomergeFlag:                  true,
+		cmd.AutoplanIgnoreFlag:             "**/*.md,!README.md",
+		cmd.AutoplanModulesFlag:            true,
+		cmd.AutoplanVerboseFlag:            true,
+		cmd.AzureDevopsTokenFlag:           "azuredevops-token",
+		cmd.AzureDevopsBaseURLFlag:         "https://azuredevops-base-url.com",
+		cmd.AzureDevopsUserFlag:            "azuredevops-user",
+		cmd.AzureDevopsWebhookPasswordFlag: "azuredevops-webhook-password",
+		cmd.AzureDevopsWebhookUserFlag:     "azuredevops-webhook-user",
+		cmd.BitbucketBaseURLFlag:           "https://bitbucket-base-url.com",
+		cmd.BitbucketTokenFlag:             "bitbucket-token",
+		cmd.BitbucketUserFlag:              "bitbucket-user",
+		cmd.BitbucketWebhookSecretFlag:     "bitbucket-secret",
+		cmd.CheckoutStrategyFlag:           "merge",
+		cmd.DataDirFlag:                    "/path",
+		cmd.DefaultTFVersionFlag:           "v0.11.0",
+		cmd.DenyUnmatchedAppliesFlag:       true,
+		cmd.GHHostnameFlag:                 "ghhostname",
+		cmd.GHTokenFlag:                    "token",
+		cmd.GHUserFlag:                     "user",
+		cmd.GHWebhookSecretFlag:            "secret",
+		cmd.GitlabHostnameFlag:             "gitlab-hostname",
+		cmd.GitlabTokenFlag:                "gitlab-token",
+		cmd.GitlabUserFlag:                 "gitlab-user",
+		cmd.GitlabWebhookSecretFlag:        "gitlab-secret",
+		cmd.JobOutputRetentionDaysFlag:     7,
+		cmd.LogLevelFlag:                   "debug",
+		cmd.MaxCommentOutputCharsFlag:      1000,
+		cmd.PlanMinPermissionFlag:          "read",
+		cmd.ApplyMinPermissionFlag:         "admin",
+		cmd.PortFlag:                       8181,
+		cmd.ReadyzMinFreeDiskMBFlag:        50,
+		cmd.RepoConfigFlag:                 "/path/to/repos.yaml",
+		cmd.RepoWhitelistFlag:              "github.com/runatlantis/atlantis",
+		cmd.RequireApprovalFlag:            true,
+		cmd.RequireMergeableFlag:           true,
+		cmd.SlackTokenFlag:                 "slack-token",
+		cmd.SSLCertFileFlag:                "cert-file",
+		cmd.SSLKeyFileFlag:                 "key-file",
+		cmd.TeamCacheTTLMinutesFlag:        10,
+		cmd.TFETokenFlag:                   "my-token",
 	})
 	err := c.Execute()
 	Ok(t, err)
@@ -472,7 +615,17 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "url", passedConfig.AtlantisURL)
 	Equals(t, true, passedConfig.AllowForkPRs)
 	Equals(t, true, passedConfig.AllowRepoConfig)
+	Equals(t, "api-user", passedConfig.APIUser)
+	Equals(t, "alice,bob", passedConfig.ApplyWindowOverrideUsers)
 	Equals(t, true, passedConfig.Automerge)
+	Equals(t, "**/*.md,!README.md", passedConfig.AutoplanIgnore)
+	Equals(t, true, passedConfig.AutoplanModules)
+	Equals(t, true, passedConfig.AutoplanVerbose)
+	Equals(t, "https://azuredevops-base-url.com", passedConfig.AzureDevopsBaseURL)
+	Equals(t, "azuredevops-token", passedConfig.AzureDevopsToken)
+	Equals(t, "azuredevops-user", passedConfig.AzureDevopsUser)
+	Equals(t, "azuredevops-webhook-password", passedConfig.AzureDevopsWebhookPassword)
+	Equals(t, "azuredevops-webhook-user", passedConfig.AzureDevopsWebhookUser)
 	Equals(t, "https://bitbucket-base-url.com", passedConfig.BitbucketBaseURL)
 	Equals(t, "bitbucket-token", passedConfig.BitbucketToken)
 	Equals(t, "bitbucket-user", passedConfig.BitbucketUser)
@@ -480,6 +633,7 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "merge", passedConfig.CheckoutStrategy)
 	Equals(t, "/path", passedConfig.DataDir)
 	Equals(t, "v0.11.0", passedConfig.DefaultTFVersion)
+	Equals(t, true, passedConfig.DenyUnmatchedApplies)
 	Equals(t, "ghhostname", passedConfig.GithubHostname)
 	Equals(t, "token", passedConfig.GithubToken)
 	Equals(t, "user", passedConfig.GithubUser)
@@ -488,14 +642,21 @@ func TestExecute_Flags(t *testing.T) {
 	Equals(t, "gitlab-token", passedConfig.GitlabToken)
 	Equals(t, "gitlab-user", passedConfig.GitlabUser)
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebhookSecret)
+	Equals(t, 7, passedConfig.JobOutputRetentionDays)
 	Equals(t, "debug", passedConfig.LogLevel)
+	Equals(t, 1000, passedConfig.MaxCommentOutputChars)
 	Equals(t, 8181, passedConfig.Port)
+	Equals(t, "read", passedConfig.PlanMinPermission)
+	Equals(t, "admin", passedConfig.ApplyMinPermission)
+	Equals(t, 50, passedConfig.ReadyzMinFreeDiskMB)
+	Equals(t, "/path/to/repos.yaml", passedConfig.RepoConfig)
 	Equals(t, "github.com/runatlantis/atlantis", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
 	Equals(t, true, passedConfig.RequireMergeable)
 	Equals(t, "slack-token", passedConfig.SlackToken)
 	Equals(t, "cert-file", passedConfig.SSLCertFile)
 	Equals(t, "key-file", passedConfig.SSLKeyFile)
+	Equals(t, 10, passedConfig.TeamCacheTTLMinutes)
 	Equals(t, "my-token", passedConfig.TFEToken)
 }
 
@@ -505,6 +666,8 @@ func TestExecute_ConfigFile(t *testing.T) {
 atlantis-url: "url"
 allow-fork-prs: true
 allow-repo-config: true
+api-user: "api-user"
+apply-window-override-users: "alice,bob"
 automerge: true
 bitbucket-base-url: "https://mydomain.com"
 bitbucket-token: "bitbucket-token"
@@ -523,12 +686,17 @@ gitlab-user: "gitlab-user"
 gitlab-webhook-secret: "gitlab-secret"
 log-level: "debug"
 port: 8181
+plan-min-permission: "read"
+apply-min-permission: "admin"
+readyz-min-free-disk-mb: 50
+repo-config: /path/to/repos.yaml
 repo-whitelist: "github.com/runatlantis/atlantis"
 require-approval: true
 require-mergeable: true
 slack-token: slack-token
 ssl-cert-file: cert-file
 ssl-key-file: key-file
+team-cache-ttl-minutes: 10
 tfe-token: my-token
 `)
 	defer os.Remove(tmpFile) // nolint: errcheck
@@ -541,6 +709,8 @@ tfe-token: my-token
 	Equals(t, "url", passedConfig.AtlantisURL)
 	Equals(t, true, passedConfig.AllowForkPRs)
 	Equals(t, true, passedConfig.AllowRepoConfig)
+	Equals(t, "api-user", passedConfig.APIUser)
+	Equals(t, "alice,bob", passedConfig.ApplyWindowOverrideUsers)
 	Equals(t, true, passedConfig.Automerge)
 	Equals(t, "https://mydomain.com", passedConfig.BitbucketBaseURL)
 	Equals(t, "bitbucket-token", passedConfig.BitbucketToken)
@@ -559,12 +729,17 @@ tfe-token: my-token
 	Equals(t, "gitlab-secret", passedConfig.GitlabWebhookSecret)
 	Equals(t, "debug", passedConfig.LogLevel)
 	Equals(t, 8181, passedConfig.Port)
+	Equals(t, "read", passedConfig.PlanMinPermission)
+	Equals(t, "admin", passedConfig.ApplyMinPermission)
+	Equals(t, 50, passedConfig.ReadyzMinFreeDiskMB)
+	Equals(t, "/path/to/repos.yaml", passedConfig.RepoConfig)
 	Equals(t, "github.com/runatlantis/atlantis", passedConfig.RepoWhitelist)
 	Equals(t, true, passedConfig.RequireApproval)
 	Equals(t, true, passedConfig.RequireMergeable)
 	Equals(t, "slack-token", passedConfig.SlackToken)
 	Equals(t, "cert-file", passedConfig.SSLCertFile)
 	Equals(t, "key-file", passedConfig.SSLKeyFile)
+	Equals(t, 10, passedConfig.TeamCacheTTLMinutes)
 	Equals(t, "my-token", passedConfig.TFEToken)
 }
 
@@ -902,6 +1077,16 @@ func TestExecute_BitbucketServerBaseURLScheme(t *testing.T) {
 	ErrEquals(t, "error parsing --bitbucket-webhook-secret flag value \"://mydomain.com\": parse ://mydomain.com: missing protocol scheme", c.Execute())
 }
 
+func TestExecute_AzureDevopsBaseURLScheme(t *testing.T) {
+	c := setup(map[string]interface{}{
+		cmd.AzureDevopsUserFlag:    "user",
+		cmd.AzureDevopsTokenFlag:   "token",
+		cmd.RepoWhitelistFlag:      "*",
+		cmd.AzureDevopsBaseURLFlag: "azuredevops.example.com/DefaultCollection",
+	})
+	ErrEquals(t, "--azuredevops-base-url must have http:// or https://, got \"azuredevops.example.com/DefaultCollection\"", c.Execute())
+}
+
 // Port should be retained on base url.
 func TestExecute_BitbucketServerBaseURLPort(t *testing.T) {
 	c := setup(map[string]interface{}{
@@ -0,0 +1,191 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/spf13/cobra"
+)
+
+// ValidateConfigCmd validates the atlantis.yaml file in a local checkout so
+// mistakes can be caught before they're pushed.
+type ValidateConfigCmd struct {
+	repoDir      string
+	repoFullName string
+	repoConfig   string
+	changedFiles []string
+	printSchema  bool
+}
+
+// Init returns the runnable cobra command.
+func (v *ValidateConfigCmd) Init() *cobra.Command {
+	c := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Validate a repo's atlantis.yaml file",
+		Long: `Validate a repo's atlantis.yaml file and list the projects and workflows it configures.
+
+If --changed-files is set, also lists the projects that would be autoplanned
+for a pull request that changes those files.
+
+If --repo-config is set, the atlantis.yaml file is validated against the
+server-side repo config for --repo like it is on the server, so it can use
+server-side workflows and can only set the keys that are allowed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var err error
+			if v.printSchema {
+				err = v.writeSchema(cmd.OutOrStdout())
+			} else {
+				err = v.validate(cmd.OutOrStdout())
+			}
+			if err != nil {
+				fmt.Fprintf(cmd.OutOrStderr(), "\033[31mError: %s\033[39m\n", err.Error()) // nolint: errcheck
+			}
+			return err
+		},
+		SilenceErrors: true,
+		SilenceUsage:  true,
+	}
+	c.Flags().StringVar(&v.repoDir, "repo-dir", ".", "Path to the root of the repo containing the atlantis.yaml file.")
+	c.Flags().StringVar(&v.repoConfig, RepoConfigFlag, "", "Path to the server-side repo config file the Atlantis server is run with.")
+	c.Flags().StringVar(&v.repoFullName, "repo", "", "Full name of the repo, ex. runatlantis/atlantis. Used to find its server-side repo config. Required if --"+RepoConfigFlag+" is set.")
+	c.Flags().StringSliceVar(&v.changedFiles, "changed-files", nil, "Comma-separated paths, relative to the repo root, of files changed by a pull request. The projects that would be autoplanned are listed.")
+	c.Flags().BoolVar(&v.printSchema, "print-schema", false, "Print the JSON Schema for atlantis.yaml files instead of validating.")
+	return c
+}
+
+func (v *ValidateConfigCmd) writeSchema(out io.Writer) error {
+	schema, err := json.MarshalIndent(raw.JSONSchema(), "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(out, string(schema))
+	return err
+}
+
+func (v *ValidateConfigCmd) validate(out io.Writer) error {
+	parser := &yaml.ParserValidator{}
+	serverCfg, serverWorkflows, err := v.serverRepoCfg(parser)
+	if err != nil {
+		return err
+	}
+	config, err := parser.ReadConfigWithServerWorkflows(v.repoDir, serverWorkflows)
+	if os.IsNotExist(err) {
+		return fmt.Errorf("no %s file found in %s", yaml.AtlantisYAMLFilename, v.repoDir)
+	}
+	if err != nil {
+		configData, readErr := ioutil.ReadFile(filepath.Join(v.repoDir, yaml.AtlantisYAMLFilename))
+		if readErr != nil {
+			return err
+		}
+		configErrs := yaml.ConfigErrors(err, configData)
+		fmt.Fprintf(out, "%s is invalid:\n", yaml.AtlantisYAMLFilename) // nolint: errcheck
+		for _, e := range configErrs {
+			fmt.Fprintf(out, "  %s\n", e.Error()) // nolint: errcheck
+		}
+		return fmt.Errorf("found %d error(s) in %s", len(configErrs), yaml.AtlantisYAMLFilename)
+	}
+	if serverCfg != nil {
+		if err := serverCfg.ValidateRepoCfg(config); err != nil {
+			return errors.Wrapf(err, "validating %s", yaml.AtlantisYAMLFilename)
+		}
+	}
+
+	fmt.Fprintf(out, "%s is valid.\n\n", yaml.AtlantisYAMLFilename) // nolint: errcheck
+	v.writeProjects(out, "Projects:", config.Projects)
+	v.writeWorkflows(out, config)
+
+	if len(v.changedFiles) > 0 {
+		finder := &events.DefaultProjectFinder{}
+		modified, _, err := finder.DetermineProjectsViaConfig(logging.NewNoopLogger(), v.changedFiles, config, v.repoDir)
+		if err != nil {
+			return errors.Wrap(err, "determining projects to autoplan")
+		}
+		var autoplanned []valid.Project
+		for _, p := range modified {
+			if p.Autoplan.Enabled {
+				autoplanned = append(autoplanned, p)
+			}
+		}
+		fmt.Fprintln(out) // nolint: errcheck
+		v.writeProjects(out, "Projects that would be autoplanned:", autoplanned)
+	}
+	return nil
+}
+
+// serverRepoCfg returns the server-side repo config for --repo and the
+// server-side workflows its atlantis.yaml can use. repoCfg is nil if
+// --repo-config isn't set or none of it applies to the repo.
+func (v *ValidateConfigCmd) serverRepoCfg(parser *yaml.ParserValidator) (repoCfg *valid.RepoConfig, workflows map[string]valid.Workflow, err error) {
+	if v.repoConfig == "" {
+		return nil, nil, nil
+	}
+	if v.repoFullName == "" {
+		return nil, nil, fmt.Errorf("--repo must be set with --%s", RepoConfigFlag)
+	}
+	serverCfg, err := parser.ReadServerConfig(v.repoConfig)
+	if err != nil {
+		return nil, nil, err
+	}
+	cfg, ok := serverCfg.ForRepo(v.repoFullName)
+	if !ok {
+		return nil, nil, nil
+	}
+	return &cfg, serverCfg.Workflows, nil
+}
+
+func (v *ValidateConfigCmd) writeProjects(out io.Writer, title string, projects []valid.Project) {
+	fmt.Fprintln(out, title) // nolint: errcheck
+	if len(projects) == 0 {
+		fmt.Fprintln(out, "  none") // nolint: errcheck
+		return
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "  NAME\tDIR\tWORKSPACE\tWORKFLOW\tTERRAFORM\tAUTOPLAN") // nolint: errcheck
+	for _, p := range projects {
+		name, workflow, tfVersion := "-", "default", "default"
+		if p.Name != nil {
+			name = *p.Name
+		}
+		if p.Workflow != nil {
+			workflow = *p.Workflow
+		}
+		if p.TerraformVersion != nil {
+			tfVersion = p.TerraformVersion.String()
+		}
+		autoplan := "disabled"
+		if p.Autoplan.Enabled {
+			autoplan = strings.Join(p.Autoplan.WhenModified, ", ")
+		}
+		fmt.Fprintf(w, "  %s\t%s\t%s\t%s\t%s\t%s\n", name, p.Dir, p.Workspace, workflow, tfVersion, autoplan) // nolint: errcheck
+	}
+	w.Flush() // nolint: errcheck
+}
+
+func (v *ValidateConfigCmd) writeWorkflows(out io.Writer, config valid.Config) {
+	fmt.Fprintln(out, "\nWorkflows:") // nolint: errcheck
+	if len(config.Workflows) == 0 {
+		fmt.Fprintln(out, "  none") // nolint: errcheck
+		return
+	}
+	var names []string
+	for name := range config.Workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(out, "  %s\n", name) // nolint: errcheck
+	}
+}
@@ -0,0 +1,130 @@
+package cmd_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/cmd"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestValidateConfigCmd_Valid(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	// Projects are only autoplanned if their dirs exist.
+	Ok(t, os.Mkdir(filepath.Join(tmp, "staging"), 0700))
+	Ok(t, os.Mkdir(filepath.Join(tmp, "prod"), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "atlantis.yaml"), []byte(`
+version: 2
+projects:
+- name: staging
+  dir: staging
+- dir: prod
+  autoplan:
+    enabled: false
+workflows:
+  custom:
+    plan:
+      steps: [init, plan]
+`), 0600))
+
+	c := (&cmd.ValidateConfigCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"--repo-dir", tmp, "--changed-files", "staging/main.tf,prod/main.tf"})
+	Ok(t, c.Execute())
+
+	output := out.String()
+	Assert(t, strings.HasPrefix(output, "atlantis.yaml is valid."), "exp valid, got %q", output)
+	Assert(t, strings.Contains(output, "staging"), "exp staging project, got %q", output)
+	Assert(t, strings.Contains(output, "  custom\n"), "exp custom workflow, got %q", output)
+	autoplanned := output[strings.Index(output, "Projects that would be autoplanned:"):]
+	Assert(t, strings.Contains(autoplanned, "staging"), "exp staging to be autoplanned, got %q", autoplanned)
+	Assert(t, !strings.Contains(autoplanned, "prod"), "exp prod not to be autoplanned, got %q", autoplanned)
+}
+
+// An invalid config should return an error so that the command exits
+// non-zero.
+func TestValidateConfigCmd_Invalid(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "atlantis.yaml"), []byte(`
+version: 2
+projects:
+- dir: .
+  workflow: undefined
+`), 0600))
+
+	c := (&cmd.ValidateConfigCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"--repo-dir", tmp})
+	ErrEquals(t, "found 1 error(s) in atlantis.yaml", c.Execute())
+	Assert(t, strings.HasPrefix(out.String(), "atlantis.yaml is invalid:"), "exp invalid, got %q", out.String())
+}
+
+func TestValidateConfigCmd_MissingFile(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	c := (&cmd.ValidateConfigCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"--repo-dir", tmp})
+	ErrEquals(t, "no atlantis.yaml file found in "+tmp, c.Execute())
+}
+
+// With --repo-config, atlantis.yaml can use server-side workflows and can only
+// set the keys the server-side config allows.
+func TestValidateConfigCmd_RepoConfig(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	repoConfig := filepath.Join(tmp, "repos.yaml")
+	Ok(t, ioutil.WriteFile(repoConfig, []byte(`
+repos:
+- id: owner/repo
+  allowed_overrides: [workflow]
+workflows:
+  server:
+    plan:
+      steps: [init, plan]
+`), 0600))
+	atlantisYAML := filepath.Join(tmp, "atlantis.yaml")
+	Ok(t, ioutil.WriteFile(atlantisYAML, []byte(`
+version: 2
+projects:
+- dir: .
+  workflow: server
+`), 0600))
+
+	c := (&cmd.ValidateConfigCmd{}).Init()
+	out := new(bytes.Buffer)
+	c.SetOutput(out)
+	c.SetArgs([]string{"--repo-dir", tmp, "--repo-config", repoConfig, "--repo", "owner/repo"})
+	Ok(t, c.Execute())
+	Assert(t, strings.HasPrefix(out.String(), "atlantis.yaml is valid."), "exp valid, got %q", out.String())
+
+	t.Log("keys that aren't allowed are errors")
+	Ok(t, ioutil.WriteFile(atlantisYAML, []byte(`
+version: 2
+projects:
+- dir: .
+  workflow: server
+  apply_requirements: []
+`), 0600))
+	c = (&cmd.ValidateConfigCmd{}).Init()
+	c.SetOutput(new(bytes.Buffer))
+	c.SetArgs([]string{"--repo-dir", tmp, "--repo-config", repoConfig, "--repo", "owner/repo"})
+	err := c.Execute()
+	Assert(t, err != nil && strings.HasPrefix(err.Error(), "validating atlantis.yaml: "), "exp validation error, got %v", err)
+
+	t.Log("--repo is required")
+	c = (&cmd.ValidateConfigCmd{}).Init()
+	c.SetOutput(new(bytes.Buffer))
+	c.SetArgs([]string{"--repo-dir", tmp, "--repo-config", repoConfig})
+	ErrEquals(t, "--repo must be set with --repo-config", c.Execute())
+}
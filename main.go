@@ -35,8 +35,12 @@ func main() {
 	}
 	version := &cmd.VersionCmd{AtlantisVersion: atlantisVersion}
 	testdrive := &cmd.TestdriveCmd{}
+	validateConfig := &cmd.ValidateConfigCmd{}
+	run := &cmd.RunCmd{TerraformClientCreator: &cmd.DefaultTerraformClientCreator{}}
 	cmd.RootCmd.AddCommand(server.Init())
 	cmd.RootCmd.AddCommand(version.Init())
 	cmd.RootCmd.AddCommand(testdrive.Init())
+	cmd.RootCmd.AddCommand(validateConfig.Init())
+	cmd.RootCmd.AddCommand(run.Init())
 	cmd.Execute()
 }
@@ -54,14 +54,20 @@ module.exports = {
                         'configuring-webhooks',
                         'server-configuration',
                         'provider-credentials',
-                        'terraform-enterprise'
+                        'terraform-enterprise',
+                        'metrics',
+                        'ui-authentication'
                     ]
                 },
                 {
                     title: 'Using Atlantis',
                     collapsable: true,
                     children: [
-                        ['using-atlantis', 'Overview']
+                        ['using-atlantis', 'Overview'],
+                        'api',
+                        'freezes',
+                        'apply-windows',
+                        'allowed-appliers'
                     ]
                 },
                 {
@@ -70,6 +76,8 @@ module.exports = {
                     children: [
                         ['customizing-atlantis', 'Overview'],
                         'atlantis-yaml-reference',
+                        'server-side-repo-config',
+                        'testing-locally',
                         'upgrading-atlantis-yaml-to-version-2',
                         'apply-requirements',
                         'checkout-strategy',
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// NewApplyAuthorizer validates the user's allowed appliers. It returns nil if
+// none were configured, ie. anyone can apply. If denyUnmatched is true,
+// projects that no rule covers can't be applied.
+func NewApplyAuthorizer(configs []AllowedAppliersConfig, denyUnmatched bool, vcsClient vcs.Client, teamCacheTTL time.Duration) (events.ApplyAuthorizer, error) {
+	if len(configs) == 0 {
+		if denyUnmatched {
+			return nil, errors.New("allowed appliers must be configured to deny unmatched applies")
+		}
+		return nil, nil
+	}
+	var rules []models.ApplierRule
+	for i, c := range configs {
+		if len(c.Users) == 0 && len(c.Teams) == 0 {
+			return nil, fmt.Errorf("allowed appliers at index %d must have at least one user or team", i)
+		}
+		if _, err := path.Match(c.Dir, ""); err != nil {
+			return nil, fmt.Errorf("allowed appliers at index %d has invalid dir glob %q: %s", i, c.Dir, err)
+		}
+		rules = append(rules, models.ApplierRule{
+			RepoFullName: c.Repo,
+			DirGlob:      c.Dir,
+			Workspace:    c.Workspace,
+			Users:        c.Users,
+			Teams:        c.Teams,
+		})
+	}
+	return &events.DefaultApplyAuthorizer{
+		Rules:         rules,
+		DenyUnmatched: denyUnmatched,
+		Teams:         &events.TeamMembershipCache{VCSClient: vcsClient, TTL: teamCacheTTL},
+	}, nil
+}
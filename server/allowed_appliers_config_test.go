@@ -0,0 +1,40 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewApplyAuthorizer(t *testing.T) {
+	t.Log("If no appliers are configured, anyone can apply.")
+	authorizer, err := server.NewApplyAuthorizer(nil, false, nil, time.Minute)
+	Ok(t, err)
+	Assert(t, authorizer == nil, "exp nil authorizer")
+
+	vcsClient := mocks.NewMockClient()
+	authorizer, err = server.NewApplyAuthorizer([]server.AllowedAppliersConfig{
+		{Repo: "owner/repo", Dir: "prod/*", Workspace: "default", Users: []string{"alice"}, Teams: []string{"platform"}},
+	}, true, vcsClient, time.Minute)
+	Ok(t, err)
+	Equals(t, []models.ApplierRule{
+		{RepoFullName: "owner/repo", DirGlob: "prod/*", Workspace: "default", Users: []string{"alice"}, Teams: []string{"platform"}},
+	}, authorizer.(*events.DefaultApplyAuthorizer).Rules)
+	Equals(t, true, authorizer.(*events.DefaultApplyAuthorizer).DenyUnmatched)
+}
+
+func TestNewApplyAuthorizer_Invalid(t *testing.T) {
+	_, err := server.NewApplyAuthorizer([]server.AllowedAppliersConfig{{Repo: "owner/repo"}}, false, nil, time.Minute)
+	ErrEquals(t, "allowed appliers at index 0 must have at least one user or team", err)
+
+	_, err = server.NewApplyAuthorizer([]server.AllowedAppliersConfig{{Dir: "[", Users: []string{"alice"}}}, false, nil, time.Minute)
+	ErrEquals(t, "allowed appliers at index 0 has invalid dir glob \"[\": syntax error in pattern", err)
+
+	_, err = server.NewApplyAuthorizer(nil, true, nil, time.Minute)
+	ErrEquals(t, "allowed appliers must be configured to deny unmatched applies", err)
+}
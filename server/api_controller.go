@@ -0,0 +1,737 @@
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/locking"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+const (
+	// APIReadScope allows a token to call the read-only API endpoints.
+	APIReadScope = "read"
+	// APIWriteScope allows a token to call all API endpoints, including
+	// the read-only ones.
+	APIWriteScope = "write"
+)
+
+// APIToken is a token that can be used to authenticate with the API.
+type APIToken struct {
+	// Name identifies who the token was issued to. It's used for logging.
+	Name string
+	// Token is the secret that must be sent as a bearer token.
+	Token string
+	// Scope is either APIReadScope or APIWriteScope.
+	Scope string
+}
+
+// NewAPITokens validates the user's token config and converts it into
+// APITokens.
+func NewAPITokens(configs []APITokenConfig) ([]APIToken, error) {
+	var tokens []APIToken
+	for i, c := range configs {
+		if c.Name == "" {
+			return nil, fmt.Errorf("api token at index %d must have a name", i)
+		}
+		if c.Token == "" {
+			return nil, fmt.Errorf("api token %q must have a token set", c.Name)
+		}
+		if c.Scope != APIReadScope && c.Scope != APIWriteScope {
+			return nil, fmt.Errorf("api token %q has invalid scope %q: must be one of %s or %s", c.Name, c.Scope, APIReadScope, APIWriteScope)
+		}
+		tokens = append(tokens, APIToken{Name: c.Name, Token: c.Token, Scope: c.Scope})
+	}
+	return tokens, nil
+}
+
+// APIVCSHost is a VCS host that commands can be run against via the API.
+type APIVCSHost struct {
+	Type models.VCSHostType
+	// Hostname is the hostname of the VCS host, ex. github.com. It can
+	// include a scheme, ex. http://gitlab.example.com.
+	Hostname string
+	// User and Token are the credentials used to clone repos.
+	User  string
+	Token string
+}
+
+// APIController handles requests to the JSON API served under /api/v1.
+type APIController struct {
+	Locker locking.Locker
+	// LocksController is used to delete locks so that the API has the same
+	// behaviour as the UI.
+	LocksController *LocksController
+	DB              *db.BoltDB
+	Freezes         *events.FreezeManager
+	Jobs            *events.JobTracker
+	Logger          *logging.SimpleLogger
+	Tokens          []APIToken
+	CommandRunner   events.CommandRunner
+	// CommentParser validates and builds the commands run via the API the
+	// same way it does for comments.
+	CommentParser            *events.CommentParser
+	RepoWhitelistChecker     *events.RepoWhitelistChecker
+	GitlabMergeRequestGetter events.GitlabMergeRequestGetter
+	// VCSHosts are the hosts that commands can be run against. Only GitHub
+	// and GitLab hosts are supported.
+	VCSHosts []APIVCSHost
+	// User is the service user that commands run via the API are run as.
+	User models.User
+	// ConfigReloader reloads the server's config. If nil, the config can't be
+	// reloaded via the API.
+	ConfigReloader *ConfigReloader
+	// TestingMode is true when we're running tests and want commands to run
+	// synchronously.
+	TestingMode bool
+}
+
+// APICommandRequest is the JSON body of a request to run a command.
+type APICommandRequest struct {
+	// VCS is the VCS host the repo is on, either "github" or "gitlab". It can
+	// be omitted if Atlantis is only configured for one of them. Bitbucket and
+	// Azure DevOps aren't supported.
+	VCS string `json:"vcs"`
+	// Repo is the full name of the repo, ex. "runatlantis/atlantis".
+	Repo    string `json:"repo"`
+	PullNum int    `json:"pull_num"`
+	// Dir, Workspace and Project behave the same as the -d, -w and -p flags
+	// of comment commands.
+	Dir       string `json:"dir"`
+	Workspace string `json:"workspace"`
+	Project   string `json:"project"`
+	// ExtraArgs are appended to the terraform command, just like arguments
+	// after -- in comment commands.
+	ExtraArgs []string `json:"extra_args"`
+	Verbose   bool     `json:"verbose"`
+}
+
+// APIUnlockRequest is the JSON body of a request to delete locks in bulk.
+// A lock is deleted if it matches every field that's set.
+type APIUnlockRequest struct {
+	IDs           []string `json:"ids"`
+	Repo          string   `json:"repo"`
+	PullNum       int      `json:"pull_num"`
+	Workspace     string   `json:"workspace"`
+	OlderThanDays int      `json:"older_than_days"`
+	// DryRun is true if the locks that match should be listed but not
+	// deleted.
+	DryRun bool `json:"dry_run"`
+}
+
+// APIUnlockResponse is the response to a request to delete locks in bulk.
+type APIUnlockResponse struct {
+	DryRun bool      `json:"dry_run"`
+	Locks  []APILock `json:"locks"`
+}
+
+// APIFreezeRequest is the JSON body of a request to freeze applies.
+type APIFreezeRequest struct {
+	// Repo is the full name of the repo to freeze. If empty, all repos are
+	// frozen.
+	Repo string `json:"repo"`
+	// Projects is a glob matched against project names and dirs. If empty,
+	// all projects are frozen.
+	Projects string `json:"projects"`
+	Reason   string `json:"reason"`
+}
+
+// APIFreeze is the JSON representation of a freeze.
+type APIFreeze struct {
+	ID        string     `json:"id"`
+	Repo      string     `json:"repo"`
+	Projects  string     `json:"projects"`
+	Reason    string     `json:"reason"`
+	CreatedBy string     `json:"created_by"`
+	CreatedAt time.Time  `json:"created_at"`
+	Start     *time.Time `json:"start,omitempty"`
+	End       *time.Time `json:"end,omitempty"`
+}
+
+// APILock is the JSON representation of a lock.
+type APILock struct {
+	ID           string    `json:"id"`
+	RepoFullName string    `json:"repo_full_name"`
+	Path         string    `json:"path"`
+	Workspace    string    `json:"workspace"`
+	PullNum      int       `json:"pull_num"`
+	PullURL      string    `json:"pull_url"`
+	User         string    `json:"user"`
+	Time         time.Time `json:"time"`
+}
+
+// APIPullStatus is the JSON representation of a pull request's status.
+type APIPullStatus struct {
+	RepoFullName string             `json:"repo_full_name"`
+	PullNum      int                `json:"pull_num"`
+	PullURL      string             `json:"pull_url"`
+	HeadCommit   string             `json:"head_commit"`
+	Author       string             `json:"author"`
+	Projects     []APIProjectStatus `json:"projects"`
+}
+
+// APIProjectStatus is the JSON representation of a project's status within
+// a pull request.
+type APIProjectStatus struct {
+	Dir         string `json:"dir"`
+	Workspace   string `json:"workspace"`
+	ProjectName string `json:"project_name,omitempty"`
+	Status      string `json:"status"`
+}
+
+// APIJob is the JSON representation of a job.
+type APIJob struct {
+	ID           string             `json:"id"`
+	RepoFullName string             `json:"repo_full_name"`
+	PullNum      int                `json:"pull_num"`
+	Command      string             `json:"command"`
+	Autoplan     bool               `json:"autoplan"`
+	User         string             `json:"user"`
+	Status       string             `json:"status"`
+	StartTime    time.Time          `json:"start_time"`
+	EndTime      *time.Time         `json:"end_time,omitempty"`
+	Error        string             `json:"error,omitempty"`
+	Failure      string             `json:"failure,omitempty"`
+	Projects     []APIProjectResult `json:"projects,omitempty"`
+}
+
+// APIProjectResult is the JSON representation of the result of running a
+// command on a project.
+type APIProjectResult struct {
+	Dir         string `json:"dir"`
+	Workspace   string `json:"workspace"`
+	ProjectName string `json:"project_name,omitempty"`
+	Status      string `json:"status"`
+	Output      string `json:"output,omitempty"`
+	Error       string `json:"error,omitempty"`
+	Failure     string `json:"failure,omitempty"`
+}
+
+// APIError is the JSON body returned whenever an API call fails.
+type APIError struct {
+	Error string `json:"error"`
+}
+
+// Authenticate wraps h so that it's only called if the request has a bearer
+// token with the required scope. Tokens with the write scope can also call
+// read endpoints.
+func (a *APIController) Authenticate(scope string, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		header := r.Header.Get("Authorization")
+		if !strings.HasPrefix(header, "Bearer ") {
+			a.respondErr(w, logging.Warn, http.StatusUnauthorized, "missing bearer token in Authorization header")
+			return
+		}
+		token := a.findToken(strings.TrimPrefix(header, "Bearer "))
+		if token == nil {
+			a.respondErr(w, logging.Warn, http.StatusUnauthorized, "invalid token")
+			return
+		}
+		if scope == APIWriteScope && token.Scope != APIWriteScope {
+			a.respondErr(w, logging.Warn, http.StatusForbidden, "token %q does not have the %s scope", token.Name, APIWriteScope)
+			return
+		}
+		a.Logger.Debug("api request %s %s authenticated as %q", r.Method, r.URL.Path, token.Name)
+		h(w, auth.SetUsername(r, token.Name))
+	}
+}
+
+// ListLocks is the GET /api/v1/locks route. It returns all the current locks.
+func (a *APIController) ListLocks(w http.ResponseWriter, _ *http.Request) {
+	locks, err := a.Locker.List()
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "listing locks: %s", err)
+		return
+	}
+	// Always return an array, even if it's empty.
+	apiLocks := []APILock{}
+	for id, l := range locks {
+		apiLocks = append(apiLocks, a.toAPILock(id, l))
+	}
+	a.respondJSON(w, http.StatusOK, struct {
+		Locks []APILock `json:"locks"`
+	}{apiLocks})
+}
+
+// GetLock is the GET /api/v1/locks?id={id} route.
+func (a *APIController) GetLock(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.lockID(w, r)
+	if !ok {
+		return
+	}
+	lock, err := a.Locker.GetLock(id)
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "getting lock: %s", err)
+		return
+	}
+	if lock == nil {
+		a.respondErr(w, logging.Info, http.StatusNotFound, "no lock found at id %q", id)
+		return
+	}
+	a.respondJSON(w, http.StatusOK, a.toAPILock(id, *lock))
+}
+
+// DeleteLock is the DELETE /api/v1/locks?id={id} route. Like deleting a lock
+// from the UI, it also deletes the plan and comments on the pull request.
+func (a *APIController) DeleteLock(w http.ResponseWriter, r *http.Request) {
+	id, ok := a.lockID(w, r)
+	if !ok {
+		return
+	}
+	lock, err := a.LocksController.DeleteLockByID(id, "the Atlantis API")
+	if err != nil && lock == nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	if err != nil {
+		// The lock was deleted but commenting failed. Since the lock is gone
+		// the request still succeeded.
+		a.Logger.Err("deleting lock id %q: %s", id, err)
+	}
+	if lock == nil {
+		a.respondErr(w, logging.Info, http.StatusNotFound, "no lock found at id %q", id)
+		return
+	}
+	a.Logger.Info("deleted lock id %q via the API", id)
+	a.respondJSON(w, http.StatusOK, a.toAPILock(id, *lock))
+}
+
+// lockID returns the lock id from the request's id query parameter. If it's
+// missing or invalid, it responds with an error and returns false.
+func (a *APIController) lockID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	// The id is read from the raw query and decoded once with the same
+	// function as the UI so that a "+" in the id isn't turned into a space.
+	var rawID string
+	for _, param := range strings.Split(r.URL.RawQuery, "&") {
+		if strings.HasPrefix(param, "id=") {
+			rawID = strings.TrimPrefix(param, "id=")
+			break
+		}
+	}
+	if rawID == "" {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "no lock id in request")
+		return "", false
+	}
+	id, err := url.PathUnescape(rawID)
+	if err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid lock id %q: %s", rawID, err)
+		return "", false
+	}
+	return id, true
+}
+
+// DeleteLocks is the POST /api/v1/locks/unlock route. It deletes all the
+// locks that match the request the same way as DeleteLock, or only lists
+// them if dry_run is set.
+func (a *APIController) DeleteLocks(w http.ResponseWriter, r *http.Request) {
+	var req APIUnlockRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	if req.PullNum < 0 || req.OlderThanDays < 0 {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "pull_num and older_than_days can't be negative")
+		return
+	}
+	filter := LockFilter{
+		IDs:          req.IDs,
+		RepoFullName: req.Repo,
+		PullNum:      req.PullNum,
+		Workspace:    req.Workspace,
+		OlderThan:    time.Duration(req.OlderThanDays) * 24 * time.Hour,
+	}
+	if filter.IsEmpty() {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "at least one of ids, repo, pull_num, workspace or older_than_days must be set")
+		return
+	}
+	locks, err := a.LocksController.DeleteLocksMatching(filter, req.DryRun, "the Atlantis API")
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	// Always return an array, even if it's empty.
+	resp := APIUnlockResponse{DryRun: req.DryRun, Locks: []APILock{}}
+	for id, l := range locks {
+		resp.Locks = append(resp.Locks, a.toAPILock(id, l))
+	}
+	sort.Slice(resp.Locks, func(i, j int) bool { return resp.Locks[i].ID < resp.Locks[j].ID })
+	if !req.DryRun {
+		a.Logger.Info("deleted %d locks via the API", len(resp.Locks))
+	}
+	a.respondJSON(w, http.StatusOK, resp)
+}
+
+// ListFreezes is the GET /api/v1/freezes route. It returns the active
+// freezes, including scheduled ones.
+func (a *APIController) ListFreezes(w http.ResponseWriter, _ *http.Request) {
+	freezes, err := a.Freezes.ActiveFreezes(time.Now())
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "listing freezes: %s", err)
+		return
+	}
+	// Always return an array, even if it's empty.
+	apiFreezes := []APIFreeze{}
+	for _, f := range freezes {
+		apiFreezes = append(apiFreezes, a.toAPIFreeze(f))
+	}
+	a.respondJSON(w, http.StatusOK, struct {
+		Freezes []APIFreeze `json:"freezes"`
+	}{apiFreezes})
+}
+
+// CreateFreeze is the POST /api/v1/freezes route. It freezes applies until
+// the freeze is deleted.
+func (a *APIController) CreateFreeze(w http.ResponseWriter, r *http.Request) {
+	var req APIFreezeRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	createdBy := fmt.Sprintf("the Atlantis API (token %q)", auth.Username(r))
+	freeze, err := a.Freezes.Freeze(req.Repo, req.Projects, req.Reason, createdBy)
+	if err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "%s", err)
+		return
+	}
+	a.Logger.Info("froze applies for %s via the API", freeze.Scope())
+	a.respondJSON(w, http.StatusOK, a.toAPIFreeze(freeze))
+}
+
+// DeleteFreeze is the DELETE /api/v1/freezes/{id} route. Scheduled freezes
+// can't be deleted.
+func (a *APIController) DeleteFreeze(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	freeze, err := a.Freezes.Unfreeze(id)
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "deleting freeze: %s", err)
+		return
+	}
+	if freeze == nil {
+		a.respondErr(w, logging.Info, http.StatusNotFound, "no freeze found at id %q", id)
+		return
+	}
+	a.Logger.Info("unfroze applies for %s via the API", freeze.Scope())
+	a.respondJSON(w, http.StatusOK, a.toAPIFreeze(*freeze))
+}
+
+// ReloadConfig is the POST /api/v1/config/reload route. It reloads the
+// server's config the same way a SIGHUP does and responds with which settings
+// were reloaded and which require a restart.
+func (a *APIController) ReloadConfig(w http.ResponseWriter, _ *http.Request) {
+	if a.ConfigReloader == nil {
+		a.respondErr(w, logging.Warn, http.StatusNotImplemented, "reloading config is not supported")
+		return
+	}
+	result, err := a.ConfigReloader.Reload()
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "reloading config, keeping current config: %s", err)
+		return
+	}
+	a.respondJSON(w, http.StatusOK, result)
+}
+
+// GetPullStatus is the GET /api/v1/repos/{hostname}/{repo}/pulls/{num} route.
+// It returns the plan and apply status of each project in the pull request.
+func (a *APIController) GetPullStatus(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	num, err := strconv.Atoi(vars["num"])
+	if err != nil || num <= 0 {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid pull request number %q", vars["num"])
+		return
+	}
+	pull := models.PullRequest{
+		Num: num,
+		BaseRepo: models.Repo{
+			FullName: vars["repo"],
+			VCSHost:  models.VCSHost{Hostname: vars["hostname"]},
+		},
+	}
+	status, err := a.DB.GetPullStatus(pull)
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "getting pull status: %s", err)
+		return
+	}
+	if status == nil {
+		a.respondErr(w, logging.Info, http.StatusNotFound, "no status found for %s/%s#%d", vars["hostname"], vars["repo"], num)
+		return
+	}
+
+	apiStatus := APIPullStatus{
+		RepoFullName: status.Pull.BaseRepo.FullName,
+		PullNum:      status.Pull.Num,
+		PullURL:      status.Pull.URL,
+		HeadCommit:   status.Pull.HeadCommit,
+		Author:       status.Pull.Author,
+		Projects:     []APIProjectStatus{},
+	}
+	for _, p := range status.Projects {
+		apiStatus.Projects = append(apiStatus.Projects, APIProjectStatus{
+			Dir:         p.RepoRelDir,
+			Workspace:   p.Workspace,
+			ProjectName: p.ProjectName,
+			Status:      p.Status.String(),
+		})
+	}
+	a.respondJSON(w, http.StatusOK, apiStatus)
+}
+
+// ListJobs is the GET /api/v1/jobs route. It returns the jobs that are
+// currently running.
+func (a *APIController) ListJobs(w http.ResponseWriter, _ *http.Request) {
+	apiJobs := []APIJob{}
+	for _, j := range a.Jobs.Running() {
+		apiJobs = append(apiJobs, a.toAPIJob(j))
+	}
+	a.respondJSON(w, http.StatusOK, struct {
+		Jobs []APIJob `json:"jobs"`
+	}{apiJobs})
+}
+
+// GetJob is the GET /api/v1/jobs/{id} route. It returns the job's status
+// and, once it's finished, its results.
+func (a *APIController) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	job, ok := a.Jobs.Get(id)
+	if !ok {
+		a.respondErr(w, logging.Info, http.StatusNotFound, "no job found with id %q", id)
+		return
+	}
+	a.respondJSON(w, http.StatusOK, a.toAPIJob(job))
+}
+
+// Plan is the POST /api/v1/plan route. It runs plan on a pull request as if
+// the service user had commented.
+func (a *APIController) Plan(w http.ResponseWriter, r *http.Request) {
+	a.runCommand(w, r, models.PlanCommand)
+}
+
+// Apply is the POST /api/v1/apply route. It runs apply on a pull request as if
+// the service user had commented. The same apply requirements apply.
+func (a *APIController) Apply(w http.ResponseWriter, r *http.Request) {
+	a.runCommand(w, r, models.ApplyCommand)
+}
+
+// runCommand starts running the command described by the request body and
+// responds with the job tracking it.
+func (a *APIController) runCommand(w http.ResponseWriter, r *http.Request, name models.CommandName) {
+	var req APICommandRequest
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&req); err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid request body: %s", err)
+		return
+	}
+	if req.Repo == "" {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "repo must be set")
+		return
+	}
+	if req.PullNum <= 0 {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "pull_num must be set to a pull request number")
+		return
+	}
+
+	host, err := a.findVCSHost(req.VCS)
+	if err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "%s", err)
+		return
+	}
+	baseRepo, err := models.NewRepo(host.Type, req.Repo, a.cloneURL(host.Hostname, req.Repo), host.User, host.Token)
+	if err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "invalid repo: %s", err)
+		return
+	}
+	if !a.RepoWhitelistChecker.IsWhitelisted(baseRepo.FullName, baseRepo.VCSHost.Hostname) {
+		a.respondErr(w, logging.Warn, http.StatusForbidden, "repo %s/%s is not in the whitelist", baseRepo.VCSHost.Hostname, baseRepo.FullName)
+		return
+	}
+	cmd, err := a.CommentParser.BuildCommentCommand(name, req.Dir, req.Workspace, req.Project, req.Verbose, req.ExtraArgs)
+	if err != nil {
+		a.respondErr(w, logging.Warn, http.StatusBadRequest, "%s", err)
+		return
+	}
+
+	// For GitHub, the command runner looks up the head repo itself. GitLab
+	// comment events contain the head repo so the command runner expects us
+	// to pass it in. We don't know how to construct it for merge requests from
+	// forks so we don't support them.
+	var maybeHeadRepo *models.Repo
+	if host.Type == models.Gitlab {
+		mr, err := a.GitlabMergeRequestGetter.GetMergeRequest(baseRepo.FullName, req.PullNum)
+		if err != nil {
+			a.respondErr(w, logging.Error, http.StatusInternalServerError, "getting merge request: %s", err)
+			return
+		}
+		if mr.SourceProjectID != mr.TargetProjectID {
+			a.respondErr(w, logging.Warn, http.StatusBadRequest, "commands can't be run via the API on merge requests from forks")
+			return
+		}
+		maybeHeadRepo = &baseRepo
+	}
+
+	cmd.JobID = a.Jobs.Start(baseRepo.FullName, req.PullNum, name, false, a.User.Username)
+	a.Logger.Info("running %s on %s#%d via the API as job %q", name.String(), baseRepo.FullName, req.PullNum, cmd.JobID)
+	if a.TestingMode {
+		a.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, nil, a.User, req.PullNum, cmd)
+	} else {
+		go a.CommandRunner.RunCommentCommand(baseRepo, maybeHeadRepo, nil, a.User, req.PullNum, cmd)
+	}
+
+	job, _ := a.Jobs.Get(cmd.JobID)
+	a.respondJSON(w, http.StatusAccepted, a.toAPIJob(job))
+}
+
+// findVCSHost returns the host matching vcs, ex. "github". If vcs is empty
+// and there's only one host configured, that host is returned.
+// Only GitHub and GitLab hosts are supported because Bitbucket and Azure DevOps
+// pull requests are only parsed from their webhooks, so we can't look them up
+// by number.
+func (a *APIController) findVCSHost(vcs string) (APIVCSHost, error) {
+	if vcs == "" {
+		if len(a.VCSHosts) == 1 {
+			return a.VCSHosts[0], nil
+		}
+		if len(a.VCSHosts) == 0 {
+			return APIVCSHost{}, errors.New("running commands via the API is only supported for GitHub and GitLab and neither is configured")
+		}
+		return APIVCSHost{}, errors.New("vcs must be set to one of github or gitlab")
+	}
+	for _, h := range a.VCSHosts {
+		if strings.EqualFold(h.Type.String(), vcs) {
+			return h, nil
+		}
+	}
+	for _, unsupported := range []string{"bitbucket", models.BitbucketCloud.String(), models.BitbucketServer.String(), models.AzureDevops.String()} {
+		if strings.EqualFold(unsupported, vcs) {
+			return APIVCSHost{}, fmt.Errorf("running commands via the API is not supported for vcs %q: only GitHub and GitLab pull requests can be looked up by number. Comment on the pull request instead", vcs)
+		}
+	}
+	return APIVCSHost{}, fmt.Errorf("running commands via the API is not supported for vcs %q: must be one of the configured github or gitlab hosts", vcs)
+}
+
+// cloneURL returns the https clone URL for repoFullName on hostname.
+func (a *APIController) cloneURL(hostname string, repoFullName string) string {
+	base := hostname
+	if !strings.HasPrefix(base, "http://") && !strings.HasPrefix(base, "https://") {
+		base = "https://" + base
+	}
+	return fmt.Sprintf("%s/%s.git", strings.TrimSuffix(base, "/"), repoFullName)
+}
+
+func (a *APIController) findToken(token string) *APIToken {
+	for i, t := range a.Tokens {
+		if subtle.ConstantTimeCompare([]byte(t.Token), []byte(token)) == 1 {
+			return &a.Tokens[i]
+		}
+	}
+	return nil
+}
+
+func (a *APIController) toAPILock(id string, l models.ProjectLock) APILock {
+	return APILock{
+		ID:           id,
+		RepoFullName: l.Project.RepoFullName,
+		Path:         l.Project.Path,
+		Workspace:    l.Workspace,
+		PullNum:      l.Pull.Num,
+		PullURL:      l.Pull.URL,
+		User:         l.User.Username,
+		Time:         l.Time,
+	}
+}
+
+func (a *APIController) toAPIFreeze(f models.Freeze) APIFreeze {
+	apiFreeze := APIFreeze{
+		ID:        f.ID,
+		Repo:      f.RepoFullName,
+		Projects:  f.ProjectGlob,
+		Reason:    f.Reason,
+		CreatedBy: f.CreatedBy,
+		CreatedAt: f.CreatedAt,
+	}
+	if !f.Start.IsZero() {
+		start := f.Start
+		apiFreeze.Start = &start
+	}
+	if !f.End.IsZero() {
+		end := f.End
+		apiFreeze.End = &end
+	}
+	return apiFreeze
+}
+
+func (a *APIController) toAPIJob(j events.Job) APIJob {
+	apiJob := APIJob{
+		ID:           j.ID,
+		RepoFullName: j.RepoFullName,
+		PullNum:      j.PullNum,
+		Command:      j.Command.String(),
+		Autoplan:     j.Autoplan,
+		User:         j.Username,
+		Status:       j.Status.String(),
+		StartTime:    j.StartTime,
+	}
+	if !j.EndTime.IsZero() {
+		end := j.EndTime
+		apiJob.EndTime = &end
+	}
+	apiJob.Error = j.Error
+	apiJob.Failure = j.Failure
+	for _, p := range j.ProjectResults {
+		result := APIProjectResult{
+			Dir:         p.RepoRelDir,
+			Workspace:   p.Workspace,
+			ProjectName: p.ProjectName,
+			Status:      p.PlanStatus().String(),
+			Failure:     p.Failure,
+			Output:      p.ApplySuccess,
+		}
+		if p.Error != nil {
+			result.Error = p.Error.Error()
+		}
+		if p.PlanSuccess != nil {
+			result.Output = p.PlanSuccess.TerraformOutput
+		}
+		apiJob.Projects = append(apiJob.Projects, result)
+	}
+	return apiJob
+}
+
+// respondJSON writes data as the JSON response body.
+func (a *APIController) respondJSON(w http.ResponseWriter, responseCode int, data interface{}) {
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		a.respondErr(w, logging.Error, http.StatusInternalServerError, "%s", errors.Wrap(err, "creating json response"))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseCode)
+	w.Write(body) // nolint: errcheck
+}
+
+// respondErr logs the error and responds with an APIError body. lvl is the
+// log level to log at, code is the HTTP response code.
+func (a *APIController) respondErr(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	msg := fmt.Sprintf(format, args...)
+	a.Logger.Log(lvl, "api: %s", msg)
+	body, _ := json.Marshal(APIError{Error: msg}) // nolint: errcheck
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(responseCode)
+	w.Write(body) // nolint: errcheck
+}
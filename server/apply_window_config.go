@@ -0,0 +1,47 @@
+package server
+
+import (
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
+
+// NewDefaultApplyWindows validates the user's default apply windows. It
+// returns nil if no windows were configured, ie. projects can be applied at
+// any time.
+func NewDefaultApplyWindows(config ApplyWindowsConfig) (*valid.ApplyWindows, error) {
+	if config.Timezone == "" && len(config.Windows) == 0 {
+		return nil, nil
+	}
+	// Convert to the atlantis.yaml format so both are validated the same way.
+	rawWindows := raw.ApplyWindows{}
+	if config.Timezone != "" {
+		rawWindows.Timezone = &config.Timezone
+	}
+	for _, w := range config.Windows {
+		start, end := w.Start, w.End
+		rawWindows.Windows = append(rawWindows.Windows, raw.ApplyWindow{
+			Days:  w.Days,
+			Start: &start,
+			End:   &end,
+		})
+	}
+	if err := rawWindows.Validate(); err != nil {
+		return nil, err
+	}
+	windows := rawWindows.ToValid()
+	return &windows, nil
+}
+
+// splitCommaList splits a comma-separated list, ignoring whitespace and empty
+// items.
+func splitCommaList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,40 @@
+package server_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewDefaultApplyWindows(t *testing.T) {
+	t.Log("If no windows are configured, projects can be applied at any time.")
+	windows, err := server.NewDefaultApplyWindows(server.ApplyWindowsConfig{})
+	Ok(t, err)
+	Assert(t, windows == nil, "exp nil windows")
+
+	windows, err = server.NewDefaultApplyWindows(server.ApplyWindowsConfig{
+		Windows: []server.ApplyWindowConfig{
+			{Days: []string{"mon-tue"}, Start: "09:00", End: "17:00"},
+		},
+	})
+	Ok(t, err)
+	Equals(t, &valid.ApplyWindows{
+		Location: time.UTC,
+		Windows: []valid.ApplyWindow{
+			{Days: []time.Weekday{time.Monday, time.Tuesday}, Start: 9 * time.Hour, End: 17 * time.Hour},
+		},
+	}, windows)
+}
+
+func TestNewDefaultApplyWindows_Invalid(t *testing.T) {
+	_, err := server.NewDefaultApplyWindows(server.ApplyWindowsConfig{Timezone: "America/New_York"})
+	ErrEquals(t, "windows: at least one window must be specified", err)
+
+	_, err = server.NewDefaultApplyWindows(server.ApplyWindowsConfig{
+		Windows: []server.ApplyWindowConfig{{Start: "09:00"}},
+	})
+	ErrEquals(t, "windows: 0: end: \"\" must be a 24-hour time, ex. 09:00", err)
+}
@@ -0,0 +1,68 @@
+// Package auth authenticates users of the Atlantis UI.
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// Authenticator authenticates requests.
+type Authenticator interface {
+	// Authenticate returns the username of the user that made r. If r isn't
+	// authenticated, Authenticate writes a response to w, ex. a login
+	// challenge or a redirect to a login page, and returns false.
+	Authenticate(w http.ResponseWriter, r *http.Request) (string, bool)
+}
+
+// Middleware is negroni middleware that only lets authenticated requests
+// through, unless their path is exempt.
+type Middleware struct {
+	Authenticator Authenticator
+	// ExemptPaths are the paths that don't require authentication. Paths
+	// that end in "/" are prefixes, ex. "/static/" exempts all static files.
+	ExemptPaths []string
+	Logger      *logging.SimpleLogger
+}
+
+// ServeHTTP implements the negroni middleware function. Authenticated
+// requests are passed to next with their username set so it can be read by
+// Username.
+func (m *Middleware) ServeHTTP(w http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	if m.isExempt(r.URL.Path) {
+		next(w, r)
+		return
+	}
+	username, ok := m.Authenticator.Authenticate(w, r)
+	if !ok {
+		m.Logger.Debug("unauthenticated request to %s %s from %s", r.Method, r.URL.Path, r.RemoteAddr)
+		return
+	}
+	next(w, SetUsername(r, username))
+}
+
+func (m *Middleware) isExempt(path string) bool {
+	for _, p := range m.ExemptPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// usernameKey is the context key for the authenticated username.
+type usernameKey struct{}
+
+// SetUsername returns a copy of r that was authenticated as username.
+func SetUsername(r *http.Request, username string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), usernameKey{}, username))
+}
+
+// Username returns the username of the user that made r or "" if r wasn't
+// authenticated, ex. because UI authentication is disabled.
+func Username(r *http.Request) string {
+	username, _ := r.Context().Value(usernameKey{}).(string)
+	return username
+}
@@ -0,0 +1,60 @@
+package auth_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := auth.Middleware{
+		Authenticator: &auth.BasicAuthenticator{Users: map[string]string{"alice": "password"}},
+		ExemptPaths:   []string{"/events", "/static/"},
+		Logger:        logging.NewNoopLogger(),
+	}
+	cases := []struct {
+		description string
+		path        string
+		user        string
+		password    string
+		expCode     int
+		expUsername string
+	}{
+		{"no credentials", "/", "", "", http.StatusUnauthorized, ""},
+		{"wrong password", "/", "alice", "wrong", http.StatusUnauthorized, ""},
+		{"unknown user", "/", "bob", "password", http.StatusUnauthorized, ""},
+		{"valid credentials", "/locks", "alice", "password", http.StatusOK, "alice"},
+		{"exempt path", "/events", "", "", http.StatusOK, ""},
+		{"exempt prefix", "/static/atlantis.css", "", "", http.StatusOK, ""},
+		{"only exact match for paths without a trailing slash", "/events/other", "", "", http.StatusUnauthorized, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			req, _ := http.NewRequest("GET", c.path, nil)
+			if c.user != "" {
+				req.SetBasicAuth(c.user, c.password)
+			}
+			w := httptest.NewRecorder()
+			called := false
+			m.ServeHTTP(w, req, func(_ http.ResponseWriter, r *http.Request) {
+				called = true
+				Equals(t, c.expUsername, auth.Username(r))
+			})
+			Equals(t, c.expCode == http.StatusOK, called)
+			Equals(t, c.expCode, w.Code)
+			if c.expCode == http.StatusUnauthorized {
+				Equals(t, `Basic realm="Atlantis", charset="UTF-8"`, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestUsername_NotSet(t *testing.T) {
+	req, _ := http.NewRequest("GET", "/", nil)
+	Equals(t, "", auth.Username(req))
+	Equals(t, "alice", auth.Username(auth.SetUsername(req, "alice")))
+}
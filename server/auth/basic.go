@@ -0,0 +1,29 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// BasicAuthenticator authenticates requests using HTTP basic auth against a
+// static set of users.
+type BasicAuthenticator struct {
+	// Users maps usernames to passwords.
+	Users map[string]string
+}
+
+// Authenticate implements Authenticator.
+func (b *BasicAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	username, password, ok := r.BasicAuth()
+	if ok {
+		expected, found := b.Users[username]
+		// Compare even if the user wasn't found so it takes the same time.
+		matches := subtle.ConstantTimeCompare([]byte(expected), []byte(password)) == 1
+		if found && matches {
+			return username, true
+		}
+	}
+	w.Header().Set("WWW-Authenticate", `Basic realm="Atlantis", charset="UTF-8"`)
+	respond(w, http.StatusUnauthorized, "Unauthorized")
+	return "", false
+}
@@ -0,0 +1,437 @@
+package auth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+)
+
+// CallbackPath is the path the OIDC issuer redirects users back to after
+// they've logged in.
+const CallbackPath = "/auth/callback"
+
+const (
+	// DefaultUsernameClaim is the ID token claim used as the username if
+	// OIDCConfig.UsernameClaim isn't set.
+	DefaultUsernameClaim = "email"
+	// DefaultSessionTTL is how long users stay logged in if
+	// OIDCConfig.SessionTTL isn't set.
+	DefaultSessionTTL = 12 * time.Hour
+)
+
+const (
+	sessionCookieName = "atlantis_session"
+	stateCookieName   = "atlantis_oidc_state"
+	// stateTTL is how long users have to log in with the issuer before they
+	// have to start again.
+	stateTTL = 10 * time.Minute
+)
+
+// OIDCConfig configures an OIDCAuthenticator.
+type OIDCConfig struct {
+	// IssuerURL is the OpenID Connect issuer, ex. https://accounts.google.com.
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute URL of CallbackPath on this server, ex.
+	// https://atlantis.example.com/auth/callback. It must be registered with
+	// the issuer.
+	RedirectURL string
+	// UsernameClaim is the ID token claim to use as the username. Defaults to
+	// DefaultUsernameClaim.
+	UsernameClaim string
+	// AllowedUsers are the usernames that are allowed to log in.
+	AllowedUsers []string
+	// AllowedDomains are the email domains of the users that are allowed to
+	// log in, ex. example.com. They're matched against the "email" claim.
+	// If AllowedUsers and AllowedDomains are both empty, every user the
+	// issuer authenticates is allowed.
+	AllowedDomains []string
+	// SessionSecret is used to sign cookies. If it's empty, a random secret
+	// is generated which means users have to log in again when Atlantis
+	// restarts.
+	SessionSecret string
+	// SessionTTL is how long users stay logged in. Defaults to
+	// DefaultSessionTTL.
+	SessionTTL time.Duration
+	// HTTPClient is used to make requests to the issuer. Defaults to
+	// http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// OIDCAuthenticator authenticates users by sending them to log in with an
+// OpenID Connect issuer. Once they've logged in, they're given a signed
+// session cookie.
+type OIDCAuthenticator struct {
+	config     OIDCConfig
+	oauth2     oauth2.Config
+	secret     []byte
+	basePath   string
+	secure     bool
+	httpClient *http.Client
+}
+
+// discoveryDoc is the subset of the issuer's discovery document that we use.
+// See https://openid.net/specs/openid-connect-discovery-1_0.html.
+type discoveryDoc struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+}
+
+// loginState is stored in a cookie while the user is logging in with the
+// issuer.
+type loginState struct {
+	State string `json:"state"`
+	Nonce string `json:"nonce"`
+	// Redirect is the request URI the user was trying to get to.
+	Redirect string `json:"redirect"`
+}
+
+// session is stored in a cookie once the user has logged in.
+type session struct {
+	Username string `json:"username"`
+}
+
+// NewOIDCAuthenticator validates config and fetches the issuer's discovery
+// document.
+func NewOIDCAuthenticator(config OIDCConfig) (*OIDCAuthenticator, error) {
+	if config.IssuerURL == "" {
+		return nil, errors.New("oidc issuer-url must be set")
+	}
+	if config.ClientID == "" || config.ClientSecret == "" {
+		return nil, errors.New("oidc client-id and client-secret must be set")
+	}
+	redirectURL, err := url.Parse(config.RedirectURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing oidc redirect url %q", config.RedirectURL)
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = DefaultUsernameClaim
+	}
+	if config.SessionTTL == 0 {
+		config.SessionTTL = DefaultSessionTTL
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	secret := []byte(config.SessionSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, errors.Wrap(err, "generating session secret")
+		}
+	}
+
+	doc, err := discover(httpClient, config.IssuerURL)
+	if err != nil {
+		return nil, err
+	}
+	return &OIDCAuthenticator{
+		config: config,
+		oauth2: oauth2.Config{
+			ClientID:     config.ClientID,
+			ClientSecret: config.ClientSecret,
+			Endpoint: oauth2.Endpoint{
+				AuthURL:  doc.AuthorizationEndpoint,
+				TokenURL: doc.TokenEndpoint,
+			},
+			RedirectURL: config.RedirectURL,
+			Scopes:      []string{"openid", "email", "profile"},
+		},
+		secret:     secret,
+		basePath:   strings.TrimSuffix(redirectURL.Path, CallbackPath),
+		secure:     redirectURL.Scheme == "https",
+		httpClient: httpClient,
+	}, nil
+}
+
+// discover fetches the issuer's discovery document.
+func discover(client *http.Client, issuerURL string) (discoveryDoc, error) {
+	var doc discoveryDoc
+	docURL := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+	resp, err := client.Get(docURL)
+	if err != nil {
+		return doc, errors.Wrapf(err, "fetching oidc discovery document from %s", docURL)
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	if resp.StatusCode != http.StatusOK {
+		return doc, fmt.Errorf("fetching oidc discovery document from %s: got status %d", docURL, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&doc); err != nil {
+		return doc, errors.Wrapf(err, "decoding oidc discovery document from %s", docURL)
+	}
+	if doc.Issuer != issuerURL {
+		return doc, fmt.Errorf("oidc issuer %q in discovery document doesn't match issuer-url %q", doc.Issuer, issuerURL)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" {
+		return doc, fmt.Errorf("oidc discovery document from %s is missing the authorization or token endpoint", docURL)
+	}
+	return doc, nil
+}
+
+// Authenticate implements Authenticator. Requests with a valid session cookie
+// are authenticated. Other GET requests are redirected to the issuer to log
+// in. Requests to CallbackPath complete the login.
+func (o *OIDCAuthenticator) Authenticate(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.URL.Path == CallbackPath {
+		o.callback(w, r)
+		return "", false
+	}
+	var s session
+	if err := o.readCookie(r, sessionCookieName, &s); err == nil {
+		return s.Username, true
+	}
+	// We can only redirect browsers that are navigating to a page. Other
+	// requests, ex. the UI deleting a lock, need to be retried after logging in.
+	if r.Method != http.MethodGet {
+		respond(w, http.StatusUnauthorized, "Unauthorized: log in to Atlantis and try again")
+		return "", false
+	}
+	o.login(w, r)
+	return "", false
+}
+
+// login redirects the user to the issuer to log in.
+func (o *OIDCAuthenticator) login(w http.ResponseWriter, r *http.Request) {
+	redirect := r.URL.RequestURI()
+	// Don't allow redirecting to other hosts via protocol-relative URLs.
+	if !strings.HasPrefix(redirect, "/") || strings.HasPrefix(redirect, "//") {
+		redirect = "/"
+	}
+	state := loginState{State: randomString(), Nonce: randomString(), Redirect: redirect}
+	if err := o.setCookie(w, stateCookieName, state, stateTTL); err != nil {
+		respond(w, http.StatusInternalServerError, "Failed to start logging in: %s", err)
+		return
+	}
+	http.Redirect(w, r, o.oauth2.AuthCodeURL(state.State, oauth2.SetAuthURLParam("nonce", state.Nonce)), http.StatusFound)
+}
+
+// callback handles the issuer redirecting the user back to us after they've
+// logged in.
+func (o *OIDCAuthenticator) callback(w http.ResponseWriter, r *http.Request) {
+	var state loginState
+	if err := o.readCookie(r, stateCookieName, &state); err != nil {
+		respond(w, http.StatusBadRequest, "Invalid login state, try logging in again: %s", err)
+		return
+	}
+	o.clearCookie(w, stateCookieName)
+
+	query := r.URL.Query()
+	if errCode := query.Get("error"); errCode != "" {
+		respond(w, http.StatusUnauthorized, "Logging in failed: %s: %s", errCode, query.Get("error_description"))
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(query.Get("state")), []byte(state.State)) != 1 {
+		respond(w, http.StatusBadRequest, "Invalid login state, try logging in again")
+		return
+	}
+
+	ctx := context.WithValue(r.Context(), oauth2.HTTPClient, o.httpClient)
+	token, err := o.oauth2.Exchange(ctx, query.Get("code"))
+	if err != nil {
+		respond(w, http.StatusUnauthorized, "Logging in failed: exchanging code for token: %s", err)
+		return
+	}
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok {
+		respond(w, http.StatusUnauthorized, "Logging in failed: token response did not contain an id_token")
+		return
+	}
+	claims, err := o.parseIDToken(rawIDToken, state.Nonce)
+	if err != nil {
+		respond(w, http.StatusUnauthorized, "Logging in failed: %s", err)
+		return
+	}
+	username, ok := claims[o.config.UsernameClaim].(string)
+	if !ok || username == "" {
+		respond(w, http.StatusUnauthorized, "Logging in failed: id token does not contain the %q claim", o.config.UsernameClaim)
+		return
+	}
+	if !o.isAllowed(username, claims) {
+		respond(w, http.StatusForbidden, "User %q is not allowed to use Atlantis", username)
+		return
+	}
+
+	if err := o.setCookie(w, sessionCookieName, session{Username: username}, o.config.SessionTTL); err != nil {
+		respond(w, http.StatusInternalServerError, "Failed to create session: %s", err)
+		return
+	}
+	http.Redirect(w, r, o.basePath+state.Redirect, http.StatusFound)
+}
+
+// parseIDToken returns the claims in rawIDToken after validating them.
+// We don't verify the token's signature because we got it directly from the
+// issuer's token endpoint which, as allowed by section 3.1.3.7 of the OpenID
+// Connect spec, means TLS has already verified who issued it.
+func (o *OIDCAuthenticator) parseIDToken(rawIDToken string, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(rawIDToken, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed id token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding id token")
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, errors.Wrap(err, "decoding id token claims")
+	}
+
+	if iss, _ := claims["iss"].(string); iss != o.config.IssuerURL {
+		return nil, fmt.Errorf("id token was issued by %q, not %q", iss, o.config.IssuerURL)
+	}
+	if !audienceContains(claims["aud"], o.config.ClientID) {
+		return nil, fmt.Errorf("id token was not issued for client %q", o.config.ClientID)
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok || time.Unix(int64(exp), 0).Before(time.Now()) {
+		return nil, errors.New("id token has expired")
+	}
+	if n, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(n), []byte(nonce)) != 1 {
+		return nil, errors.New("id token nonce does not match")
+	}
+	return claims, nil
+}
+
+// audienceContains returns true if the aud claim, which can be a string or a
+// list of strings, contains clientID.
+func audienceContains(aud interface{}, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return a == clientID
+	case []interface{}:
+		for _, v := range a {
+			if v == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (o *OIDCAuthenticator) isAllowed(username string, claims map[string]interface{}) bool {
+	if len(o.config.AllowedUsers) == 0 && len(o.config.AllowedDomains) == 0 {
+		return true
+	}
+	for _, u := range o.config.AllowedUsers {
+		if u == username {
+			return true
+		}
+	}
+
+	email, _ := claims["email"].(string)
+	if verified, ok := claims["email_verified"].(bool); ok && !verified {
+		return false
+	}
+	at := strings.LastIndex(email, "@")
+	if at == -1 {
+		return false
+	}
+	for _, d := range o.config.AllowedDomains {
+		if strings.EqualFold(email[at+1:], d) {
+			return true
+		}
+	}
+	return false
+}
+
+// signedCookie is the value of our cookies before it's signed.
+type signedCookie struct {
+	Data    json.RawMessage `json:"data"`
+	Expires int64           `json:"exp"`
+}
+
+// setCookie sets the cookie name to the signed JSON encoding of v.
+func (o *OIDCAuthenticator) setCookie(w http.ResponseWriter, name string, v interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	expires := time.Now().Add(ttl)
+	payload, err := json.Marshal(signedCookie{Data: data, Expires: expires.Unix()})
+	if err != nil {
+		return err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	http.SetCookie(w, &http.Cookie{
+		Name:     name,
+		Value:    encoded + "." + base64.RawURLEncoding.EncodeToString(o.sign(name, encoded)),
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   o.secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+// readCookie verifies the cookie name and decodes it into v.
+func (o *OIDCAuthenticator) readCookie(r *http.Request, name string, v interface{}) error {
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return err
+	}
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 2 {
+		return errors.New("malformed cookie")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(sig, o.sign(name, parts[0])) {
+		return errors.New("invalid cookie signature")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return err
+	}
+	var c signedCookie
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return err
+	}
+	if time.Unix(c.Expires, 0).Before(time.Now()) {
+		return errors.New("cookie has expired")
+	}
+	return json.Unmarshal(c.Data, v)
+}
+
+func (o *OIDCAuthenticator) clearCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, &http.Cookie{Name: name, Path: "/", MaxAge: -1, HttpOnly: true, Secure: o.secure})
+}
+
+// sign returns the signature of a cookie's value. The name is included so
+// that one kind of cookie can't be used as another.
+func (o *OIDCAuthenticator) sign(name string, value string) []byte {
+	mac := hmac.New(sha256.New, o.secret)
+	mac.Write([]byte(name + "." + value)) // nolint: errcheck
+	return mac.Sum(nil)
+}
+
+// randomString returns a random string suitable for use as the OAuth state
+// or the OIDC nonce.
+func randomString() string {
+	b := make([]byte, 24)
+	// crypto/rand only errors if the OS's random number generator fails, in
+	// which case we've got bigger problems.
+	rand.Read(b) // nolint: errcheck
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// respond writes a plain text response.
+func respond(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	w.WriteHeader(code)
+	fmt.Fprintf(w, format+"\n", args...)
+}
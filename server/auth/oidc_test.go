@@ -0,0 +1,318 @@
+package auth_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const (
+	testClientID     = "atlantis"
+	testClientSecret = "secret"
+	testRedirectURL  = "https://atlantis.example.com/basepath/auth/callback"
+)
+
+// issuer is a stand-in OpenID Connect issuer. It issues ID tokens with
+// claims for any auth code.
+type issuer struct {
+	*httptest.Server
+	// claims are the claims in the next ID token it issues. "nonce" is set
+	// from the authorization request if it isn't in claims.
+	claims map[string]interface{}
+	// nonce is the nonce from the last authorization request.
+	nonce string
+}
+
+func newIssuer(t *testing.T) *issuer {
+	i := &issuer{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"issuer": %q, "authorization_endpoint": %q, "token_endpoint": %q}`, i.URL, i.URL+"/authorize", i.URL+"/token")
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		Ok(t, r.ParseForm())
+		Equals(t, "code", r.Form.Get("code"))
+		Equals(t, testRedirectURL, r.Form.Get("redirect_uri"))
+		if id, secret, ok := r.BasicAuth(); ok {
+			Equals(t, testClientID, id)
+			Equals(t, testClientSecret, secret)
+		} else {
+			Equals(t, testClientID, r.Form.Get("client_id"))
+			Equals(t, testClientSecret, r.Form.Get("client_secret"))
+		}
+		claims := map[string]interface{}{"nonce": i.nonce}
+		for k, v := range i.claims {
+			claims[k] = v
+		}
+		payload, err := json.Marshal(claims)
+		Ok(t, err)
+		idToken := "eyJhbGciOiJSUzI1NiJ9." + base64.RawURLEncoding.EncodeToString(payload) + ".c2ln"
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"access_token": "token", "token_type": "Bearer", "id_token": %q}`, idToken)
+	})
+	i.Server = httptest.NewServer(mux)
+	i.claims = map[string]interface{}{
+		"iss":   i.URL,
+		"aud":   testClientID,
+		"exp":   time.Now().Add(time.Hour).Unix(),
+		"email": "alice@example.com",
+	}
+	return i
+}
+
+// login starts logging in to a and follows the redirects through the issuer.
+// It returns the response to the callback request.
+func (i *issuer) login(t *testing.T, a *auth.OIDCAuthenticator, path string) *httptest.ResponseRecorder {
+	t.Helper()
+	req, _ := http.NewRequest("GET", path, nil)
+	w := httptest.NewRecorder()
+	_, ok := a.Authenticate(w, req)
+	Equals(t, false, ok)
+	Equals(t, http.StatusFound, w.Code)
+	loc, err := url.Parse(w.Header().Get("Location"))
+	Ok(t, err)
+	Equals(t, i.URL+"/authorize", fmt.Sprintf("%s://%s%s", loc.Scheme, loc.Host, loc.Path))
+	Equals(t, testClientID, loc.Query().Get("client_id"))
+	Equals(t, testRedirectURL, loc.Query().Get("redirect_uri"))
+	i.nonce = loc.Query().Get("nonce")
+	Assert(t, i.nonce != "", "exp nonce to be set")
+
+	callback, _ := http.NewRequest("GET", auth.CallbackPath+"?code=code&state="+url.QueryEscape(loc.Query().Get("state")), nil)
+	for _, c := range w.Result().Cookies() {
+		callback.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	_, ok = a.Authenticate(w, callback)
+	Equals(t, false, ok)
+	return w
+}
+
+func newOIDCAuthenticator(t *testing.T, i *issuer, modify func(*auth.OIDCConfig)) *auth.OIDCAuthenticator {
+	t.Helper()
+	config := auth.OIDCConfig{
+		IssuerURL:    i.URL,
+		ClientID:     testClientID,
+		ClientSecret: testClientSecret,
+		RedirectURL:  testRedirectURL,
+	}
+	if modify != nil {
+		modify(&config)
+	}
+	a, err := auth.NewOIDCAuthenticator(config)
+	Ok(t, err)
+	return a
+}
+
+func TestOIDCAuthenticator_Login(t *testing.T) {
+	i := newIssuer(t)
+	defer i.Close()
+	a := newOIDCAuthenticator(t, i, nil)
+
+	w := i.login(t, a, "/lock?id=abc")
+	Equals(t, http.StatusFound, w.Code)
+	Equals(t, "/basepath/lock?id=abc", w.Header().Get("Location"))
+
+	t.Log("the session cookie should authenticate later requests")
+	req, _ := http.NewRequest("DELETE", "/locks?id=abc", nil)
+	for _, c := range w.Result().Cookies() {
+		if c.MaxAge >= 0 {
+			Equals(t, true, c.Secure)
+			Equals(t, true, c.HttpOnly)
+			req.AddCookie(c)
+		}
+	}
+	username, ok := a.Authenticate(httptest.NewRecorder(), req)
+	Equals(t, true, ok)
+	Equals(t, "alice@example.com", username)
+}
+
+func TestOIDCAuthenticator_NotLoggedIn(t *testing.T) {
+	i := newIssuer(t)
+	defer i.Close()
+	a := newOIDCAuthenticator(t, i, nil)
+
+	t.Log("requests that aren't page loads can't be redirected")
+	req, _ := http.NewRequest("DELETE", "/locks?id=abc", nil)
+	w := httptest.NewRecorder()
+	_, ok := a.Authenticate(w, req)
+	Equals(t, false, ok)
+	Equals(t, http.StatusUnauthorized, w.Code)
+
+	t.Log("session cookies signed with a different secret should be rejected")
+	w = i.login(t, newOIDCAuthenticator(t, i, nil), "/")
+	Equals(t, http.StatusFound, w.Code)
+	req, _ = http.NewRequest("DELETE", "/locks?id=abc", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	_, ok = a.Authenticate(w, req)
+	Equals(t, false, ok)
+	Equals(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestOIDCAuthenticator_LoginErrs(t *testing.T) {
+	cases := []struct {
+		description string
+		claims      map[string]interface{}
+		modify      func(*auth.OIDCConfig)
+		expCode     int
+		expBody     string
+	}{
+		{
+			description: "wrong issuer",
+			claims:      map[string]interface{}{"iss": "https://evil.example.com"},
+			expCode:     http.StatusUnauthorized,
+			expBody:     `id token was issued by "https://evil.example.com"`,
+		},
+		{
+			description: "wrong audience",
+			claims:      map[string]interface{}{"aud": []string{"other"}},
+			expCode:     http.StatusUnauthorized,
+			expBody:     `id token was not issued for client "atlantis"`,
+		},
+		{
+			description: "expired",
+			claims:      map[string]interface{}{"exp": time.Now().Add(-time.Minute).Unix()},
+			expCode:     http.StatusUnauthorized,
+			expBody:     "id token has expired",
+		},
+		{
+			description: "wrong nonce",
+			claims:      map[string]interface{}{"nonce": "replayed"},
+			expCode:     http.StatusUnauthorized,
+			expBody:     "id token nonce does not match",
+		},
+		{
+			description: "missing username claim",
+			modify:      func(c *auth.OIDCConfig) { c.UsernameClaim = "preferred_username" },
+			expCode:     http.StatusUnauthorized,
+			expBody:     `id token does not contain the "preferred_username" claim`,
+		},
+		{
+			description: "user not allowed",
+			modify:      func(c *auth.OIDCConfig) { c.AllowedUsers = []string{"bob@example.com"} },
+			expCode:     http.StatusForbidden,
+			expBody:     `User "alice@example.com" is not allowed to use Atlantis`,
+		},
+		{
+			description: "domain not allowed",
+			modify:      func(c *auth.OIDCConfig) { c.AllowedDomains = []string{"example.org"} },
+			expCode:     http.StatusForbidden,
+			expBody:     "is not allowed to use Atlantis",
+		},
+		{
+			description: "email not verified",
+			claims:      map[string]interface{}{"email_verified": false},
+			modify:      func(c *auth.OIDCConfig) { c.AllowedDomains = []string{"example.com"} },
+			expCode:     http.StatusForbidden,
+			expBody:     "is not allowed to use Atlantis",
+		},
+		{
+			description: "domain allowed",
+			modify:      func(c *auth.OIDCConfig) { c.AllowedDomains = []string{"EXAMPLE.com"} },
+			expCode:     http.StatusFound,
+		},
+		{
+			description: "user allowed",
+			modify: func(c *auth.OIDCConfig) {
+				c.UsernameClaim = "sub"
+				c.AllowedUsers = []string{"alice"}
+			},
+			claims:  map[string]interface{}{"sub": "alice"},
+			expCode: http.StatusFound,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			i := newIssuer(t)
+			defer i.Close()
+			for k, v := range c.claims {
+				i.claims[k] = v
+			}
+			a := newOIDCAuthenticator(t, i, c.modify)
+			w := i.login(t, a, "/")
+			Equals(t, c.expCode, w.Code)
+			Assert(t, strings.Contains(w.Body.String(), c.expBody), "exp %q to contain %q", w.Body.String(), c.expBody)
+			if c.expCode != http.StatusFound {
+				for _, cookie := range w.Result().Cookies() {
+					Assert(t, cookie.Name != "atlantis_session", "exp no session cookie")
+				}
+			}
+		})
+	}
+}
+
+func TestOIDCAuthenticator_CallbackErrs(t *testing.T) {
+	i := newIssuer(t)
+	defer i.Close()
+	a := newOIDCAuthenticator(t, i, nil)
+
+	t.Log("callbacks without the state cookie should be rejected")
+	req, _ := http.NewRequest("GET", auth.CallbackPath+"?code=code&state=state", nil)
+	w := httptest.NewRecorder()
+	_, ok := a.Authenticate(w, req)
+	Equals(t, false, ok)
+	Equals(t, http.StatusBadRequest, w.Code)
+	Assert(t, strings.Contains(w.Body.String(), "Invalid login state"), "got %q", w.Body.String())
+
+	t.Log("callbacks with a different state should be rejected")
+	req, _ = http.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	a.Authenticate(w, req)
+	req, _ = http.NewRequest("GET", auth.CallbackPath+"?code=code&state=other", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	a.Authenticate(w, req)
+	Equals(t, http.StatusBadRequest, w.Code)
+
+	t.Log("errors from the issuer should be shown")
+	req, _ = http.NewRequest("GET", "/", nil)
+	w = httptest.NewRecorder()
+	a.Authenticate(w, req)
+	req, _ = http.NewRequest("GET", auth.CallbackPath+"?error=access_denied&error_description=denied+by+user", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	w = httptest.NewRecorder()
+	a.Authenticate(w, req)
+	Equals(t, http.StatusUnauthorized, w.Code)
+	Equals(t, "Logging in failed: access_denied: denied by user\n", w.Body.String())
+}
+
+func TestOIDCAuthenticator_ProtocolRelativeRedirect(t *testing.T) {
+	i := newIssuer(t)
+	defer i.Close()
+	a := newOIDCAuthenticator(t, i, nil)
+	w := i.login(t, a, "//evil.example.com")
+	Equals(t, http.StatusFound, w.Code)
+	Equals(t, "/basepath/", w.Header().Get("Location"))
+}
+
+func TestNewOIDCAuthenticator_Errs(t *testing.T) {
+	i := newIssuer(t)
+	defer i.Close()
+
+	_, err := auth.NewOIDCAuthenticator(auth.OIDCConfig{ClientID: "id", ClientSecret: "secret"})
+	ErrEquals(t, "oidc issuer-url must be set", err)
+
+	_, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{IssuerURL: i.URL, ClientID: "id"})
+	ErrEquals(t, "oidc client-id and client-secret must be set", err)
+
+	_, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{IssuerURL: i.URL + "/", ClientID: "id", ClientSecret: "secret"})
+	ErrEquals(t, fmt.Sprintf("oidc issuer %q in discovery document doesn't match issuer-url %q", i.URL, i.URL+"/"), err)
+
+	_, err = auth.NewOIDCAuthenticator(auth.OIDCConfig{IssuerURL: i.URL + "/nonexistent", ClientID: "id", ClientSecret: "secret"})
+	ErrEquals(t, fmt.Sprintf("fetching oidc discovery document from %s/nonexistent/.well-known/openid-configuration: got status 404", i.URL), err)
+}
@@ -0,0 +1,161 @@
+package server
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// reloadableSettings are the keys of the settings that ConfigReloader can
+// change while the server is running. Changes to any other settings are only
+// applied after a restart. That includes the contents of the --repo-config
+// file, which are only read on startup.
+var reloadableSettings = map[string]bool{
+	"log-level":         true,
+	"repo-whitelist":    true,
+	"require-approval":  true,
+	"require-mergeable": true,
+	"slack-token":       true,
+	"webhooks":          true,
+}
+
+// ConfigReloader reloads the server's config while it's running, ex. on
+// SIGHUP.
+type ConfigReloader struct {
+	// LoadConfig returns the new config. It should return an error if the
+	// config is invalid.
+	LoadConfig           func() (UserConfig, error)
+	Logger               *logging.SimpleLogger
+	Webhooks             *webhooks.MultiWebhookSender
+	RepoWhitelist        *events.RepoWhitelistChecker
+	ProjectCommandRunner *events.DefaultProjectCommandRunner
+
+	// mutex ensures only one reload happens at a time.
+	mutex sync.Mutex
+	// current is the config that the server is running with.
+	current UserConfig
+}
+
+// ReloadResult is the result of reloading the config.
+type ReloadResult struct {
+	// Reloaded are the keys of the settings that changed and were reloaded.
+	Reloaded []string `json:"reloaded"`
+	// RequireRestart are the keys of the settings that changed but won't be
+	// used until the server is restarted.
+	RequireRestart []string `json:"require_restart"`
+}
+
+// NewConfigReloader returns a reloader for a server that's running with
+// current.
+func NewConfigReloader(current UserConfig, loadConfig func() (UserConfig, error), logger *logging.SimpleLogger, webhooksSender *webhooks.MultiWebhookSender, repoWhitelist *events.RepoWhitelistChecker, projectCommandRunner *events.DefaultProjectCommandRunner) *ConfigReloader {
+	return &ConfigReloader{
+		LoadConfig:           loadConfig,
+		Logger:               logger,
+		Webhooks:             webhooksSender,
+		RepoWhitelist:        repoWhitelist,
+		ProjectCommandRunner: projectCommandRunner,
+		current:              current,
+	}
+}
+
+// Reload loads the config and applies the settings that can be changed while
+// the server is running. Either all of them are applied or, if the new config
+// is invalid, none of them are and an error is returned.
+func (c *ConfigReloader) Reload() (ReloadResult, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.LoadConfig == nil {
+		return ReloadResult{}, errors.New("reloading config is not supported")
+	}
+	newConfig, err := c.LoadConfig()
+	if err != nil {
+		c.Logger.Err("not reloading config: %s", err)
+		return ReloadResult{}, errors.Wrap(err, "loading config")
+	}
+
+	// Build everything before swapping anything so an error leaves the
+	// server running with the old config.
+	webhooksSender, err := newWebhooksSender(newConfig)
+	if err != nil {
+		c.Logger.Err("not reloading config: initializing webhooks: %s", err)
+		return ReloadResult{}, errors.Wrap(err, "initializing webhooks")
+	}
+	repoWhitelist, err := events.NewRepoWhitelistChecker(newConfig.RepoWhitelist)
+	if err != nil {
+		c.Logger.Err("not reloading config: %s", err)
+		return ReloadResult{}, errors.Wrap(err, "parsing repo whitelist")
+	}
+
+	c.swap(webhooksSender, repoWhitelist, newConfig)
+
+	result := c.apply(newConfig)
+	if len(result.Reloaded) > 0 {
+		c.Logger.Info("reloaded config: %s", strings.Join(result.Reloaded, ", "))
+	} else {
+		c.Logger.Info("reloaded config: no reloadable settings changed")
+	}
+	if len(result.RequireRestart) > 0 {
+		c.Logger.Warn("config settings changed that require a restart: %s", strings.Join(result.RequireRestart, ", "))
+	}
+	return result, nil
+}
+
+// swap replaces the settings of every component at once so nothing sees
+// some settings from the old config and some from the new one.
+func (c *ConfigReloader) swap(webhooksSender *webhooks.MultiWebhookSender, repoWhitelist *events.RepoWhitelistChecker, newConfig UserConfig) {
+	c.Webhooks.LockSettings()
+	defer c.Webhooks.UnlockSettings()
+	c.RepoWhitelist.LockSettings()
+	defer c.RepoWhitelist.UnlockSettings()
+	c.ProjectCommandRunner.LockSettings()
+	defer c.ProjectCommandRunner.UnlockSettings()
+
+	c.Webhooks.ReplaceLocked(webhooksSender)
+	c.RepoWhitelist.ReplaceLocked(repoWhitelist)
+	c.ProjectCommandRunner.SetApplyRequirementOverridesLocked(newConfig.RequireApproval, newConfig.RequireMergeable)
+	c.Logger.SetLevel(newConfig.ToLogLevel())
+}
+
+// apply updates the current config with the reloadable settings from
+// newConfig and returns which settings changed. Settings that require a
+// restart keep their current value so they're reported until the server is
+// restarted.
+func (c *ConfigReloader) apply(newConfig UserConfig) ReloadResult {
+	result := ReloadResult{Reloaded: []string{}, RequireRestart: []string{}}
+	current := reflect.ValueOf(&c.current).Elem()
+	updated := reflect.ValueOf(newConfig)
+	for i := 0; i < current.NumField(); i++ {
+		key := current.Type().Field(i).Tag.Get("mapstructure")
+		if reflect.DeepEqual(current.Field(i).Interface(), updated.Field(i).Interface()) {
+			continue
+		}
+		if reloadableSettings[key] {
+			current.Field(i).Set(updated.Field(i))
+			result.Reloaded = append(result.Reloaded, key)
+		} else {
+			result.RequireRestart = append(result.RequireRestart, key)
+		}
+	}
+	return result
+}
+
+// newWebhooksSender returns the sender for the webhooks in userConfig.
+func newWebhooksSender(userConfig UserConfig) (*webhooks.MultiWebhookSender, error) {
+	var webhooksConfig []webhooks.Config
+	for _, c := range userConfig.Webhooks {
+		config := webhooks.Config{
+			Channel:        c.Channel,
+			Event:          c.Event,
+			Kind:           c.Kind,
+			WorkspaceRegex: c.WorkspaceRegex,
+		}
+		webhooksConfig = append(webhooksConfig, config)
+	}
+	return webhooks.NewMultiWebhookSender(webhooksConfig, webhooks.NewSlackClient(userConfig.SlackToken))
+}
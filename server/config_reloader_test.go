@@ -0,0 +1,97 @@
+package server_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/webhooks"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func newTestReloader(t *testing.T, current server.UserConfig, loadConfig func() (server.UserConfig, error)) (*server.ConfigReloader, *events.RepoWhitelistChecker, *events.DefaultProjectCommandRunner, *logging.SimpleLogger) {
+	repoWhitelist, err := events.NewRepoWhitelistChecker(current.RepoWhitelist)
+	Ok(t, err)
+	runner := &events.DefaultProjectCommandRunner{}
+	logger := logging.NewNoopLogger()
+	reloader := server.NewConfigReloader(current, loadConfig, logger, &webhooks.MultiWebhookSender{}, repoWhitelist, runner)
+	return reloader, repoWhitelist, runner, logger
+}
+
+func TestConfigReloader_Reload(t *testing.T) {
+	current := server.UserConfig{
+		LogLevel:      "info",
+		Port:          4141,
+		RepoWhitelist: "github.com/owner/repo",
+	}
+	updated := current
+	updated.LogLevel = "debug"
+	updated.Port = 8080
+	updated.RepoWhitelist = "github.com/owner/other"
+	updated.RequireApproval = true
+	updated.SlackToken = "token"
+	reloader, repoWhitelist, runner, logger := newTestReloader(t, current, func() (server.UserConfig, error) {
+		return updated, nil
+	})
+
+	result, err := reloader.Reload()
+	Ok(t, err)
+	Equals(t, []string{"log-level", "repo-whitelist", "require-approval", "slack-token"}, result.Reloaded)
+	Equals(t, []string{"port"}, result.RequireRestart)
+	Equals(t, logging.Debug, logger.GetLevel())
+	Equals(t, true, repoWhitelist.IsWhitelisted("owner/other", "github.com"))
+	Equals(t, false, repoWhitelist.IsWhitelisted("owner/repo", "github.com"))
+	Equals(t, true, runner.RequireApprovalOverride)
+	Equals(t, false, runner.RequireMergeableOverride)
+
+	// Settings that require a restart should keep being reported until the
+	// server is restarted.
+	result, err = reloader.Reload()
+	Ok(t, err)
+	Equals(t, []string{}, result.Reloaded)
+	Equals(t, []string{"port"}, result.RequireRestart)
+}
+
+func TestConfigReloader_ReloadKeepsConfigOnError(t *testing.T) {
+	current := server.UserConfig{
+		LogLevel:      "info",
+		RepoWhitelist: "github.com/owner/repo",
+	}
+	cases := map[string]func() (server.UserConfig, error){
+		"invalid config": func() (server.UserConfig, error) {
+			return server.UserConfig{}, errors.New("invalid log level")
+		},
+		"invalid whitelist": func() (server.UserConfig, error) {
+			updated := current
+			updated.LogLevel = "debug"
+			updated.RequireApproval = true
+			updated.RepoWhitelist = "https://github.com/owner/other"
+			return updated, nil
+		},
+		"invalid webhook": func() (server.UserConfig, error) {
+			updated := current
+			updated.LogLevel = "debug"
+			updated.RequireApproval = true
+			updated.Webhooks = []server.WebhookConfig{{Event: "unsupported"}}
+			return updated, nil
+		},
+	}
+	for name, loadConfig := range cases {
+		t.Run(name, func(t *testing.T) {
+			reloader, repoWhitelist, runner, logger := newTestReloader(t, current, loadConfig)
+			_, err := reloader.Reload()
+			Assert(t, err != nil, "expected error")
+			Equals(t, logging.Info, logger.GetLevel())
+			Equals(t, true, repoWhitelist.IsWhitelisted("owner/repo", "github.com"))
+			Equals(t, false, runner.RequireApprovalOverride)
+		})
+	}
+}
+
+func TestConfigReloader_ReloadNotSupported(t *testing.T) {
+	reloader, _, _, _ := newTestReloader(t, server.UserConfig{}, nil)
+	_, err := reloader.Reload()
+	ErrEquals(t, "reloading config is not supported", err)
+}
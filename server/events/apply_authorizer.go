@@ -0,0 +1,119 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_apply_authorizer.go ApplyAuthorizer
+
+// ApplyAuthorizer checks who is allowed to apply a project.
+type ApplyAuthorizer interface {
+	// CanApply returns true if username can apply the project at repoRelDir
+	// and workspace in repo. If they can't, appliers describes who can,
+	// ex. ["alice", "team platform"].
+	CanApply(repo models.Repo, repoRelDir string, workspace string, username string) (allowed bool, appliers []string, err error)
+}
+
+// DefaultApplyAuthorizer restricts applies using the server's allowed
+// appliers. Projects that no rule matches can be applied by anyone unless
+// DenyUnmatched is set. If more than one rule matches, users allowed by any of
+// them can apply.
+type DefaultApplyAuthorizer struct {
+	Rules []models.ApplierRule
+	// DenyUnmatched is true if projects that no rule matches can't be
+	// applied by anyone.
+	DenyUnmatched bool
+	Teams         *TeamMembershipCache
+}
+
+// CanApply returns true if username is one of the users or is a member of one
+// of the teams allowed to apply the project.
+func (d *DefaultApplyAuthorizer) CanApply(repo models.Repo, repoRelDir string, workspace string, username string) (bool, []string, error) {
+	var users, teams []string
+	matched := false
+	for _, rule := range d.Rules {
+		if rule.Matches(repo.FullName, repoRelDir, workspace) {
+			matched = true
+			users = append(users, rule.Users...)
+			teams = append(teams, rule.Teams...)
+		}
+	}
+	if !matched {
+		return !d.DenyUnmatched, nil, nil
+	}
+
+	for _, u := range users {
+		if strings.EqualFold(u, username) {
+			return true, nil, nil
+		}
+	}
+	for _, t := range teams {
+		member, err := d.Teams.IsTeamMember(repo, t, username)
+		if err != nil {
+			return false, nil, errors.Wrapf(err, "checking if %s is a member of %s", username, t)
+		}
+		if member {
+			return true, nil, nil
+		}
+	}
+
+	var appliers []string
+	appliers = append(appliers, users...)
+	for _, t := range teams {
+		appliers = append(appliers, fmt.Sprintf("team %s", t))
+	}
+	return false, appliers, nil
+}
+
+// TeamMembershipCache caches team membership lookups so we don't hit the VCS
+// host's API rate limits. Errors aren't cached.
+type TeamMembershipCache struct {
+	VCSClient vcs.Client
+	// TTL is how long lookups are cached for.
+	TTL time.Duration
+
+	mutex   sync.Mutex
+	entries map[string]teamMembershipEntry
+	// now is overridden in tests.
+	now func() time.Time
+}
+
+type teamMembershipEntry struct {
+	member  bool
+	expires time.Time
+}
+
+// IsTeamMember returns true if username is a member of team.
+func (t *TeamMembershipCache) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	key := fmt.Sprintf("%s|%s|%s|%s", repo.VCSHost.Hostname, repo.Owner, team, strings.ToLower(username))
+	now := time.Now()
+	if t.now != nil {
+		now = t.now()
+	}
+
+	t.mutex.Lock()
+	entry, ok := t.entries[key]
+	t.mutex.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.member, nil
+	}
+
+	member, err := t.VCSClient.IsTeamMember(repo, team, username)
+	if err != nil {
+		return false, err
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	if t.entries == nil {
+		t.entries = make(map[string]teamMembershipEntry)
+	}
+	t.entries[key] = teamMembershipEntry{member: member, expires: now.Add(t.TTL)}
+	return member, nil
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultApplyAuthorizer_CanApply(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner"}
+	authorizer := DefaultApplyAuthorizer{
+		Rules: []models.ApplierRule{
+			{RepoFullName: "owner/repo", DirGlob: "prod/*", Users: []string{"Alice"}, Teams: []string{"platform"}},
+			{DirGlob: "prod/*", Users: []string{"bob"}},
+			{DirGlob: "staging/*", Workspace: "prod", Users: []string{"erin"}},
+		},
+		Teams: &TeamMembershipCache{VCSClient: vcsClient, TTL: time.Minute},
+	}
+	When(vcsClient.IsTeamMember(repo, "platform", "carol")).ThenReturn(true, nil)
+
+	t.Log("projects no rule matches can be applied by anyone")
+	allowed, _, err := authorizer.CanApply(repo, "staging/vpc", "default", "dave")
+	Ok(t, err)
+	Equals(t, true, allowed)
+
+	t.Log("rules can be limited to a workspace")
+	allowed, appliers, err := authorizer.CanApply(repo, "staging/vpc", "prod", "dave")
+	Ok(t, err)
+	Equals(t, false, allowed)
+	Equals(t, []string{"erin"}, appliers)
+
+	t.Log("users from any matching rule can apply, case-insensitively")
+	for _, user := range []string{"alice", "bob"} {
+		allowed, _, err = authorizer.CanApply(repo, "prod/vpc", "", user)
+		Ok(t, err)
+		Equals(t, true, allowed)
+	}
+	vcsClient.VerifyWasCalled(Never()).IsTeamMember(repo, "platform", "alice")
+
+	t.Log("team members can apply")
+	allowed, _, err = authorizer.CanApply(repo, "prod/vpc", "", "carol")
+	Ok(t, err)
+	Equals(t, true, allowed)
+
+	t.Log("everyone else gets who can apply")
+	allowed, appliers, err = authorizer.CanApply(repo, "prod/vpc", "", "dave")
+	Ok(t, err)
+	Equals(t, false, allowed)
+	Equals(t, []string{"Alice", "bob", "team platform"}, appliers)
+
+	t.Log("projects no rule matches can't be applied if DenyUnmatched is set")
+	authorizer.DenyUnmatched = true
+	allowed, appliers, err = authorizer.CanApply(repo, "staging/vpc", "default", "dave")
+	Ok(t, err)
+	Equals(t, false, allowed)
+	Equals(t, 0, len(appliers))
+}
+
+func TestTeamMembershipCache_IsTeamMember(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner"}
+	now := time.Now()
+	cache := &TeamMembershipCache{
+		VCSClient: vcsClient,
+		TTL:       5 * time.Minute,
+		now:       func() time.Time { return now },
+	}
+	When(vcsClient.IsTeamMember(repo, "platform", "alice")).ThenReturn(true, nil)
+
+	for i := 0; i < 2; i++ {
+		member, err := cache.IsTeamMember(repo, "platform", "alice")
+		Ok(t, err)
+		Equals(t, true, member)
+	}
+	vcsClient.VerifyWasCalledOnce().IsTeamMember(repo, "platform", "alice")
+
+	t.Log("lookups expire after the TTL")
+	now = now.Add(5 * time.Minute)
+	_, err := cache.IsTeamMember(repo, "platform", "alice")
+	Ok(t, err)
+	vcsClient.VerifyWasCalled(Times(2)).IsTeamMember(repo, "platform", "alice")
+
+	t.Log("errors aren't cached")
+	When(vcsClient.IsTeamMember(repo, "platform", "bob")).ThenReturn(false, errors.New("rate limited"))
+	for i := 0; i < 2; i++ {
+		_, err = cache.IsTeamMember(repo, "platform", "bob")
+		ErrEquals(t, "rate limited", err)
+	}
+	vcsClient.VerifyWasCalled(Times(2)).IsTeamMember(repo, "platform", "bob")
+}
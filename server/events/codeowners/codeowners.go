@@ -0,0 +1,178 @@
+// Package codeowners parses CODEOWNERS files in the GitHub and GitLab syntax.
+package codeowners
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Locations are where CODEOWNERS files are looked for, relative to the repo
+// root, in order. GitHub and GitLab each only look in some of them.
+var Locations = []string{
+	".github/CODEOWNERS",
+	".gitlab/CODEOWNERS",
+	"CODEOWNERS",
+	"docs/CODEOWNERS",
+}
+
+// File is a parsed CODEOWNERS file.
+type File struct {
+	// Sections are GitLab's CODEOWNERS sections. Rules before the first
+	// section header, and all the rules of GitHub files, are in an unnamed
+	// section.
+	Sections []Section
+}
+
+// Section is a group of rules. The owners of a path are picked separately in
+// each section.
+type Section struct {
+	Name  string
+	Rules []Rule
+}
+
+// Rule assigns owners to the paths matching its pattern.
+type Rule struct {
+	Pattern string
+	// Owners are usernames or teams prefixed with @, or emails. A rule with
+	// no owners makes the paths it matches unowned.
+	Owners []string
+	regex  *regexp.Regexp
+}
+
+// sectionHeaderRegex matches GitLab section headers, ex. "[Docs]",
+// "^[Docs][2] @docs-team".
+var sectionHeaderRegex = regexp.MustCompile(`^\^?\[([^\]]+)\](?:\[\d+\])?(.*)$`)
+
+// Find parses the first CODEOWNERS file in repoDir. It returns nil if there
+// isn't one.
+func Find(repoDir string) (*File, error) {
+	for _, loc := range Locations {
+		f, err := os.Open(filepath.Join(repoDir, loc))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close() // nolint: errcheck
+		file, err := Parse(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parsing %s", loc)
+		}
+		return file, nil
+	}
+	return nil, nil
+}
+
+// Parse parses a CODEOWNERS file.
+func Parse(r io.Reader) (*File, error) {
+	file := &File{Sections: []Section{{}}}
+	var defaultOwners []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if match := sectionHeaderRegex.FindStringSubmatch(line); match != nil {
+			defaultOwners = strings.Fields(stripComment(match[2]))
+			file.Sections = append(file.Sections, Section{Name: match[1]})
+			continue
+		}
+		fields := strings.Fields(stripComment(line))
+		if len(fields) == 0 {
+			continue
+		}
+		owners := fields[1:]
+		if len(owners) == 0 {
+			owners = defaultOwners
+		}
+		section := &file.Sections[len(file.Sections)-1]
+		section.Rules = append(section.Rules, Rule{
+			Pattern: fields[0],
+			Owners:  owners,
+			regex:   patternRegex(fields[0]),
+		})
+	}
+	return file, scanner.Err()
+}
+
+// OwnerGroups returns the owners of path from each section. Each group is
+// the owners of the last rule in its section that matches path. Sections
+// where path is unowned are skipped.
+func (f *File) OwnerGroups(path string) [][]string {
+	path = strings.TrimPrefix(filepath.ToSlash(path), "/")
+	var groups [][]string
+	for _, section := range f.Sections {
+		for i := len(section.Rules) - 1; i >= 0; i-- {
+			rule := section.Rules[i]
+			if !rule.regex.MatchString(path) {
+				continue
+			}
+			if len(rule.Owners) > 0 {
+				groups = append(groups, rule.Owners)
+			}
+			break
+		}
+	}
+	return groups
+}
+
+// stripComment removes a trailing comment from line.
+func stripComment(line string) string {
+	if i := strings.Index(line, " #"); i != -1 {
+		return line[:i]
+	}
+	return line
+}
+
+// patternRegex converts a gitignore-style pattern into a regex. Patterns
+// that contain a slash other than a trailing one are relative to the repo
+// root, others match at any depth. Patterns match everything under the
+// directories they match, except for patterns ending in /* which only match
+// the files directly in a directory, and patterns ending in a slash only
+// match directories.
+func patternRegex(pattern string) *regexp.Regexp {
+	dirOnly := strings.HasSuffix(pattern, "/")
+	pattern = strings.TrimSuffix(pattern, "/")
+	filesOnly := strings.HasSuffix(pattern, "/*") && !strings.HasSuffix(pattern, "/**")
+	anchored := strings.Contains(pattern, "/")
+	pattern = strings.TrimPrefix(pattern, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	if !anchored {
+		re.WriteString("(?:.*/)?")
+	}
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			re.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			re.WriteString(".*")
+			i++
+		case c == '*':
+			re.WriteString("[^/]*")
+		case c == '?':
+			re.WriteString("[^/]")
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	switch {
+	case dirOnly:
+		re.WriteString("/.*$")
+	case filesOnly:
+		re.WriteString("$")
+	default:
+		re.WriteString("(?:/.*)?$")
+	}
+	return regexp.MustCompile(re.String())
+}
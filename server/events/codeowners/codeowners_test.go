@@ -0,0 +1,83 @@
+package codeowners_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/codeowners"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestOwnerGroups(t *testing.T) {
+	file, err := codeowners.Parse(strings.NewReader(`
+# Default owners.
+*                 @global-owner
+
+*.tf              @terraform-team # inline comment
+/prod/            @org/infra @alice
+apps/             @apps-owner
+docs/*            @docs-owner
+/build/**/logs    @logs-owner
+/prod/unowned.tf
+`))
+	Ok(t, err)
+	cases := []struct {
+		path string
+		exp  [][]string
+	}{
+		{"README.md", [][]string{{"@global-owner"}}},
+		{"staging/main.tf", [][]string{{"@terraform-team"}}},
+		{"prod/main.tf", [][]string{{"@org/infra", "@alice"}}},
+		{"prod/modules/vpc/main.tf", [][]string{{"@org/infra", "@alice"}}},
+		{"staging/prod/main.tf", [][]string{{"@terraform-team"}}},
+		{"apps/web/index.js", [][]string{{"@apps-owner"}}},
+		{"src/apps/web/index.js", [][]string{{"@apps-owner"}}},
+		{"docs/index.md", [][]string{{"@docs-owner"}}},
+		{"docs/guides/index.md", [][]string{{"@global-owner"}}},
+		{"build/logs/out.log", [][]string{{"@logs-owner"}}},
+		{"build/a/b/logs/out.log", [][]string{{"@logs-owner"}}},
+		{"prod/unowned.tf", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			Equals(t, c.exp, file.OwnerGroups(c.path))
+		})
+	}
+}
+
+func TestOwnerGroups_GitlabSections(t *testing.T) {
+	file, err := codeowners.Parse(strings.NewReader(`
+*.tf @terraform-team
+
+[Production] @prod-team
+prod/
+prod/secrets/ @security
+
+^[Optional][2]
+prod/*.md @docs
+`))
+	Ok(t, err)
+	Equals(t, []string{"", "Production", "Optional"}, []string{file.Sections[0].Name, file.Sections[1].Name, file.Sections[2].Name})
+	Equals(t, [][]string{{"@terraform-team"}, {"@prod-team"}}, file.OwnerGroups("prod/main.tf"))
+	Equals(t, [][]string{{"@terraform-team"}, {"@security"}}, file.OwnerGroups("prod/secrets/main.tf"))
+	Equals(t, [][]string{{"@prod-team"}, {"@docs"}}, file.OwnerGroups("prod/README.md"))
+}
+
+func TestFind(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	file, err := codeowners.Find(tmp)
+	Ok(t, err)
+	Assert(t, file == nil, "exp nil when there's no CODEOWNERS file")
+
+	Ok(t, os.MkdirAll(filepath.Join(tmp, "docs"), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "docs", "CODEOWNERS"), []byte("* @docs"), 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "CODEOWNERS"), []byte("* @root"), 0600))
+	file, err = codeowners.Find(tmp)
+	Ok(t, err)
+	Equals(t, [][]string{{"@root"}}, file.OwnerGroups("main.tf"))
+}
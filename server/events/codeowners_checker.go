@@ -0,0 +1,136 @@
+package events
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/codeowners"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_codeowners_checker.go CodeownersChecker
+
+// CodeownersChecker checks that the code owners of a project's modified files
+// have approved the pull request.
+type CodeownersChecker interface {
+	// MissingOwners returns the owner groups of the files modified in the
+	// project that haven't approved the pull request. Each group is satisfied
+	// by an approval from any one of its owners. repoDir is the root of the
+	// pull request's cloned repo.
+	MissingOwners(ctx models.ProjectCommandContext, repoDir string) ([][]string, error)
+}
+
+// DefaultCodeownersChecker reads the CODEOWNERS file from the cloned repo and
+// uses the VCS host to get the modified files and approvals.
+type DefaultCodeownersChecker struct {
+	VCSClient vcs.Client
+	// Teams is used to check if approvers are members of teams listed as
+	// owners, ex. @org/team.
+	Teams *TeamMembershipCache
+}
+
+// MissingOwners returns an error if the repo has no CODEOWNERS file or if the
+// pull request modifies one. Since the file is read from the pull request's
+// branch, its author could otherwise change who has to approve it.
+func (d *DefaultCodeownersChecker) MissingOwners(ctx models.ProjectCommandContext, repoDir string) ([][]string, error) {
+	modifiedFiles, err := d.VCSClient.GetModifiedFiles(ctx.BaseRepo, ctx.Pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting modified files")
+	}
+	for _, f := range modifiedFiles {
+		for _, loc := range codeowners.Locations {
+			if filepath.Clean(f) == loc {
+				return nil, fmt.Errorf("pull request modifies %s so code owner approvals can't be checked: merge that change in a separate pull request first", loc)
+			}
+		}
+	}
+
+	file, err := codeowners.Find(repoDir)
+	if err != nil {
+		return nil, err
+	}
+	if file == nil {
+		return nil, errors.New("no CODEOWNERS file found in .github/, .gitlab/, the repo root or docs/")
+	}
+
+	groups := make(map[string][]string)
+	for _, f := range modifiedFiles {
+		if !inDir(f, ctx.RepoRelDir) {
+			continue
+		}
+		for _, g := range file.OwnerGroups(f) {
+			groups[strings.Join(g, " ")] = g
+		}
+	}
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	approvals, err := d.VCSClient.GetApprovals(ctx.BaseRepo, ctx.Pull)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting pull request approvals")
+	}
+	var approvers []string
+	for _, a := range approvals {
+		// Authors can't approve their own changes.
+		if !strings.EqualFold(a.Username, ctx.Pull.Author) {
+			approvers = append(approvers, a.Username)
+		}
+	}
+
+	var missing [][]string
+	for _, g := range groups {
+		approved, err := d.approvedByGroup(ctx.BaseRepo, g, approvers)
+		if err != nil {
+			return nil, err
+		}
+		if !approved {
+			missing = append(missing, g)
+		}
+	}
+	sort.Slice(missing, func(i, j int) bool {
+		return strings.Join(missing[i], " ") < strings.Join(missing[j], " ")
+	})
+	return missing, nil
+}
+
+// approvedByGroup returns true if one of approvers is one of owners or is a
+// member of one of the teams in owners. Email owners can't be matched to
+// approvers so they're ignored.
+func (d *DefaultCodeownersChecker) approvedByGroup(repo models.Repo, owners []string, approvers []string) (bool, error) {
+	for _, owner := range owners {
+		if !strings.HasPrefix(owner, "@") {
+			continue
+		}
+		name := strings.TrimPrefix(owner, "@")
+		for _, approver := range approvers {
+			if strings.EqualFold(name, approver) {
+				return true, nil
+			}
+			if !strings.Contains(name, "/") {
+				continue
+			}
+			member, err := d.Teams.IsTeamMember(repo, name, approver)
+			if err != nil {
+				return false, errors.Wrapf(err, "checking if %s is a member of %s", approver, name)
+			}
+			if member {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// inDir returns true if the repo-relative file is in dir.
+func inDir(file string, dir string) bool {
+	dir = filepath.Clean(dir)
+	if dir == "." {
+		return true
+	}
+	return strings.HasPrefix(filepath.Clean(file)+"/", dir+"/")
+}
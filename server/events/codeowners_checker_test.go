@@ -0,0 +1,76 @@
+package events_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestDefaultCodeownersChecker_MissingOwners(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	checker := &events.DefaultCodeownersChecker{
+		VCSClient: vcsClient,
+		Teams:     &events.TeamMembershipCache{VCSClient: vcsClient, TTL: time.Minute},
+	}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, os.Mkdir(filepath.Join(repoDir, ".github"), 0700))
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, ".github", "CODEOWNERS"), []byte(`
+*.tf           @terraform
+/prod/         @org/infra @alice
+/prod/iam/     @security security@example.com
+`), 0600))
+
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner"}
+	pull := models.PullRequest{Num: 1, Author: "alice"}
+	ctx := models.ProjectCommandContext{BaseRepo: repo, Pull: pull, RepoRelDir: "prod"}
+	When(vcsClient.GetModifiedFiles(repo, pull)).ThenReturn([]string{"prod/main.tf", "prod/iam/roles.tf", "staging/main.tf"}, nil)
+
+	t.Log("the author's approval doesn't count and files outside the project are ignored")
+	When(vcsClient.GetApprovals(repo, pull)).ThenReturn([]models.Approval{{Username: "alice"}}, nil)
+	missing, err := checker.MissingOwners(ctx, repoDir)
+	Ok(t, err)
+	Equals(t, [][]string{{"@org/infra", "@alice"}, {"@security", "security@example.com"}}, missing)
+
+	t.Log("team members and users satisfy their groups")
+	When(vcsClient.GetApprovals(repo, pull)).ThenReturn([]models.Approval{{Username: "bob"}, {Username: "Security"}}, nil)
+	When(vcsClient.IsTeamMember(repo, "org/infra", "bob")).ThenReturn(true, nil)
+	missing, err = checker.MissingOwners(ctx, repoDir)
+	Ok(t, err)
+	Equals(t, 0, len(missing))
+}
+
+func TestDefaultCodeownersChecker_NoCodeowners(t *testing.T) {
+	RegisterMockTestingT(t)
+	checker := &events.DefaultCodeownersChecker{VCSClient: mocks.NewMockClient()}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	_, err := checker.MissingOwners(models.ProjectCommandContext{RepoRelDir: "."}, repoDir)
+	ErrEquals(t, "no CODEOWNERS file found in .github/, .gitlab/, the repo root or docs/", err)
+}
+
+func TestDefaultCodeownersChecker_ModifiedCodeowners(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	checker := &events.DefaultCodeownersChecker{VCSClient: vcsClient}
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "CODEOWNERS"), []byte("* @alice\n"), 0600))
+
+	// Adding a CODEOWNERS file with a higher precedence counts too.
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner"}
+	pull := models.PullRequest{Num: 1, Author: "bob"}
+	When(vcsClient.GetModifiedFiles(repo, pull)).ThenReturn([]string{"main.tf", ".github/CODEOWNERS"}, nil)
+	_, err := checker.MissingOwners(models.ProjectCommandContext{BaseRepo: repo, Pull: pull, RepoRelDir: "."}, repoDir)
+	ErrEquals(t, "pull request modifies .github/CODEOWNERS so code owner approvals can't be checked: merge that change in a separate pull request first", err)
+	vcsClient.VerifyWasCalled(Never()).GetApprovals(repo, pull)
+}
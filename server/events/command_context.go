@@ -37,4 +37,7 @@ type CommandContext struct {
 	// set our own build statuses which can affect mergeability if users have
 	// required the Atlantis status to be successful prior to merging.
 	PullMergeable bool
+	// JobID is the id of the job tracking this command. It will be empty if
+	// jobs aren't being tracked.
+	JobID string
 }
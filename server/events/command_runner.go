@@ -15,13 +15,17 @@ package events
 
 import (
 	"fmt"
+	"time"
+
 	"github.com/google/go-github/github"
 	"github.com/lkysow/go-gitlab"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/db"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 	"github.com/runatlantis/atlantis/server/recovery"
 )
 
@@ -52,6 +56,14 @@ type GitlabMergeRequestGetter interface {
 	GetMergeRequest(repoFullName string, pullNum int) (*gitlab.MergeRequest, error)
 }
 
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_job_url_generator.go JobURLGenerator
+
+// JobURLGenerator generates urls to the page that shows a job's output.
+type JobURLGenerator interface {
+	// GenerateJobURL returns the full URL to the output of the job at jobID.
+	GenerateJobURL(jobID string) string
+}
+
 // DefaultCommandRunner is the first step when processing a comment command.
 type DefaultCommandRunner struct {
 	VCSClient                vcs.Client
@@ -75,30 +87,49 @@ type DefaultCommandRunner struct {
 	PendingPlanFinder PendingPlanFinder
 	WorkingDir        WorkingDir
 	DB                *db.BoltDB
+	// Jobs tracks the commands that are running. If nil, jobs aren't tracked.
+	Jobs *JobTracker
+	// JobURLGenerator is used to link commit statuses and comments to the
+	// output of the job running the command. If nil, or if jobs aren't
+	// tracked, they don't link to the output.
+	JobURLGenerator JobURLGenerator
+	// ServerConfig is the server-side repo config. Commands on pull requests
+	// into base branches that don't match the repo's branch config are
+	// refused. If nil, commands on pull requests into any branch are run.
+	ServerConfig *valid.ServerConfig
 }
 
 // RunAutoplanCommand runs plan when a pull request is opened or updated.
 func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo models.Repo, pull models.PullRequest, user models.User) {
 	log := c.buildLogger(baseRepo.FullName, pull.Num)
 	defer c.logPanics(baseRepo, pull.Num, log)
+	jobID := c.startJob(baseRepo.FullName, pull.Num, user, nil)
+	defer c.finishJob(jobID)
 	ctx := &CommandContext{
 		User:     user,
 		Log:      log,
 		Pull:     pull,
 		HeadRepo: headRepo,
 		BaseRepo: baseRepo,
+		JobID:    jobID,
 	}
 	if !c.validateCtxAndComment(ctx) {
 		return
 	}
 
-	if err := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.PendingCommitStatus, models.PlanCommand); err != nil {
+	if err := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.PendingCommitStatus, models.PlanCommand, c.jobURL(ctx)); err != nil {
 		ctx.Log.Warn("unable to update commit status: %s", err)
 	}
 
 	projectCmds, err := c.ProjectCommandBuilder.BuildAutoplanCommands(ctx)
+	if branchErr, ok := errors.Cause(err).(BranchNotMatchedErr); ok {
+		// Pull requests into branches that the repo's atlantis.yaml doesn't
+		// match are ignored like when no projects were modified.
+		log.Info("not autoplanning: %s", branchErr)
+		err = nil
+	}
 	if err != nil {
-		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, models.PlanCommand); statusErr != nil {
+		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, models.PlanCommand, c.jobURL(ctx)); statusErr != nil {
 			ctx.Log.Warn("unable to update commit status: %s", statusErr)
 		}
 
@@ -110,13 +141,13 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 		// If there were no projects modified, we set a successful commit status
 		// with 0/0 projects planned successfully because we've already set an
 		// in-progress status and we don't want that to be "in progress" forever.
-		if err := c.CommitStatusUpdater.UpdateCombinedCount(baseRepo, pull, models.SuccessCommitStatus, models.PlanCommand, 0, 0); err != nil {
+		if err := c.CommitStatusUpdater.UpdateCombinedCount(baseRepo, pull, models.SuccessCommitStatus, models.PlanCommand, 0, 0, c.jobURL(ctx)); err != nil {
 			ctx.Log.Warn("unable to update commit status: %s", err)
 		}
 		return
 	}
 
-	result := c.runProjectCmds(projectCmds, models.PlanCommand)
+	result := c.runProjectCmds(ctx, projectCmds, models.PlanCommand)
 	if c.automergeEnabled(ctx, projectCmds) && result.HasErrors() {
 		ctx.Log.Info("deleting plans because there were errors and automerge requires all plans succeed")
 		c.deletePlans(ctx)
@@ -139,6 +170,8 @@ func (c *DefaultCommandRunner) RunAutoplanCommand(baseRepo models.Repo, headRepo
 func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHeadRepo *models.Repo, maybePull *models.PullRequest, user models.User, pullNum int, cmd *CommentCommand) {
 	log := c.buildLogger(baseRepo.FullName, pullNum)
 	defer c.logPanics(baseRepo, pullNum, log)
+	jobID := c.startJob(baseRepo.FullName, pullNum, user, cmd)
+	defer c.finishJob(jobID)
 
 	var headRepo models.Repo
 	if maybeHeadRepo != nil {
@@ -152,7 +185,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		pull, headRepo, err = c.getGithubData(baseRepo, pullNum)
 	case models.Gitlab:
 		pull, err = c.getGitlabData(baseRepo, pullNum)
-	case models.BitbucketCloud, models.BitbucketServer:
+	case models.BitbucketCloud, models.BitbucketServer, models.AzureDevops:
 		if maybePull == nil {
 			err = errors.New("pull request should not be nil–this is a bug")
 		}
@@ -162,6 +195,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 	}
 	if err != nil {
 		log.Err(err.Error())
+		c.failJob(jobID, err.Error())
 		if commentErr := c.VCSClient.CreateComment(baseRepo, pullNum, fmt.Sprintf("`Error: %s`", err)); commentErr != nil {
 			log.Err("unable to comment: %s", commentErr)
 		}
@@ -173,10 +207,14 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		Pull:     pull,
 		HeadRepo: headRepo,
 		BaseRepo: baseRepo,
+		JobID:    jobID,
 	}
 	if !c.validateCtxAndComment(ctx) {
 		return
 	}
+	if !c.validateBranchAndComment(ctx) {
+		return
+	}
 
 	if cmd.CommandName() == models.ApplyCommand {
 		// Get the mergeable status before we set any build statuses of our own.
@@ -194,7 +232,7 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		ctx.Log.Info("pull request mergeable status: %t", ctx.PullMergeable)
 	}
 
-	if err = c.CommitStatusUpdater.UpdateCombined(baseRepo, pull, models.PendingCommitStatus, cmd.CommandName()); err != nil {
+	if err = c.CommitStatusUpdater.UpdateCombined(baseRepo, pull, models.PendingCommitStatus, cmd.CommandName(), c.jobURL(ctx)); err != nil {
 		ctx.Log.Warn("unable to update commit status: %s", err)
 	}
 
@@ -206,17 +244,27 @@ func (c *DefaultCommandRunner) RunCommentCommand(baseRepo models.Repo, maybeHead
 		projectCmds, err = c.ProjectCommandBuilder.BuildApplyCommands(ctx, cmd)
 	default:
 		ctx.Log.Err("failed to determine desired command, neither plan nor apply")
+		c.failJob(ctx.JobID, "unknown command")
+		return
+	}
+	if branchErr, ok := errors.Cause(err).(BranchNotMatchedErr); ok {
+		// We've already set a pending status so we set a successful one with
+		// 0/0 projects, like when there are no projects to run.
+		if statusErr := c.CommitStatusUpdater.UpdateCombinedCount(ctx.BaseRepo, ctx.Pull, models.SuccessCommitStatus, cmd.CommandName(), 0, 0, c.jobURL(ctx)); statusErr != nil {
+			ctx.Log.Warn("unable to update commit status: %s", statusErr)
+		}
+		c.refuseBranch(ctx, branchErr.Branch)
 		return
 	}
 	if err != nil {
-		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmd.CommandName()); statusErr != nil {
+		if statusErr := c.CommitStatusUpdater.UpdateCombined(ctx.BaseRepo, ctx.Pull, models.FailedCommitStatus, cmd.CommandName(), c.jobURL(ctx)); statusErr != nil {
 			ctx.Log.Warn("unable to update commit status: %s", statusErr)
 		}
 		c.updatePull(ctx, cmd, CommandResult{Error: err})
 		return
 	}
 
-	result := c.runProjectCmds(projectCmds, cmd.Name)
+	result := c.runProjectCmds(ctx, projectCmds, cmd.Name)
 	if cmd.Name == models.PlanCommand && c.automergeEnabled(ctx, projectCmds) && result.HasErrors() {
 		ctx.Log.Info("deleting plans because there were errors and automerge requires all plans succeed")
 		c.deletePlans(ctx)
@@ -267,7 +315,7 @@ func (c *DefaultCommandRunner) updateCommitStatus(ctx *CommandContext, cmd model
 		}
 	}
 
-	if err := c.CommitStatusUpdater.UpdateCombinedCount(ctx.BaseRepo, ctx.Pull, status, cmd, numSuccess, len(pullStatus.Projects)); err != nil {
+	if err := c.CommitStatusUpdater.UpdateCombinedCount(ctx.BaseRepo, ctx.Pull, status, cmd, numSuccess, len(pullStatus.Projects), c.jobURL(ctx)); err != nil {
 		ctx.Log.Warn("unable to update commit status: %s", err)
 	}
 }
@@ -301,21 +349,51 @@ func (c *DefaultCommandRunner) automerge(ctx *CommandContext, pullStatus models.
 	}
 }
 
-func (c *DefaultCommandRunner) runProjectCmds(cmds []models.ProjectCommandContext, cmdName models.CommandName) CommandResult {
+func (c *DefaultCommandRunner) runProjectCmds(ctx *CommandContext, cmds []models.ProjectCommandContext, cmdName models.CommandName) CommandResult {
 	var results []models.ProjectResult
 	for _, pCmd := range cmds {
+		if c.Jobs != nil && ctx.JobID != "" {
+			pCmd.JobID = ctx.JobID
+			pCmd.Output = c.Jobs.OutputWriter(ctx.JobID)
+			fmt.Fprintf(pCmd.Output, "Running %s in dir: %s workspace: %s\n", cmdName.String(), pCmd.RepoRelDir, pCmd.Workspace) // nolint: errcheck
+		}
+
 		var res models.ProjectResult
+		start := time.Now()
 		switch cmdName {
 		case models.PlanCommand:
 			res = c.ProjectCommandRunner.Plan(pCmd)
 		case models.ApplyCommand:
 			res = c.ProjectCommandRunner.Apply(pCmd)
 		}
+		metrics.ProjectCommandDuration.WithLabelValues(cmdName.String(), projectResultLabel(res)).Observe(time.Since(start).Seconds())
 		results = append(results, res)
 	}
 	return CommandResult{ProjectResults: results}
 }
 
+// jobURL returns the URL of the output of the job that ctx is running as. It
+// returns an empty string if jobs aren't being tracked.
+func (c *DefaultCommandRunner) jobURL(ctx *CommandContext) string {
+	if c.JobURLGenerator == nil || ctx.JobID == "" {
+		return ""
+	}
+	return c.JobURLGenerator.GenerateJobURL(ctx.JobID)
+}
+
+// projectResultLabel returns the value of the result label for res in our
+// metrics.
+func projectResultLabel(res models.ProjectResult) string {
+	switch {
+	case res.Error != nil:
+		return "error"
+	case res.Failure != "":
+		return "failure"
+	default:
+		return "success"
+	}
+}
+
 func (c *DefaultCommandRunner) getGithubData(baseRepo models.Repo, pullNum int) (models.PullRequest, models.Repo, error) {
 	if c.GithubPullGetter == nil {
 		return models.PullRequest{}, models.Repo{}, errors.New("Atlantis not configured to support GitHub")
@@ -351,7 +429,9 @@ func (c *DefaultCommandRunner) buildLogger(repoFullName string, pullNum int) *lo
 func (c *DefaultCommandRunner) validateCtxAndComment(ctx *CommandContext) bool {
 	if !c.AllowForkPRs && ctx.HeadRepo.Owner != ctx.BaseRepo.Owner {
 		ctx.Log.Info("command was run on a fork pull request which is disallowed")
-		if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, fmt.Sprintf("Atlantis commands can't be run on fork pull requests. To enable, set --%s", c.AllowForkPRsFlag)); err != nil {
+		msg := fmt.Sprintf("Atlantis commands can't be run on fork pull requests. To enable, set --%s", c.AllowForkPRsFlag)
+		c.failJob(ctx.JobID, msg)
+		if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, msg); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
@@ -359,7 +439,9 @@ func (c *DefaultCommandRunner) validateCtxAndComment(ctx *CommandContext) bool {
 
 	if ctx.Pull.State != models.OpenPullState {
 		ctx.Log.Info("command was run on closed pull request")
-		if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, "Atlantis commands can't be run on closed pull requests"); err != nil {
+		msg := "Atlantis commands can't be run on closed pull requests"
+		c.failJob(ctx.JobID, msg)
+		if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, msg); err != nil {
 			ctx.Log.Err("unable to comment: %s", err)
 		}
 		return false
@@ -367,6 +449,33 @@ func (c *DefaultCommandRunner) validateCtxAndComment(ctx *CommandContext) bool {
 	return true
 }
 
+// validateBranchAndComment returns false and comments on the pull request if
+// it's into a base branch that doesn't match the repo's server-side branch
+// config. The branch config of the repo's atlantis.yaml file is checked once
+// it's cloned, see BranchNotMatchedErr.
+func (c *DefaultCommandRunner) validateBranchAndComment(ctx *CommandContext) bool {
+	if c.ServerConfig == nil {
+		return true
+	}
+	ok, branch := c.ServerConfig.MatchesBranch(ctx.BaseRepo.FullName, ctx.Pull.BaseBranch)
+	if ok {
+		return true
+	}
+	c.refuseBranch(ctx, branch)
+	return false
+}
+
+// refuseBranch comments on the pull request that commands can't be run on it
+// because it's into a base branch that doesn't match branch.
+func (c *DefaultCommandRunner) refuseBranch(ctx *CommandContext, branch string) {
+	ctx.Log.Info("command was run on a pull request into branch %q which doesn't match the branch config %q", ctx.Pull.BaseBranch, branch)
+	msg := fmt.Sprintf("Atlantis commands can't be run on pull requests into branch `%s`. This repo is configured to only run them on pull requests into `%s`.", ctx.Pull.BaseBranch, branch)
+	c.failJob(ctx.JobID, msg)
+	if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, msg); err != nil {
+		ctx.Log.Err("unable to comment: %s", err)
+	}
+}
+
 func (c *DefaultCommandRunner) updatePull(ctx *CommandContext, command PullCommand, res CommandResult) {
 	// Log if we got any errors or failures.
 	if res.Error != nil {
@@ -374,13 +483,44 @@ func (c *DefaultCommandRunner) updatePull(ctx *CommandContext, command PullComma
 	} else if res.Failure != "" {
 		ctx.Log.Warn(res.Failure)
 	}
+	if c.Jobs != nil {
+		c.Jobs.SetResult(ctx.JobID, res)
+	}
 
-	comment := c.MarkdownRenderer.Render(res, command.CommandName(), ctx.Log.History.String(), command.IsVerbose(), ctx.BaseRepo.VCSHost.Type)
+	comment := c.MarkdownRenderer.Render(res, command.CommandName(), ctx.Log.History.String(), command.IsVerbose(), ctx.BaseRepo.VCSHost.Type, c.jobURL(ctx))
 	if err := c.VCSClient.CreateComment(ctx.BaseRepo, ctx.Pull.Num, comment); err != nil {
 		ctx.Log.Err("unable to comment: %s", err)
 	}
 }
 
+// startJob records that a command has started running and returns the job id.
+// cmd is nil for autoplan commands. If the caller already started a job for
+// cmd, we use that job. It returns an empty id if jobs aren't being tracked.
+func (c *DefaultCommandRunner) startJob(repoFullName string, pullNum int, user models.User, cmd *CommentCommand) string {
+	if c.Jobs == nil {
+		return ""
+	}
+	if cmd == nil {
+		return c.Jobs.Start(repoFullName, pullNum, models.PlanCommand, true, user.Username)
+	}
+	if cmd.JobID != "" {
+		return cmd.JobID
+	}
+	return c.Jobs.Start(repoFullName, pullNum, cmd.Name, false, user.Username)
+}
+
+func (c *DefaultCommandRunner) failJob(jobID string, reason string) {
+	if c.Jobs != nil {
+		c.Jobs.Fail(jobID, reason)
+	}
+}
+
+func (c *DefaultCommandRunner) finishJob(jobID string) {
+	if c.Jobs != nil {
+		c.Jobs.Finish(jobID)
+	}
+}
+
 // logPanics logs and creates a comment on the pull request for panics.
 func (c *DefaultCommandRunner) logPanics(baseRepo models.Repo, pullNum int, logger logging.SimpleLogging) {
 	if err := recover(); err != nil {
@@ -29,6 +29,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/models/fixtures"
 	vcsmocks "github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	logmocks "github.com/runatlantis/atlantis/server/logging/mocks"
 	. "github.com/runatlantis/atlantis/testing"
 )
@@ -161,6 +162,53 @@ func TestRunCommentCommand_ClosedPull(t *testing.T) {
 	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, "Atlantis commands can't be run on closed pull requests")
 }
 
+func TestRunCommentCommand_BranchNotMatched(t *testing.T) {
+	t.Log("if a command is run on a pull request into a branch that doesn't match" +
+		" the repo's branch config atlantis should comment saying that this is not allowed")
+	vcsClient := setup(t)
+	ch.ServerConfig = &valid.ServerConfig{
+		Repos: []valid.RepoConfig{{ID: fixtures.GithubRepo.FullName, Branch: String("master")}},
+	}
+	defer func() { ch.ServerConfig = nil }()
+	var pull github.PullRequest
+	modelPull := models.PullRequest{State: models.OpenPullState, BaseBranch: "feature"}
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(&pull, nil)
+	When(eventParsing.ParseGithubPull(&pull)).ThenReturn(modelPull, modelPull.BaseRepo, fixtures.GithubRepo, nil)
+
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand})
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, "Atlantis commands can't be run on pull requests into branch `feature`. This repo is configured to only run them on pull requests into `master`.")
+	projectCommandBuilder.VerifyWasCalled(Never()).BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())
+}
+
+func TestRunCommentCommand_RepoBranchNotMatched(t *testing.T) {
+	t.Log("if a command is run on a pull request into a branch that doesn't match" +
+		" the branch config in atlantis.yaml atlantis should comment saying that this is not allowed")
+	vcsClient := setup(t)
+	var pull github.PullRequest
+	modelPull := models.PullRequest{State: models.OpenPullState, BaseBranch: "feature"}
+	When(githubGetter.GetPullRequest(fixtures.GithubRepo, fixtures.Pull.Num)).ThenReturn(&pull, nil)
+	When(eventParsing.ParseGithubPull(&pull)).ThenReturn(modelPull, modelPull.BaseRepo, fixtures.GithubRepo, nil)
+	When(projectCommandBuilder.BuildPlanCommands(matchers.AnyPtrToEventsCommandContext(), matchers.AnyPtrToEventsCommentCommand())).
+		ThenReturn(nil, events.BranchNotMatchedErr{BaseBranch: "feature", Branch: "master"})
+
+	ch.RunCommentCommand(fixtures.GithubRepo, &fixtures.GithubRepo, nil, fixtures.User, fixtures.Pull.Num, &events.CommentCommand{Name: models.PlanCommand})
+	vcsClient.VerifyWasCalledOnce().CreateComment(fixtures.GithubRepo, modelPull.Num, "Atlantis commands can't be run on pull requests into branch `feature`. This repo is configured to only run them on pull requests into `master`.")
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(matchers.AnyModelsProjectCommandContext())
+}
+
+func TestRunAutoplanCommand_RepoBranchNotMatched(t *testing.T) {
+	t.Log("if a pull request is into a branch that doesn't match the branch config" +
+		" in atlantis.yaml atlantis shouldn't comment")
+	vcsClient := setup(t)
+	modelPull := models.PullRequest{State: models.OpenPullState, BaseBranch: "feature"}
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn(nil, events.BranchNotMatchedErr{BaseBranch: "feature", Branch: "master"})
+
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, modelPull, fixtures.User)
+	vcsClient.VerifyWasCalled(Never()).CreateComment(matchers.AnyModelsRepo(), AnyInt(), AnyString())
+	projectCommandRunner.VerifyWasCalled(Never()).Plan(matchers.AnyModelsProjectCommandContext())
+}
+
 // Test that if one plan fails and we are using automerge, that
 // we delete the plans.
 func TestRunAutoplanCommand_DeletePlans(t *testing.T) {
@@ -199,3 +247,44 @@ func TestRunAutoplanCommand_DeletePlans(t *testing.T) {
 	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User)
 	pendingPlanFinder.VerifyWasCalledOnce().DeletePlans(tmp)
 }
+
+func TestRunAutoplanCommand_UpdatesStatusWithJobURL(t *testing.T) {
+	t.Log("if jobs are tracked, the commit status should link to the job's output")
+	vcsClient := setup(t)
+	ch.Jobs = events.NewJobTracker()
+	jobURLGenerator := mocks.NewMockJobURLGenerator()
+	ch.JobURLGenerator = jobURLGenerator
+	When(jobURLGenerator.GenerateJobURL(AnyString())).ThenReturn("https://atlantis/jobs/1")
+	defer func() {
+		ch.Jobs = nil
+		ch.JobURLGenerator = nil
+	}()
+
+	When(projectCommandBuilder.BuildAutoplanCommands(matchers.AnyPtrToEventsCommandContext())).
+		ThenReturn([]models.ProjectCommandContext{
+			{
+				BaseRepo:   fixtures.GithubRepo,
+				Pull:       fixtures.Pull,
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Log:        pullLogger,
+			},
+		}, nil)
+	When(projectCommandRunner.Plan(matchers.AnyModelsProjectCommandContext())).Then(func(params []Param) ReturnValues {
+		pCmd := params[0].(models.ProjectCommandContext)
+		fmt.Fprintln(pCmd.Output, "planning")
+		return ReturnValues{models.ProjectResult{PlanSuccess: &models.PlanSuccess{}}}
+	})
+	ch.RunAutoplanCommand(fixtures.GithubRepo, fixtures.GithubRepo, fixtures.Pull, fixtures.User)
+
+	vcsClient.VerifyWasCalledOnce().UpdateStatus(fixtures.GithubRepo, fixtures.Pull, models.PendingCommitStatus, "atlantis/plan", "Plan in progress...", "https://atlantis/jobs/1")
+	// We don't set a status for each project.
+	vcsClient.VerifyWasCalled(Never()).UpdateStatus(fixtures.GithubRepo, fixtures.Pull, models.PendingCommitStatus, "atlantis/plan: ./default", "Plan in progress...", "https://atlantis/jobs/1")
+	jobs := ch.Jobs.List()
+	Equals(t, 1, len(jobs))
+	lines, _, _, _ := ch.Jobs.SubscribeOutput(jobs[0].ID, 0)
+	Equals(t, []events.OutputLine{
+		{Num: 0, Text: "Running plan in dir: . workspace: default"},
+		{Num: 1, Text: "planning"},
+	}, lines)
+}
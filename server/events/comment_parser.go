@@ -35,6 +35,7 @@ const (
 	projectFlagShort   = "p"
 	verboseFlagLong    = "verbose"
 	verboseFlagShort   = ""
+	overrideWindowFlag = "override-apply-window"
 	atlantisExecutable = "atlantis"
 )
 
@@ -64,9 +65,10 @@ type CommentBuilder interface {
 
 // CommentParser implements CommentParsing
 type CommentParser struct {
-	GithubUser    string
-	GitlabUser    string
-	BitbucketUser string
+	GithubUser      string
+	GitlabUser      string
+	BitbucketUser   string
+	AzureDevopsUser string
 }
 
 // CommentParseResult describes the result of parsing a comment as a command.
@@ -124,6 +126,8 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		vcsUser = e.GitlabUser
 	case models.BitbucketCloud, models.BitbucketServer:
 		vcsUser = e.BitbucketUser
+	case models.AzureDevops:
+		vcsUser = e.AzureDevopsUser
 	}
 	executableNames := []string{"run", atlantisExecutable, "@" + vcsUser}
 	if !e.stringInSlice(args[0], executableNames) {
@@ -161,6 +165,7 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 	var dir string
 	var project string
 	var verbose bool
+	var overrideWindow bool
 	var extraArgs []string
 	var flagSet *pflag.FlagSet
 	var name models.CommandName
@@ -183,6 +188,7 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 		flagSet.StringVarP(&dir, dirFlagLong, dirFlagShort, "", "Apply the plan for this directory, relative to root of repo, ex. 'child/dir'.")
 		flagSet.StringVarP(&project, projectFlagLong, projectFlagShort, "", fmt.Sprintf("Apply the plan for this project. Refers to the name of the project configured in %s. Cannot be used at same time as workspace or dir flags.", yaml.AtlantisYAMLFilename))
 		flagSet.BoolVarP(&verbose, verboseFlagLong, verboseFlagShort, false, "Append Atlantis log to comment.")
+		flagSet.BoolVar(&overrideWindow, overrideWindowFlag, false, "Apply even if it's outside of the project's apply windows. Only allowed for some users.")
 	default:
 		return CommentParseResult{CommentResponse: fmt.Sprintf("Error: unknown command %q – this is a bug", command)}
 	}
@@ -208,25 +214,40 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 	}
 
 	if flagSet.ArgsLenAtDash() != -1 {
-		extraArgsUnsafe := flagSet.Args()[flagSet.ArgsLenAtDash():]
-		// Quote all extra args so there isn't a security issue when we append
-		// them to the terraform commands, ex. "; cat /etc/passwd"
-		for _, arg := range extraArgsUnsafe {
-			quotesEscaped := strings.Replace(arg, `"`, `\"`, -1)
-			extraArgs = append(extraArgs, fmt.Sprintf(`"%s"`, quotesEscaped))
-		}
+		extraArgs = flagSet.Args()[flagSet.ArgsLenAtDash():]
 	}
 
-	dir, err = e.validateDir(dir)
+	cmd, err := e.BuildCommentCommand(name, dir, workspace, project, verbose, extraArgs)
 	if err != nil {
 		return CommentParseResult{CommentResponse: e.errMarkdown(err.Error(), command, flagSet)}
 	}
+	cmd.OverrideApplyWindow = overrideWindow
+	return CommentParseResult{Command: cmd}
+}
+
+// BuildCommentCommand validates the flags of a command and builds the
+// CommentCommand. It's used by Parse and also for commands that don't come
+// from comments, ex. from the API, so that they're validated the same way.
+// extraArgs are the unquoted arguments to be appended to the terraform command.
+func (e *CommentParser) BuildCommentCommand(name models.CommandName, dir string, workspace string, project string, verbose bool, extraArgs []string) (*CommentCommand, error) {
+	// Quote all extra args so there isn't a security issue when we append
+	// them to the terraform commands, ex. "; cat /etc/passwd"
+	var quotedArgs []string
+	for _, arg := range extraArgs {
+		quotesEscaped := strings.Replace(arg, `"`, `\"`, -1)
+		quotedArgs = append(quotedArgs, fmt.Sprintf(`"%s"`, quotesEscaped))
+	}
+
+	dir, err := e.validateDir(dir)
+	if err != nil {
+		return nil, err
+	}
 
 	// Use the same validation that Terraform uses: https://git.io/vxGhU. Plus
 	// we also don't allow '..'. We don't want the workspace to contain a path
 	// since we create files based on the name.
 	if workspace != url.PathEscape(workspace) || strings.Contains(workspace, "..") {
-		return CommentParseResult{CommentResponse: e.errMarkdown(fmt.Sprintf("invalid workspace: %q", workspace), command, flagSet)}
+		return nil, fmt.Errorf("invalid workspace: %q", workspace)
 	}
 
 	// If project is specified, dir or workspace should not be set. Since we
@@ -235,13 +256,10 @@ func (e *CommentParser) Parse(comment string, vcsHost models.VCSHostType) Commen
 	// don't detect, ex. atlantis plan -p project -d . -w default won't cause
 	// an error.
 	if project != "" && (workspace != "" || dir != "") {
-		err := fmt.Sprintf("cannot use -%s/--%s at same time as -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
-		return CommentParseResult{CommentResponse: e.errMarkdown(err, command, flagSet)}
+		return nil, fmt.Errorf("cannot use -%s/--%s at same time as -%s/--%s or -%s/--%s", projectFlagShort, projectFlagLong, dirFlagShort, dirFlagLong, workspaceFlagShort, workspaceFlagLong)
 	}
 
-	return CommentParseResult{
-		Command: NewCommentCommand(dir, extraArgs, name, verbose, workspace, project),
-	}
+	return NewCommentCommand(dir, quotedArgs, name, verbose, workspace, project), nil
 }
 
 // BuildPlanComment builds a plan comment for the specified args.
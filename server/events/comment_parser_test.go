@@ -642,11 +642,25 @@ func TestBuildPlanApplyComment(t *testing.T) {
 	}
 }
 
+func TestParse_OverrideApplyWindow(t *testing.T) {
+	r := commentParser.Parse("atlantis apply -d dir --override-apply-window", models.Github)
+	Equals(t, "", r.CommentResponse)
+	Equals(t, true, r.Command.OverrideApplyWindow)
+	Equals(t, "dir", r.Command.RepoRelDir)
+
+	r = commentParser.Parse("atlantis apply -d dir", models.Github)
+	Equals(t, false, r.Command.OverrideApplyWindow)
+
+	r = commentParser.Parse("atlantis plan --override-apply-window", models.Github)
+	Assert(t, strings.Contains(r.CommentResponse, "unknown flag: --override-apply-window"), "exp error about unknown flag, got %q", r.CommentResponse)
+}
+
 func TestParse_VCSUsername(t *testing.T) {
 	cp := events.CommentParser{
-		GithubUser:    "gh",
-		GitlabUser:    "gl",
-		BitbucketUser: "bb",
+		GithubUser:      "gh",
+		GitlabUser:      "gl",
+		BitbucketUser:   "bb",
+		AzureDevopsUser: "ad",
 	}
 	cases := []struct {
 		vcs  models.VCSHostType
@@ -668,6 +682,10 @@ func TestParse_VCSUsername(t *testing.T) {
 			vcs:  models.BitbucketCloud,
 			user: "bb",
 		},
+		{
+			vcs:  models.AzureDevops,
+			user: "ad",
+		},
 	}
 
 	for _, c := range cases {
@@ -689,11 +707,13 @@ var PlanUsage = `Usage of plan:
 `
 
 var ApplyUsage = `Usage of apply:
-  -d, --dir string         Apply the plan for this directory, relative to root of
-                           repo, ex. 'child/dir'.
-  -p, --project string     Apply the plan for this project. Refers to the name of
-                           the project configured in atlantis.yaml. Cannot be used
-                           at same time as workspace or dir flags.
-      --verbose            Append Atlantis log to comment.
-  -w, --workspace string   Apply the plan for this Terraform workspace.
+  -d, --dir string              Apply the plan for this directory, relative to root
+                                of repo, ex. 'child/dir'.
+      --override-apply-window   Apply even if it's outside of the project's apply
+                                windows. Only allowed for some users.
+  -p, --project string          Apply the plan for this project. Refers to the name
+                                of the project configured in atlantis.yaml. Cannot
+                                be used at same time as workspace or dir flags.
+      --verbose                 Append Atlantis log to comment.
+  -w, --workspace string        Apply the plan for this Terraform workspace.
 `
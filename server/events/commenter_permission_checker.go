@@ -0,0 +1,34 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+)
+
+// CommenterPermissionChecker checks that users commenting on pull requests
+// have enough permission on the repo to run commands. Without it, anyone who
+// can comment could run plans, which can run arbitrary code.
+type CommenterPermissionChecker struct {
+	VCSClient vcs.Client
+	// PlanPermission and ApplyPermission are the minimum permissions needed to
+	// run plan and apply. If NoPermission, anyone can run the command.
+	PlanPermission  models.PermissionLevel
+	ApplyPermission models.PermissionLevel
+}
+
+// HasPermission returns true if username can run cmd on repo. required is the
+// minimum permission needed to run cmd.
+func (c *CommenterPermissionChecker) HasPermission(repo models.Repo, username string, cmd models.CommandName) (allowed bool, required models.PermissionLevel, err error) {
+	required = c.PlanPermission
+	if cmd == models.ApplyCommand {
+		required = c.ApplyPermission
+	}
+	if required == models.NoPermission {
+		return true, required, nil
+	}
+	permission, err := c.VCSClient.GetUserPermission(repo, username)
+	if err != nil {
+		return false, required, err
+	}
+	return permission >= required, required, nil
+}
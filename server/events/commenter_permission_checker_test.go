@@ -0,0 +1,54 @@
+package events_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestCommenterPermissionChecker_HasPermission(t *testing.T) {
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	repo := models.Repo{FullName: "owner/repo"}
+	checker := events.CommenterPermissionChecker{
+		VCSClient:       vcsClient,
+		PlanPermission:  models.WritePermission,
+		ApplyPermission: models.AdminPermission,
+	}
+	When(vcsClient.GetUserPermission(repo, "writer")).ThenReturn(models.WritePermission, nil)
+	When(vcsClient.GetUserPermission(repo, "reader")).ThenReturn(models.ReadPermission, nil)
+
+	allowed, required, err := checker.HasPermission(repo, "writer", models.PlanCommand)
+	Ok(t, err)
+	Equals(t, true, allowed)
+	Equals(t, models.WritePermission, required)
+
+	allowed, required, err = checker.HasPermission(repo, "writer", models.ApplyCommand)
+	Ok(t, err)
+	Equals(t, false, allowed)
+	Equals(t, models.AdminPermission, required)
+
+	allowed, _, err = checker.HasPermission(repo, "reader", models.PlanCommand)
+	Ok(t, err)
+	Equals(t, false, allowed)
+
+	When(vcsClient.GetUserPermission(repo, "unknown")).ThenReturn(models.NoPermission, errors.New("forbidden"))
+	_, _, err = checker.HasPermission(repo, "unknown", models.PlanCommand)
+	ErrEquals(t, "forbidden", err)
+}
+
+func TestCommenterPermissionChecker_NoPermissionRequired(t *testing.T) {
+	t.Log("if no permission is required we don't call the VCS host")
+	RegisterMockTestingT(t)
+	vcsClient := mocks.NewMockClient()
+	checker := events.CommenterPermissionChecker{VCSClient: vcsClient}
+	allowed, _, err := checker.HasPermission(models.Repo{}, "anyone", models.PlanCommand)
+	Ok(t, err)
+	Equals(t, true, allowed)
+	vcsClient.VerifyWasCalled(Never()).GetUserPermission(models.Repo{}, "anyone")
+}
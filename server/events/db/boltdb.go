@@ -24,6 +24,8 @@ type BoltDB struct {
 const (
 	locksBucketName  = "runLocks"
 	pullsBucketName  = "pulls"
+	healthBucketName = "health"
+	freezeBucketName = "freezes"
 	pullKeySeparator = "::"
 )
 
@@ -49,6 +51,9 @@ func New(dataDir string) (*BoltDB, error) {
 		if _, err = tx.CreateBucketIfNotExists([]byte(pullsBucketName)); err != nil {
 			return errors.Wrapf(err, "creating bucket %q", pullsBucketName)
 		}
+		if _, err = tx.CreateBucketIfNotExists([]byte(freezeBucketName)); err != nil {
+			return errors.Wrapf(err, "creating bucket %q", freezeBucketName)
+		}
 		return nil
 	})
 	if err != nil {
@@ -63,6 +68,26 @@ func NewWithDB(db *bolt.DB, bucket string) (*BoltDB, error) {
 	return &BoltDB{db: db, locksBucketName: []byte(bucket), pullsBucketName: []byte(pullsBucketName)}, nil
 }
 
+// CheckReadWrite returns an error if we can't write a value to the database
+// and read it back.
+func (b *BoltDB) CheckReadWrite() error {
+	now := []byte(time.Now().UTC().Format(time.RFC3339Nano))
+	key := []byte("last-checked")
+	return b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(healthBucketName))
+		if err != nil {
+			return errors.Wrapf(err, "creating bucket %q", healthBucketName)
+		}
+		if err := bucket.Put(key, now); err != nil {
+			return errors.Wrap(err, "writing to DB")
+		}
+		if !bytes.Equal(bucket.Get(key), now) {
+			return errors.New("reading from DB: value read didn't match value written")
+		}
+		return nil
+	})
+}
+
 // TryLock attempts to create a new lock. If the lock is
 // acquired, it will return true and the lock returned will be newLock.
 // If the lock is not acquired, it will return false and the current
@@ -273,6 +298,7 @@ func (b *BoltDB) UpdatePullWithResults(pull models.PullRequest, newResults []mod
 		}
 
 		// Now, we overwrite the key with our new status.
+		newStatus.UpdatedAt = time.Now()
 		return b.writePullToBucket(bucket, key, newStatus)
 	})
 	return newStatus, errors.Wrap(err, "DB transaction failed")
@@ -295,6 +321,82 @@ func (b *BoltDB) GetPullStatus(pull models.PullRequest) (*models.PullStatus, err
 	return s, errors.Wrap(err, "DB transaction failed")
 }
 
+// ListPullStatuses returns the statuses of all the pulls we know about.
+func (b *BoltDB) ListPullStatuses() ([]models.PullStatus, error) {
+	var statuses []models.PullStatus
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(b.pullsBucketName)
+		return bucket.ForEach(func(k, v []byte) error {
+			s, err := b.getPullFromBucket(bucket, k)
+			if err != nil {
+				return err
+			}
+			statuses = append(statuses, *s)
+			return nil
+		})
+	})
+	return statuses, errors.Wrap(err, "DB transaction failed")
+}
+
+// AddFreeze saves freeze. It replaces any freeze with the same ID.
+func (b *BoltDB) AddFreeze(freeze models.Freeze) error {
+	serialized, err := json.Marshal(freeze)
+	if err != nil {
+		return errors.Wrap(err, "serializing freeze")
+	}
+	err = b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(freezeBucketName))
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(freeze.ID), serialized)
+	})
+	return errors.Wrap(err, "DB transaction failed")
+}
+
+// DeleteFreeze deletes the freeze with id and returns it. If there was no
+// freeze, it returns a nil pointer.
+func (b *BoltDB) DeleteFreeze(id string) (*models.Freeze, error) {
+	var freeze *models.Freeze
+	err := b.db.Update(func(tx *bolt.Tx) error {
+		bucket, err := tx.CreateBucketIfNotExists([]byte(freezeBucketName))
+		if err != nil {
+			return err
+		}
+		serialized := bucket.Get([]byte(id))
+		if serialized == nil {
+			return nil
+		}
+		var f models.Freeze
+		if err := json.Unmarshal(serialized, &f); err != nil {
+			return errors.Wrapf(err, "deserializing freeze %q", id)
+		}
+		freeze = &f
+		return bucket.Delete([]byte(id))
+	})
+	return freeze, errors.Wrap(err, "DB transaction failed")
+}
+
+// ListFreezes returns all the saved freezes.
+func (b *BoltDB) ListFreezes() ([]models.Freeze, error) {
+	var freezes []models.Freeze
+	err := b.db.View(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket([]byte(freezeBucketName))
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var f models.Freeze
+			if err := json.Unmarshal(v, &f); err != nil {
+				return errors.Wrapf(err, "deserializing freeze %q", string(k))
+			}
+			freezes = append(freezes, f)
+			return nil
+		})
+	})
+	return freezes, errors.Wrap(err, "DB transaction failed")
+}
+
 // DeletePullStatus deletes the status for pull.
 func (b *BoltDB) DeletePullStatus(pull models.PullRequest) error {
 	key, err := b.pullKey(pull)
@@ -684,6 +684,90 @@ func TestPullStatus_UpdateMerge(t *testing.T) {
 	}
 }
 
+func TestPullStatus_List(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+	statuses, err := b.ListPullStatuses()
+	Ok(t, err)
+	Equals(t, 0, len(statuses))
+
+	before := time.Now()
+	for _, num := range []int{1, 2} {
+		_, err := b.UpdatePullWithResults(models.PullRequest{
+			Num: num,
+			BaseRepo: models.Repo{
+				FullName: "runatlantis/atlantis",
+				VCSHost: models.VCSHost{
+					Hostname: "github.com",
+				},
+			},
+		}, []models.ProjectResult{
+			{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: "success",
+			},
+		})
+		Ok(t, err)
+	}
+
+	statuses, err = b.ListPullStatuses()
+	Ok(t, err)
+	Equals(t, 2, len(statuses))
+	for i, s := range statuses {
+		Equals(t, i+1, s.Pull.Num)
+		Equals(t, models.AppliedPlanStatus, s.Projects[0].Status)
+		Assert(t, !s.UpdatedAt.Before(before), "exp updated at to be set")
+	}
+}
+
+func TestFreezes(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+	freezes, err := b.ListFreezes()
+	Ok(t, err)
+	Equals(t, 0, len(freezes))
+
+	freeze := models.Freeze{
+		ID:           "id",
+		RepoFullName: "runatlantis/atlantis",
+		Reason:       "migration",
+		CreatedBy:    "lkysow",
+		CreatedAt:    time.Now().UTC().Round(time.Second),
+	}
+	Ok(t, b.AddFreeze(freeze))
+	freezes, err = b.ListFreezes()
+	Ok(t, err)
+	Equals(t, []models.Freeze{freeze}, freezes)
+
+	deleted, err := b.DeleteFreeze("id")
+	Ok(t, err)
+	Equals(t, &freeze, deleted)
+	deleted, err = b.DeleteFreeze("id")
+	Ok(t, err)
+	Assert(t, deleted == nil, "exp nil freeze")
+	freezes, err = b.ListFreezes()
+	Ok(t, err)
+	Equals(t, 0, len(freezes))
+}
+
+func TestListFreezes_NoBucket(t *testing.T) {
+	t.Log("dbs created with NewWithDB won't have a freezes bucket")
+	boltDB, b := newTestDB()
+	defer cleanupDB(boltDB)
+	freezes, err := b.ListFreezes()
+	Ok(t, err)
+	Equals(t, 0, len(freezes))
+}
+
+func TestCheckReadWrite(t *testing.T) {
+	b, cleanup := newTestDB2(t)
+	defer cleanup()
+	Ok(t, b.CheckReadWrite())
+	t.Log("should succeed when run again")
+	Ok(t, b.CheckReadWrite())
+}
+
 // newTestDB returns a TestDB using a temporary path.
 func newTestDB() (*bolt.DB, *db.BoltDB) {
 	// Retrieve a temporary path.
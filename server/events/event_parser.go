@@ -16,6 +16,7 @@ package events
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"path"
 	"strings"
 
@@ -23,6 +24,7 @@ import (
 	"github.com/lkysow/go-gitlab"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/azuredevops"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
 	"gopkg.in/go-playground/validator.v9"
@@ -79,6 +81,12 @@ type CommentCommand struct {
 	// project specified in an atlantis.yaml file.
 	// If empty then the comment specified no project.
 	ProjectName string
+	// JobID is the id of a job that the caller has already started to track
+	// this command. If empty, a new job is started when the command runs.
+	JobID string
+	// OverrideApplyWindow is true if an apply should run even if it's outside
+	// of the project's apply windows.
+	OverrideApplyWindow bool
 }
 
 // IsForSpecificProject returns true if the command is for a specific dir, workspace
@@ -234,6 +242,28 @@ type EventParsing interface {
 	// GetBitbucketServerPullEventType returns the type of the pull request
 	// event given the Bitbucket Server header.
 	GetBitbucketServerPullEventType(eventTypeHeader string) models.PullRequestEventType
+
+	// ParseAzureDevopsPullEvent parses a pull request created, updated or
+	// merged service hook event from Azure DevOps.
+	// pull is the parsed pull request.
+	// pullEventType is the type of event, for example opened/closed.
+	// baseRepo is the repo the pull request will be merged into.
+	// headRepo is the repo the pull request branch is from.
+	// user is the pull request author.
+	ParseAzureDevopsPullEvent(body []byte) (
+		pull models.PullRequest, pullEventType models.PullRequestEventType,
+		baseRepo models.Repo, headRepo models.Repo, user models.User, err error)
+
+	// ParseAzureDevopsPullCommentEvent parses a pull request comment service
+	// hook event from Azure DevOps.
+	// pull is the parsed pull request.
+	// baseRepo is the repo the pull request will be merged into.
+	// headRepo is the repo the pull request branch is from.
+	// user is the comment author.
+	// comment is the comment that triggered the event.
+	ParseAzureDevopsPullCommentEvent(body []byte) (
+		pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo,
+		user models.User, comment string, err error)
 }
 
 // EventParser parses VCS events.
@@ -245,6 +275,11 @@ type EventParser struct {
 	BitbucketUser      string
 	BitbucketToken     string
 	BitbucketServerURL string
+	AzureDevopsUser    string
+	AzureDevopsToken   string
+	// AzureDevopsBaseURL is the collection URL if using Azure DevOps Server.
+	// Repos must be under it.
+	AzureDevopsBaseURL string
 }
 
 // GetBitbucketCloudPullEventType returns the type of the pull request
@@ -671,3 +706,137 @@ func (e *EventParser) ParseBitbucketServerPullEvent(body []byte) (pull models.Pu
 	pull, baseRepo, headRepo, user, err = e.parseCommonBitbucketServerEventData(event.CommonEventData)
 	return
 }
+
+// ParseAzureDevopsPullEvent parses a pull request created, updated or merged
+// service hook event from Azure DevOps.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseAzureDevopsPullEvent(body []byte) (pull models.PullRequest, pullEventType models.PullRequestEventType, baseRepo models.Repo, headRepo models.Repo, user models.User, err error) {
+	var event azuredevops.PullRequestEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		err = errors.Wrap(err, "parsing json")
+		return
+	}
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
+		return
+	}
+	pull, baseRepo, headRepo, err = e.parseAzureDevopsPull(event.Resource)
+	if err != nil {
+		return
+	}
+	var message string
+	if event.Message != nil && event.Message.Text != nil {
+		message = *event.Message.Text
+	}
+	pullEventType = azureDevopsPullEventType(*event.EventType, *event.Resource.Status, message)
+	user = models.User{
+		Username: *event.Resource.CreatedBy.UniqueName,
+	}
+	return
+}
+
+// ParseAzureDevopsPullCommentEvent parses a pull request comment service hook
+// event from Azure DevOps.
+// See EventParsing for return value docs.
+func (e *EventParser) ParseAzureDevopsPullCommentEvent(body []byte) (pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo, user models.User, comment string, err error) {
+	var event azuredevops.CommentEvent
+	if err = json.Unmarshal(body, &event); err != nil {
+		err = errors.Wrap(err, "parsing json")
+		return
+	}
+	if err = validator.New().Struct(event); err != nil {
+		err = errors.Wrapf(err, "API response %q was missing fields", string(body))
+		return
+	}
+	pull, baseRepo, headRepo, err = e.parseAzureDevopsPull(event.Resource.PullRequest)
+	if err != nil {
+		return
+	}
+	user = models.User{
+		Username: *event.Resource.Comment.Author.UniqueName,
+	}
+	comment = *event.Resource.Comment.Content
+	return
+}
+
+// azureDevopsPullEventType returns the type of a pull request event given its
+// service hook event type, the pull request's status and the event's message.
+// Updated events are sent for any change to the pull request, ex. votes and
+// Atlantis' own statuses, so only pushes to the source branch and
+// reactivations are treated as updates.
+func azureDevopsPullEventType(eventType string, status string, message string) models.PullRequestEventType {
+	if status == "completed" || status == "abandoned" {
+		return models.ClosedPullEvent
+	}
+	switch eventType {
+	case azuredevops.PullCreatedEvent:
+		return models.OpenedPullEvent
+	case azuredevops.PullUpdatedEvent:
+		if strings.Contains(message, "updated the source branch") || strings.Contains(message, "reactivated") {
+			return models.UpdatedPullEvent
+		}
+	}
+	return models.OtherPullEvent
+}
+
+func (e *EventParser) parseAzureDevopsPull(adPull *azuredevops.PullRequest) (pull models.PullRequest, baseRepo models.Repo, headRepo models.Repo, err error) {
+	var prState models.PullRequestState
+	switch *adPull.Status {
+	case "active":
+		prState = models.OpenPullState
+	case "completed", "abandoned":
+		prState = models.ClosedPullState
+	default:
+		err = fmt.Errorf("unable to determine pull request state from %q–this is a bug", *adPull.Status)
+		return
+	}
+
+	baseRepo, err = e.parseAzureDevopsRepo(adPull.Repository)
+	if err != nil {
+		return
+	}
+	// Pull requests from forks have a fork source, otherwise the head repo is
+	// the base repo.
+	headRepo = baseRepo
+	if adPull.ForkSource != nil {
+		headRepo, err = e.parseAzureDevopsRepo(adPull.ForkSource.Repository)
+		if err != nil {
+			return
+		}
+	}
+
+	pull = models.PullRequest{
+		Num:        *adPull.PullRequestID,
+		HeadCommit: *adPull.LastMergeSourceCommit.CommitID,
+		URL:        fmt.Sprintf("%s/pullrequest/%d", baseRepo.SanitizedCloneURL, *adPull.PullRequestID),
+		HeadBranch: strings.TrimPrefix(*adPull.SourceRefName, "refs/heads/"),
+		BaseBranch: strings.TrimPrefix(*adPull.TargetRefName, "refs/heads/"),
+		Author:     *adPull.CreatedBy.UniqueName,
+		State:      prState,
+		BaseRepo:   baseRepo,
+	}
+	return
+}
+
+// parseAzureDevopsRepo parses a repo from its clone URL, ex.
+// https://org@dev.azure.com/org/project/_git/repo has the full name
+// "org/project/repo".
+func (e *EventParser) parseAzureDevopsRepo(adRepo *azuredevops.Repository) (models.Repo, error) {
+	cloneURL, err := url.Parse(*adRepo.RemoteURL)
+	if err != nil {
+		return models.Repo{}, errors.Wrapf(err, "parsing clone url %q", *adRepo.RemoteURL)
+	}
+	// Azure DevOps adds the organization as the user of the clone URL.
+	cloneURL.User = nil
+	// Our token is added to the clone URL so the repo must be on the Azure
+	// DevOps host we're configured for.
+	if err := azuredevops.ValidateURL(cloneURL.String(), e.AzureDevopsBaseURL); err != nil {
+		return models.Repo{}, err
+	}
+	i := strings.LastIndex(cloneURL.Path, "/_git/")
+	if i == -1 {
+		return models.Repo{}, fmt.Errorf("clone url %q isn't an Azure DevOps clone url, ex. https://dev.azure.com/org/project/_git/repo", cloneURL)
+	}
+	fullName := strings.TrimPrefix(cloneURL.Path[:i], "/") + "/" + cloneURL.Path[i+len("/_git/"):]
+	return models.NewRepo(models.AzureDevops, fullName, cloneURL.String(), e.AzureDevopsUser, e.AzureDevopsToken)
+}
@@ -0,0 +1,94 @@
+package events
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// ScheduledFreezeCreator is used as the creator of freezes from the server's
+// freeze schedule.
+const ScheduledFreezeCreator = "the freeze schedule"
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_freeze_checker.go FreezeChecker
+
+// FreezeChecker checks if applies are frozen.
+type FreezeChecker interface {
+	// ActiveFreeze returns the freeze that's blocking applies for the project
+	// at repoRelDir with projectName in repoFullName. It returns nil if
+	// applies aren't frozen.
+	ActiveFreeze(repoFullName string, repoRelDir string, projectName string) (*models.Freeze, error)
+}
+
+// FreezeManager manages freezes. Freezes set via the API or UI are saved in
+// the DB and last until they're deleted. Scheduled freezes come from the
+// server config and are only active between their start and end.
+type FreezeManager struct {
+	DB       *db.BoltDB
+	Schedule []models.Freeze
+}
+
+// Freeze saves a new freeze that blocks applies for projects matching
+// projectGlob in repoFullName. Either can be empty to freeze all repos or all
+// projects.
+func (f *FreezeManager) Freeze(repoFullName string, projectGlob string, reason string, createdBy string) (models.Freeze, error) {
+	if reason == "" {
+		return models.Freeze{}, errors.New("a reason must be given")
+	}
+	if _, err := path.Match(projectGlob, ""); err != nil {
+		return models.Freeze{}, fmt.Errorf("invalid project glob %q: %s", projectGlob, err)
+	}
+	freeze := models.Freeze{
+		ID:           uuid.New().String(),
+		RepoFullName: repoFullName,
+		ProjectGlob:  projectGlob,
+		Reason:       reason,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+	return freeze, f.DB.AddFreeze(freeze)
+}
+
+// Unfreeze deletes the freeze with id. It returns nil if there was no such
+// freeze. Scheduled freezes can't be deleted.
+func (f *FreezeManager) Unfreeze(id string) (*models.Freeze, error) {
+	return f.DB.DeleteFreeze(id)
+}
+
+// ActiveFreezes returns the freezes that are active at now, oldest first.
+func (f *FreezeManager) ActiveFreezes(now time.Time) ([]models.Freeze, error) {
+	saved, err := f.DB.ListFreezes()
+	if err != nil {
+		return nil, err
+	}
+	var active []models.Freeze
+	for _, freeze := range append(saved, f.Schedule...) {
+		if freeze.IsActive(now) {
+			active = append(active, freeze)
+		}
+	}
+	sort.SliceStable(active, func(i, j int) bool {
+		return active[i].CreatedAt.Before(active[j].CreatedAt)
+	})
+	return active, nil
+}
+
+// ActiveFreeze returns the oldest active freeze that covers the project.
+func (f *FreezeManager) ActiveFreeze(repoFullName string, repoRelDir string, projectName string) (*models.Freeze, error) {
+	active, err := f.ActiveFreezes(time.Now())
+	if err != nil {
+		return nil, errors.Wrap(err, "listing freezes")
+	}
+	for _, freeze := range active {
+		if freeze.Matches(repoFullName, repoRelDir, projectName) {
+			return &freeze, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,78 @@
+package events_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func newFreezeManager(t *testing.T, schedule []models.Freeze) (*events.FreezeManager, func()) {
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	return &events.FreezeManager{DB: boltdb, Schedule: schedule}, cleanup
+}
+
+func TestFreezeManager_Freeze(t *testing.T) {
+	f, cleanup := newFreezeManager(t, nil)
+	defer cleanup()
+
+	_, err := f.Freeze("owner/repo", "", "", "lkysow")
+	ErrEquals(t, "a reason must be given", err)
+	_, err = f.Freeze("owner/repo", "[", "migration", "lkysow")
+	ErrEquals(t, "invalid project glob \"[\": syntax error in pattern", err)
+
+	freeze, err := f.Freeze("owner/repo", "prod/*", "migration", "lkysow")
+	Ok(t, err)
+	Assert(t, freeze.ID != "", "exp id to be set")
+
+	active, err := f.ActiveFreeze("owner/repo", "prod/vpc", "")
+	Ok(t, err)
+	Equals(t, freeze.ID, active.ID)
+	active, err = f.ActiveFreeze("owner/repo", "staging/vpc", "")
+	Ok(t, err)
+	Assert(t, active == nil, "exp staging not to be frozen")
+
+	unfrozen, err := f.Unfreeze(freeze.ID)
+	Ok(t, err)
+	Equals(t, freeze.ID, unfrozen.ID)
+	active, err = f.ActiveFreeze("owner/repo", "prod/vpc", "")
+	Ok(t, err)
+	Assert(t, active == nil, "exp no freeze after unfreezing")
+}
+
+func TestFreezeManager_Schedule(t *testing.T) {
+	now := time.Now()
+	f, cleanup := newFreezeManager(t, []models.Freeze{
+		{
+			Reason:    "past",
+			CreatedBy: events.ScheduledFreezeCreator,
+			Start:     now.Add(-2 * time.Hour),
+			End:       now.Add(-time.Hour),
+		},
+		{
+			RepoFullName: "owner/repo",
+			Reason:       "holidays",
+			CreatedBy:    events.ScheduledFreezeCreator,
+			Start:        now.Add(-time.Hour),
+			End:          now.Add(time.Hour),
+		},
+	})
+	defer cleanup()
+
+	active, err := f.ActiveFreezes(now)
+	Ok(t, err)
+	Equals(t, 1, len(active))
+	Equals(t, "holidays", active[0].Reason)
+
+	freeze, err := f.ActiveFreeze("owner/repo", ".", "")
+	Ok(t, err)
+	Equals(t, "holidays", freeze.Reason)
+	freeze, err = f.ActiveFreeze("owner/other", ".", "")
+	Ok(t, err)
+	Assert(t, freeze == nil, "exp other repo not to be frozen")
+}
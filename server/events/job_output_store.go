@@ -0,0 +1,137 @@
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+const (
+	jobOutputExt   = ".log"
+	jobMetadataExt = ".json"
+)
+
+// JobOutputStore stores the full output of each job on disk so that it can
+// be viewed after the job has finished, even once the JobTracker has
+// forgotten about it or Atlantis has restarted. Each job is stored as two
+// files in Dir: <id>.log holds the output and <id>.json holds the job itself.
+type JobOutputStore struct {
+	// Dir is the directory the jobs are stored in.
+	Dir string
+	// Retention is how long we keep jobs for after they were last updated.
+	// If 0, jobs are kept forever.
+	Retention time.Duration
+}
+
+// NewJobOutputStore creates dir if it doesn't exist and returns a store that
+// keeps jobs in it for retention.
+func NewJobOutputStore(dir string, retention time.Duration) (*JobOutputStore, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, errors.Wrap(err, "creating job output dir")
+	}
+	return &JobOutputStore{Dir: dir, Retention: retention}, nil
+}
+
+// Create saves job and returns a writer that appends to its output. The
+// caller must close the writer.
+func (s *JobOutputStore) Create(job Job) (io.WriteCloser, error) {
+	if err := s.SaveJob(job); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.path(job.ID, jobOutputExt), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating output file for job %s", job.ID)
+	}
+	return f, nil
+}
+
+// SaveJob saves job, ex. to record that it has finished. Project results
+// aren't saved since their output is already part of the job's output.
+func (s *JobOutputStore) SaveJob(job Job) error {
+	job.ProjectResults = nil
+	serialized, err := json.Marshal(job)
+	if err != nil {
+		return errors.Wrap(err, "serializing job")
+	}
+	if err := ioutil.WriteFile(s.path(job.ID, jobMetadataExt), serialized, 0600); err != nil {
+		return errors.Wrapf(err, "writing job %s", job.ID)
+	}
+	return nil
+}
+
+// Get returns the job at id. The second return value is false if the job
+// isn't stored.
+func (s *JobOutputStore) Get(id string) (Job, bool, error) {
+	if !validJobID(id) {
+		return Job{}, false, nil
+	}
+	serialized, err := ioutil.ReadFile(s.path(id, jobMetadataExt))
+	if os.IsNotExist(err) {
+		return Job{}, false, nil
+	}
+	if err != nil {
+		return Job{}, false, errors.Wrapf(err, "reading job %s", id)
+	}
+	var job Job
+	if err := json.Unmarshal(serialized, &job); err != nil {
+		return Job{}, false, errors.Wrapf(err, "deserializing job %s", id)
+	}
+	return job, true, nil
+}
+
+// Output returns the full output of the job at id. The caller must close it.
+func (s *JobOutputStore) Output(id string) (io.ReadCloser, error) {
+	if !validJobID(id) {
+		return nil, errors.Errorf("invalid job id %q", id)
+	}
+	f, err := os.Open(s.path(id, jobOutputExt))
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening output for job %s", id)
+	}
+	return f, nil
+}
+
+// DeleteExpired deletes the jobs that haven't been updated within the
+// retention period and returns how many were deleted.
+func (s *JobOutputStore) DeleteExpired(now time.Time) (int, error) {
+	if s.Retention == 0 {
+		return 0, nil
+	}
+	files, err := ioutil.ReadDir(s.Dir)
+	if err != nil {
+		return 0, errors.Wrap(err, "listing job output dir")
+	}
+	deleted := 0
+	for _, f := range files {
+		id := strings.TrimSuffix(f.Name(), jobMetadataExt)
+		if id == f.Name() || !validJobID(id) || now.Sub(f.ModTime()) < s.Retention {
+			continue
+		}
+		if err := os.Remove(s.path(id, jobOutputExt)); err != nil && !os.IsNotExist(err) {
+			return deleted, errors.Wrapf(err, "deleting output for job %s", id)
+		}
+		if err := os.Remove(s.path(id, jobMetadataExt)); err != nil {
+			return deleted, errors.Wrapf(err, "deleting job %s", id)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
+
+func (s *JobOutputStore) path(id string, ext string) string {
+	return filepath.Join(s.Dir, id+ext)
+}
+
+// validJobID returns true if id could be a job id. We check this before
+// using ids in file paths since they can come from URLs.
+func validJobID(id string) bool {
+	parsed, err := uuid.Parse(id)
+	return err == nil && parsed.String() == id
+}
@@ -0,0 +1,121 @@
+package events_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestJobOutputStore_StoresTrackedJobs(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := events.NewJobOutputStore(filepath.Join(tmp, "jobs"), 0)
+	Ok(t, err)
+	j := events.NewJobTrackerWithStore(store, logging.NewNoopLogger())
+	id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+
+	job, found, err := store.Get(id)
+	Ok(t, err)
+	Assert(t, found, "exp job to be stored when it starts")
+	Equals(t, events.RunningJobStatus, job.Status)
+
+	w := j.OutputWriter(id)
+	_, err = w.Write([]byte("line 0\nline 1"))
+	Ok(t, err)
+	j.Fail(id, "failed")
+	j.Finish(id)
+
+	job, found, err = store.Get(id)
+	Ok(t, err)
+	Assert(t, found, "exp job to be stored")
+	Equals(t, "owner/repo", job.RepoFullName)
+	Equals(t, 1, job.PullNum)
+	Equals(t, models.PlanCommand, job.Command)
+	Equals(t, "user", job.Username)
+	Equals(t, events.FailedJobStatus, job.Status)
+	Equals(t, "failed", job.Failure)
+	Assert(t, !job.EndTime.IsZero(), "exp end time to be set")
+
+	out, err := store.Output(id)
+	Ok(t, err)
+	defer out.Close() // nolint: errcheck
+	bytes, err := ioutil.ReadAll(out)
+	Ok(t, err)
+	Equals(t, "line 0\nline 1", string(bytes))
+}
+
+func TestJobOutputStore_FinishedOutputReadFromStore(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := events.NewJobOutputStore(tmp, 0)
+	Ok(t, err)
+	j := events.NewJobTrackerWithStore(store, logging.NewNoopLogger())
+	id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	_, err = j.OutputWriter(id).Write([]byte("line 0\r\nline 1\nline 2\n"))
+	Ok(t, err)
+	j.Finish(id)
+
+	lines, updates, unsubscribe, ok := j.SubscribeOutput(id, 1)
+	Assert(t, ok, "exp job to be found")
+	defer unsubscribe()
+	Equals(t, []events.OutputLine{{Num: 1, Text: "line 1"}, {Num: 2, Text: "line 2"}}, lines)
+	_, open := <-updates
+	Assert(t, !open, "exp updates to be closed for finished job")
+
+	t.Log("the output shouldn't also be kept in memory")
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, id+".log"), []byte("rewritten\n"), 0600))
+	lines, _, _, _ = j.SubscribeOutput(id, 0)
+	Equals(t, []events.OutputLine{{Num: 0, Text: "rewritten"}}, lines)
+}
+
+func TestJobOutputStore_InvalidID(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := events.NewJobOutputStore(tmp, 0)
+	Ok(t, err)
+	for _, id := range []string{"", "../atlantis", "nope"} {
+		_, found, err := store.Get(id)
+		Ok(t, err)
+		Assert(t, !found, "exp job %q to not be found", id)
+		_, err = store.Output(id)
+		Assert(t, err != nil, "exp error for id %q", id)
+	}
+}
+
+func TestJobOutputStore_DeleteExpired(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := events.NewJobOutputStore(tmp, 24*time.Hour)
+	Ok(t, err)
+	j := events.NewJobTrackerWithStore(store, logging.NewNoopLogger())
+	oldID := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	j.Finish(oldID)
+	newID := j.Start("owner/repo", 2, models.PlanCommand, false, "user")
+	j.Finish(newID)
+	old := time.Now().Add(-48 * time.Hour)
+	Ok(t, os.Chtimes(filepath.Join(tmp, oldID+".json"), old, old))
+	// Other files in the dir should be left alone.
+	Ok(t, ioutil.WriteFile(filepath.Join(tmp, "other.json"), nil, 0600))
+	Ok(t, os.Chtimes(filepath.Join(tmp, "other.json"), old, old))
+
+	deleted, err := store.DeleteExpired(time.Now())
+	Ok(t, err)
+	Equals(t, 1, deleted)
+	_, found, err := store.Get(oldID)
+	Ok(t, err)
+	Assert(t, !found, "exp old job to be deleted")
+	_, err = os.Stat(filepath.Join(tmp, oldID+".log"))
+	Assert(t, os.IsNotExist(err), "exp old job output to be deleted")
+	_, found, err = store.Get(newID)
+	Ok(t, err)
+	Assert(t, found, "exp new job to be kept")
+	_, err = os.Stat(filepath.Join(tmp, "other.json"))
+	Ok(t, err)
+}
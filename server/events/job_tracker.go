@@ -0,0 +1,532 @@
+package events
+
+import (
+	"io"
+	"io/ioutil"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// defaultMaxFinishedJobs is how many finished jobs we remember so that their
+// status can still be looked up after they complete.
+const defaultMaxFinishedJobs = 500
+
+const (
+	// maxJobOutputLines is how many lines of output we keep for each job. Once
+	// a job has output more lines, the oldest ones are dropped.
+	maxJobOutputLines = 10000
+	// outputSubscriberBuffer is how many lines can be waiting to be read by an
+	// output subscriber before we drop it for being too slow.
+	outputSubscriberBuffer = 1000
+)
+
+// JobStatus is the status of a job.
+type JobStatus int
+
+const (
+	// RunningJobStatus means the job is still running.
+	RunningJobStatus JobStatus = iota
+	// SucceededJobStatus means the job finished without any errors.
+	SucceededJobStatus
+	// FailedJobStatus means the job finished but had errors or failures in
+	// at least one project.
+	FailedJobStatus
+)
+
+// String returns a string representation of the status.
+func (j JobStatus) String() string {
+	switch j {
+	case RunningJobStatus:
+		return "running"
+	case SucceededJobStatus:
+		return "succeeded"
+	case FailedJobStatus:
+		return "failed"
+	}
+	return ""
+}
+
+// Job is a command that Atlantis is running, or has run, on a pull request.
+type Job struct {
+	// ID uniquely identifies this job.
+	ID string
+	// RepoFullName is the owner and repo name of the pull request's base repo,
+	// ex. "runatlantis/atlantis".
+	RepoFullName string
+	// PullNum is the pull request number.
+	PullNum int
+	// Command is the command being run.
+	Command models.CommandName
+	// Autoplan is true if this job was triggered automatically rather than by
+	// a comment.
+	Autoplan bool
+	// Username is the user that triggered the job.
+	Username string
+	// Status is where this job is at.
+	Status JobStatus
+	// StartTime is when the job started.
+	StartTime time.Time
+	// EndTime is when the job finished. It is the zero time while the job
+	// is still running.
+	EndTime time.Time
+	// Error is set if the command errored before it could run on any
+	// projects.
+	Error string
+	// Failure is set if the command couldn't run, ex. because the pull request
+	// was closed.
+	Failure string
+	// ProjectResults are the results of running the command on each project.
+	// They're only set once the command has finished running on all projects.
+	ProjectResults []models.ProjectResult
+
+	failed bool
+}
+
+// OutputLine is a line of a job's output.
+type OutputLine struct {
+	// Num is the position of the line in the job's output, starting at 0.
+	Num  int
+	Text string
+}
+
+// jobOutput is the output of a job.
+type jobOutput struct {
+	lines []string
+	// first is the Num of lines[0]. It's greater than 0 once lines have been
+	// dropped because there were more than maxJobOutputLines.
+	first int
+	// partial is the start of a line that hasn't had its newline written yet.
+	partial     string
+	subscribers map[chan OutputLine]struct{}
+	finished    bool
+	// pending is the output waiting to be written to file, oldest first.
+	pending [][]byte
+	// flushing is true while a writer is writing pending to file. Only that
+	// writer touches file, without holding the tracker's mutex, so slow disk
+	// writes don't block other writers or jobs.
+	flushing bool
+	// flushed is closed once the output is finished and all of it has been
+	// written to file.
+	flushed chan struct{}
+	// file is where the full output is written if we're storing it.
+	file io.WriteCloser
+	// storeFailed is true if some of the output couldn't be stored.
+	storeFailed bool
+	// inStore is true once the job has finished and all its output has been
+	// stored. lines is then dropped and the output is read from the store.
+	inStore bool
+}
+
+// JobTracker keeps track of the commands Atlantis is running and their
+// output. It also remembers the most recently finished jobs so that their
+// status can be polled after they complete. If their output is stored, it's
+// read from the store rather than kept in memory. It is safe for concurrent
+// use.
+type JobTracker struct {
+	mutex       sync.RWMutex
+	jobs        map[string]*Job
+	outputs     map[string]*jobOutput
+	finished    []string
+	maxFinished int
+	store       *JobOutputStore
+	logger      *logging.SimpleLogger
+}
+
+// NewJobTracker returns a JobTracker that remembers up to
+// defaultMaxFinishedJobs finished jobs.
+func NewJobTracker() *JobTracker {
+	return NewJobTrackerWithStore(nil, nil)
+}
+
+// NewJobTrackerWithStore returns a JobTracker that also writes each job and
+// its full output to store. If store is nil, jobs are only kept in memory.
+// logger is used to log errors writing to store.
+func NewJobTrackerWithStore(store *JobOutputStore, logger *logging.SimpleLogger) *JobTracker {
+	return &JobTracker{
+		jobs:        make(map[string]*Job),
+		outputs:     make(map[string]*jobOutput),
+		maxFinished: defaultMaxFinishedJobs,
+		store:       store,
+		logger:      logger,
+	}
+}
+
+// Store returns where jobs are stored once the tracker has forgotten them or
+// nil if they aren't stored.
+func (j *JobTracker) Store() *JobOutputStore {
+	return j.store
+}
+
+// Start records that a new job has started and returns its id.
+func (j *JobTracker) Start(repoFullName string, pullNum int, cmd models.CommandName, autoplan bool, username string) string {
+	id := uuid.New().String()
+	job := &Job{
+		ID:           id,
+		RepoFullName: repoFullName,
+		PullNum:      pullNum,
+		Command:      cmd,
+		Autoplan:     autoplan,
+		Username:     username,
+		Status:       RunningJobStatus,
+		StartTime:    time.Now(),
+	}
+	out := &jobOutput{
+		subscribers: make(map[chan OutputLine]struct{}),
+		flushed:     make(chan struct{}),
+	}
+	if j.store != nil {
+		file, err := j.store.Create(*job)
+		if err != nil {
+			j.logger.Err("unable to store output of job %s: %s", id, err)
+			out.storeFailed = true
+		}
+		out.file = file
+	}
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	j.jobs[id] = job
+	j.outputs[id] = out
+	return id
+}
+
+// Fail records that the job at id couldn't run because of reason. The job is
+// still running until Finish is called.
+func (j *JobTracker) Fail(id string, reason string) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	if job, ok := j.jobs[id]; ok {
+		job.failed = true
+		if job.Failure == "" {
+			job.Failure = reason
+		}
+	}
+}
+
+// SetResult records the result of the command being run by the job at id.
+// The job is still running until Finish is called.
+func (j *JobTracker) SetResult(id string, res CommandResult) {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return
+	}
+	if res.Error != nil {
+		job.Error = res.Error.Error()
+	}
+	job.Failure = res.Failure
+	job.ProjectResults = res.ProjectResults
+	if res.HasErrors() {
+		job.failed = true
+	}
+}
+
+// Finish marks the job at id as finished. If Fail wasn't called for this job
+// then it is marked as succeeded.
+func (j *JobTracker) Finish(id string) {
+	j.mutex.Lock()
+	job, ok := j.jobs[id]
+	if !ok || !job.EndTime.IsZero() {
+		j.mutex.Unlock()
+		return
+	}
+	job.Status = SucceededJobStatus
+	if job.failed {
+		job.Status = FailedJobStatus
+	}
+	job.EndTime = time.Now()
+	finishedJob := *job
+
+	out, hasOutput := j.outputs[id]
+	if hasOutput {
+		if out.partial != "" {
+			out.append(out.partial)
+			out.partial = ""
+		}
+		for sub := range out.subscribers {
+			close(sub)
+		}
+		out.subscribers = nil
+		out.finished = true
+	}
+	// If no one is flushing the output, we flush and close its file
+	// ourselves.
+	flush := hasOutput && !out.flushing
+	if flush {
+		out.flushing = true
+	}
+
+	// Forget the oldest finished jobs so we don't grow forever.
+	j.finished = append(j.finished, id)
+	for len(j.finished) > j.maxFinished {
+		delete(j.jobs, j.finished[0])
+		delete(j.outputs, j.finished[0])
+		j.finished = j.finished[1:]
+	}
+	j.mutex.Unlock()
+
+	// The job is finished so no more output will be written. We still need
+	// to wait for the pending output to be written before the job is saved.
+	if flush {
+		j.flushOutput(id, out)
+	}
+	if hasOutput {
+		<-out.flushed
+	}
+	if j.store != nil {
+		if err := j.store.SaveJob(finishedJob); err != nil {
+			j.logger.Err("unable to store job %s: %s", id, err)
+			return
+		}
+		// Now that the full output is stored, we don't need to keep it in
+		// memory too.
+		if hasOutput {
+			j.mutex.Lock()
+			if !out.storeFailed {
+				out.lines = nil
+				out.inStore = true
+			}
+			j.mutex.Unlock()
+		}
+	}
+}
+
+// OutputWriter returns a writer that appends to the output of the job at id.
+// Writes after the job has finished are ignored.
+func (j *JobTracker) OutputWriter(id string) io.Writer {
+	return &jobOutputWriter{tracker: j, id: id}
+}
+
+// SubscribeOutput returns the output of the job at id starting at line
+// number from, and a channel that receives each new line as it's written.
+// The channel is closed once the job finishes, or if the caller falls too far
+// behind reading it in which case the caller should subscribe again.
+// unsubscribe must be called once the caller is done. The last return value is
+// false if there is no job with that id.
+func (j *JobTracker) SubscribeOutput(id string, from int) (lines []OutputLine, updates <-chan OutputLine, unsubscribe func(), ok bool) {
+	j.mutex.Lock()
+	out, ok := j.outputs[id]
+	if ok && out.inStore {
+		j.mutex.Unlock()
+		lines, err := j.storedOutput(id, from)
+		if err != nil {
+			j.logger.Err("unable to read stored output of job %s: %s", id, err)
+		}
+		ch := make(chan OutputLine)
+		close(ch)
+		return lines, ch, func() {}, true
+	}
+	defer j.mutex.Unlock()
+	if !ok {
+		return nil, nil, nil, false
+	}
+	if from < out.first {
+		from = out.first
+	}
+	for i := from - out.first; i < len(out.lines); i++ {
+		lines = append(lines, OutputLine{Num: out.first + i, Text: out.lines[i]})
+	}
+
+	ch := make(chan OutputLine, outputSubscriberBuffer)
+	if out.finished {
+		close(ch)
+		return lines, ch, func() {}, true
+	}
+	out.subscribers[ch] = struct{}{}
+	return lines, ch, func() {
+		j.mutex.Lock()
+		defer j.mutex.Unlock()
+		if _, subscribed := out.subscribers[ch]; subscribed {
+			delete(out.subscribers, ch)
+			close(ch)
+		}
+	}, true
+}
+
+// storedOutput returns the stored output of the finished job at id starting at
+// line number from.
+func (j *JobTracker) storedOutput(id string, from int) ([]OutputLine, error) {
+	r, err := j.store.Output(id)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close() // nolint: errcheck
+	contents, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	split := strings.Split(string(contents), "\n")
+	// Output ending in a newline doesn't have another line after it.
+	if split[len(split)-1] == "" {
+		split = split[:len(split)-1]
+	}
+	var lines []OutputLine
+	for i := from; i < len(split); i++ {
+		lines = append(lines, OutputLine{Num: i, Text: strings.TrimSuffix(split[i], "\r")})
+	}
+	return lines, nil
+}
+
+// writeOutput appends p to the output of the job at id and sends each
+// completed line to the job's subscribers.
+func (j *JobTracker) writeOutput(id string, p []byte) {
+	j.mutex.Lock()
+	out, ok := j.outputs[id]
+	if !ok || out.finished {
+		j.mutex.Unlock()
+		return
+	}
+	out.write(p)
+	if j.store == nil {
+		j.mutex.Unlock()
+		return
+	}
+	// p is queued while holding the mutex so the file is written in the same
+	// order as the lines. If someone is already flushing, they'll write it.
+	out.pending = append(out.pending, append([]byte(nil), p...))
+	flush := !out.flushing
+	out.flushing = true
+	j.mutex.Unlock()
+
+	if flush {
+		j.flushOutput(id, out)
+	}
+}
+
+// flushOutput writes out's pending output to its file until there's none
+// left. It must only be called by the caller that set out.flushing. If the
+// output is finished, the file is closed once everything has been written.
+func (j *JobTracker) flushOutput(id string, out *jobOutput) {
+	for {
+		j.mutex.Lock()
+		pending := out.pending
+		out.pending = nil
+		if len(pending) == 0 {
+			out.flushing = false
+			finished := out.finished
+			j.mutex.Unlock()
+			if finished {
+				j.closeOutputFile(id, out)
+				close(out.flushed)
+			}
+			return
+		}
+		j.mutex.Unlock()
+
+		for _, p := range pending {
+			if out.file == nil {
+				break
+			}
+			if _, err := out.file.Write(p); err != nil {
+				j.logger.Err("unable to store output of job %s: %s", id, err)
+				out.file.Close() // nolint: errcheck
+				out.file = nil
+				j.mutex.Lock()
+				out.storeFailed = true
+				j.mutex.Unlock()
+			}
+		}
+	}
+}
+
+// closeOutputFile closes out's file if it has one. It must only be called
+// while flushing.
+func (j *JobTracker) closeOutputFile(id string, out *jobOutput) {
+	if out.file == nil {
+		return
+	}
+	if err := out.file.Close(); err != nil {
+		j.logger.Err("unable to close output of job %s: %s", id, err)
+		j.mutex.Lock()
+		out.storeFailed = true
+		j.mutex.Unlock()
+	}
+	out.file = nil
+}
+
+// write adds p to the output's lines and sends each completed line to its
+// subscribers. The tracker's mutex must be held.
+func (o *jobOutput) write(p []byte) {
+	split := strings.Split(o.partial+string(p), "\n")
+	o.partial = split[len(split)-1]
+	for _, line := range split[:len(split)-1] {
+		line = strings.TrimSuffix(line, "\r")
+		num := o.append(line)
+		for sub := range o.subscribers {
+			select {
+			case sub <- OutputLine{Num: num, Text: line}:
+			default:
+				// The subscriber is too slow so we drop it rather than
+				// blocking the command that's writing the output.
+				delete(o.subscribers, sub)
+				close(sub)
+			}
+		}
+	}
+}
+
+// append adds line to the output and returns its line number.
+func (o *jobOutput) append(line string) int {
+	o.lines = append(o.lines, line)
+	if len(o.lines) > maxJobOutputLines {
+		drop := len(o.lines) - maxJobOutputLines
+		o.lines = o.lines[drop:]
+		o.first += drop
+	}
+	return o.first + len(o.lines) - 1
+}
+
+// jobOutputWriter is an io.Writer that writes to a job's output.
+type jobOutputWriter struct {
+	tracker *JobTracker
+	id      string
+}
+
+// Write implements io.Writer.
+func (w *jobOutputWriter) Write(p []byte) (int, error) {
+	w.tracker.writeOutput(w.id, p)
+	return len(p), nil
+}
+
+// Get returns the job at id. The second return value is false if there is no
+// job with that id.
+func (j *JobTracker) Get(id string) (Job, bool) {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	job, ok := j.jobs[id]
+	if !ok {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// List returns all the jobs being tracked, oldest first.
+func (j *JobTracker) List() []Job {
+	return j.filter(func(Job) bool { return true })
+}
+
+// Running returns the jobs that haven't finished yet, oldest first.
+func (j *JobTracker) Running() []Job {
+	return j.filter(func(job Job) bool { return job.Status == RunningJobStatus })
+}
+
+func (j *JobTracker) filter(include func(Job) bool) []Job {
+	j.mutex.RLock()
+	defer j.mutex.RUnlock()
+	var jobs []Job
+	for _, job := range j.jobs {
+		if include(*job) {
+			jobs = append(jobs, *job)
+		}
+	}
+	sort.Slice(jobs, func(a, b int) bool {
+		return jobs[a].StartTime.Before(jobs[b].StartTime)
+	})
+	return jobs
+}
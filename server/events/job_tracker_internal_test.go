@@ -0,0 +1,81 @@
+package events
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// blockingFile is an output file whose writes block until unblock is closed.
+type blockingFile struct {
+	bytes.Buffer
+	started chan struct{}
+	unblock chan struct{}
+}
+
+func (b *blockingFile) Write(p []byte) (int, error) {
+	select {
+	case b.started <- struct{}{}:
+	default:
+	}
+	<-b.unblock
+	return b.Buffer.Write(p)
+}
+
+func (b *blockingFile) Close() error {
+	return nil
+}
+
+// A slow write to one job's output file shouldn't block other writers or
+// other jobs.
+func TestJobTracker_SlowOutputFile(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := NewJobOutputStore(tmp, 0)
+	Ok(t, err)
+	j := NewJobTrackerWithStore(store, logging.NewNoopLogger())
+	slowID := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	otherID := j.Start("owner/repo", 2, models.PlanCommand, false, "user")
+
+	file := &blockingFile{started: make(chan struct{}, 1), unblock: make(chan struct{})}
+	Ok(t, j.outputs[slowID].file.Close())
+	j.outputs[slowID].file = file
+
+	go j.OutputWriter(slowID).Write([]byte("first\n")) // nolint: errcheck
+	select {
+	case <-file.started:
+	case <-time.After(time.Second):
+		t.Fatal("exp write to the file to start")
+	}
+
+	// All of these would wait behind the blocked write if it held the
+	// tracker's mutex.
+	var found bool
+	var lines []OutputLine
+	done := make(chan struct{})
+	go func() {
+		j.OutputWriter(slowID).Write([]byte("second\n")) // nolint: errcheck
+		j.OutputWriter(otherID).Write([]byte("other\n")) // nolint: errcheck
+		_, found = j.Get(otherID)
+		j.List()
+		var unsubscribe func()
+		lines, _, unsubscribe, _ = j.SubscribeOutput(slowID, 0)
+		unsubscribe()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("exp other writers and jobs not to be blocked by a slow output file")
+	}
+	Assert(t, found, "exp job to be found")
+	Equals(t, []OutputLine{{Num: 0, Text: "first"}, {Num: 1, Text: "second"}}, lines)
+
+	close(file.unblock)
+	j.Finish(slowID)
+	Equals(t, "first\nsecond\n", file.String())
+}
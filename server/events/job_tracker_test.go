@@ -0,0 +1,183 @@
+package events_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestJobTracker_StartAndFinish(t *testing.T) {
+	j := events.NewJobTracker()
+	id := j.Start("owner/repo", 1, models.PlanCommand, true, "user")
+	Assert(t, id != "", "exp id to be set")
+
+	job, ok := j.Get(id)
+	Assert(t, ok, "exp job to be found")
+	Equals(t, "owner/repo", job.RepoFullName)
+	Equals(t, 1, job.PullNum)
+	Equals(t, models.PlanCommand, job.Command)
+	Equals(t, true, job.Autoplan)
+	Equals(t, "user", job.Username)
+	Equals(t, events.RunningJobStatus, job.Status)
+	Assert(t, job.EndTime.IsZero(), "exp end time to not be set")
+	Equals(t, 1, len(j.Running()))
+
+	j.Finish(id)
+	job, ok = j.Get(id)
+	Assert(t, ok, "exp job to be found")
+	Equals(t, events.SucceededJobStatus, job.Status)
+	Assert(t, !job.EndTime.IsZero(), "exp end time to be set")
+	Equals(t, 0, len(j.Running()))
+	Equals(t, 1, len(j.List()))
+}
+
+func TestJobTracker_Fail(t *testing.T) {
+	t.Log("a failed job should still be running until it's finished")
+	j := events.NewJobTracker()
+	id := j.Start("owner/repo", 1, models.ApplyCommand, false, "user")
+	j.Fail(id, "pull request is closed")
+	job, _ := j.Get(id)
+	Equals(t, events.RunningJobStatus, job.Status)
+	Equals(t, "pull request is closed", job.Failure)
+
+	j.Finish(id)
+	job, _ = j.Get(id)
+	Equals(t, events.FailedJobStatus, job.Status)
+}
+
+func TestJobTracker_SetResult(t *testing.T) {
+	cases := []struct {
+		description string
+		result      events.CommandResult
+		expStatus   events.JobStatus
+	}{
+		{
+			"success",
+			events.CommandResult{
+				ProjectResults: []models.ProjectResult{{PlanSuccess: &models.PlanSuccess{}}},
+			},
+			events.SucceededJobStatus,
+		},
+		{
+			"project error",
+			events.CommandResult{
+				ProjectResults: []models.ProjectResult{{Failure: "failure"}},
+			},
+			events.FailedJobStatus,
+		},
+		{
+			"command error",
+			events.CommandResult{
+				Error: errors.New("err"),
+			},
+			events.FailedJobStatus,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			j := events.NewJobTracker()
+			id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+			j.SetResult(id, c.result)
+			j.Finish(id)
+			job, _ := j.Get(id)
+			Equals(t, c.expStatus, job.Status)
+			Equals(t, c.result.ProjectResults, job.ProjectResults)
+			if c.result.Error != nil {
+				Equals(t, c.result.Error.Error(), job.Error)
+			}
+		})
+	}
+}
+
+func TestJobTracker_GetNotFound(t *testing.T) {
+	j := events.NewJobTracker()
+	_, ok := j.Get("nope")
+	Assert(t, !ok, "exp job to not be found")
+}
+
+func TestJobTracker_ListOrdered(t *testing.T) {
+	j := events.NewJobTracker()
+	first := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	second := j.Start("owner/repo", 2, models.PlanCommand, false, "user")
+	jobs := j.List()
+	Equals(t, 2, len(jobs))
+	Equals(t, first, jobs[0].ID)
+	Equals(t, second, jobs[1].ID)
+}
+
+func TestJobTracker_Output(t *testing.T) {
+	j := events.NewJobTracker()
+	id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	w := j.OutputWriter(id)
+	_, err := w.Write([]byte("line 0\r\nline"))
+	Ok(t, err)
+
+	t.Log("partial lines shouldn't be sent until they're completed")
+	lines, updates, unsubscribe, ok := j.SubscribeOutput(id, 0)
+	Assert(t, ok, "exp job to be found")
+	defer unsubscribe()
+	Equals(t, []events.OutputLine{{Num: 0, Text: "line 0"}}, lines)
+
+	_, err = w.Write([]byte(" 1\nline 2"))
+	Ok(t, err)
+	Equals(t, events.OutputLine{Num: 1, Text: "line 1"}, <-updates)
+
+	t.Log("finishing should flush the partial line and close the channel")
+	j.Finish(id)
+	_, open := <-updates
+	Assert(t, !open, "exp updates to be closed")
+	lines, updates, _, ok = j.SubscribeOutput(id, 1)
+	Assert(t, ok, "exp job to be found")
+	Equals(t, []events.OutputLine{{Num: 1, Text: "line 1"}, {Num: 2, Text: "line 2"}}, lines)
+	_, open = <-updates
+	Assert(t, !open, "exp updates to be closed for finished job")
+
+	t.Log("writes after finishing should be ignored")
+	_, err = w.Write([]byte("ignored\n"))
+	Ok(t, err)
+	lines, _, _, _ = j.SubscribeOutput(id, 0)
+	Equals(t, 3, len(lines))
+}
+
+func TestJobTracker_OutputNotFound(t *testing.T) {
+	j := events.NewJobTracker()
+	_, _, _, ok := j.SubscribeOutput("nope", 0)
+	Assert(t, !ok, "exp job to not be found")
+}
+
+func TestJobTracker_OutputDropsOldestLines(t *testing.T) {
+	j := events.NewJobTracker()
+	id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	w := j.OutputWriter(id)
+	for i := 0; i < 10005; i++ {
+		fmt.Fprintf(w, "%d\n", i)
+	}
+	lines, _, unsubscribe, _ := j.SubscribeOutput(id, 0)
+	defer unsubscribe()
+	Equals(t, 10000, len(lines))
+	Equals(t, events.OutputLine{Num: 5, Text: "5"}, lines[0])
+	Equals(t, events.OutputLine{Num: 10004, Text: "10004"}, lines[len(lines)-1])
+}
+
+func TestJobTracker_OutputDropsSlowSubscribers(t *testing.T) {
+	j := events.NewJobTracker()
+	id := j.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	_, updates, unsubscribe, _ := j.SubscribeOutput(id, 0)
+	defer unsubscribe()
+	w := j.OutputWriter(id)
+	for i := 0; i < 1001; i++ {
+		fmt.Fprintf(w, "%d\n", i)
+	}
+
+	// We should be able to read everything that was buffered before the
+	// channel was closed.
+	count := 0
+	for range updates {
+		count++
+	}
+	Equals(t, 1000, count)
+}
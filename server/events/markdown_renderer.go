@@ -16,8 +16,10 @@ package events
 import (
 	"bytes"
 	"fmt"
+	"regexp"
 	"strings"
 	"text/template"
+	"unicode/utf8"
 
 	"github.com/Masterminds/sprig"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -29,14 +31,41 @@ const (
 	// maxUnwrappedLines is the maximum number of lines the Terraform output
 	// can be before we wrap it in an expandable template.
 	maxUnwrappedLines = 12
+	// truncatedOutputLines is how many lines of output we include in the
+	// comment when the output is too long and we link to the full output.
+	truncatedOutputLines = 100
 )
 
+// defaultMaxOutputChars is how many characters of a project's output we
+// include in a comment for each VCS host before truncating it. They leave
+// room below each host's maximum comment size for the rest of the comment.
+var defaultMaxOutputChars = map[models.VCSHostType]int{
+	// GitHub comments can be up to 65536 characters.
+	models.Github: 50000,
+	// GitLab notes can be up to 1,000,000 characters.
+	models.Gitlab: 500000,
+	// Bitbucket Server comments can be up to 32768 characters. Bitbucket
+	// Cloud's limit isn't documented so we use the same value.
+	models.BitbucketCloud:  25000,
+	models.BitbucketServer: 25000,
+	// Azure DevOps comments can be up to 150000 characters.
+	models.AzureDevops: 100000,
+}
+
+// outputSummaryRegex matches the line Terraform outputs that summarizes a plan
+// or apply.
+var outputSummaryRegex = regexp.MustCompile(`(?m)^(Plan: \d+ to add, \d+ to change, \d+ to destroy\.|No changes\. Infrastructure is up-to-date\.|Apply complete! Resources: \d+ added, \d+ changed, \d+ destroyed\.)\s*$`)
+
 // MarkdownRenderer renders responses as markdown.
 type MarkdownRenderer struct {
 	// GitlabSupportsCommonMark is true if the version of GitLab we're
 	// using supports the CommonMark markdown format.
 	// If we're not configured with a GitLab client, this will be false.
 	GitlabSupportsCommonMark bool
+	// MaxOutputChars overrides how many characters of a project's output we
+	// include in a comment before truncating it. If 0, we use the default
+	// for the VCS host.
+	MaxOutputChars int
 }
 
 // commonData is data that all responses have.
@@ -68,6 +97,26 @@ type resultData struct {
 type planSuccessData struct {
 	models.PlanSuccess
 	PlanWasDeleted bool
+	Truncated      *truncatedOutput
+	// AutoplanReason is why the project was planned, if it's known.
+	AutoplanReason string
+}
+
+type applySuccessData struct {
+	Output    string
+	Truncated *truncatedOutput
+	// OverriddenBy is who applied outside of the project's apply windows.
+	OverriddenBy string
+}
+
+// truncatedOutput is data about output that was too long to include in full.
+type truncatedOutput struct {
+	// Summary is Terraform's summary of the plan or apply if it output one.
+	Summary string
+	// OmittedLines is how many lines of output aren't included.
+	OmittedLines int
+	// FullOutputURL links to the full output.
+	FullOutputURL string
 }
 
 type projectResultTmplData struct {
@@ -77,9 +126,11 @@ type projectResultTmplData struct {
 	Rendered    string
 }
 
-// Render formats the data into a markdown string.
+// Render formats the data into a markdown string. If jobURL is set, it should
+// link to the full output of the command and any project output that's too
+// long for vcsHost is truncated with a link to jobURL.
 // nolint: interfacer
-func (m *MarkdownRenderer) Render(res CommandResult, cmdName models.CommandName, log string, verbose bool, vcsHost models.VCSHostType) string {
+func (m *MarkdownRenderer) Render(res CommandResult, cmdName models.CommandName, log string, verbose bool, vcsHost models.VCSHostType, jobURL string) string {
 	commandStr := strings.Title(cmdName.String())
 	common := commonData{
 		Command:      commandStr,
@@ -93,10 +144,10 @@ func (m *MarkdownRenderer) Render(res CommandResult, cmdName models.CommandName,
 	if res.Failure != "" {
 		return m.renderTemplate(failureWithLogTmpl, failureData{res.Failure, common})
 	}
-	return m.renderProjectResults(res.ProjectResults, common, vcsHost)
+	return m.renderProjectResults(res.ProjectResults, common, vcsHost, jobURL)
 }
 
-func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult, common commonData, vcsHost models.VCSHostType) string {
+func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult, common commonData, vcsHost models.VCSHostType, jobURL string) string {
 	var resultsTmplData []projectResultTmplData
 	numPlanSuccesses := 0
 
@@ -107,16 +158,21 @@ func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult,
 			ProjectName: result.ProjectName,
 		}
 		if result.Error != nil {
+			// Errors are usually at the end of the output so that's the part
+			// we keep.
+			errOutput, truncated := m.truncateOutput(result.Error.Error(), vcsHost, jobURL, true)
 			tmpl := unwrappedErrTmpl
-			if m.shouldUseWrappedTmpl(vcsHost, result.Error.Error()) {
+			if m.shouldUseWrappedTmpl(vcsHost, errOutput) {
 				tmpl = wrappedErrTmpl
 			}
 			resultData.Rendered = m.renderTemplate(tmpl, struct {
-				Command string
-				Error   string
+				Command   string
+				Error     string
+				Truncated *truncatedOutput
 			}{
-				Command: common.Command,
-				Error:   result.Error.Error(),
+				Command:   common.Command,
+				Error:     errOutput,
+				Truncated: truncated,
 			})
 		} else if result.Failure != "" {
 			resultData.Rendered = m.renderTemplate(failureTmpl, struct {
@@ -127,17 +183,22 @@ func (m *MarkdownRenderer) renderProjectResults(results []models.ProjectResult,
 				Failure: result.Failure,
 			})
 		} else if result.PlanSuccess != nil {
-			if m.shouldUseWrappedTmpl(vcsHost, result.PlanSuccess.TerraformOutput) {
-				resultData.Rendered = m.renderTemplate(planSuccessWrappedTmpl, planSuccessData{PlanSuccess: *result.PlanSuccess, PlanWasDeleted: common.PlansDeleted})
+			data := planSuccessData{PlanSuccess: *result.PlanSuccess, PlanWasDeleted: common.PlansDeleted, AutoplanReason: result.AutoplanReason}
+			data.TerraformOutput, data.Truncated = m.truncateOutput(result.PlanSuccess.TerraformOutput, vcsHost, jobURL, false)
+			if m.shouldUseWrappedTmpl(vcsHost, data.TerraformOutput) {
+				resultData.Rendered = m.renderTemplate(planSuccessWrappedTmpl, data)
 			} else {
-				resultData.Rendered = m.renderTemplate(planSuccessUnwrappedTmpl, planSuccessData{PlanSuccess: *result.PlanSuccess, PlanWasDeleted: common.PlansDeleted})
+				resultData.Rendered = m.renderTemplate(planSuccessUnwrappedTmpl, data)
 			}
 			numPlanSuccesses++
 		} else if result.ApplySuccess != "" {
-			if m.shouldUseWrappedTmpl(vcsHost, result.ApplySuccess) {
-				resultData.Rendered = m.renderTemplate(applyWrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
+			var data applySuccessData
+			data.Output, data.Truncated = m.truncateOutput(result.ApplySuccess, vcsHost, jobURL, false)
+			data.OverriddenBy = result.OverriddenBy
+			if m.shouldUseWrappedTmpl(vcsHost, data.Output) {
+				resultData.Rendered = m.renderTemplate(applyWrappedSuccessTmpl, data)
 			} else {
-				resultData.Rendered = m.renderTemplate(applyUnwrappedSuccessTmpl, struct{ Output string }{result.ApplySuccess})
+				resultData.Rendered = m.renderTemplate(applyUnwrappedSuccessTmpl, data)
 			}
 
 		} else {
@@ -181,6 +242,78 @@ func (m *MarkdownRenderer) shouldUseWrappedTmpl(vcsHost models.VCSHostType, outp
 	return strings.Count(output, "\n") > maxUnwrappedLines
 }
 
+// truncateOutput returns output unchanged if it's short enough to include in a
+// comment for vcsHost or if there's no jobURL to link to the full output.
+// Otherwise it returns the first truncatedOutputLines lines of output, or the
+// last lines if keepEnd is true, along with data about what was truncated.
+func (m *MarkdownRenderer) truncateOutput(output string, vcsHost models.VCSHostType, jobURL string, keepEnd bool) (string, *truncatedOutput) {
+	maxChars := m.maxOutputChars(vcsHost)
+	if jobURL == "" || len(output) <= maxChars {
+		return output, nil
+	}
+	lines := strings.Split(output, "\n")
+	keep := truncatedOutputLines
+	if keep > len(lines) {
+		keep = len(lines)
+	}
+	var kept []string
+	if keepEnd {
+		kept = lines[len(lines)-keep:]
+	} else {
+		kept = lines[:keep]
+	}
+	// Lines can be very long so we might still be over the limit. We drop
+	// whole lines until we're under it, but always keep at least one.
+	size := len(strings.Join(kept, "\n"))
+	for len(kept) > 1 && size > maxChars {
+		if keepEnd {
+			size -= len(kept[0]) + 1
+			kept = kept[1:]
+		} else {
+			size -= len(kept[len(kept)-1]) + 1
+			kept = kept[:len(kept)-1]
+		}
+	}
+	keptOutput := strings.Join(kept, "\n")
+	if len(keptOutput) > maxChars {
+		keptOutput = truncateRunes(keptOutput, maxChars, keepEnd)
+	}
+	return keptOutput, &truncatedOutput{
+		Summary:       outputSummaryRegex.FindString(output),
+		OmittedLines:  len(lines) - len(kept),
+		FullOutputURL: jobURL,
+	}
+}
+
+// truncateRunes returns the first maxBytes bytes of s, or the last if keepEnd
+// is true, without splitting a multi-byte character.
+func truncateRunes(s string, maxBytes int, keepEnd bool) string {
+	if keepEnd {
+		i := len(s) - maxBytes
+		for i < len(s) && !utf8.RuneStart(s[i]) {
+			i++
+		}
+		return s[i:]
+	}
+	i := maxBytes
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return s[:i]
+}
+
+// maxOutputChars returns how many characters of output we include in a
+// comment for vcsHost.
+func (m *MarkdownRenderer) maxOutputChars(vcsHost models.VCSHostType) int {
+	if m.MaxOutputChars > 0 {
+		return m.MaxOutputChars
+	}
+	if max, ok := defaultMaxOutputChars[vcsHost]; ok {
+		return max
+	}
+	return defaultMaxOutputChars[models.Github]
+}
+
 func (m *MarkdownRenderer) renderTemplate(tmpl *template.Template, data interface{}) string {
 	buf := &bytes.Buffer{}
 	if err := tmpl.Execute(buf, data); err != nil {
@@ -223,14 +356,19 @@ var multiProjectApplyTmpl = template.Must(template.New("").Funcs(sprig.TxtFuncMa
 		"---\n{{end}}" +
 		logTmpl))
 var planSuccessUnwrappedTmpl = template.Must(template.New("").Parse(
-	"```diff\n" +
+	autoplanReasonTmplText +
+		truncatedSummaryTmplText +
+		"```diff\n" +
 		"{{.TerraformOutput}}\n" +
-		"```\n\n" + planNextSteps))
+		"```\n\n" + truncatedLinkTmplText + planNextSteps))
 var planSuccessWrappedTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+	autoplanReasonTmplText +
+		truncatedSummaryTmplText +
+		"<details><summary>Show Output</summary>\n\n" +
 		"```diff\n" +
 		"{{.TerraformOutput}}\n" +
 		"```\n\n" +
+		truncatedLinkTmplText +
 		planNextSteps + "\n" +
 		"</details>"))
 
@@ -242,14 +380,20 @@ var planNextSteps = "{{ if .PlanWasDeleted }}This plan was not saved because one
 	"* :repeat: To **plan** this project again, comment:\n" +
 	"    * `{{.RePlanCmd}}`{{end}}"
 var applyUnwrappedSuccessTmpl = template.Must(template.New("").Parse(
-	"```diff\n" +
+	applyWindowOverriddenTmplText +
+		truncatedSummaryTmplText +
+		"```diff\n" +
 		"{{.Output}}\n" +
-		"```"))
+		"```" +
+		"{{ if .Truncated }}\n\n{{end}}" + truncatedLinkTmplText))
 var applyWrappedSuccessTmpl = template.Must(template.New("").Parse(
-	"<details><summary>Show Output</summary>\n\n" +
+	applyWindowOverriddenTmplText +
+		truncatedSummaryTmplText +
+		"<details><summary>Show Output</summary>\n\n" +
 		"```diff\n" +
 		"{{.Output}}\n" +
 		"```\n" +
+		"{{ if .Truncated }}\n{{end}}" + truncatedLinkTmplText +
 		"</details>"))
 var unwrappedErrTmplText = "**{{.Command}} Error**\n" +
 	"```\n" +
@@ -260,10 +404,21 @@ var wrappedErrTmplText = "**{{.Command}} Error**\n" +
 	"```\n" +
 	"{{.Error}}\n" +
 	"```\n</details>"
-var unwrappedErrTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText))
+var unwrappedErrTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText + "{{ if .Truncated }}\n\n{{end}}" + truncatedLinkTmplText))
 var unwrappedErrWithLogTmpl = template.Must(template.New("").Parse(unwrappedErrTmplText + logTmpl))
-var wrappedErrTmpl = template.Must(template.New("").Parse(wrappedErrTmplText))
+var wrappedErrTmpl = template.Must(template.New("").Parse(strings.Replace(wrappedErrTmplText, "</details>", "{{ if .Truncated }}\n{{end}}"+truncatedLinkTmplText+"</details>", 1)))
 var failureTmplText = "**{{.Command}} Failed**: {{.Failure}}"
 var failureTmpl = template.Must(template.New("").Parse(failureTmplText))
 var failureWithLogTmpl = template.Must(template.New("").Parse(failureTmplText + logTmpl))
+
+// autoplanReasonTmplText explains why a project was autoplanned and
+// applyWindowOverriddenTmplText warns that it was applied outside of its
+// apply windows. They're empty unless that's the case.
+var autoplanReasonTmplText = "{{ if .AutoplanReason }}:information_source: Planned because {{.AutoplanReason}}.\n\n{{end}}"
+var applyWindowOverriddenTmplText = "{{ if .OverriddenBy }}:warning: Applied outside of this project's apply windows by **{{.OverriddenBy}}**.\n\n{{end}}"
+
+// truncatedSummaryTmplText and truncatedLinkTmplText are included in the
+// output templates. They're empty unless the output was truncated.
+var truncatedSummaryTmplText = "{{ with .Truncated }}{{ if .Summary }}**{{.Summary}}**\n\n{{end}}{{end}}"
+var truncatedLinkTmplText = "{{ with .Truncated }}:warning: Output truncated.{{ if .OmittedLines }} {{.OmittedLines}} more lines not shown.{{end}} [View full output]({{.FullOutputURL}})\n\n{{end}}"
 var logTmpl = "{{if .Verbose}}\n<details><summary>Log</summary>\n  <p>\n\n```\n{{.Log}}```\n</p></details>{{end}}\n"
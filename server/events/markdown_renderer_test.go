@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -53,7 +54,7 @@ func TestRenderErr(t *testing.T) {
 		}
 		for _, verbose := range []bool{true, false} {
 			t.Run(fmt.Sprintf("%s_%t", c.Description, verbose), func(t *testing.T) {
-				s := r.Render(res, c.Command, "log", verbose, models.Github)
+				s := r.Render(res, c.Command, "log", verbose, models.Github, "")
 				if !verbose {
 					Equals(t, c.Expected, s)
 				} else {
@@ -92,7 +93,7 @@ func TestRenderFailure(t *testing.T) {
 		}
 		for _, verbose := range []bool{true, false} {
 			t.Run(fmt.Sprintf("%s_%t", c.Description, verbose), func(t *testing.T) {
-				s := r.Render(res, c.Command, "log", verbose, models.Github)
+				s := r.Render(res, c.Command, "log", verbose, models.Github, "")
 				if !verbose {
 					Equals(t, c.Expected, s)
 				} else {
@@ -109,7 +110,7 @@ func TestRenderErrAndFailure(t *testing.T) {
 		Error:   errors.New("error"),
 		Failure: "failure",
 	}
-	s := r.Render(res, models.PlanCommand, "", false, models.Github)
+	s := r.Render(res, models.PlanCommand, "", false, models.Github, "")
 	Equals(t, "**Plan Error**\n```\nerror\n```\n", s)
 }
 
@@ -232,6 +233,64 @@ $$$diff
 success
 $$$
 
+`,
+		},
+		{
+			"single successful apply outside of apply windows",
+			models.ApplyCommand,
+			[]models.ProjectResult{
+				{
+					ApplySuccess: "success",
+					Workspace:    "workspace",
+					RepoRelDir:   "path",
+					OverriddenBy: "lkysow",
+				},
+			},
+			models.Github,
+			`Ran Apply for dir: $path$ workspace: $workspace$
+
+:warning: Applied outside of this project's apply windows by **lkysow**.
+
+$$$diff
+success
+$$$
+
+`,
+		},
+		{
+			"single successful plan because of a modified module",
+			models.PlanCommand,
+			[]models.ProjectResult{
+				{
+					PlanSuccess: &models.PlanSuccess{
+						TerraformOutput: "terraform-output",
+						LockURL:         "lock-url",
+						RePlanCmd:       "atlantis plan -d path -w workspace",
+						ApplyCmd:        "atlantis apply -d path -w workspace",
+					},
+					Workspace:      "workspace",
+					RepoRelDir:     "path",
+					AutoplanReason: "module `modules/vpc` was modified",
+				},
+			},
+			models.Github,
+			`Ran Plan for dir: $path$ workspace: $workspace$
+
+:information_source: Planned because module $modules/vpc$ was modified.
+
+$$$diff
+terraform-output
+$$$
+
+* :arrow_forward: To **apply** this plan, comment:
+    * $atlantis apply -d path -w workspace$
+* :put_litter_in_its_place: To **delete** this plan click [here](lock-url)
+* :repeat: To **plan** this project again, comment:
+    * $atlantis plan -d path -w workspace$
+
+---
+* :fast_forward: To **apply** all unapplied plans from this pull request, comment:
+    * $atlantis apply$
 `,
 		},
 		{
@@ -527,7 +586,7 @@ $$$
 			}
 			for _, verbose := range []bool{true, false} {
 				t.Run(c.Description, func(t *testing.T) {
-					s := r.Render(res, c.Command, "log", verbose, c.VCSHost)
+					s := r.Render(res, c.Command, "log", verbose, c.VCSHost, "")
 					expWithBackticks := strings.Replace(c.Expected, "$", "`", -1)
 					if !verbose {
 						Equals(t, expWithBackticks, s)
@@ -620,7 +679,7 @@ func TestRenderProjectResults_WrappedErr(t *testing.T) {
 							Error:      errors.New(c.Output),
 						},
 					},
-				}, models.PlanCommand, "log", false, c.VCSHost)
+				}, models.PlanCommand, "log", false, c.VCSHost, "")
 				var exp string
 				if c.ShouldWrap {
 					exp = `Ran Plan for dir: $.$ workspace: $default$
@@ -745,7 +804,7 @@ func TestRenderProjectResults_WrapSingleProject(t *testing.T) {
 					}
 					rendered := mr.Render(events.CommandResult{
 						ProjectResults: []models.ProjectResult{pr},
-					}, cmd, "log", false, c.VCSHost)
+					}, cmd, "log", false, c.VCSHost, "")
 
 					// Check result.
 					var exp string
@@ -835,7 +894,7 @@ func TestRenderProjectResults_MultiProjectApplyWrapped(t *testing.T) {
 				ApplySuccess: tfOut,
 			},
 		},
-	}, models.ApplyCommand, "log", false, models.Github)
+	}, models.ApplyCommand, "log", false, models.Github, "")
 	exp := `Ran Apply for 2 projects:
 1. dir: $.$ workspace: $staging$
 1. dir: $.$ workspace: $production$
@@ -890,7 +949,7 @@ func TestRenderProjectResults_MultiProjectPlanWrapped(t *testing.T) {
 				},
 			},
 		},
-	}, models.PlanCommand, "log", false, models.Github)
+	}, models.PlanCommand, "log", false, models.Github, "")
 	exp := `Ran Plan for 2 projects:
 1. dir: $.$ workspace: $staging$
 1. dir: $.$ workspace: $production$
@@ -1032,9 +1091,109 @@ This plan was not saved because one or more projects failed and automerge requir
 	for name, c := range cases {
 		t.Run(name, func(t *testing.T) {
 			mr := events.MarkdownRenderer{}
-			rendered := mr.Render(c.cr, models.PlanCommand, "log", false, models.Github)
+			rendered := mr.Render(c.cr, models.PlanCommand, "log", false, models.Github, "")
 			expWithBackticks := strings.Replace(c.exp, "$", "`", -1)
 			Equals(t, expWithBackticks, rendered)
 		})
 	}
 }
+
+func TestRenderProjectResults_TruncatesLongOutput(t *testing.T) {
+	var lines []string
+	for i := 0; i < 150; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	lines = append(lines, "Plan: 1 to add, 0 to change, 0 to destroy.")
+	output := strings.Join(lines, "\n")
+	first100 := strings.Join(lines[:100], "\n")
+	last100 := strings.Join(lines[len(lines)-100:], "\n")
+
+	cases := map[string]struct {
+		jobURL string
+		result models.ProjectResult
+		exp    string
+	}{
+		"no job url": {
+			result: models.ProjectResult{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: "short",
+			},
+			exp: "Ran Apply for dir: `.` workspace: `default`\n\n```diff\nshort\n```\n\n",
+		},
+		"apply": {
+			jobURL: "https://atlantis/jobs/1",
+			result: models.ProjectResult{
+				RepoRelDir:   ".",
+				Workspace:    "default",
+				ApplySuccess: output,
+			},
+			exp: "Ran Apply for dir: `.` workspace: `default`\n\n" +
+				"**Plan: 1 to add, 0 to change, 0 to destroy.**\n\n" +
+				"<details><summary>Show Output</summary>\n\n```diff\n" + first100 + "\n```\n\n" +
+				":warning: Output truncated. 51 more lines not shown. [View full output](https://atlantis/jobs/1)\n\n" +
+				"</details>\n\n",
+		},
+		"error": {
+			jobURL: "https://atlantis/jobs/1",
+			result: models.ProjectResult{
+				RepoRelDir: ".",
+				Workspace:  "default",
+				Error:      errors.New(output),
+			},
+			exp: "Ran Apply for dir: `.` workspace: `default`\n\n" +
+				"**Apply Error**\n<details><summary>Show Output</summary>\n\n```\n" + last100 + "\n```\n\n" +
+				":warning: Output truncated. 51 more lines not shown. [View full output](https://atlantis/jobs/1)\n\n" +
+				"</details>\n\n",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mr := events.MarkdownRenderer{MaxOutputChars: 1000}
+			rendered := mr.Render(events.CommandResult{
+				ProjectResults: []models.ProjectResult{c.result},
+			}, models.ApplyCommand, "log", false, models.Github, c.jobURL)
+			Equals(t, c.exp, rendered)
+		})
+	}
+}
+
+func TestRenderProjectResults_TruncatesLongLines(t *testing.T) {
+	long := strings.Repeat("a", 300)
+	var lines []string
+	for i := 0; i < 10; i++ {
+		lines = append(lines, long)
+	}
+	// € is 3 bytes so 1000 bytes would split one.
+	nonASCII := strings.Repeat("€", 400)
+
+	cases := map[string]struct {
+		output string
+		exp    string
+	}{
+		"whole lines": {
+			output: strings.Join(lines, "\n"),
+			exp: "```diff\n" + strings.Join(lines[:3], "\n") + "\n```\n\n" +
+				":warning: Output truncated. 7 more lines not shown. [View full output](https://atlantis/jobs/1)\n\n\n\n",
+		},
+		"single non-ASCII line": {
+			output: nonASCII,
+			exp: "```diff\n" + strings.Repeat("€", 333) + "\n```\n\n" +
+				":warning: Output truncated. [View full output](https://atlantis/jobs/1)\n\n\n\n",
+		},
+	}
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			mr := events.MarkdownRenderer{MaxOutputChars: 1000}
+			rendered := mr.Render(events.CommandResult{
+				ProjectResults: []models.ProjectResult{{
+					RepoRelDir:   ".",
+					Workspace:    "default",
+					ApplySuccess: c.output,
+				}},
+			}, models.ApplyCommand, "log", false, models.Github, "https://atlantis/jobs/1")
+			Equals(t, "Ran Apply for dir: `.` workspace: `default`\n\n"+c.exp, rendered)
+			Assert(t, utf8.ValidString(rendered), "rendered output isn't valid UTF-8")
+		})
+	}
+}
@@ -36,3 +36,13 @@ func (s CommitStatus) String() string {
 	}
 	return "failed"
 }
+
+// StatusCheck is the latest status of a check on a commit, ex. a CI build or
+// a GitHub check run.
+type StatusCheck struct {
+	// Name identifies the check, ex. ci/circleci: test. It's the status's
+	// context on GitHub, the job's name on GitLab and the build status's key
+	// on Bitbucket.
+	Name   string
+	Status CommitStatus
+}
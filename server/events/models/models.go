@@ -18,6 +18,7 @@ package models
 
 import (
 	"fmt"
+	"io"
 	"net/url"
 	paths "path"
 	"strings"
@@ -33,10 +34,12 @@ import (
 // Repo is a VCS repository.
 type Repo struct {
 	// FullName is the owner and repo name separated
-	// by a "/", ex. "runatlantis/atlantis", "gitlab/subgroup/atlantis", "Bitbucket Server/atlantis".
+	// by a "/", ex. "runatlantis/atlantis", "gitlab/subgroup/atlantis",
+	// "Bitbucket Server/atlantis", "azuredevops-org/project/atlantis".
 	FullName string
 	// Owner is just the repo owner, ex. "runatlantis" or "gitlab/subgroup".
-	// This may contain /'s in the case of GitLab subgroups.
+	// This may contain /'s in the case of GitLab subgroups and Azure DevOps
+	// organizations and projects.
 	// This may contain spaces in the case of Bitbucket Server.
 	Owner string
 	// Name is just the repo name, ex. "atlantis". This will never have
@@ -64,7 +67,9 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 		return Repo{}, errors.New("cloneURL can't be empty")
 	}
 
-	if !strings.HasSuffix(cloneURL, ".git") {
+	// Azure DevOps clone URLs don't end with .git, ex.
+	// https://dev.azure.com/org/project/_git/repo.
+	if !strings.HasSuffix(cloneURL, ".git") && vcsHostType != AzureDevops {
 		cloneURL += ".git"
 	}
 
@@ -79,6 +84,10 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 	// from the repo name and so there's no point checking if they match.
 	if vcsHostType != BitbucketServer {
 		expClonePath := fmt.Sprintf("/%s.git", repoFullName)
+		if vcsHostType == AzureDevops {
+			owner, repo := SplitRepoFullName(repoFullName)
+			expClonePath = fmt.Sprintf("/%s/_git/%s", owner, repo)
+		}
 		if expClonePath != cloneURLParsed.Path {
 			return Repo{}, fmt.Errorf("expected clone url to have path %q but had %q", expClonePath, cloneURLParsed.Path)
 		}
@@ -100,9 +109,9 @@ func NewRepo(vcsHostType VCSHostType, repoFullName string, cloneURL string, vcsU
 	if owner == "" || repo == "" {
 		return Repo{}, fmt.Errorf("invalid repo format %q, owner %q or repo %q was empty", repoFullName, owner, repo)
 	}
-	// Only GitLab repos can have /'s in their owners. This is for GitLab
-	// subgroups.
-	if strings.Contains(owner, "/") && vcsHostType != Gitlab {
+	// Only GitLab and Azure DevOps repos can have /'s in their owners. This is
+	// for GitLab subgroups and Azure DevOps organizations and projects.
+	if strings.Contains(owner, "/") && vcsHostType != Gitlab && vcsHostType != AzureDevops {
 		return Repo{}, fmt.Errorf("invalid repo format %q, owner %q should not contain any /'s", repoFullName, owner)
 	}
 	if strings.Contains(repo, "/") {
@@ -187,6 +196,60 @@ type User struct {
 	Username string
 }
 
+// Approval is a user's approval of a pull request.
+type Approval struct {
+	// Username is the VCS username of who approved.
+	Username string
+	// CommitSHA is the commit that was approved. It's empty if the VCS host
+	// doesn't say which commit was approved.
+	CommitSHA string
+}
+
+// IsStale returns true if the approval wasn't given for pull's latest commit.
+// Approvals that don't say which commit was approved are always stale since
+// they could have been given before the latest commit.
+func (a Approval) IsStale(pull PullRequest) bool {
+	return a.CommitSHA != pull.HeadCommit
+}
+
+// PermissionLevel is a user's level of access to a repo. Each VCS host's
+// permissions are mapped onto these levels.
+type PermissionLevel int
+
+const (
+	// NoPermission means the user has no access to the repo.
+	NoPermission PermissionLevel = iota
+	// ReadPermission means the user can read the repo but not push to it.
+	ReadPermission
+	// WritePermission means the user can push to the repo.
+	WritePermission
+	// AdminPermission means the user can administer the repo.
+	AdminPermission
+)
+
+// String returns the string representation of p.
+func (p PermissionLevel) String() string {
+	switch p {
+	case ReadPermission:
+		return "read"
+	case WritePermission:
+		return "write"
+	case AdminPermission:
+		return "admin"
+	}
+	return "none"
+}
+
+// ParsePermissionLevel parses one of "none", "read", "write" or "admin".
+func ParsePermissionLevel(s string) (PermissionLevel, error) {
+	for _, p := range []PermissionLevel{NoPermission, ReadPermission, WritePermission, AdminPermission} {
+		if p.String() == s {
+			return p, nil
+		}
+	}
+	return NoPermission, fmt.Errorf("invalid permission level %q: not one of none, read, write or admin", s)
+}
+
 // ProjectLock represents a lock on a project.
 type ProjectLock struct {
 	// Project is the project that is being locked.
@@ -263,6 +326,7 @@ const (
 	Gitlab
 	BitbucketCloud
 	BitbucketServer
+	AzureDevops
 )
 
 func (h VCSHostType) String() string {
@@ -275,6 +339,8 @@ func (h VCSHostType) String() string {
 		return "BitbucketCloud"
 	case BitbucketServer:
 		return "BitbucketServer"
+	case AzureDevops:
+		return "AzureDevops"
 	}
 	return "<missing String() implementation>"
 }
@@ -283,6 +349,12 @@ type ProjectCommandContext struct {
 	// ApplyCmd is the command that users should run to apply this plan. If
 	// this is an apply then this will be empty.
 	ApplyCmd string
+	// AutoplanReason explains why this project was planned, ex. because a
+	// module it uses was modified. Which modified file matched its
+	// when_modified config is only explained for verbose plans, and for
+	// autoplans if --autoplan-verbose is set. It's empty if the reason is
+	// obvious, ex. a comment named the project.
+	AutoplanReason string
 	// BaseRepo is the repository that the pull request will be merged into.
 	BaseRepo Repo
 	// CommentArgs are the extra arguments appended to comment,
@@ -294,7 +366,17 @@ type ProjectCommandContext struct {
 	// be the same as BaseRepo.
 	// See https://help.github.com/articles/about-pull-request-merges/.
 	HeadRepo Repo
-	Log      *logging.SimpleLogger
+	// JobID is the id of the job this command is being run as part of. It's
+	// empty if jobs aren't being tracked.
+	JobID string
+	Log   *logging.SimpleLogger
+	// Output receives the output of the commands run for this project while
+	// they're running so it can be streamed to the UI. If it's nil, the
+	// output isn't streamed.
+	Output io.Writer
+	// OverrideApplyWindow is true if the user asked to apply outside of the
+	// project's apply windows.
+	OverrideApplyWindow bool
 	// PullMergeable is true if the pull request for this project is able to be merged.
 	PullMergeable bool
 	Pull          PullRequest
@@ -343,6 +425,11 @@ type ProjectResult struct {
 	PlanSuccess  *PlanSuccess
 	ApplySuccess string
 	ProjectName  string
+	// OverriddenBy is the username of who applied the project outside of its
+	// apply windows. It's empty if the windows weren't overridden.
+	OverriddenBy string
+	// AutoplanReason is the AutoplanReason of the project's command context.
+	AutoplanReason string
 }
 
 // CommitStatus returns the vcs commit status of this project result.
@@ -403,6 +490,9 @@ type PullStatus struct {
 	Projects []ProjectStatus
 	// Pull is the original pull request model.
 	Pull PullRequest
+	// UpdatedAt is when the status was last updated with project results. It
+	// is the zero time for statuses saved before we started recording it.
+	UpdatedAt time.Time
 }
 
 // StatusCount returns the number of projects that have status.
@@ -481,3 +571,102 @@ func (c CommandName) String() string {
 	}
 	return ""
 }
+
+// Freeze blocks applies, ex. during a change freeze. Plans are still allowed.
+type Freeze struct {
+	// ID uniquely identifies the freeze.
+	ID string
+	// RepoFullName is the repo that's frozen. If empty, all repos are frozen.
+	RepoFullName string
+	// ProjectGlob is a glob, ex. "prod/*", matched against the project's
+	// name and its dir. If empty, all projects are frozen.
+	ProjectGlob string
+	// Reason explains why applies are blocked.
+	Reason string
+	// CreatedBy is who set the freeze.
+	CreatedBy string
+	CreatedAt time.Time
+	// Start and End are set for freezes from the server's freeze schedule.
+	// They're zero for freezes set via the API or UI, which are active until
+	// they're deleted.
+	Start time.Time
+	End   time.Time
+}
+
+// IsActive returns true if the freeze is in effect at now.
+func (f Freeze) IsActive(now time.Time) bool {
+	if !f.Start.IsZero() && now.Before(f.Start) {
+		return false
+	}
+	if !f.End.IsZero() && !now.Before(f.End) {
+		return false
+	}
+	return true
+}
+
+// Matches returns true if the freeze covers the project at repoRelDir with
+// projectName in repoFullName. projectName can be empty.
+func (f Freeze) Matches(repoFullName string, repoRelDir string, projectName string) bool {
+	return projectMatches(f.RepoFullName, f.ProjectGlob, repoFullName, repoRelDir, projectName)
+}
+
+// Scope describes what the freeze covers, ex. "all repos" or "owner/repo
+// projects matching prod/*".
+func (f Freeze) Scope() string {
+	scope := "all repos"
+	if f.RepoFullName != "" {
+		scope = f.RepoFullName
+	}
+	if f.ProjectGlob != "" {
+		scope += fmt.Sprintf(" projects matching %s", f.ProjectGlob)
+	}
+	return scope
+}
+
+// ApplierRule restricts who can apply the projects it matches.
+type ApplierRule struct {
+	// RepoFullName is the repo the rule covers. If empty, it covers all repos.
+	RepoFullName string
+	// DirGlob is a glob, ex. "prod/*", matched against the project's dir. If
+	// empty, it covers all dirs. Project names aren't matched since a pull
+	// request can change them in its atlantis.yaml file.
+	DirGlob string
+	// Workspace is the workspace the rule covers. If empty, it covers all
+	// workspaces.
+	Workspace string
+	// Users can apply the matching projects.
+	Users []string
+	// Teams are VCS teams or groups whose members can apply the matching
+	// projects.
+	Teams []string
+}
+
+// Matches returns true if the rule covers the project at repoRelDir and
+// workspace in repoFullName.
+func (a ApplierRule) Matches(repoFullName string, repoRelDir string, workspace string) bool {
+	if a.Workspace != "" && a.Workspace != workspace {
+		return false
+	}
+	return projectMatches(a.RepoFullName, a.DirGlob, repoFullName, repoRelDir, "")
+}
+
+// projectMatches returns true if repoFilter and projectGlob cover the project
+// at repoRelDir with projectName in repoFullName. An empty repoFilter or
+// projectGlob matches everything.
+func projectMatches(repoFilter string, projectGlob string, repoFullName string, repoRelDir string, projectName string) bool {
+	if repoFilter != "" && repoFilter != repoFullName {
+		return false
+	}
+	if projectGlob == "" {
+		return true
+	}
+	if matched, _ := paths.Match(projectGlob, repoRelDir); matched {
+		return true
+	}
+	if projectName != "" {
+		if matched, _ := paths.Match(projectGlob, projectName); matched {
+			return true
+		}
+	}
+	return false
+}
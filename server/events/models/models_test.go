@@ -17,6 +17,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/runatlantis/atlantis/server/events/models"
 	. "github.com/runatlantis/atlantis/testing"
@@ -60,6 +61,25 @@ func TestNewRepo_CloneURLBitbucketServer(t *testing.T) {
 	}, repo)
 }
 
+func TestNewRepo_AzureDevops(t *testing.T) {
+	repo, err := models.NewRepo(models.AzureDevops, "org/project/repo", "https://dev.azure.com/org/project/_git/repo", "u", "p")
+	Ok(t, err)
+	Equals(t, models.Repo{
+		FullName:          "org/project/repo",
+		Owner:             "org/project",
+		Name:              "repo",
+		CloneURL:          "https://u:p@dev.azure.com/org/project/_git/repo",
+		SanitizedCloneURL: "https://dev.azure.com/org/project/_git/repo",
+		VCSHost: models.VCSHost{
+			Hostname: "dev.azure.com",
+			Type:     models.AzureDevops,
+		},
+	}, repo)
+
+	_, err = models.NewRepo(models.AzureDevops, "org/project/repo", "https://dev.azure.com/org/project/_git/other", "u", "p")
+	ErrEquals(t, `expected clone url to have path "/org/project/_git/repo" but had "/org/project/_git/other"`, err)
+}
+
 func TestNewRepo_FullNameWrongFormat(t *testing.T) {
 	cases := []struct {
 		repoFullName string
@@ -196,6 +216,10 @@ func TestVCSHostType_ToString(t *testing.T) {
 			models.BitbucketServer,
 			"BitbucketServer",
 		},
+		{
+			models.AzureDevops,
+			"AzureDevops",
+		},
 	}
 
 	for _, c := range cases {
@@ -374,3 +398,66 @@ func TestPullStatus_StatusCount(t *testing.T) {
 	Equals(t, 1, ps.StatusCount(models.ErroredApplyStatus))
 	Equals(t, 0, ps.StatusCount(models.ErroredPlanStatus))
 }
+
+func TestFreeze_IsActive(t *testing.T) {
+	now := time.Now()
+	Equals(t, true, models.Freeze{}.IsActive(now))
+	Equals(t, true, models.Freeze{Start: now.Add(-time.Hour), End: now.Add(time.Hour)}.IsActive(now))
+	Equals(t, false, models.Freeze{Start: now.Add(time.Hour)}.IsActive(now))
+	Equals(t, false, models.Freeze{End: now}.IsActive(now))
+}
+
+func TestFreeze_Matches(t *testing.T) {
+	cases := []struct {
+		freeze      models.Freeze
+		repoRelDir  string
+		projectName string
+		exp         bool
+	}{
+		{models.Freeze{}, ".", "", true},
+		{models.Freeze{RepoFullName: "owner/repo"}, ".", "", true},
+		{models.Freeze{RepoFullName: "owner/other"}, ".", "", false},
+		{models.Freeze{ProjectGlob: "prod/*"}, "prod/vpc", "", true},
+		{models.Freeze{ProjectGlob: "prod/*"}, "staging/vpc", "", false},
+		{models.Freeze{ProjectGlob: "prod-*"}, "vpc", "prod-vpc", true},
+		{models.Freeze{ProjectGlob: "prod-*"}, "vpc", "staging-vpc", false},
+		{models.Freeze{RepoFullName: "owner/other", ProjectGlob: "prod/*"}, "prod/vpc", "", false},
+	}
+	for _, c := range cases {
+		t.Run(c.freeze.Scope(), func(t *testing.T) {
+			Equals(t, c.exp, c.freeze.Matches("owner/repo", c.repoRelDir, c.projectName))
+		})
+	}
+}
+
+func TestFreeze_Scope(t *testing.T) {
+	Equals(t, "all repos", models.Freeze{}.Scope())
+	Equals(t, "owner/repo", models.Freeze{RepoFullName: "owner/repo"}.Scope())
+	Equals(t, "all repos projects matching prod/*", models.Freeze{ProjectGlob: "prod/*"}.Scope())
+}
+
+func TestApplierRule_Matches(t *testing.T) {
+	Equals(t, true, models.ApplierRule{}.Matches("owner/repo", ".", "default"))
+	Equals(t, true, models.ApplierRule{RepoFullName: "owner/repo", DirGlob: "prod-*"}.Matches("owner/repo", "prod-vpc", "default"))
+	Equals(t, false, models.ApplierRule{RepoFullName: "owner/other"}.Matches("owner/repo", ".", "default"))
+	Equals(t, false, models.ApplierRule{DirGlob: "prod/*"}.Matches("owner/repo", "staging/vpc", "default"))
+	Equals(t, true, models.ApplierRule{Workspace: "prod"}.Matches("owner/repo", ".", "prod"))
+	Equals(t, false, models.ApplierRule{Workspace: "prod"}.Matches("owner/repo", ".", "default"))
+}
+
+func TestApproval_IsStale(t *testing.T) {
+	pull := models.PullRequest{HeadCommit: "new"}
+	Equals(t, false, models.Approval{Username: "user", CommitSHA: "new"}.IsStale(pull))
+	Equals(t, true, models.Approval{Username: "user", CommitSHA: "old"}.IsStale(pull))
+	Equals(t, true, models.Approval{Username: "user"}.IsStale(pull))
+}
+
+func TestParsePermissionLevel(t *testing.T) {
+	for _, p := range []models.PermissionLevel{models.NoPermission, models.ReadPermission, models.WritePermission, models.AdminPermission} {
+		parsed, err := models.ParsePermissionLevel(p.String())
+		Ok(t, err)
+		Equals(t, p, parsed)
+	}
+	_, err := models.ParsePermissionLevel("maintain")
+	ErrEquals(t, "invalid permission level \"maintain\": not one of none, read, write or admin", err)
+}
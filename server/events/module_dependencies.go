@@ -0,0 +1,193 @@
+package events
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/hashicorp/hcl/hcl/parser"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// moduleDependencies finds the local modules, ex. source = "../modules/vpc",
+// that the Terraform code in a repo's dirs uses.
+type moduleDependencies struct {
+	log     *logging.SimpleLogger
+	repoDir string
+	// modules caches the local modules called by each dir, keyed by the dir
+	// relative to the repo root.
+	modules map[string][]string
+}
+
+func newModuleDependencies(log *logging.SimpleLogger, repoDir string) *moduleDependencies {
+	return &moduleDependencies{
+		log:     log,
+		repoDir: repoDir,
+		modules: make(map[string][]string),
+	}
+}
+
+// localModules returns the dirs, relative to the repo root, of the local
+// modules called by the .tf files in dir. Files that can't be parsed are
+// skipped.
+func (m *moduleDependencies) localModules(dir string) []string {
+	if modules, ok := m.modules[dir]; ok {
+		return modules
+	}
+	var modules []string
+	files, _ := filepath.Glob(filepath.Join(m.repoDir, dir, "*.tf")) // nolint: errcheck
+	for _, file := range files {
+		for _, source := range m.moduleSources(file) {
+			if !strings.HasPrefix(source, "./") && !strings.HasPrefix(source, "../") {
+				continue
+			}
+			moduleDir := filepath.ToSlash(filepath.Clean(filepath.Join(dir, source)))
+			// Ignore modules outside of the repo.
+			if moduleDir == ".." || strings.HasPrefix(moduleDir, "../") {
+				continue
+			}
+			modules = append(modules, moduleDir)
+		}
+	}
+	sort.Strings(modules)
+	m.modules[dir] = modules
+	return modules
+}
+
+// moduleSources returns the source of each module block in the .tf file.
+func (m *moduleDependencies) moduleSources(file string) []string {
+	contents, err := ioutil.ReadFile(file) // nolint: gosec
+	if err != nil {
+		m.log.Warn("unable to read %s to find its modules: %s", file, err)
+		return nil
+	}
+	parsed, err := parser.Parse(contents)
+	if err != nil {
+		m.log.Warn("unable to parse %s to find its modules: %s", file, err)
+		return nil
+	}
+	list, ok := parsed.Node.(*ast.ObjectList)
+	if !ok {
+		return nil
+	}
+	var sources []string
+	for _, module := range list.Filter("module").Items {
+		body, ok := module.Val.(*ast.ObjectType)
+		if !ok {
+			continue
+		}
+		for _, source := range body.List.Filter("source").Items {
+			if lit, ok := source.Val.(*ast.LiteralType); ok {
+				if s, ok := lit.Token.Value().(string); ok {
+					sources = append(sources, s)
+				}
+			}
+		}
+	}
+	return sources
+}
+
+// modifiedModule returns the chain of local modules from dir to the first
+// module it uses, directly or through other modules, that contains one of
+// modifiedFiles. The last element is the modified module. It returns nil if
+// none of the modules dir uses were modified.
+func (m *moduleDependencies) modifiedModule(dir string, modifiedFiles []string) []string {
+	dir = filepath.ToSlash(filepath.Clean(dir))
+	// Search breadth first so we find the shortest chain.
+	visited := map[string]bool{dir: true}
+	queue := [][]string{nil}
+	for len(queue) > 0 {
+		chain := queue[0]
+		queue = queue[1:]
+		current := dir
+		if len(chain) > 0 {
+			current = chain[len(chain)-1]
+		}
+		for _, module := range m.localModules(current) {
+			if visited[module] {
+				continue
+			}
+			visited[module] = true
+			moduleChain := append(append([]string{}, chain...), module)
+			if m.containsAny(module, modifiedFiles) {
+				return moduleChain
+			}
+			queue = append(queue, moduleChain)
+		}
+	}
+	return nil
+}
+
+// containsAny returns true if any of files are inside dir.
+func (m *moduleDependencies) containsAny(dir string, files []string) bool {
+	for _, f := range files {
+		if dir == "." || strings.HasPrefix(filepath.ToSlash(f), dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// rootDirs returns the dirs, relative to the repo root, that contain .tf files
+// and aren't used as local modules by any other dir, and the dirs that are.
+// Hidden dirs are skipped.
+func (m *moduleDependencies) rootDirs() (roots []string, modules map[string]bool, err error) {
+	var tfDirs []string
+	seen := make(map[string]bool)
+	err = filepath.Walk(m.repoDir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			if path != m.repoDir && strings.HasPrefix(info.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".tf" {
+			return nil
+		}
+		rel, err := filepath.Rel(m.repoDir, filepath.Dir(path))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if !seen[rel] {
+			seen[rel] = true
+			tfDirs = append(tfDirs, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modules = make(map[string]bool)
+	for _, dir := range tfDirs {
+		for _, module := range m.localModules(dir) {
+			if module != dir {
+				modules[module] = true
+			}
+		}
+	}
+	for _, dir := range tfDirs {
+		if !modules[dir] {
+			roots = append(roots, dir)
+		}
+	}
+	return roots, modules, nil
+}
+
+// moduleAutoplanReason explains that a project was planned because of the
+// modified module at the end of chain.
+func moduleAutoplanReason(chain []string) string {
+	reason := fmt.Sprintf("module `%s` was modified", chain[len(chain)-1])
+	if len(chain) > 1 {
+		reason += fmt.Sprintf(" (used via `%s`)", strings.Join(chain[:len(chain)-1], "` → `"))
+	}
+	return reason
+}
@@ -2,6 +2,8 @@ package events
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/hashicorp/go-version"
@@ -9,6 +11,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
 	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
 )
@@ -22,6 +25,18 @@ const (
 	DefaultWorkspace = "default"
 )
 
+// BranchNotMatchedErr is returned when a pull request is into a base branch
+// that doesn't match the branch config of the repo's atlantis.yaml file.
+type BranchNotMatchedErr struct {
+	BaseBranch string
+	Branch     string
+}
+
+// Error implements the error interface.
+func (b BranchNotMatchedErr) Error() string {
+	return fmt.Sprintf("pull request is into branch %q which doesn't match the branch config %q in %s", b.BaseBranch, b.Branch, yaml.AtlantisYAMLFilename)
+}
+
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_command_builder.go ProjectCommandBuilder
 
 // ProjectCommandBuilder builds commands that run on individual projects.
@@ -52,6 +67,15 @@ type DefaultProjectCommandBuilder struct {
 	AllowRepoConfigFlag string
 	PendingPlanFinder   *DefaultPendingPlanFinder
 	CommentBuilder      CommentBuilder
+	// ServerConfig is the server-side repo config. If nil, repos are only
+	// configured by their atlantis.yaml files.
+	ServerConfig *valid.ServerConfig
+	// AutoplanModules is true if projects should also be planned when a
+	// local module they use is modified.
+	AutoplanModules bool
+	// AutoplanVerbose is true if autoplans should explain which modified file
+	// matched which when_modified pattern, like plans run with --verbose.
+	AutoplanVerbose bool
 }
 
 // TFCommandRunner runs Terraform commands.
@@ -63,7 +87,7 @@ type TFCommandRunner interface {
 // BuildAutoplanCommands builds project commands that will run plan on
 // the projects determined to be modified.
 func (p *DefaultProjectCommandBuilder) BuildAutoplanCommands(ctx *CommandContext) ([]models.ProjectCommandContext, error) {
-	cmds, err := p.buildPlanAllCommands(ctx, nil, false)
+	cmds, err := p.buildPlanAllCommands(ctx, nil, p.AutoplanVerbose)
 	if err != nil {
 		return nil, err
 	}
@@ -96,20 +120,15 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *CommandContext,
 	}
 
 	// Parse config file if it exists.
-	var config valid.Config
-	hasConfigFile, err := p.ParserValidator.HasConfigFile(repoDir)
+	config, hasConfigFile, serverCfg, err := p.getRepoCfg(ctx.BaseRepo.FullName, repoDir)
 	if err != nil {
-		return nil, errors.Wrapf(err, "looking for %s file in %q", yaml.AtlantisYAMLFilename, repoDir)
+		return nil, err
 	}
 	if hasConfigFile {
-		if !p.AllowRepoConfig {
-			return nil, fmt.Errorf("%s files not allowed because Atlantis is not running with --%s", yaml.AtlantisYAMLFilename, p.AllowRepoConfigFlag)
-		}
-		config, err = p.ParserValidator.ReadConfig(repoDir)
-		if err != nil {
-			return nil, err
-		}
 		ctx.Log.Info("successfully parsed %s file", yaml.AtlantisYAMLFilename)
+		if !config.MatchesBranch(ctx.Pull.BaseBranch) {
+			return nil, BranchNotMatchedErr{BaseBranch: ctx.Pull.BaseBranch, Branch: *config.Branch}
+		}
 	} else {
 		ctx.Log.Info("found no %s file", yaml.AtlantisYAMLFilename)
 	}
@@ -129,58 +148,181 @@ func (p *DefaultProjectCommandBuilder) buildPlanAllCommands(ctx *CommandContext,
 	if !hasConfigFile {
 		modifiedProjects := p.ProjectFinder.DetermineProjects(ctx.Log, modifiedFiles, ctx.BaseRepo.FullName, repoDir)
 		ctx.Log.Info("automatically determined that there were %d projects modified in this pull request: %s", len(modifiedProjects), modifiedProjects)
+		autoplanReasons := make(map[string]string)
+		if p.AutoplanModules {
+			// Like when finding the modified projects, only Terraform files
+			// that aren't ignored count as changes to modules.
+			var moduleFiles []string
+			moduleFiles, err = p.ProjectFinder.FilterIgnored(ctx.Log, modifiedFiles)
+			if err != nil {
+				return nil, err
+			}
+			modifiedProjects, autoplanReasons, err = p.addModuleDependents(ctx, modifiedProjects, filterToTerraform(moduleFiles), repoDir)
+			if err != nil {
+				return nil, err
+			}
+		}
+		// Without server-side config, there is no config for these projects.
+		var globalCfg *valid.Config
+		if serverCfg != nil {
+			globalCfg = &config
+		}
 		for _, mp := range modifiedProjects {
 			projCtxs = append(projCtxs, models.ProjectCommandContext{
-				BaseRepo:      ctx.BaseRepo,
-				HeadRepo:      ctx.HeadRepo,
-				Pull:          ctx.Pull,
-				User:          ctx.User,
-				Log:           ctx.Log,
-				RepoRelDir:    mp.Path,
-				ProjectConfig: nil,
-				GlobalConfig:  nil,
-				CommentArgs:   commentFlags,
-				Workspace:     DefaultWorkspace,
-				Verbose:       verbose,
-				RePlanCmd:     p.CommentBuilder.BuildPlanComment(mp.Path, DefaultWorkspace, "", commentFlags),
-				ApplyCmd:      p.CommentBuilder.BuildApplyComment(mp.Path, DefaultWorkspace, ""),
-				PullMergeable: ctx.PullMergeable,
+				BaseRepo:       ctx.BaseRepo,
+				HeadRepo:       ctx.HeadRepo,
+				Pull:           ctx.Pull,
+				User:           ctx.User,
+				Log:            ctx.Log,
+				RepoRelDir:     mp.Path,
+				ProjectConfig:  p.defaultProjectCfg(serverCfg, mp.Path, DefaultWorkspace),
+				GlobalConfig:   globalCfg,
+				CommentArgs:    commentFlags,
+				Workspace:      DefaultWorkspace,
+				Verbose:        verbose,
+				RePlanCmd:      p.CommentBuilder.BuildPlanComment(mp.Path, DefaultWorkspace, "", commentFlags),
+				ApplyCmd:       p.CommentBuilder.BuildApplyComment(mp.Path, DefaultWorkspace, ""),
+				PullMergeable:  ctx.PullMergeable,
+				AutoplanReason: autoplanReasons[mp.Path],
 			})
 		}
 	} else {
 		// Otherwise, we use the projects that match the WhenModified fields
 		// in the config file.
-		matchingProjects, err := p.ProjectFinder.DetermineProjectsViaConfig(ctx.Log, modifiedFiles, config, repoDir)
+		matchingProjects, autoplanReasons, err := p.ProjectFinder.DetermineProjectsViaConfig(ctx.Log, modifiedFiles, config, repoDir)
 		if err != nil {
 			return nil, err
 		}
 		ctx.Log.Info("%d projects are to be planned based on their when_modified config", len(matchingProjects))
+		// Which file matched which when_modified pattern is only explained in
+		// verbose comments, which autoplans are if AutoplanVerbose is set.
+		if !verbose {
+			autoplanReasons = make([]string, len(matchingProjects))
+		}
+		if p.AutoplanModules {
+			// Ignored files don't count as changes to modules either.
+			moduleFiles, err := p.ProjectFinder.FilterIgnored(ctx.Log, modifiedFiles)
+			if err != nil {
+				return nil, err
+			}
+			matchingProjects, autoplanReasons = p.addConfiguredModuleDependents(ctx, config, matchingProjects, autoplanReasons, moduleFiles, repoDir)
+		}
+		matchingProjects, autoplanReasons = p.filterByBranch(ctx, matchingProjects, autoplanReasons)
 
 		// Use for i instead of range because need to get the pointer to the
 		// project config.
 		for i := 0; i < len(matchingProjects); i++ {
 			mp := matchingProjects[i]
 			projCtxs = append(projCtxs, models.ProjectCommandContext{
-				BaseRepo:      ctx.BaseRepo,
-				HeadRepo:      ctx.HeadRepo,
-				Pull:          ctx.Pull,
-				User:          ctx.User,
-				Log:           ctx.Log,
-				CommentArgs:   commentFlags,
-				Workspace:     mp.Workspace,
-				RepoRelDir:    mp.Dir,
-				ProjectConfig: &mp,
-				GlobalConfig:  &config,
-				Verbose:       verbose,
-				RePlanCmd:     p.CommentBuilder.BuildPlanComment(mp.Dir, mp.Workspace, mp.GetName(), commentFlags),
-				ApplyCmd:      p.CommentBuilder.BuildApplyComment(mp.Dir, mp.Workspace, mp.GetName()),
-				PullMergeable: ctx.PullMergeable,
+				BaseRepo:       ctx.BaseRepo,
+				HeadRepo:       ctx.HeadRepo,
+				Pull:           ctx.Pull,
+				User:           ctx.User,
+				Log:            ctx.Log,
+				CommentArgs:    commentFlags,
+				Workspace:      mp.Workspace,
+				RepoRelDir:     mp.Dir,
+				ProjectConfig:  &mp,
+				GlobalConfig:   &config,
+				Verbose:        verbose,
+				RePlanCmd:      p.CommentBuilder.BuildPlanComment(mp.Dir, mp.Workspace, mp.GetName(), commentFlags),
+				ApplyCmd:       p.CommentBuilder.BuildApplyComment(mp.Dir, mp.Workspace, mp.GetName()),
+				PullMergeable:  ctx.PullMergeable,
+				AutoplanReason: autoplanReasons[i],
 			})
 		}
 	}
 	return projCtxs, nil
 }
 
+// addModuleDependents returns modifiedProjects, which were found without a
+// config file, along with the projects that use a modified local module and
+// why each of those were added, keyed by their dir. Dirs that are only used as
+// modules aren't projects so they're removed.
+func (p *DefaultProjectCommandBuilder) addModuleDependents(ctx *CommandContext, modifiedProjects []models.Project, modifiedFiles []string, repoDir string) ([]models.Project, map[string]string, error) {
+	deps := newModuleDependencies(ctx.Log, repoDir)
+	roots, modules, err := deps.rootDirs()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "finding Terraform projects")
+	}
+
+	var projects []models.Project
+	included := make(map[string]bool)
+	for _, mp := range modifiedProjects {
+		if modules[mp.Path] {
+			ctx.Log.Info("not planning dir %q because it's used as a module", mp.Path)
+			continue
+		}
+		projects = append(projects, mp)
+		included[mp.Path] = true
+	}
+
+	reasons := make(map[string]string)
+	for _, root := range roots {
+		if included[root] {
+			continue
+		}
+		if chain := deps.modifiedModule(root, modifiedFiles); chain != nil {
+			reasons[root] = moduleAutoplanReason(chain)
+			ctx.Log.Info("planning dir %q because %s", root, reasons[root])
+			projects = append(projects, models.NewProject(ctx.BaseRepo.FullName, root))
+		}
+	}
+	return projects, reasons, nil
+}
+
+// addConfiguredModuleDependents returns matchingProjects, which matched their
+// when_modified config for matchingReasons, followed by the other projects in
+// config that use a modified local module. It also returns why each project
+// was added, indexed the same as the projects.
+func (p *DefaultProjectCommandBuilder) addConfiguredModuleDependents(ctx *CommandContext, config valid.Config, matchingProjects []valid.Project, matchingReasons []string, modifiedFiles []string, repoDir string) ([]valid.Project, []string) {
+	deps := newModuleDependencies(ctx.Log, repoDir)
+	projects := matchingProjects
+	reasons := matchingReasons
+	for _, project := range config.Projects {
+		if p.containsProject(matchingProjects, project) {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(repoDir, project.Dir)); err != nil {
+			continue
+		}
+		if chain := deps.modifiedModule(project.Dir, modifiedFiles); chain != nil {
+			reason := moduleAutoplanReason(chain)
+			ctx.Log.Info("planning project at dir %q workspace %q because %s", project.Dir, project.Workspace, reason)
+			projects = append(projects, project)
+			reasons = append(reasons, reason)
+		}
+	}
+	return projects, reasons
+}
+
+// filterByBranch returns the projects, and their reasons, whose branch config
+// matches the pull request's base branch.
+func (p *DefaultProjectCommandBuilder) filterByBranch(ctx *CommandContext, projects []valid.Project, reasons []string) ([]valid.Project, []string) {
+	var filteredProjects []valid.Project
+	var filteredReasons []string
+	for i, project := range projects {
+		if !project.MatchesBranch(ctx.Pull.BaseBranch) {
+			ctx.Log.Info("not planning project at dir %q workspace %q because branch %q doesn't match its branch config %q", project.Dir, project.Workspace, ctx.Pull.BaseBranch, *project.Branch)
+			continue
+		}
+		filteredProjects = append(filteredProjects, project)
+		filteredReasons = append(filteredReasons, reasons[i])
+	}
+	return filteredProjects, filteredReasons
+}
+
+// containsProject returns true if projects contains a project with the same
+// name, dir and workspace as project.
+func (p *DefaultProjectCommandBuilder) containsProject(projects []valid.Project, project valid.Project) bool {
+	for _, candidate := range projects {
+		if candidate.GetName() == project.GetName() && candidate.Dir == project.Dir && candidate.Workspace == project.Workspace {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *DefaultProjectCommandBuilder) buildProjectPlanCommand(ctx *CommandContext, cmd *CommentCommand) (models.ProjectCommandContext, error) {
 	workspace := DefaultWorkspace
 	if cmd.Workspace != "" {
@@ -256,14 +398,24 @@ func (p *DefaultProjectCommandBuilder) buildApplyAllCommands(ctx *CommandContext
 // comment doesn't specify one project then there may be multiple commands
 // to be run.
 func (p *DefaultProjectCommandBuilder) BuildApplyCommands(ctx *CommandContext, cmd *CommentCommand) ([]models.ProjectCommandContext, error) {
+	var cmds []models.ProjectCommandContext
 	if !cmd.IsForSpecificProject() {
-		return p.buildApplyAllCommands(ctx, cmd)
+		var err error
+		cmds, err = p.buildApplyAllCommands(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		pac, err := p.buildProjectApplyCommand(ctx, cmd)
+		if err != nil {
+			return nil, err
+		}
+		cmds = []models.ProjectCommandContext{pac}
 	}
-	pac, err := p.buildProjectApplyCommand(ctx, cmd)
-	if err != nil {
-		return nil, err
+	for i := range cmds {
+		cmds[i].OverrideApplyWindow = cmd.OverrideApplyWindow
 	}
-	return []models.ProjectCommandContext{pac}, nil
+	return cmds, nil
 }
 
 func (p *DefaultProjectCommandBuilder) buildProjectApplyCommand(ctx *CommandContext, cmd *CommentCommand) (models.ProjectCommandContext, error) {
@@ -293,7 +445,7 @@ func (p *DefaultProjectCommandBuilder) buildProjectApplyCommand(ctx *CommandCont
 }
 
 func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *CommandContext, projectName string, commentFlags []string, repoDir string, repoRelDir string, workspace string) (models.ProjectCommandContext, error) {
-	projCfg, globalCfg, err := p.getCfg(projectName, repoRelDir, workspace, repoDir)
+	projCfg, globalCfg, err := p.getCfg(ctx.BaseRepo.FullName, projectName, repoRelDir, workspace, repoDir)
 	if err != nil {
 		return models.ProjectCommandContext{}, err
 	}
@@ -306,9 +458,15 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *CommandContex
 		workspace = projCfg.Workspace
 	}
 
+	if globalCfg != nil && !globalCfg.MatchesBranch(ctx.Pull.BaseBranch) {
+		return models.ProjectCommandContext{}, BranchNotMatchedErr{BaseBranch: ctx.Pull.BaseBranch, Branch: *globalCfg.Branch}
+	}
 	if err := p.validateWorkspaceAllowed(globalCfg, repoRelDir, workspace); err != nil {
 		return models.ProjectCommandContext{}, err
 	}
+	if projCfg != nil && !projCfg.MatchesBranch(ctx.Pull.BaseBranch) {
+		return models.ProjectCommandContext{}, fmt.Errorf("project at dir %q workspace %q can't be run on pull requests into branch %q because it's configured with branch %q in %s", repoRelDir, workspace, ctx.Pull.BaseBranch, *projCfg.Branch, yaml.AtlantisYAMLFilename)
+	}
 
 	return models.ProjectCommandContext{
 		BaseRepo:      ctx.BaseRepo,
@@ -327,10 +485,9 @@ func (p *DefaultProjectCommandBuilder) buildProjectCommandCtx(ctx *CommandContex
 	}, nil
 }
 
-func (p *DefaultProjectCommandBuilder) getCfg(projectName string, dir string, workspace string, repoDir string) (projectCfg *valid.Project, globalCfg *valid.Config, err error) {
-	hasConfigFile, err := p.ParserValidator.HasConfigFile(repoDir)
+func (p *DefaultProjectCommandBuilder) getCfg(repoFullName string, projectName string, dir string, workspace string, repoDir string) (projectCfg *valid.Project, globalCfg *valid.Config, err error) {
+	config, hasConfigFile, serverCfg, err := p.getRepoCfg(repoFullName, repoDir)
 	if err != nil {
-		err = errors.Wrapf(err, "looking for %s file in %q", yaml.AtlantisYAMLFilename, repoDir)
 		return
 	}
 	if !hasConfigFile {
@@ -338,19 +495,13 @@ func (p *DefaultProjectCommandBuilder) getCfg(projectName string, dir string, wo
 			err = fmt.Errorf("cannot specify a project name unless an %s file exists to configure projects", yaml.AtlantisYAMLFilename)
 			return
 		}
+		if serverCfg != nil {
+			projectCfg = p.defaultProjectCfg(serverCfg, dir, workspace)
+			globalCfg = &config
+		}
 		return
 	}
-
-	if !p.AllowRepoConfig {
-		err = fmt.Errorf("%s files not allowed because Atlantis is not running with --%s", yaml.AtlantisYAMLFilename, p.AllowRepoConfigFlag)
-		return
-	}
-
-	globalCfgStruct, err := p.ParserValidator.ReadConfig(repoDir)
-	if err != nil {
-		return
-	}
-	globalCfg = &globalCfgStruct
+	globalCfg = &config
 
 	// If they've specified a project by name we look it up. Otherwise we
 	// use the dir and workspace.
@@ -365,6 +516,7 @@ func (p *DefaultProjectCommandBuilder) getCfg(projectName string, dir string, wo
 
 	projCfgs := globalCfg.FindProjectsByDirWorkspace(dir, workspace)
 	if len(projCfgs) == 0 {
+		projectCfg = p.defaultProjectCfg(serverCfg, dir, workspace)
 		return
 	}
 	if len(projCfgs) > 1 {
@@ -375,6 +527,63 @@ func (p *DefaultProjectCommandBuilder) getCfg(projectName string, dir string, wo
 	return
 }
 
+// getRepoCfg returns the repo's atlantis.yaml config merged with the
+// server-side repo config. hasConfigFile is false if the repo doesn't have an
+// atlantis.yaml file. serverCfg is nil if no server-side repo config matches
+// the repo.
+func (p *DefaultProjectCommandBuilder) getRepoCfg(repoFullName string, repoDir string) (config valid.Config, hasConfigFile bool, serverCfg *valid.RepoConfig, err error) {
+	var serverWorkflows map[string]valid.Workflow
+	if p.ServerConfig != nil {
+		if repoCfg, ok := p.ServerConfig.ForRepo(repoFullName); ok {
+			serverCfg = &repoCfg
+			serverWorkflows = p.ServerConfig.Workflows
+		}
+	}
+
+	hasConfigFile, err = p.ParserValidator.HasConfigFile(repoDir)
+	if err != nil {
+		err = errors.Wrapf(err, "looking for %s file in %q", yaml.AtlantisYAMLFilename, repoDir)
+		return
+	}
+	if hasConfigFile {
+		// --allow-repo-config must be set even for repos with server-side
+		// config. They can then only set the keys it allows.
+		if !p.AllowRepoConfig {
+			err = fmt.Errorf("%s files not allowed because Atlantis is not running with --%s", yaml.AtlantisYAMLFilename, p.AllowRepoConfigFlag)
+			return
+		}
+		config, err = p.ParserValidator.ReadConfigWithServerWorkflows(repoDir, serverWorkflows)
+		if err != nil {
+			return
+		}
+		if serverCfg != nil {
+			if err = serverCfg.ValidateRepoCfg(config); err != nil {
+				err = errors.Wrapf(err, "validating %s", yaml.AtlantisYAMLFilename)
+				return
+			}
+		}
+	}
+	if serverCfg != nil {
+		config = p.ServerConfig.MergeConfig(*serverCfg, config)
+	}
+	return
+}
+
+// defaultProjectCfg returns the config for a project in dir and workspace
+// that isn't configured in the repo's atlantis.yaml file. It returns nil if
+// there is no server-side config for the repo.
+func (p *DefaultProjectCommandBuilder) defaultProjectCfg(serverCfg *valid.RepoConfig, dir string, workspace string) *valid.Project {
+	if serverCfg == nil {
+		return nil
+	}
+	project := serverCfg.MergeProject(valid.Project{
+		Dir:       dir,
+		Workspace: workspace,
+		Autoplan:  raw.DefaultAutoPlan(),
+	})
+	return &project
+}
+
 // validateWorkspaceAllowed returns an error if there are projects configured
 // in globalCfg for repoRelDir and none of those projects use workspace.
 func (p *DefaultProjectCommandBuilder) validateWorkspaceAllowed(globalCfg *valid.Config, repoRelDir string, workspace string) error {
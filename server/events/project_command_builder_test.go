@@ -1,10 +1,13 @@
 package events_test
 
 import (
+	"fmt"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/hashicorp/go-version"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/mocks"
@@ -578,6 +581,73 @@ func TestDefaultProjectCommandBuilder_BuildMultiPlanNoAtlantisYAML(t *testing.T)
 	Equals(t, nilProjectConfig, ctxs[1].ProjectConfig)
 }
 
+// Test that which file matched which when_modified pattern is only explained
+// when planning with --verbose, or when autoplanning with AutoplanVerbose.
+func TestDefaultProjectCommandBuilder_WhenModifiedReasonVerbose(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+		"project1": map[string]interface{}{
+			"main.tf": nil,
+		},
+	})
+	defer cleanup()
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, yaml.AtlantisYAMLFilename), []byte("version: 2\nprojects:\n- dir: project1\n"), 0600))
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString())).ThenReturn(tmpDir, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"project1/main.tf"}, nil)
+
+	builder := &events.DefaultProjectCommandBuilder{
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		WorkingDir:          workingDir,
+		ParserValidator:     &yaml.ParserValidator{},
+		VCSClient:           vcsClient,
+		ProjectFinder:       &events.DefaultProjectFinder{},
+		AllowRepoConfig:     true,
+		AllowRepoConfigFlag: "allow-repo-config",
+		CommentBuilder:      &events.CommentParser{},
+	}
+
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("verbose %t", verbose), func(t *testing.T) {
+			ctxs, err := builder.BuildPlanCommands(&events.CommandContext{
+				Log: logging.NewNoopLogger(),
+			}, &events.CommentCommand{
+				Name:    models.PlanCommand,
+				Verbose: verbose,
+			})
+			Ok(t, err)
+			Equals(t, 1, len(ctxs))
+			expReason := ""
+			if verbose {
+				expReason = "`project1/main.tf` matched when_modified pattern `**/*.tf*`"
+			}
+			Equals(t, expReason, ctxs[0].AutoplanReason)
+		})
+	}
+
+	for _, verbose := range []bool{false, true} {
+		t.Run(fmt.Sprintf("autoplan verbose %t", verbose), func(t *testing.T) {
+			builder.AutoplanVerbose = verbose
+			ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+				Log: logging.NewNoopLogger(),
+			})
+			Ok(t, err)
+			Equals(t, 1, len(ctxs))
+			expReason := ""
+			if verbose {
+				expReason = "`project1/main.tf` matched when_modified pattern `**/*.tf*`"
+			}
+			Equals(t, expReason, ctxs[0].AutoplanReason)
+		})
+	}
+}
+
 // Test building plan command for multiple projects when the comment
 // isn't for a specific project, i.e. atlantis plan and there's no atlantis.yaml.
 // In this case there are no modified files so there should be 0 plans.
@@ -820,15 +890,19 @@ func TestDefaultProjectCommandBuilder_BuildMultiApply(t *testing.T) {
 		User:     models.User{},
 		Log:      logging.NewNoopLogger(),
 	}, &events.CommentCommand{
-		RepoRelDir:  "",
-		Flags:       nil,
-		Name:        models.ApplyCommand,
-		Verbose:     false,
-		Workspace:   "",
-		ProjectName: "",
+		RepoRelDir:          "",
+		Flags:               nil,
+		Name:                models.ApplyCommand,
+		Verbose:             false,
+		Workspace:           "",
+		ProjectName:         "",
+		OverrideApplyWindow: true,
 	})
 	Ok(t, err)
 	Equals(t, 4, len(ctxs))
+	for _, ctx := range ctxs {
+		Equals(t, true, ctx.OverrideApplyWindow)
+	}
 	Equals(t, "project1", ctxs[0].RepoRelDir)
 	Equals(t, "workspace1", ctxs[0].Workspace)
 	Equals(t, "project2", ctxs[1].RepoRelDir)
@@ -967,3 +1041,519 @@ projects:
 }
 
 func String(v string) *string { return &v }
+
+// Test that the server-side repo config is merged with atlantis.yaml files.
+func TestDefaultProjectCommandBuilder_ServerConfig(t *testing.T) {
+	serverCfgYAML := `
+repos:
+- id: /runatlantis/.*/
+  workflow: custom
+  apply_requirements: [approved]
+  allowed_overrides: [workflow]
+- id: runatlantis/atlantis
+  terraform_version: v0.12.0
+workflows:
+  custom:
+    plan:
+      steps: [init, plan]
+  other:
+    plan:
+      steps: [plan]
+`
+	tfVersion, _ := version.NewVersion("v0.12.0")
+	cases := []struct {
+		description  string
+		repoFullName string
+		atlantisYAML string
+		// repoConfigDisabled is true if --allow-repo-config isn't set.
+		repoConfigDisabled bool
+		expProject         *valid.Project
+		expErr             string
+	}{
+		{
+			description:  "no atlantis.yaml",
+			repoFullName: "runatlantis/atlantis",
+			expProject: &valid.Project{
+				Dir:               ".",
+				Workspace:         "default",
+				Workflow:          String("custom"),
+				TerraformVersion:  tfVersion,
+				Autoplan:          valid.Autoplan{Enabled: true, WhenModified: []string{"**/*.tf*"}},
+				ApplyRequirements: []string{"approved"},
+			},
+		},
+		{
+			description:  "allowed override",
+			repoFullName: "runatlantis/other",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+  workflow: other
+`,
+			expProject: &valid.Project{
+				Dir:               ".",
+				Workspace:         "default",
+				Workflow:          String("other"),
+				Autoplan:          valid.Autoplan{Enabled: true, WhenModified: []string{"**/*.tf*"}},
+				ApplyRequirements: []string{"approved"},
+			},
+		},
+		{
+			description:  "forbidden override",
+			repoFullName: "runatlantis/atlantis",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+  apply_requirements: []
+`,
+			expErr: "validating atlantis.yaml: repo config not allowed to set \"apply_requirements\" key in project at dir \".\" workspace \"default\": server-side config needs \"allowed_overrides: [apply_requirements]\"",
+		},
+		{
+			description:  "forbidden workflows",
+			repoFullName: "runatlantis/atlantis",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+workflows:
+  custom:
+    plan:
+      steps: [plan]
+`,
+			expErr: "validating atlantis.yaml: repo config not allowed to set \"workflows\" key: server-side config needs \"allowed_overrides: [workflows]\"",
+		},
+		{
+			description:  "forbidden apply_windows",
+			repoFullName: "runatlantis/other",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+  apply_windows:
+    windows:
+    - start: "09:00"
+      end: "17:00"
+`,
+			expErr: "validating atlantis.yaml: repo config not allowed to set \"apply_windows\" key in project at dir \".\" workspace \"default\": server-side config needs \"allowed_overrides: [apply_windows]\"",
+		},
+		{
+			description:  "forbidden branch",
+			repoFullName: "runatlantis/other",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+  branch: /.*/
+`,
+			expErr: "validating atlantis.yaml: repo config not allowed to set \"branch\" key in project at dir \".\" workspace \"default\": server-side config needs \"allowed_overrides: [branch]\"",
+		},
+		{
+			description:  "forbidden repo branch",
+			repoFullName: "runatlantis/other",
+			atlantisYAML: `
+version: 2
+branch: main
+projects:
+- dir: .
+`,
+			expErr: "validating atlantis.yaml: repo config not allowed to set \"branch\" key: server-side config needs \"allowed_overrides: [branch]\"",
+		},
+		{
+			description:        "repo config not allowed",
+			repoFullName:       "runatlantis/atlantis",
+			repoConfigDisabled: true,
+			atlantisYAML: `
+version: 2
+projects:
+- dir: .
+`,
+			expErr: "atlantis.yaml files not allowed because Atlantis is not running with --allow-repo-config",
+		},
+		{
+			description:        "repo config not allowed without atlantis.yaml",
+			repoFullName:       "runatlantis/atlantis",
+			repoConfigDisabled: true,
+			expProject: &valid.Project{
+				Dir:               ".",
+				Workspace:         "default",
+				Workflow:          String("custom"),
+				TerraformVersion:  tfVersion,
+				Autoplan:          valid.Autoplan{Enabled: true, WhenModified: []string{"**/*.tf*"}},
+				ApplyRequirements: []string{"approved"},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir, cleanup := TempDir(t)
+			defer cleanup()
+			serverCfgFile := filepath.Join(tmpDir, "repos.yaml")
+			Ok(t, ioutil.WriteFile(serverCfgFile, []byte(serverCfgYAML), 0600))
+			parserValidator := &yaml.ParserValidator{}
+			serverCfg, err := parserValidator.ReadServerConfig(serverCfgFile)
+			Ok(t, err)
+
+			repoDir := filepath.Join(tmpDir, "repo")
+			Ok(t, os.Mkdir(repoDir, 0700))
+			Ok(t, ioutil.WriteFile(filepath.Join(repoDir, "main.tf"), nil, 0600))
+			if c.atlantisYAML != "" {
+				Ok(t, ioutil.WriteFile(filepath.Join(repoDir, yaml.AtlantisYAMLFilename), []byte(c.atlantisYAML), 0600))
+			}
+			baseRepo := models.Repo{FullName: c.repoFullName}
+			logger := logging.NewNoopLogger()
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(logger, baseRepo, models.Repo{}, models.PullRequest{}, "default")).ThenReturn(repoDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(baseRepo, models.PullRequest{})).ThenReturn([]string{"main.tf"}, nil)
+
+			builder := &events.DefaultProjectCommandBuilder{
+				WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+				WorkingDir:          workingDir,
+				ParserValidator:     parserValidator,
+				VCSClient:           vcsClient,
+				ProjectFinder:       &events.DefaultProjectFinder{},
+				AllowRepoConfig:     !c.repoConfigDisabled,
+				AllowRepoConfigFlag: "allow-repo-config",
+				CommentBuilder:      &events.CommentParser{},
+				ServerConfig:        &serverCfg,
+			}
+			ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+				BaseRepo: baseRepo,
+				Log:      logger,
+			})
+			if c.expErr != "" {
+				ErrEquals(t, c.expErr, err)
+				return
+			}
+			Ok(t, err)
+			Equals(t, 1, len(ctxs))
+			Equals(t, c.expProject, ctxs[0].ProjectConfig)
+			Assert(t, ctxs[0].GlobalConfig.GetPlanStage(*c.expProject.Workflow) != nil, "exp workflow %q to be defined", *c.expProject.Workflow)
+		})
+	}
+}
+
+// Test that when AutoplanModules is set, projects that use a modified local
+// module, directly or through other modules, are planned and dirs that are
+// only used as modules aren't.
+func TestDefaultProjectCommandBuilder_AutoplanModules(t *testing.T) {
+	cases := []struct {
+		description  string
+		atlantisYAML string
+	}{
+		{
+			description: "no atlantis.yaml",
+		},
+		{
+			description: "atlantis.yaml",
+			atlantisYAML: `
+version: 2
+projects:
+- dir: project1
+- dir: project2
+- dir: project3
+`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+				"shared": map[string]interface{}{
+					"vpc": map[string]interface{}{
+						"main.tf": nil,
+					},
+				},
+				"modules": map[string]interface{}{
+					"network": map[string]interface{}{},
+					"other": map[string]interface{}{
+						"main.tf": nil,
+					},
+				},
+				"project1": map[string]interface{}{},
+				"project2": map[string]interface{}{},
+				"project3": map[string]interface{}{},
+			})
+			defer cleanup()
+			files := map[string]string{
+				"modules/network/main.tf": `module "vpc" { source = "../../shared/vpc" }`,
+				"project1/main.tf":        `module "network" { source = "../modules/network" }`,
+				"project2/main.tf":        `module "other" { source = "../modules/other" }`,
+				"project3/main.tf":        `module "vpc" { source = "../shared/vpc" }`,
+			}
+			if c.atlantisYAML != "" {
+				files[yaml.AtlantisYAMLFilename] = c.atlantisYAML
+			}
+			for name, contents := range files {
+				Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, name), []byte(contents), 0600))
+			}
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(
+				matchers.AnyPtrToLoggingSimpleLogger(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"shared/vpc/main.tf", "project3/main.tf"}, nil)
+
+			builder := &events.DefaultProjectCommandBuilder{
+				WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+				WorkingDir:          workingDir,
+				ParserValidator:     &yaml.ParserValidator{},
+				VCSClient:           vcsClient,
+				ProjectFinder:       &events.DefaultProjectFinder{},
+				AllowRepoConfig:     true,
+				AllowRepoConfigFlag: "allow-repo-config",
+				CommentBuilder:      &events.CommentParser{},
+				AutoplanModules:     true,
+			}
+
+			ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+				BaseRepo: models.Repo{},
+				HeadRepo: models.Repo{},
+				Pull:     models.PullRequest{},
+				User:     models.User{},
+				Log:      logging.NewNoopLogger(),
+			})
+			Ok(t, err)
+			Equals(t, 2, len(ctxs))
+			Equals(t, "project3", ctxs[0].RepoRelDir)
+			Equals(t, "", ctxs[0].AutoplanReason)
+			Equals(t, "project1", ctxs[1].RepoRelDir)
+			Equals(t, "module `shared/vpc` was modified (used via `modules/network`)", ctxs[1].AutoplanReason)
+		})
+	}
+}
+
+// Test that changes to modules that wouldn't cause the modules to be planned
+// if they were projects don't cause the projects using them to be planned.
+func TestDefaultProjectCommandBuilder_AutoplanModulesIgnoredFiles(t *testing.T) {
+	cases := []struct {
+		description    string
+		atlantisYAML   string
+		ignorePatterns []string
+		modifiedFiles  []string
+	}{
+		{
+			description:   "no atlantis.yaml, non-Terraform file",
+			modifiedFiles: []string{"modules/other/README.md"},
+		},
+		{
+			description:    "atlantis.yaml, ignored file",
+			atlantisYAML:   "version: 2\nprojects:\n- dir: project1\n",
+			ignorePatterns: []string{"**/*.md"},
+			modifiedFiles:  []string{"modules/other/README.md"},
+		},
+		{
+			description:   "atlantis.yaml, state file",
+			atlantisYAML:  "version: 2\nprojects:\n- dir: project1\n",
+			modifiedFiles: []string{"modules/other/terraform.tfstate"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+				"modules": map[string]interface{}{
+					"other": map[string]interface{}{
+						"main.tf":   nil,
+						"README.md": nil,
+					},
+				},
+				"project1": map[string]interface{}{},
+			})
+			defer cleanup()
+			Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "project1", "main.tf"), []byte(`module "other" { source = "../modules/other" }`), 0600))
+			if c.atlantisYAML != "" {
+				Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, yaml.AtlantisYAMLFilename), []byte(c.atlantisYAML), 0600))
+			}
+
+			workingDir := mocks.NewMockWorkingDir()
+			When(workingDir.Clone(
+				matchers.AnyPtrToLoggingSimpleLogger(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString())).ThenReturn(tmpDir, nil)
+			vcsClient := vcsmocks.NewMockClient()
+			When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn(c.modifiedFiles, nil)
+
+			builder := &events.DefaultProjectCommandBuilder{
+				WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+				WorkingDir:          workingDir,
+				ParserValidator:     &yaml.ParserValidator{},
+				VCSClient:           vcsClient,
+				ProjectFinder:       &events.DefaultProjectFinder{IgnorePatterns: c.ignorePatterns},
+				AllowRepoConfig:     true,
+				AllowRepoConfigFlag: "allow-repo-config",
+				CommentBuilder:      &events.CommentParser{},
+				AutoplanModules:     true,
+			}
+
+			ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+				Log: logging.NewNoopLogger(),
+			})
+			Ok(t, err)
+			Equals(t, 0, len(ctxs))
+		})
+	}
+}
+
+// Test that projects are only planned on pull requests into branches matching
+// their branch config.
+func TestDefaultProjectCommandBuilder_ProjectBranch(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+		"main": map[string]interface{}{
+			"main.tf": nil,
+		},
+		"release": map[string]interface{}{
+			"main.tf": nil,
+		},
+		"any": map[string]interface{}{
+			"main.tf": nil,
+		},
+	})
+	defer cleanup()
+	yamlCfg := `version: 2
+projects:
+- dir: main
+  branch: main
+- dir: release
+  branch: /^release-.*$/
+- dir: any
+`
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, yaml.AtlantisYAMLFilename), []byte(yamlCfg), 0600))
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString())).ThenReturn(tmpDir, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"main/main.tf", "release/main.tf", "any/main.tf"}, nil)
+
+	builder := &events.DefaultProjectCommandBuilder{
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		WorkingDir:          workingDir,
+		ParserValidator:     &yaml.ParserValidator{},
+		VCSClient:           vcsClient,
+		ProjectFinder:       &events.DefaultProjectFinder{},
+		AllowRepoConfig:     true,
+		AllowRepoConfigFlag: "allow-repo-config",
+		CommentBuilder:      &events.CommentParser{},
+	}
+
+	cases := map[string][]string{
+		"main":      {"main", "any"},
+		"release-1": {"release", "any"},
+		"feature":   {"any"},
+	}
+	for baseBranch, expDirs := range cases {
+		t.Run(baseBranch, func(t *testing.T) {
+			ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+				Pull: models.PullRequest{BaseBranch: baseBranch},
+				Log:  logging.NewNoopLogger(),
+			})
+			Ok(t, err)
+			var dirs []string
+			for _, ctx := range ctxs {
+				dirs = append(dirs, ctx.RepoRelDir)
+			}
+			Equals(t, expDirs, dirs)
+		})
+	}
+
+	t.Run("specific project", func(t *testing.T) {
+		_, err := builder.BuildPlanCommands(&events.CommandContext{
+			Pull: models.PullRequest{BaseBranch: "feature"},
+			Log:  logging.NewNoopLogger(),
+		}, &events.CommentCommand{
+			RepoRelDir: "main",
+			Name:       models.PlanCommand,
+		})
+		ErrEquals(t, "project at dir \"main\" workspace \"default\" can't be run on pull requests into branch \"feature\" because it's configured with branch \"main\" in atlantis.yaml", err)
+	})
+}
+
+// Test that nothing is planned on pull requests into branches that don't
+// match the branch config at the top of atlantis.yaml.
+func TestDefaultProjectCommandBuilder_RepoBranch(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+		"project1": map[string]interface{}{
+			"main.tf": nil,
+		},
+	})
+	defer cleanup()
+	yamlCfg := `version: 2
+branch: /^(main|release-.*)$/
+projects:
+- dir: project1
+`
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, yaml.AtlantisYAMLFilename), []byte(yamlCfg), 0600))
+
+	workingDir := mocks.NewMockWorkingDir()
+	When(workingDir.Clone(
+		matchers.AnyPtrToLoggingSimpleLogger(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsRepo(),
+		matchers.AnyModelsPullRequest(),
+		AnyString())).ThenReturn(tmpDir, nil)
+	vcsClient := vcsmocks.NewMockClient()
+	When(vcsClient.GetModifiedFiles(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())).ThenReturn([]string{"project1/main.tf"}, nil)
+
+	builder := &events.DefaultProjectCommandBuilder{
+		WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+		WorkingDir:          workingDir,
+		ParserValidator:     &yaml.ParserValidator{},
+		VCSClient:           vcsClient,
+		ProjectFinder:       &events.DefaultProjectFinder{},
+		AllowRepoConfig:     true,
+		AllowRepoConfigFlag: "allow-repo-config",
+		CommentBuilder:      &events.CommentParser{},
+	}
+	expErr := "pull request is into branch \"feature\" which doesn't match the branch config \"/^(main|release-.*)$/\" in atlantis.yaml"
+
+	t.Run("autoplan matching branch", func(t *testing.T) {
+		ctxs, err := builder.BuildAutoplanCommands(&events.CommandContext{
+			Pull: models.PullRequest{BaseBranch: "release-1"},
+			Log:  logging.NewNoopLogger(),
+		})
+		Ok(t, err)
+		Equals(t, 1, len(ctxs))
+	})
+
+	t.Run("autoplan", func(t *testing.T) {
+		_, err := builder.BuildAutoplanCommands(&events.CommandContext{
+			Pull: models.PullRequest{BaseBranch: "feature"},
+			Log:  logging.NewNoopLogger(),
+		})
+		ErrEquals(t, expErr, err)
+		_, ok := err.(events.BranchNotMatchedErr)
+		Assert(t, ok, "exp BranchNotMatchedErr")
+	})
+
+	t.Run("specific project", func(t *testing.T) {
+		_, err := builder.BuildPlanCommands(&events.CommandContext{
+			Pull: models.PullRequest{BaseBranch: "feature"},
+			Log:  logging.NewNoopLogger(),
+		}, &events.CommentCommand{
+			RepoRelDir: "project1",
+			Name:       models.PlanCommand,
+		})
+		ErrEquals(t, expErr, err)
+		_, ok := err.(events.BranchNotMatchedErr)
+		Assert(t, ok, "exp BranchNotMatchedErr")
+	})
+}
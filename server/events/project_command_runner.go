@@ -16,8 +16,11 @@ package events
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -88,30 +91,83 @@ type DefaultProjectCommandRunner struct {
 	WorkingDirLocker         WorkingDirLocker
 	RequireApprovalOverride  bool
 	RequireMergeableOverride bool
+	// FreezeChecker is used to block applies during freezes. If nil, applies
+	// are never frozen.
+	FreezeChecker FreezeChecker
+	// DefaultApplyWindows apply to every project. Projects that configure
+	// their own apply windows can only be applied when both are open. If nil,
+	// only the projects' own windows are used.
+	DefaultApplyWindows *valid.ApplyWindows
+	// ApplyWindowOverrideUsers are the users allowed to apply outside of
+	// apply windows.
+	ApplyWindowOverrideUsers []string
+	// ApplyAuthorizer restricts who can apply projects. If nil, anyone can
+	// apply.
+	ApplyAuthorizer ApplyAuthorizer
+	// CodeownersChecker is used for the codeowners_approved apply
+	// requirement.
+	CodeownersChecker CodeownersChecker
+	// StatusCheckGetter is used for the status_checks_passed apply
+	// requirement.
+	StatusCheckGetter StatusCheckGetter
+
+	// overridesMutex guards RequireApprovalOverride and
+	// RequireMergeableOverride so they can be changed while the server is
+	// running.
+	overridesMutex sync.RWMutex
+}
+
+// SetApplyRequirementOverrides sets RequireApprovalOverride and
+// RequireMergeableOverride, ex. when the server's config is reloaded.
+func (p *DefaultProjectCommandRunner) SetApplyRequirementOverrides(requireApproval bool, requireMergeable bool) {
+	p.LockSettings()
+	defer p.UnlockSettings()
+	p.SetApplyRequirementOverridesLocked(requireApproval, requireMergeable)
+}
+
+// LockSettings blocks checking apply requirements until UnlockSettings is
+// called so that the overrides can be changed at the same time as other
+// settings.
+func (p *DefaultProjectCommandRunner) LockSettings() {
+	p.overridesMutex.Lock()
+}
+
+// UnlockSettings undoes LockSettings.
+func (p *DefaultProjectCommandRunner) UnlockSettings() {
+	p.overridesMutex.Unlock()
+}
+
+// SetApplyRequirementOverridesLocked is like SetApplyRequirementOverrides but
+// must be called between LockSettings and UnlockSettings.
+func (p *DefaultProjectCommandRunner) SetApplyRequirementOverridesLocked(requireApproval bool, requireMergeable bool) {
+	p.RequireApprovalOverride = requireApproval
+	p.RequireMergeableOverride = requireMergeable
 }
 
 // Plan runs terraform plan for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Plan(ctx models.ProjectCommandContext) models.ProjectResult {
 	planSuccess, failure, err := p.doPlan(ctx)
 	return models.ProjectResult{
-		Command:     models.PlanCommand,
-		PlanSuccess: planSuccess,
-		Error:       err,
-		Failure:     failure,
-		RepoRelDir:  ctx.RepoRelDir,
-		Workspace:   ctx.Workspace,
-		ProjectName: ctx.GetProjectName(),
+		Command:        models.PlanCommand,
+		PlanSuccess:    planSuccess,
+		Error:          err,
+		Failure:        failure,
+		RepoRelDir:     ctx.RepoRelDir,
+		Workspace:      ctx.Workspace,
+		ProjectName:    ctx.GetProjectName(),
+		AutoplanReason: ctx.AutoplanReason,
 	}
 }
 
 // Apply runs terraform apply for the project described by ctx.
 func (p *DefaultProjectCommandRunner) Apply(ctx models.ProjectCommandContext) models.ProjectResult {
-	applyOut, failure, err := p.doApply(ctx)
+	applyOut, overriddenBy, failure, err := p.doApply(ctx)
 	return models.ProjectResult{
 		Command:      models.ApplyCommand,
 		Failure:      failure,
 		Error:        err,
 		ApplySuccess: applyOut,
+		OverriddenBy: overriddenBy,
 		RepoRelDir:   ctx.RepoRelDir,
 		Workspace:    ctx.Workspace,
 		ProjectName:  ctx.GetProjectName(),
@@ -201,53 +257,263 @@ func (p *DefaultProjectCommandRunner) runSteps(steps []valid.Step, ctx models.Pr
 	return outputs, nil
 }
 
-func (p *DefaultProjectCommandRunner) doApply(ctx models.ProjectCommandContext) (applyOut string, failure string, err error) {
+// checkApplyWindows returns a failure if the project can't be applied now
+// because of the default apply windows or its own. It can only be applied
+// when both are open. If the user has overridden the windows, their username
+// is returned as overriddenBy.
+func (p *DefaultProjectCommandRunner) checkApplyWindows(ctx models.ProjectCommandContext) (overriddenBy string, failure string) {
+	var windows []valid.ApplyWindows
+	if p.DefaultApplyWindows != nil {
+		windows = append(windows, *p.DefaultApplyWindows)
+	}
+	if ctx.ProjectConfig != nil && ctx.ProjectConfig.ApplyWindows != nil {
+		windows = append(windows, *ctx.ProjectConfig.ApplyWindows)
+	}
+	now := time.Now()
+	if applyWindowsOpen(windows, now) {
+		return "", ""
+	}
+	if !ctx.OverrideApplyWindow {
+		return "", p.applyWindowFailure(windows, now)
+	}
+	if !p.canOverrideApplyWindow(ctx.User.Username) {
+		return "", fmt.Sprintf("User %s is not allowed to apply outside of this project's apply windows.", ctx.User.Username)
+	}
+	ctx.Log.Warn("%s is applying outside of the project's apply windows", ctx.User.Username)
+	return ctx.User.Username, ""
+}
+
+func (p *DefaultProjectCommandRunner) applyWindowFailure(windows []valid.ApplyWindows, now time.Time) string {
+	var descs []string
+	for _, w := range windows {
+		descs = append(descs, w.String())
+	}
+	failure := fmt.Sprintf("Cannot apply outside of this project's apply windows: %s.", strings.Join(descs, " and "))
+	if next := nextApplyWindowsOpen(windows, now); !next.IsZero() {
+		failure += fmt.Sprintf(" The next window opens at %s.", next.Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+	return failure + fmt.Sprintf(" To apply anyway, an authorized user can comment with the --%s flag.", overrideWindowFlag)
+}
+
+// applyWindowsOpen returns true if all of windows are open at t.
+func applyWindowsOpen(windows []valid.ApplyWindows, t time.Time) bool {
+	for _, w := range windows {
+		if !w.IsOpen(t) {
+			return false
+		}
+	}
+	return true
+}
+
+// nextApplyWindowsOpen returns the next time after t that all of windows are
+// open. It returns the zero time if that doesn't happen within the next 8 days.
+func nextApplyWindowsOpen(windows []valid.ApplyWindows, t time.Time) time.Time {
+	limit := t.AddDate(0, 0, 8)
+	next := t
+	for next.Before(limit) {
+		// Skip ahead to the last of the closed windows to open and check
+		// whether the others are still open then.
+		var latest time.Time
+		for _, w := range windows {
+			if w.IsOpen(next) {
+				continue
+			}
+			open := w.NextOpen(next)
+			if open.IsZero() {
+				return time.Time{}
+			}
+			if open.After(latest) {
+				latest = open
+			}
+		}
+		if latest.IsZero() {
+			return next
+		}
+		next = latest
+	}
+	return time.Time{}
+}
+
+func (p *DefaultProjectCommandRunner) canOverrideApplyWindow(username string) bool {
+	for _, u := range p.ApplyWindowOverrideUsers {
+		if strings.EqualFold(u, username) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIndependentApprovals returns a failure if the pull request hasn't been
+// approved by enough users other than its author and the user running apply.
+func (p *DefaultProjectCommandRunner) checkIndependentApprovals(ctx models.ProjectCommandContext) (string, error) {
+	cfg := valid.IndependentApprovals{Count: raw.DefaultIndependentApprovalsCount}
+	if ctx.ProjectConfig != nil && ctx.ProjectConfig.IndependentApprovals != nil {
+		cfg = *ctx.ProjectConfig.IndependentApprovals
+	}
+	approvals, err := p.PullApprovedChecker.GetApprovals(ctx.BaseRepo, ctx.Pull)
+	if err != nil {
+		return "", errors.Wrap(err, "getting pull request approvals")
+	}
+	approvers := make(map[string]bool)
+	stale := 0
+	for _, a := range approvals {
+		if strings.EqualFold(a.Username, ctx.Pull.Author) || strings.EqualFold(a.Username, ctx.User.Username) {
+			continue
+		}
+		if cfg.InvalidateOnNewCommits && a.IsStale(ctx.Pull) {
+			stale++
+			continue
+		}
+		approvers[strings.ToLower(a.Username)] = true
+	}
+	if len(approvers) >= cfg.Count {
+		return "", nil
+	}
+	failure := fmt.Sprintf("Pull request must be approved by at least %d user(s) other than its author and the user running apply before running apply. It has %d such approval(s).", cfg.Count, len(approvers))
+	if stale > 0 {
+		failure += fmt.Sprintf(" %d approval(s) were ignored because they weren't given for the latest commit.", stale)
+	}
+	return failure, nil
+}
+
+// checkStatusChecks returns a failure message if the pull request's status
+// checks, other than Atlantis's own, haven't all passed. If the project sets
+// required status checks, only the checks matching them count and each
+// pattern must match at least one check.
+func (p *DefaultProjectCommandRunner) checkStatusChecks(ctx models.ProjectCommandContext) (string, error) {
+	var required []string
+	if ctx.ProjectConfig != nil {
+		required = ctx.ProjectConfig.RequiredStatusChecks
+	}
+	checks, err := p.StatusCheckGetter.GetStatusChecks(ctx.BaseRepo, ctx.Pull)
+	if err != nil {
+		return "", errors.Wrap(err, "getting status checks")
+	}
+
+	var notPassing []string
+	matched := make([]bool, len(required))
+	for _, check := range checks {
+		if strings.HasPrefix(check.Name, "atlantis/") {
+			continue
+		}
+		isRequired := len(required) == 0
+		for i, pattern := range required {
+			// Patterns were validated when the config was parsed.
+			if ok, _ := path.Match(pattern, check.Name); ok {
+				matched[i] = true
+				isRequired = true
+			}
+		}
+		if isRequired && check.Status != models.SuccessCommitStatus {
+			notPassing = append(notPassing, fmt.Sprintf("%s (%s)", check.Name, check.Status.String()))
+		}
+	}
+	var missing []string
+	for i, pattern := range required {
+		if !matched[i] {
+			missing = append(missing, pattern)
+		}
+	}
+	if len(notPassing) == 0 && len(missing) == 0 {
+		return "", nil
+	}
+
+	failure := "All status checks must pass before running apply."
+	if len(notPassing) > 0 {
+		failure += fmt.Sprintf(" Not passing: %s.", strings.Join(notPassing, ", "))
+	}
+	if len(missing) > 0 {
+		failure += fmt.Sprintf(" No status checks found matching: %s.", strings.Join(missing, ", "))
+	}
+	return failure, nil
+}
+
+func (p *DefaultProjectCommandRunner) doApply(ctx models.ProjectCommandContext) (applyOut string, overriddenBy string, failure string, err error) {
+	if p.FreezeChecker != nil {
+		freeze, err := p.FreezeChecker.ActiveFreeze(ctx.BaseRepo.FullName, ctx.RepoRelDir, ctx.GetProjectName()) // nolint: vetshadow
+		if err != nil {
+			return "", "", "", errors.Wrap(err, "checking for freezes")
+		}
+		if freeze != nil {
+			return "", "", fmt.Sprintf("Applies are frozen for %s by %s: %s", freeze.Scope(), freeze.CreatedBy, freeze.Reason), nil
+		}
+	}
+	if p.ApplyAuthorizer != nil {
+		allowed, appliers, err := p.ApplyAuthorizer.CanApply(ctx.BaseRepo, ctx.RepoRelDir, ctx.Workspace, ctx.User.Username) // nolint: vetshadow
+		if err != nil {
+			return "", "", "", errors.Wrap(err, "checking if user can apply")
+		}
+		if !allowed && len(appliers) == 0 {
+			return "", "", fmt.Sprintf("User %s is not allowed to apply this project. No allowed appliers are configured for it.", ctx.User.Username), nil
+		}
+		if !allowed {
+			return "", "", fmt.Sprintf("User %s is not allowed to apply this project. Only these users and teams can: %s.", ctx.User.Username, strings.Join(appliers, ", ")), nil
+		}
+	}
+	overriddenBy, failure = p.checkApplyWindows(ctx)
+	if failure != "" {
+		return "", "", failure, nil
+	}
 	repoDir, err := p.WorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return "", "", errors.New("project has not been cloned–did you run plan?")
+			return "", "", "", errors.New("project has not been cloned–did you run plan?")
 		}
-		return "", "", err
+		return "", "", "", err
 	}
 	absPath := filepath.Join(repoDir, ctx.RepoRelDir)
 	if _, err = os.Stat(absPath); os.IsNotExist(err) {
-		return "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
+		return "", "", "", DirNotExistErr{RepoRelDir: ctx.RepoRelDir}
 	}
 
-	// Figure out what our apply requirements are.
-	var applyRequirements []string
-	if p.RequireApprovalOverride || p.RequireMergeableOverride {
-		// If any server flags are set, they override project config.
-		if p.RequireMergeableOverride {
-			applyRequirements = append(applyRequirements, raw.MergeableApplyRequirement)
-		}
-		if p.RequireApprovalOverride {
-			applyRequirements = append(applyRequirements, raw.ApprovedApplyRequirement)
-		}
-	} else if ctx.ProjectConfig != nil {
-		// Else we use the project config if it's set.
-		applyRequirements = ctx.ProjectConfig.ApplyRequirements
-	}
-	for _, req := range applyRequirements {
+	for _, req := range p.applyRequirements(ctx) {
 		switch req {
 		case raw.ApprovedApplyRequirement:
 			approved, err := p.PullApprovedChecker.PullIsApproved(ctx.BaseRepo, ctx.Pull) // nolint: vetshadow
 			if err != nil {
-				return "", "", errors.Wrap(err, "checking if pull request was approved")
+				return "", "", "", errors.Wrap(err, "checking if pull request was approved")
 			}
 			if !approved {
-				return "", "Pull request must be approved before running apply.", nil
+				return "", "", "Pull request must be approved before running apply.", nil
 			}
 		case raw.MergeableApplyRequirement:
 			if !ctx.PullMergeable {
-				return "", "Pull request must be mergeable before running apply.", nil
+				return "", "", "Pull request must be mergeable before running apply.", nil
+			}
+		case raw.IndependentlyApprovedApplyRequirement:
+			failure, err := p.checkIndependentApprovals(ctx) // nolint: vetshadow
+			if err != nil {
+				return "", "", "", err
+			}
+			if failure != "" {
+				return "", "", failure, nil
+			}
+		case raw.CodeownersApprovedApplyRequirement:
+			missing, err := p.CodeownersChecker.MissingOwners(ctx, repoDir) // nolint: vetshadow
+			if err != nil {
+				return "", "", "", errors.Wrap(err, "checking code owner approvals")
+			}
+			if len(missing) > 0 {
+				var groups []string
+				for _, owners := range missing {
+					groups = append(groups, strings.Join(owners, " or "))
+				}
+				return "", "", fmt.Sprintf("Pull request must be approved by the code owners of the files it changes in this project before running apply. Missing approvals from: %s.", strings.Join(groups, "; ")), nil
+			}
+		case raw.StatusChecksPassedApplyRequirement:
+			failure, err := p.checkStatusChecks(ctx) // nolint: vetshadow
+			if err != nil {
+				return "", "", "", err
+			}
+			if failure != "" {
+				return "", "", failure, nil
 			}
 		}
 	}
 	// Acquire internal lock for the directory we're going to operate in.
 	unlockFn, err := p.WorkingDirLocker.TryLock(ctx.BaseRepo.FullName, ctx.Pull.Num, ctx.Workspace)
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 	defer unlockFn()
 
@@ -268,12 +534,31 @@ func (p *DefaultProjectCommandRunner) doApply(ctx models.ProjectCommandContext)
 		Success:   err == nil,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
+		return "", "", "", fmt.Errorf("%s\n%s", err, strings.Join(outputs, "\n"))
 	}
-	return strings.Join(outputs, "\n"), "", nil
+	return strings.Join(outputs, "\n"), overriddenBy, "", nil
 }
 
-func (p DefaultProjectCommandRunner) defaultPlanStage() valid.Stage {
+// applyRequirements returns the project's apply requirements plus the ones
+// the server flags require for every project.
+func (p *DefaultProjectCommandRunner) applyRequirements(ctx models.ProjectCommandContext) []string {
+	var reqs []string
+	if ctx.ProjectConfig != nil {
+		reqs = append(reqs, ctx.ProjectConfig.ApplyRequirements...)
+	}
+	p.overridesMutex.RLock()
+	requireApproval, requireMergeable := p.RequireApprovalOverride, p.RequireMergeableOverride
+	p.overridesMutex.RUnlock()
+	if requireMergeable && !containsString(reqs, raw.MergeableApplyRequirement) {
+		reqs = append(reqs, raw.MergeableApplyRequirement)
+	}
+	if requireApproval && !containsString(reqs, raw.ApprovedApplyRequirement) {
+		reqs = append(reqs, raw.ApprovedApplyRequirement)
+	}
+	return reqs
+}
+
+func (p *DefaultProjectCommandRunner) defaultPlanStage() valid.Stage {
 	return valid.Stage{
 		Steps: []valid.Step{
 			{
@@ -286,7 +571,7 @@ func (p DefaultProjectCommandRunner) defaultPlanStage() valid.Stage {
 	}
 }
 
-func (p DefaultProjectCommandRunner) defaultApplyStage() valid.Stage {
+func (p *DefaultProjectCommandRunner) defaultApplyStage() valid.Stage {
 	return valid.Stage{
 		Steps: []valid.Step{
 			{
@@ -295,3 +580,12 @@ func (p DefaultProjectCommandRunner) defaultApplyStage() valid.Stage {
 		},
 	}
 }
+
+func containsString(strs []string, s string) bool {
+	for _, str := range strs {
+		if str == s {
+			return true
+		}
+	}
+	return false
+}
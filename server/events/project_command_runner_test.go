@@ -14,9 +14,12 @@
 package events_test
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server/events"
@@ -232,6 +235,511 @@ func TestDefaultProjectCommandRunner_ApplyNotApproved(t *testing.T) {
 	Equals(t, "Pull request must be approved before running apply.", res.Failure)
 }
 
+func TestDefaultProjectCommandRunner_ApplyIndependentlyApproved(t *testing.T) {
+	pull := models.PullRequest{Num: 1, Author: "author", HeadCommit: "new"}
+	cases := []struct {
+		description string
+		approvals   []models.Approval
+		cfg         *valid.IndependentApprovals
+		expFailure  string
+	}{
+		{
+			description: "approved by another user",
+			approvals:   []models.Approval{{Username: "reviewer", CommitSHA: "old"}},
+		},
+		{
+			description: "no approvals",
+			expFailure:  "Pull request must be approved by at least 1 user(s) other than its author and the user running apply before running apply. It has 0 such approval(s).",
+		},
+		{
+			description: "approved by author and applier",
+			approvals:   []models.Approval{{Username: "Author"}, {Username: "applier"}},
+			expFailure:  "Pull request must be approved by at least 1 user(s) other than its author and the user running apply before running apply. It has 0 such approval(s).",
+		},
+		{
+			description: "not enough approvals",
+			approvals:   []models.Approval{{Username: "reviewer"}, {Username: "Reviewer"}},
+			cfg:         &valid.IndependentApprovals{Count: 2},
+			expFailure:  "Pull request must be approved by at least 2 user(s) other than its author and the user running apply before running apply. It has 1 such approval(s).",
+		},
+		{
+			description: "enough approvals",
+			approvals:   []models.Approval{{Username: "reviewer1"}, {Username: "reviewer2"}},
+			cfg:         &valid.IndependentApprovals{Count: 2},
+		},
+		{
+			description: "stale approvals",
+			approvals:   []models.Approval{{Username: "reviewer1", CommitSHA: "old"}, {Username: "reviewer2", CommitSHA: "new"}},
+			cfg:         &valid.IndependentApprovals{Count: 2, InvalidateOnNewCommits: true},
+			expFailure:  "Pull request must be approved by at least 2 user(s) other than its author and the user running apply before running apply. It has 1 such approval(s). 1 approval(s) were ignored because they weren't given for the latest commit.",
+		},
+		{
+			description: "approvals without commits are stale",
+			approvals:   []models.Approval{{Username: "reviewer"}},
+			cfg:         &valid.IndependentApprovals{Count: 1, InvalidateOnNewCommits: true},
+			expFailure:  "Pull request must be approved by at least 1 user(s) other than its author and the user running apply before running apply. It has 0 such approval(s). 1 approval(s) were ignored because they weren't given for the latest commit.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockApproved := mocks2.NewMockPullApprovedChecker()
+			mockApply := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				ApplyStepRunner:     mockApply,
+				WorkingDir:          mockWorkingDir,
+				PullApprovedChecker: mockApproved,
+				Webhooks:            mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:    events.NewDefaultWorkingDirLocker(),
+			}
+			ctx := models.ProjectCommandContext{
+				Log:  logging.NewNoopLogger(),
+				Pull: pull,
+				User: models.User{Username: "applier"},
+				ProjectConfig: &valid.Project{
+					Dir:                  ".",
+					ApplyRequirements:    []string{"independently_approved"},
+					IndependentApprovals: c.cfg,
+				},
+				RepoRelDir: ".",
+				Workspace:  "default",
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+			When(mockApproved.GetApprovals(ctx.BaseRepo, ctx.Pull)).ThenReturn(c.approvals, nil)
+			When(mockApply.Run(ctx, nil, tmp)).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expFailure == "" {
+				Equals(t, "apply", res.ApplySuccess)
+			}
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_ApplyCodeownersApproved(t *testing.T) {
+	cases := []struct {
+		description string
+		missing     [][]string
+		expFailure  string
+	}{
+		{
+			description: "all owners approved",
+		},
+		{
+			description: "missing owners",
+			missing:     [][]string{{"@org/infra", "@alice"}, {"@security"}},
+			expFailure:  "Pull request must be approved by the code owners of the files it changes in this project before running apply. Missing approvals from: @org/infra or @alice; @security.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockCodeowners := mocks.NewMockCodeownersChecker()
+			mockApply := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				ApplyStepRunner:   mockApply,
+				WorkingDir:        mockWorkingDir,
+				CodeownersChecker: mockCodeowners,
+				Webhooks:          mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+			}
+			ctx := models.ProjectCommandContext{
+				Log: logging.NewNoopLogger(),
+				ProjectConfig: &valid.Project{
+					Dir:               "prod",
+					ApplyRequirements: []string{"codeowners_approved"},
+				},
+				RepoRelDir: "prod",
+				Workspace:  "default",
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			Ok(t, os.Mkdir(filepath.Join(tmp, "prod"), 0700))
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+			When(mockCodeowners.MissingOwners(ctx, tmp)).ThenReturn(c.missing, nil)
+			When(mockApply.Run(ctx, nil, filepath.Join(tmp, "prod"))).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expFailure == "" {
+				Equals(t, "apply", res.ApplySuccess)
+			}
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_ApplyStatusChecksPassed(t *testing.T) {
+	checks := []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.FailedCommitStatus},
+		{Name: "ci/test", Status: models.SuccessCommitStatus},
+		{Name: "ci/lint", Status: models.PendingCommitStatus},
+		{Name: "security", Status: models.FailedCommitStatus},
+	}
+	cases := []struct {
+		description string
+		required    []string
+		expFailure  string
+	}{
+		{
+			description: "all checks except atlantis's",
+			expFailure:  "All status checks must pass before running apply. Not passing: ci/lint (pending), security (failed).",
+		},
+		{
+			description: "required checks passed",
+			required:    []string{"ci/test"},
+		},
+		{
+			description: "required checks not passed",
+			required:    []string{"ci/*"},
+			expFailure:  "All status checks must pass before running apply. Not passing: ci/lint (pending).",
+		},
+		{
+			description: "required checks missing",
+			required:    []string{"ci/test", "tflint"},
+			expFailure:  "All status checks must pass before running apply. No status checks found matching: tflint.",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockStatusChecks := mocks.NewMockStatusCheckGetter()
+			mockApply := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				ApplyStepRunner:   mockApply,
+				WorkingDir:        mockWorkingDir,
+				StatusCheckGetter: mockStatusChecks,
+				Webhooks:          mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:  events.NewDefaultWorkingDirLocker(),
+			}
+			ctx := models.ProjectCommandContext{
+				Log: logging.NewNoopLogger(),
+				ProjectConfig: &valid.Project{
+					Dir:                  ".",
+					ApplyRequirements:    []string{"status_checks_passed"},
+					RequiredStatusChecks: c.required,
+				},
+				RepoRelDir: ".",
+				Workspace:  "default",
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+			When(mockStatusChecks.GetStatusChecks(ctx.BaseRepo, ctx.Pull)).ThenReturn(checks, nil)
+			When(mockApply.Run(ctx, nil, tmp)).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expFailure == "" {
+				Equals(t, "apply", res.ApplySuccess)
+			}
+		})
+	}
+}
+
+// Test that the --require-approval and --require-mergeable flags are added to
+// the project's apply requirements instead of replacing them.
+func TestDefaultProjectCommandRunner_ApplyServerFlagsAndProjectRequirements(t *testing.T) {
+	cases := []struct {
+		description       string
+		applyRequirements []string
+		approved          bool
+		approvals         []models.Approval
+		missingOwners     [][]string
+		statusChecks      []models.StatusCheck
+		expFailure        string
+	}{
+		{
+			description:       "all requirements met",
+			applyRequirements: []string{"independently_approved", "codeowners_approved", "status_checks_passed"},
+			approved:          true,
+			approvals:         []models.Approval{{Username: "reviewer"}},
+			statusChecks:      []models.StatusCheck{{Name: "tflint", Status: models.SuccessCommitStatus}},
+		},
+		{
+			description:       "not approved",
+			applyRequirements: []string{"independently_approved"},
+			approvals:         []models.Approval{{Username: "reviewer"}},
+			expFailure:        "Pull request must be approved before running apply.",
+		},
+		{
+			description:       "approved but not independently approved",
+			applyRequirements: []string{"independently_approved"},
+			approved:          true,
+			approvals:         []models.Approval{{Username: "applier"}},
+			expFailure:        "Pull request must be approved by at least 1 user(s) other than its author and the user running apply before running apply. It has 0 such approval(s).",
+		},
+		{
+			description:       "approved but missing code owners",
+			applyRequirements: []string{"codeowners_approved"},
+			approved:          true,
+			missingOwners:     [][]string{{"@org/infra"}},
+			expFailure:        "Pull request must be approved by the code owners of the files it changes in this project before running apply. Missing approvals from: @org/infra.",
+		},
+		{
+			description:       "approved but status checks pending",
+			applyRequirements: []string{"status_checks_passed"},
+			approved:          true,
+			statusChecks:      []models.StatusCheck{{Name: "tflint", Status: models.PendingCommitStatus}},
+			expFailure:        "All status checks must pass before running apply. Not passing: tflint (pending).",
+		},
+		{
+			description:       "project also requires approval",
+			applyRequirements: []string{"approved", "mergeable"},
+			approved:          true,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			mockApproved := mocks2.NewMockPullApprovedChecker()
+			mockCodeowners := mocks.NewMockCodeownersChecker()
+			mockStatusChecks := mocks.NewMockStatusCheckGetter()
+			mockApply := mocks.NewMockStepRunner()
+			runner := &events.DefaultProjectCommandRunner{
+				ApplyStepRunner:          mockApply,
+				WorkingDir:               mockWorkingDir,
+				PullApprovedChecker:      mockApproved,
+				CodeownersChecker:        mockCodeowners,
+				StatusCheckGetter:        mockStatusChecks,
+				Webhooks:                 mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:         events.NewDefaultWorkingDirLocker(),
+				RequireApprovalOverride:  true,
+				RequireMergeableOverride: true,
+			}
+			ctx := models.ProjectCommandContext{
+				Log:           logging.NewNoopLogger(),
+				Pull:          models.PullRequest{Num: 1, Author: "author"},
+				PullMergeable: true,
+				User:          models.User{Username: "applier"},
+				ProjectConfig: &valid.Project{
+					Dir:               ".",
+					ApplyRequirements: c.applyRequirements,
+				},
+				RepoRelDir: ".",
+				Workspace:  "default",
+			}
+			tmp, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(ctx.BaseRepo, ctx.Pull, ctx.Workspace)).ThenReturn(tmp, nil)
+			When(mockApproved.PullIsApproved(ctx.BaseRepo, ctx.Pull)).ThenReturn(c.approved, nil)
+			When(mockApproved.GetApprovals(ctx.BaseRepo, ctx.Pull)).ThenReturn(c.approvals, nil)
+			When(mockCodeowners.MissingOwners(ctx, tmp)).ThenReturn(c.missingOwners, nil)
+			When(mockStatusChecks.GetStatusChecks(ctx.BaseRepo, ctx.Pull)).ThenReturn(c.statusChecks, nil)
+			When(mockApply.Run(ctx, nil, tmp)).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, c.expFailure, res.Failure)
+			if c.expFailure == "" {
+				Equals(t, "apply", res.ApplySuccess)
+			}
+		})
+	}
+}
+
+func TestDefaultProjectCommandRunner_ApplyFrozen(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockFreezes := mocks.NewMockFreezeChecker()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:    mockWorkingDir,
+		FreezeChecker: mockFreezes,
+	}
+	ctx := models.ProjectCommandContext{
+		BaseRepo:   models.Repo{FullName: "owner/repo"},
+		RepoRelDir: "prod",
+	}
+	When(mockFreezes.ActiveFreeze("owner/repo", "prod", "")).ThenReturn(&models.Freeze{
+		RepoFullName: "owner/repo",
+		Reason:       "database migration",
+		CreatedBy:    "lkysow",
+	}, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "Applies are frozen for owner/repo by lkysow: database migration", res.Failure)
+	mockWorkingDir.VerifyWasCalled(Never()).GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())
+}
+
+func TestDefaultProjectCommandRunner_ApplyNotAllowed(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	mockAuthorizer := mocks.NewMockApplyAuthorizer()
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:      mockWorkingDir,
+		ApplyAuthorizer: mockAuthorizer,
+	}
+	repo := models.Repo{FullName: "owner/repo"}
+	ctx := models.ProjectCommandContext{
+		BaseRepo:   repo,
+		RepoRelDir: "prod",
+		Workspace:  "default",
+		User:       models.User{Username: "dev"},
+	}
+	When(mockAuthorizer.CanApply(repo, "prod", "default", "dev")).ThenReturn(false, []string{"admin", "team platform"}, nil)
+
+	res := runner.Apply(ctx)
+	Equals(t, "User dev is not allowed to apply this project. Only these users and teams can: admin, team platform.", res.Failure)
+	mockWorkingDir.VerifyWasCalled(Never()).GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())
+
+	t.Log("projects without allowed appliers can be denied")
+	ctx.RepoRelDir = "staging"
+	When(mockAuthorizer.CanApply(repo, "staging", "default", "dev")).ThenReturn(false, nil, nil)
+	res = runner.Apply(ctx)
+	Equals(t, "User dev is not allowed to apply this project. No allowed appliers are configured for it.", res.Failure)
+}
+
+func TestDefaultProjectCommandRunner_ApplyOutsideWindow(t *testing.T) {
+	RegisterMockTestingT(t)
+	mockWorkingDir := mocks.NewMockWorkingDir()
+	// Only open tomorrow so that it's always closed.
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1)
+	closed := &valid.ApplyWindows{
+		Location: time.UTC,
+		Windows:  []valid.ApplyWindow{{Days: []time.Weekday{tomorrow.Weekday()}, Start: 0, End: 24 * time.Hour}},
+	}
+	runner := &events.DefaultProjectCommandRunner{
+		WorkingDir:               mockWorkingDir,
+		DefaultApplyWindows:      closed,
+		ApplyWindowOverrideUsers: []string{"admin"},
+	}
+	ctx := models.ProjectCommandContext{
+		Log:  logging.NewNoopLogger(),
+		User: models.User{Username: "dev"},
+	}
+
+	res := runner.Apply(ctx)
+	nextOpen := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 0, 0, 0, 0, time.UTC)
+	Equals(t, fmt.Sprintf("Cannot apply outside of this project's apply windows: %s 00:00-24:00 UTC. The next window opens at %s. To apply anyway, an authorized user can comment with the --override-apply-window flag.",
+		tomorrow.Weekday().String()[:3], nextOpen.Format("Mon, 02 Jan 2006 15:04 MST")), res.Failure)
+
+	ctx.OverrideApplyWindow = true
+	res = runner.Apply(ctx)
+	Equals(t, "User dev is not allowed to apply outside of this project's apply windows.", res.Failure)
+	mockWorkingDir.VerifyWasCalled(Never()).GetWorkingDir(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest(), AnyString())
+}
+
+// Projects can only be applied when both the default windows and their own
+// are open.
+func TestDefaultProjectCommandRunner_ApplyOutsideWindowIntersection(t *testing.T) {
+	now := time.Now().UTC()
+	tomorrow := now.AddDate(0, 0, 1)
+	dayAfter := now.AddDate(0, 0, 2)
+	// The default windows are open tomorrow and the day after but the
+	// project's are only open the day after.
+	defaultWindows := &valid.ApplyWindows{
+		Location: time.UTC,
+		Windows:  []valid.ApplyWindow{{Days: []time.Weekday{tomorrow.Weekday(), dayAfter.Weekday()}, Start: 0, End: 24 * time.Hour}},
+	}
+	projectWindows := &valid.ApplyWindows{
+		Location: time.UTC,
+		Windows:  []valid.ApplyWindow{{Days: []time.Weekday{dayAfter.Weekday()}, Start: 0, End: 24 * time.Hour}},
+	}
+	runner := &events.DefaultProjectCommandRunner{
+		DefaultApplyWindows: defaultWindows,
+	}
+	ctx := models.ProjectCommandContext{
+		Log:           logging.NewNoopLogger(),
+		ProjectConfig: &valid.Project{Dir: ".", ApplyWindows: projectWindows},
+		User:          models.User{Username: "dev"},
+	}
+
+	res := runner.Apply(ctx)
+	nextOpen := time.Date(dayAfter.Year(), dayAfter.Month(), dayAfter.Day(), 0, 0, 0, 0, time.UTC)
+	Equals(t, fmt.Sprintf("Cannot apply outside of this project's apply windows: %s,%s 00:00-24:00 UTC and %s 00:00-24:00 UTC. The next window opens at %s. To apply anyway, an authorized user can comment with the --override-apply-window flag.",
+		tomorrow.Weekday().String()[:3], dayAfter.Weekday().String()[:3], dayAfter.Weekday().String()[:3], nextOpen.Format("Mon, 02 Jan 2006 15:04 MST")), res.Failure)
+}
+
+func TestDefaultProjectCommandRunner_ApplyWindows(t *testing.T) {
+	allDays := []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}
+	open := &valid.ApplyWindows{
+		Location: time.UTC,
+		Windows:  []valid.ApplyWindow{{Days: allDays, Start: 0, End: 24 * time.Hour}},
+	}
+	closed := &valid.ApplyWindows{Location: time.UTC}
+	cases := []struct {
+		description     string
+		defaultWindows  *valid.ApplyWindows
+		projectWindows  *valid.ApplyWindows
+		override        bool
+		expOverriddenBy string
+	}{
+		{
+			description: "no windows",
+		},
+		{
+			description:    "default window open",
+			defaultWindows: open,
+		},
+		{
+			description:    "default and project windows open",
+			defaultWindows: open,
+			projectWindows: open,
+		},
+		{
+			description:     "project window can't open closed default",
+			defaultWindows:  closed,
+			projectWindows:  open,
+			override:        true,
+			expOverriddenBy: "Admin",
+		},
+		{
+			description:    "override flag when window is open",
+			defaultWindows: open,
+			override:       true,
+		},
+		{
+			description:     "overridden",
+			defaultWindows:  open,
+			projectWindows:  closed,
+			override:        true,
+			expOverriddenBy: "Admin",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			RegisterMockTestingT(t)
+			mockApply := mocks.NewMockStepRunner()
+			mockWorkingDir := mocks.NewMockWorkingDir()
+			runner := events.DefaultProjectCommandRunner{
+				ApplyStepRunner:          mockApply,
+				WorkingDir:               mockWorkingDir,
+				Webhooks:                 mocks.NewMockWebhooksSender(),
+				WorkingDirLocker:         events.NewDefaultWorkingDirLocker(),
+				DefaultApplyWindows:      c.defaultWindows,
+				ApplyWindowOverrideUsers: []string{"admin"},
+			}
+			repoDir, cleanup := TempDir(t)
+			defer cleanup()
+			When(mockWorkingDir.GetWorkingDir(
+				matchers.AnyModelsRepo(),
+				matchers.AnyModelsPullRequest(),
+				AnyString(),
+			)).ThenReturn(repoDir, nil)
+
+			ctx := models.ProjectCommandContext{
+				Log:                 logging.NewNoopLogger(),
+				ProjectConfig:       &valid.Project{Dir: ".", ApplyWindows: c.projectWindows},
+				Workspace:           "default",
+				RepoRelDir:          ".",
+				User:                models.User{Username: "Admin"},
+				OverrideApplyWindow: c.override,
+			}
+			When(mockApply.Run(ctx, nil, repoDir)).ThenReturn("apply", nil)
+
+			res := runner.Apply(ctx)
+			Equals(t, "", res.Failure)
+			Equals(t, "apply", res.ApplySuccess)
+			Equals(t, c.expOverriddenBy, res.OverriddenBy)
+		})
+	}
+}
+
 func TestDefaultProjectCommandRunner_ApplyNotMergeable(t *testing.T) {
 	RegisterMockTestingT(t)
 	mockWorkingDir := mocks.NewMockWorkingDir()
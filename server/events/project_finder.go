@@ -14,6 +14,7 @@
 package events
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
@@ -31,20 +32,105 @@ type ProjectFinder interface {
 	// DetermineProjects returns the list of projects that were modified based on
 	// the modifiedFiles. The list will be de-duplicated.
 	DetermineProjects(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string, repoDir string) []models.Project
-	DetermineProjectsViaConfig(log *logging.SimpleLogger, modifiedFiles []string, config valid.Config, repoDir string) ([]valid.Project, error)
+	// DetermineProjectsViaConfig returns the projects in config whose
+	// when_modified patterns match modifiedFiles and, indexed the same, which
+	// file matched which pattern.
+	DetermineProjectsViaConfig(log *logging.SimpleLogger, modifiedFiles []string, config valid.Config, repoDir string) (projects []valid.Project, reasons []string, err error)
+	// FilterIgnored returns modifiedFiles without the files whose changes
+	// never cause projects to be planned.
+	FilterIgnored(log *logging.SimpleLogger, modifiedFiles []string) ([]string, error)
 }
 
 // DefaultProjectFinder implements ProjectFinder.
-type DefaultProjectFinder struct{}
+type DefaultProjectFinder struct {
+	// IgnorePatterns match files, relative to the repo root, whose changes
+	// never cause projects to be planned, in addition to those in excludeList.
+	// They're evaluated in order and patterns starting with ! un-ignore files.
+	IgnorePatterns []string
+}
 
 var excludeList = []string{"terraform.tfstate", "terraform.tfstate.backup"}
 
+// matchRule is a pattern that files are matched against. Rules are evaluated
+// in order and the last rule that matches a file decides whether it's
+// included.
+type matchRule struct {
+	// Pattern is the pattern as it was configured, ex. "!*.md".
+	Pattern string
+	// Exclusion is true if the pattern started with !.
+	Exclusion bool
+	matcher   *fileutils.PatternMatcher
+}
+
+// newMatchRules returns a rule for each of patterns. Patterns are relative to
+// dir, which is relative to the repo root.
+func newMatchRules(patterns []string, dir string) ([]matchRule, error) {
+	var rules []matchRule
+	for _, pattern := range patterns {
+		trimmed := strings.TrimSpace(pattern)
+		if trimmed == "" {
+			continue
+		}
+		exclusion := strings.HasPrefix(trimmed, "!")
+		matcher, err := fileutils.NewPatternMatcher([]string{filepath.Join(dir, strings.TrimPrefix(trimmed, "!"))})
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid pattern %q", pattern)
+		}
+		rules = append(rules, matchRule{Pattern: trimmed, Exclusion: exclusion, matcher: matcher})
+	}
+	return rules, nil
+}
+
+// lastMatchingRule returns the last of rules that matches file or nil if none
+// do.
+func lastMatchingRule(log *logging.SimpleLogger, rules []matchRule, file string) *matchRule {
+	var last *matchRule
+	for i := range rules {
+		match, err := rules[i].matcher.Matches(file)
+		if err != nil {
+			log.Debug("match err for file %q: %s", file, err)
+			continue
+		}
+		if match {
+			last = &rules[i]
+		}
+	}
+	return last
+}
+
+// FilterIgnored returns files without the files that shouldn't cause projects
+// to be planned.
+func (p *DefaultProjectFinder) FilterIgnored(log *logging.SimpleLogger, files []string) ([]string, error) {
+	rules, err := newMatchRules(p.IgnorePatterns, ".")
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing ignore patterns")
+	}
+	var filtered []string
+	for _, f := range files {
+		if isInExcludeList(f) {
+			log.Debug("ignoring modified file %q because it's a state file", f)
+			continue
+		}
+		if rule := lastMatchingRule(log, rules, f); rule != nil && !rule.Exclusion {
+			log.Debug("ignoring modified file %q because it matched ignore pattern %q", f, rule.Pattern)
+			continue
+		}
+		filtered = append(filtered, f)
+	}
+	return filtered, nil
+}
+
 // DetermineProjects returns the list of projects that were modified based on
 // the modifiedFiles. The list will be de-duplicated.
 func (p *DefaultProjectFinder) DetermineProjects(log *logging.SimpleLogger, modifiedFiles []string, repoFullName string, repoDir string) []models.Project {
 	var projects []models.Project
 
-	modifiedTerraformFiles := p.filterToTerraform(modifiedFiles)
+	notIgnored, err := p.FilterIgnored(log, modifiedFiles)
+	if err != nil {
+		log.Warn("%s", err)
+		notIgnored = modifiedFiles
+	}
+	modifiedTerraformFiles := filterToTerraform(notIgnored)
 	if len(modifiedTerraformFiles) == 0 {
 		return projects
 	}
@@ -77,57 +163,72 @@ func (p *DefaultProjectFinder) DetermineProjects(log *logging.SimpleLogger, modi
 // DetermineProjectsViaConfig returns the list of projects that were modified
 // based on the modifiedFiles and config. We look at the WhenModified section
 // of the config for each project and see if the modifiedFiles matches.
-// The list will be de-duplicated.
-func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log *logging.SimpleLogger, modifiedFiles []string, config valid.Config, repoDir string) ([]valid.Project, error) {
+// Patterns are evaluated in order and the last one that matches a file
+// decides whether it counts, so patterns starting with ! exclude files that
+// earlier patterns matched. The list will be de-duplicated. It also returns
+// why each project was planned, ie. the first file that matched and the
+// pattern it matched, indexed the same as the projects.
+func (p *DefaultProjectFinder) DetermineProjectsViaConfig(log *logging.SimpleLogger, modifiedFiles []string, config valid.Config, repoDir string) ([]valid.Project, []string, error) {
+	notIgnored, err := p.FilterIgnored(log, modifiedFiles)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	var projects []valid.Project
+	var reasons []string
 	for _, project := range config.Projects {
 		log.Debug("checking if project at dir %q workspace %q was modified", project.Dir, project.Workspace)
 		// Prepend project dir to when modified patterns because the patterns
 		// are relative to the project dirs but our list of modified files is
 		// relative to the repo root.
-		var whenModifiedRelToRepoRoot []string
-		for _, wm := range project.Autoplan.WhenModified {
-			whenModifiedRelToRepoRoot = append(whenModifiedRelToRepoRoot, filepath.Join(project.Dir, wm))
-		}
-		pm, err := fileutils.NewPatternMatcher(whenModifiedRelToRepoRoot)
+		rules, err := newMatchRules(project.Autoplan.WhenModified, project.Dir)
 		if err != nil {
-			return nil, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
+			return nil, nil, errors.Wrapf(err, "matching modified files with patterns: %v", project.Autoplan.WhenModified)
 		}
 
 		// If any of the modified files matches the pattern then this project is
 		// considered modified.
-		for _, file := range modifiedFiles {
-			match, err := pm.Matches(file)
-			if err != nil {
-				log.Debug("match err for file %q: %s", file, err)
+		for _, file := range notIgnored {
+			rule := lastMatchingRule(log, rules, file)
+			if rule == nil {
 				continue
 			}
-			if match {
-				log.Debug("file %q matched pattern", file)
-				_, err := os.Stat(filepath.Join(repoDir, project.Dir))
-				if err == nil {
-					projects = append(projects, project)
-				} else {
-					log.Debug("project at dir %q not included because dir does not exist", project.Dir)
-				}
-				break
+			if rule.Exclusion {
+				log.Debug("file %q excluded by when_modified pattern %q of project at dir %q workspace %q", file, rule.Pattern, project.Dir, project.Workspace)
+				continue
 			}
+			_, err := os.Stat(filepath.Join(repoDir, project.Dir))
+			if err == nil {
+				log.Info("file %q matched when_modified pattern %q of project at dir %q workspace %q", file, rule.Pattern, project.Dir, project.Workspace)
+				projects = append(projects, project)
+				reasons = append(reasons, whenModifiedAutoplanReason(file, rule.Pattern))
+			} else {
+				log.Debug("project at dir %q not included because dir does not exist", project.Dir)
+			}
+			break
 		}
 	}
-	return projects, nil
+	return projects, reasons, nil
+}
+
+// whenModifiedAutoplanReason explains that a project was planned because file
+// matched its when_modified pattern.
+func whenModifiedAutoplanReason(file string, pattern string) string {
+	return fmt.Sprintf("`%s` matched when_modified pattern `%s`", file, pattern)
 }
 
-func (p *DefaultProjectFinder) filterToTerraform(files []string) []string {
+// filterToTerraform returns the files in files that are Terraform files.
+func filterToTerraform(files []string) []string {
 	var filtered []string
 	for _, fileName := range files {
-		if !p.isInExcludeList(fileName) && strings.Contains(fileName, ".tf") {
+		if !isInExcludeList(fileName) && strings.Contains(fileName, ".tf") {
 			filtered = append(filtered, fileName)
 		}
 	}
 	return filtered
 }
 
-func (p *DefaultProjectFinder) isInExcludeList(fileName string) bool {
+func isInExcludeList(fileName string) bool {
 	for _, s := range excludeList {
 		if strings.Contains(fileName, s) {
 			return true
@@ -221,6 +221,18 @@ func TestDetermineProjects(t *testing.T) {
 	}
 }
 
+func TestDetermineProjects_IgnorePatterns(t *testing.T) {
+	setupTmpRepos(t)
+	pf := events.DefaultProjectFinder{IgnorePatterns: []string{"project1/**", "!project1/modules/**"}}
+
+	projects := pf.DetermineProjects(noopLogger, []string{"project1/main.tf"}, modifiedRepo, nestedModules1)
+	Equals(t, 0, len(projects))
+
+	projects = pf.DetermineProjects(noopLogger, []string{"project1/main.tf", "project1/modules/main.tf"}, modifiedRepo, nestedModules1)
+	Equals(t, 1, len(projects))
+	Equals(t, "project1", projects[0].Path)
+}
+
 func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 	// Create dir structure:
 	// main.tf
@@ -252,6 +264,7 @@ func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 	cases := []struct {
 		description  string
 		config       valid.Config
+		ignore       []string
 		modified     []string
 		expProjPaths []string
 	}{
@@ -384,17 +397,174 @@ func TestDefaultProjectFinder_DetermineProjectsViaConfig(t *testing.T) {
 			modified:     []string{"project2/terraform.tfvars"},
 			expProjPaths: []string{"project2"},
 		},
+		{
+			description: "excluded file modified",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"*.tf*", "!*.tfvars"},
+						},
+					},
+				},
+			},
+			modified:     []string{"project1/terraform.tfvars"},
+			expProjPaths: nil,
+		},
+		{
+			description: "later pattern re-includes excluded file",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"**/*", "!**/*.md", "README.md"},
+						},
+					},
+				},
+			},
+			modified:     []string{"project1/README.md"},
+			expProjPaths: []string{"project1"},
+		},
+		{
+			description: "exclusion only applies to its project",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"../modules/**/*.tf", "!../modules/module/*.tf"},
+						},
+					},
+					{
+						Dir: "project2",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"../modules/**/*.tf"},
+						},
+					},
+				},
+			},
+			modified:     []string{"modules/module/main.tf"},
+			expProjPaths: []string{"project2"},
+		},
+		{
+			description: "ignored file modified",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"**/*"},
+						},
+					},
+				},
+			},
+			ignore:       []string{"**/*.md"},
+			modified:     []string{"project1/README.md"},
+			expProjPaths: nil,
+		},
+		{
+			description: "un-ignored file modified",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"**/*"},
+						},
+					},
+				},
+			},
+			ignore:       []string{"**/*.md", "!project1/*.md"},
+			modified:     []string{"project1/README.md"},
+			expProjPaths: []string{"project1"},
+		},
+		{
+			description: "state file modified",
+			config: valid.Config{
+				Projects: []valid.Project{
+					{
+						Dir: "project1",
+						Autoplan: valid.Autoplan{
+							Enabled:      true,
+							WhenModified: []string{"**/*"},
+						},
+					},
+				},
+			},
+			modified:     []string{"project1/terraform.tfstate"},
+			expProjPaths: nil,
+		},
 	}
 
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
-			pf := events.DefaultProjectFinder{}
-			projects, err := pf.DetermineProjectsViaConfig(logging.NewNoopLogger(), c.modified, c.config, tmpDir)
+			pf := events.DefaultProjectFinder{IgnorePatterns: c.ignore}
+			projects, reasons, err := pf.DetermineProjectsViaConfig(logging.NewNoopLogger(), c.modified, c.config, tmpDir)
 			Ok(t, err)
 			Equals(t, len(c.expProjPaths), len(projects))
+			Equals(t, len(projects), len(reasons))
 			for i, proj := range projects {
 				Equals(t, c.expProjPaths[i], proj.Dir)
 			}
 		})
 	}
 }
+
+func TestDefaultProjectFinder_DetermineProjectsViaConfig_InvalidIgnorePattern(t *testing.T) {
+	pf := events.DefaultProjectFinder{IgnorePatterns: []string{"["}}
+	_, _, err := pf.DetermineProjectsViaConfig(noopLogger, []string{"main.tf"}, valid.Config{}, "")
+	ErrContains(t, `parsing ignore patterns: invalid pattern "["`, err)
+}
+
+// Test that we explain which file matched which when_modified pattern so
+// that it can be shown in the plan comment.
+func TestDefaultProjectFinder_DetermineProjectsViaConfig_Reasons(t *testing.T) {
+	tmpDir, cleanup := DirStructure(t, map[string]interface{}{
+		"project1": map[string]interface{}{
+			"test.tf": nil,
+		},
+		"project2": map[string]interface{}{
+			"main.tf": nil,
+		},
+		"modules": map[string]interface{}{
+			"module": map[string]interface{}{
+				"main.tf": nil,
+			},
+		},
+	})
+	defer cleanup()
+	config := valid.Config{
+		Projects: []valid.Project{
+			{
+				Dir: "project1",
+				Autoplan: valid.Autoplan{
+					Enabled:      true,
+					WhenModified: []string{"**/*.tf", "!test.tf", "../modules/**/*.tf"},
+				},
+			},
+			{
+				Dir: "project2",
+				Autoplan: valid.Autoplan{
+					Enabled:      true,
+					WhenModified: []string{"**/*.tf"},
+				},
+			},
+		},
+	}
+	pf := events.DefaultProjectFinder{}
+	projects, reasons, err := pf.DetermineProjectsViaConfig(noopLogger, []string{"project1/test.tf", "modules/module/main.tf", "project2/main.tf"}, config, tmpDir)
+	Ok(t, err)
+	Equals(t, 2, len(projects))
+	Equals(t, []string{
+		"`modules/module/main.tf` matched when_modified pattern `../modules/**/*.tf`",
+		"`project2/main.tf` matched when_modified pattern `**/*.tf`",
+	}, reasons)
+}
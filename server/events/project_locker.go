@@ -19,6 +19,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_project_lock.go ProjectLocker
@@ -66,12 +67,14 @@ func (p *DefaultProjectLocker) TryLock(log *logging.SimpleLogger, pull models.Pu
 			"This project is currently locked by an unapplied plan from pull #%d. To continue, delete the lock from #%d or apply that plan and merge the pull request.\n\nOnce the lock is released, comment `atlantis plan` here to re-plan.",
 			lockAttempt.CurrLock.Pull.Num,
 			lockAttempt.CurrLock.Pull.Num)
+		metrics.LockContentions.Inc()
 		return &TryLockResponse{
 			LockAcquired:      false,
 			LockFailureReason: failureMsg,
 		}, nil
 	}
 	log.Info("acquired lock with id %q", lockAttempt.LockKey)
+	metrics.LockAcquisitions.Inc()
 	return &TryLockResponse{
 		LockAcquired: true,
 		UnlockFn: func() error {
@@ -16,6 +16,7 @@ package events
 import (
 	"fmt"
 	"strings"
+	"sync"
 )
 
 // Wildcard matches 0-n of all characters except commas.
@@ -25,6 +26,8 @@ const Wildcard = "*"
 // this Atlantis.
 type RepoWhitelistChecker struct {
 	rules []string
+	// mutex guards rules so they can be replaced while the server is running.
+	mutex sync.RWMutex
 }
 
 // NewRepoWhitelistChecker constructs a new checker and validates that the
@@ -45,7 +48,10 @@ func NewRepoWhitelistChecker(whitelist string) (*RepoWhitelistChecker, error) {
 // otherwise.
 func (r *RepoWhitelistChecker) IsWhitelisted(repoFullName string, vcsHostname string) bool {
 	candidate := fmt.Sprintf("%s/%s", vcsHostname, repoFullName)
-	for _, rule := range r.rules {
+	r.mutex.RLock()
+	rules := r.rules
+	r.mutex.RUnlock()
+	for _, rule := range rules {
 		if r.matchesRule(rule, candidate) {
 			return true
 		}
@@ -53,6 +59,33 @@ func (r *RepoWhitelistChecker) IsWhitelisted(repoFullName string, vcsHostname st
 	return false
 }
 
+// Replace replaces r's whitelist with other's, ex. when the server's config is
+// reloaded.
+func (r *RepoWhitelistChecker) Replace(other *RepoWhitelistChecker) {
+	r.LockSettings()
+	defer r.UnlockSettings()
+	r.ReplaceLocked(other)
+}
+
+// LockSettings blocks IsWhitelisted until UnlockSettings is called so that
+// the whitelist can be replaced at the same time as other settings.
+func (r *RepoWhitelistChecker) LockSettings() {
+	r.mutex.Lock()
+}
+
+// UnlockSettings undoes LockSettings.
+func (r *RepoWhitelistChecker) UnlockSettings() {
+	r.mutex.Unlock()
+}
+
+// ReplaceLocked is like Replace but must be called between LockSettings and
+// UnlockSettings.
+func (r *RepoWhitelistChecker) ReplaceLocked(other *RepoWhitelistChecker) {
+	other.mutex.RLock()
+	defer other.mutex.RUnlock()
+	r.rules = other.rules
+}
+
 func (r *RepoWhitelistChecker) matchesRule(rule string, candidate string) bool {
 	// Case insensitive compare.
 	rule = strings.ToLower(rule)
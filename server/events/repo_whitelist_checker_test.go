@@ -181,3 +181,14 @@ func TestRepoWhitelistChecker_ContainsSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestRepoWhitelistChecker_Replace(t *testing.T) {
+	w, err := events.NewRepoWhitelistChecker("github.com/owner/repo")
+	Ok(t, err)
+	other, err := events.NewRepoWhitelistChecker("github.com/owner/other")
+	Ok(t, err)
+
+	w.Replace(other)
+	Equals(t, false, w.IsWhitelisted("owner/repo", "github.com"))
+	Equals(t, true, w.IsWhitelisted("owner/other", "github.com"))
+}
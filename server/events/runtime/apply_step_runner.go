@@ -19,6 +19,7 @@ type ApplyStepRunner struct {
 	TerraformExecutor   TerraformExec
 	CommitStatusUpdater StatusUpdater
 	AsyncTFExec         AsyncTFExec
+	StreamingTFExec     StreamingTFExec
 }
 
 func (a *ApplyStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []string, path string) (string, error) {
@@ -51,7 +52,7 @@ func (a *ApplyStepRunner) Run(ctx models.ProjectCommandContext, extraArgs []stri
 		// NOTE: we need to quote the plan path because Bitbucket Server can
 		// have spaces in its repo owner names which is part of the path.
 		args := append(append(append([]string{"apply", "-input=false", "-no-color"}, extraArgs...), ctx.CommentArgs...), fmt.Sprintf("%q", planPath))
-		out, err = a.TerraformExecutor.RunCommandWithVersion(ctx.Log, path, args, tfVersion, ctx.Workspace)
+		out, err = runTerraform(a.TerraformExecutor, a.StreamingTFExec, ctx, path, args, tfVersion)
 	}
 
 	// If the apply was successful, delete the plan.
@@ -154,6 +155,7 @@ func (a *ApplyStepRunner) runRemoteApply(
 			break
 		}
 		lines = append(lines, line.Line)
+		writeOutput(ctx, line.Line)
 
 		// Here we're checking for the run url and updating the status
 		// if found.
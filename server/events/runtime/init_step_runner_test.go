@@ -1,6 +1,8 @@
 package runtime_test
 
 import (
+	"bytes"
+	"io"
 	"testing"
 
 	version "github.com/hashicorp/go-version"
@@ -11,6 +13,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/runtime"
 	"github.com/runatlantis/atlantis/server/events/terraform/mocks"
 	matchers2 "github.com/runatlantis/atlantis/server/events/terraform/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/logging"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -88,3 +91,42 @@ func TestRun_ShowInitOutputOnError(t *testing.T) {
 	ErrEquals(t, "error", err)
 	Equals(t, "output", output)
 }
+
+func TestRun_StreamsOutput(t *testing.T) {
+	RegisterMockTestingT(t)
+	terraform := mocks.NewMockClient()
+	streamingTf := &streamingTFExecFake{Output: "Initializing...\nInitialized!\n"}
+	tfVersion, _ := version.NewVersion("0.11.0")
+	iso := runtime.InitStepRunner{
+		TerraformExecutor: terraform,
+		DefaultTFVersion:  tfVersion,
+		StreamingTFExec:   streamingTf,
+	}
+	var streamed bytes.Buffer
+	output, err := iso.Run(models.ProjectCommandContext{
+		Workspace:  "workspace",
+		RepoRelDir: ".",
+		Output:     &streamed,
+	}, nil, "/path")
+	Ok(t, err)
+	Equals(t, "", output)
+	Equals(t, "Initializing...\nInitialized!\n", streamed.String())
+	Equals(t, []string{"init", "-input=false", "-no-color", "-upgrade"}, streamingTf.CalledArgs)
+	terraform.VerifyWasCalled(Never()).RunCommandWithVersion(matchers.AnyPtrToLoggingSimpleLogger(), AnyString(), AnyStringSlice(), matchers2.AnyPtrToGoVersionVersion(), AnyString())
+}
+
+// streamingTFExecFake fakes out running terraform with streamed output.
+type streamingTFExecFake struct {
+	// Output is written to the output writer and returned.
+	Output string
+	// CalledArgs is what args we were called with.
+	CalledArgs []string
+}
+
+func (s *streamingTFExecFake) RunCommandWithOutput(log *logging.SimpleLogger, path string, args []string, v *version.Version, workspace string, output io.Writer) (string, error) {
+	s.CalledArgs = args
+	if output != nil {
+		io.WriteString(output, s.Output) // nolint: errcheck
+	}
+	return s.Output, nil
+}
@@ -44,6 +44,25 @@ func (mock *MockPullApprovedChecker) PullIsApproved(baseRepo models.Repo, pull m
 	return ret0, ret1
 }
 
+func (mock *MockPullApprovedChecker) GetApprovals(baseRepo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	if mock == nil {
+		panic("mock must not be nil. Use myMock := NewMockPullApprovedChecker().")
+	}
+	params := []pegomock.Param{baseRepo, pull}
+	result := pegomock.GetGenericMockFrom(mock).Invoke("GetApprovals", params, []reflect.Type{reflect.TypeOf((*[]models.Approval)(nil)).Elem(), reflect.TypeOf((*error)(nil)).Elem()})
+	var ret0 []models.Approval
+	var ret1 error
+	if len(result) != 0 {
+		if result[0] != nil {
+			ret0 = result[0].([]models.Approval)
+		}
+		if result[1] != nil {
+			ret1 = result[1].(error)
+		}
+	}
+	return ret0, ret1
+}
+
 func (mock *MockPullApprovedChecker) VerifyWasCalledOnce() *VerifierPullApprovedChecker {
 	return &VerifierPullApprovedChecker{
 		mock:                   mock,
@@ -111,3 +130,34 @@ func (c *PullApprovedChecker_PullIsApproved_OngoingVerification) GetAllCapturedA
 	}
 	return
 }
+
+func (verifier *VerifierPullApprovedChecker) GetApprovals(baseRepo models.Repo, pull models.PullRequest) *PullApprovedChecker_GetApprovals_OngoingVerification {
+	params := []pegomock.Param{baseRepo, pull}
+	methodInvocations := pegomock.GetGenericMockFrom(verifier.mock).Verify(verifier.inOrderContext, verifier.invocationCountMatcher, "GetApprovals", params, verifier.timeout)
+	return &PullApprovedChecker_GetApprovals_OngoingVerification{mock: verifier.mock, methodInvocations: methodInvocations}
+}
+
+type PullApprovedChecker_GetApprovals_OngoingVerification struct {
+	mock              *MockPullApprovedChecker
+	methodInvocations []pegomock.MethodInvocation
+}
+
+func (c *PullApprovedChecker_GetApprovals_OngoingVerification) GetCapturedArguments() (models.Repo, models.PullRequest) {
+	baseRepo, pull := c.GetAllCapturedArguments()
+	return baseRepo[len(baseRepo)-1], pull[len(pull)-1]
+}
+
+func (c *PullApprovedChecker_GetApprovals_OngoingVerification) GetAllCapturedArguments() (_param0 []models.Repo, _param1 []models.PullRequest) {
+	params := pegomock.GetGenericMockFrom(c.mock).GetInvocationParams(c.methodInvocations)
+	if len(params) > 0 {
+		_param0 = make([]models.Repo, len(params[0]))
+		for u, param := range params[0] {
+			_param0[u] = param.(models.Repo)
+		}
+		_param1 = make([]models.PullRequest, len(params[1]))
+		for u, param := range params[1] {
+			_param1[u] = param.(models.PullRequest)
+		}
+	}
+	return
+}
@@ -8,4 +8,6 @@ import (
 
 type PullApprovedChecker interface {
 	PullIsApproved(baseRepo models.Repo, pull models.PullRequest) (bool, error)
+	// GetApprovals returns the current approvals of pull, at most one per user.
+	GetApprovals(baseRepo models.Repo, pull models.PullRequest) ([]models.Approval, error)
 }
@@ -1,7 +1,9 @@
 package runtime
 
 import (
+	"bytes"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -50,7 +52,17 @@ func (r *RunStepRunner) Run(ctx models.ProjectCommandContext, command []string,
 		finalEnvVars = append(finalEnvVars, fmt.Sprintf("%s=%s", key, val))
 	}
 	cmd.Env = finalEnvVars
-	out, err := cmd.CombinedOutput()
+	// This is equivalent to cmd.CombinedOutput() except the output is also
+	// streamed if ctx.Output is set.
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if ctx.Output != nil {
+		w = io.MultiWriter(&buf, ctx.Output)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	err := cmd.Run()
+	out := buf.Bytes()
 
 	commandStr := strings.Join(command, " ")
 	if err != nil {
@@ -1,6 +1,7 @@
 package runtime_test
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
@@ -103,3 +104,22 @@ func TestRunStepRunner_Run(t *testing.T) {
 		})
 	}
 }
+
+func TestRunStepRunner_RunStreamsOutput(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	defaultVersion, _ := version.NewVersion("0.11.0")
+	r := runtime.RunStepRunner{
+		DefaultTFVersion: defaultVersion,
+	}
+	var streamed bytes.Buffer
+	out, err := r.Run(models.ProjectCommandContext{
+		Log:        logging.NewNoopLogger(),
+		Workspace:  "default",
+		RepoRelDir: ".",
+		Output:     &streamed,
+	}, []string{"echo", "out", "&&", "echo", "err", ">&2"}, tmpDir)
+	Ok(t, err)
+	Equals(t, "out\nerr\n", out)
+	Equals(t, "out\nerr\n", streamed.String())
+}
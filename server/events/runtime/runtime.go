@@ -9,6 +9,7 @@ import (
 	"github.com/runatlantis/atlantis/server/events/terraform"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"io"
 	"regexp"
 )
 
@@ -42,6 +43,32 @@ type StatusUpdater interface {
 	UpdateProject(ctx models.ProjectCommandContext, cmdName models.CommandName, status models.CommitStatus, url string) error
 }
 
+// StreamingTFExec brings the interface from TerraformClient into this package
+// without causing circular imports.
+type StreamingTFExec interface {
+	// RunCommandWithOutput runs terraform with args and returns its combined
+	// output. If output isn't nil, the output is also written to it as
+	// terraform prints it.
+	RunCommandWithOutput(log *logging.SimpleLogger, path string, args []string, v *version.Version, workspace string, output io.Writer) (string, error)
+}
+
+// runTerraform runs terraform with args in path and returns its output. If
+// ctx.Output is set, the output is also written to ctx.Output as terraform
+// prints it.
+func runTerraform(tfExec TerraformExec, streamingTFExec StreamingTFExec, ctx models.ProjectCommandContext, path string, args []string, v *version.Version) (string, error) {
+	if ctx.Output == nil || streamingTFExec == nil {
+		return tfExec.RunCommandWithVersion(ctx.Log, path, args, v, ctx.Workspace)
+	}
+	return streamingTFExec.RunCommandWithOutput(ctx.Log, path, args, v, ctx.Workspace, ctx.Output)
+}
+
+// writeOutput writes line to ctx.Output if the output is being streamed.
+func writeOutput(ctx models.ProjectCommandContext, line string) {
+	if ctx.Output != nil {
+		fmt.Fprintln(ctx.Output, line) // nolint: errcheck
+	}
+}
+
 // MustConstraint returns a constraint. It panics on error.
 func MustConstraint(constraint string) version.Constraints {
 	c, err := version.NewConstraint(constraint)
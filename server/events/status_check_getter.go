@@ -0,0 +1,14 @@
+package events
+
+import (
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+//go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_status_check_getter.go StatusCheckGetter
+
+// StatusCheckGetter gets the status checks of pull requests, ex. CI builds.
+type StatusCheckGetter interface {
+	// GetStatusChecks returns the latest status of each check on pull's head
+	// commit.
+	GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error)
+}
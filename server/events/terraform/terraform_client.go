@@ -16,12 +16,14 @@ package terraform
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"github.com/hashicorp/go-getter"
 	"github.com/hashicorp/go-version"
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 	"io"
 	"io/ioutil"
 	"os"
@@ -31,6 +33,7 @@ import (
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 )
 
 //go:generate pegomock generate -m --use-experimental-model-gen --package mocks -o mocks/mock_terraform_client.go Client
@@ -74,6 +77,9 @@ const (
 	// binDirName is the name of the directory inside our data dir where
 	// we download terraform binaries.
 	binDirName = "bin"
+	// maxLineLength is the longest line of output RunCommandAsync can read.
+	// Plans and JSON output can have very long lines.
+	maxLineLength = 10 * 1024 * 1024
 	// releasesURL is the base url to download terraform from.
 	releasesURL = "https://releases.hashicorp.com"
 )
@@ -173,24 +179,56 @@ func (c *DefaultClient) Version() *version.Version {
 	return c.defaultVersion
 }
 
+// EnsureDefaultVersion returns an error if the default version of terraform
+// isn't available and can't be downloaded.
+func (c *DefaultClient) EnsureDefaultVersion(log *logging.SimpleLogger) error {
+	if c.overrideTF != "" {
+		// This is only set during testing.
+		return nil
+	}
+	c.versionsLock.Lock()
+	defer c.versionsLock.Unlock()
+	_, err := ensureVersion(log, c.downloader, c.versions, c.defaultVersion, c.binDir)
+	return err
+}
+
 // RunCommandWithVersion executes the provided version of terraform with
 // the provided args in path. v is the version of terraform executable to use.
 // If v is nil, will use the default version.
 // Workspace is the terraform workspace to run in. We won't switch workspaces,
 // just set a WORKSPACE environment variable.
 func (c *DefaultClient) RunCommandWithVersion(log *logging.SimpleLogger, path string, args []string, v *version.Version, workspace string) (string, error) {
+	return c.RunCommandWithOutput(log, path, args, v, workspace, nil)
+}
+
+// RunCommandWithOutput is the same as RunCommandWithVersion except that if
+// output isn't nil, terraform's combined stdout and stderr is also written to
+// it as terraform prints it.
+func (c *DefaultClient) RunCommandWithOutput(log *logging.SimpleLogger, path string, args []string, v *version.Version, workspace string, output io.Writer) (string, error) {
 	tfCmd, cmd, err := c.prepCmd(log, v, workspace, path, args)
 	if err != nil {
 		return "", err
 	}
-	out, err := cmd.CombinedOutput()
+	// This is equivalent to cmd.CombinedOutput() except the output is also
+	// written to output.
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	if output != nil {
+		w = io.MultiWriter(&buf, output)
+	}
+	cmd.Stdout = w
+	cmd.Stderr = w
+	start := time.Now()
+	err = cmd.Run()
+	c.observe(v, args, start)
+	out := buf.String()
 	if err != nil {
 		err = errors.Wrapf(err, "running %q in %q", tfCmd, path)
 		log.Err(err.Error())
-		return string(out), err
+		return out, err
 	}
 	log.Info("successfully ran %q in %q", tfCmd, path)
-	return string(out), nil
+	return out, nil
 }
 
 // prepCmd builds a ready to execute command based on the version of terraform
@@ -236,6 +274,20 @@ func (c *DefaultClient) prepCmd(log *logging.SimpleLogger, v *version.Version, w
 	return tfCmd, cmd, nil
 }
 
+// observe records how long running terraform with args took since start.
+// v is the version of terraform that was run. If v is nil, the default version
+// was run.
+func (c *DefaultClient) observe(v *version.Version, args []string, start time.Time) {
+	if v == nil {
+		v = c.defaultVersion
+	}
+	subcommand := ""
+	if len(args) > 0 {
+		subcommand = args[0]
+	}
+	metrics.TerraformDuration.WithLabelValues(v.String(), subcommand).Observe(time.Since(start).Seconds())
+}
+
 // Line represents a line that was output from a terraform command.
 type Line struct {
 	// Line is the contents of the line (without the newline).
@@ -275,6 +327,7 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 		stdin, _ := cmd.StdinPipe()
 
 		log.Debug("starting %q in %q", tfCmd, path)
+		start := time.Now()
 		err = cmd.Start()
 		if err != nil {
 			err = errors.Wrapf(err, "running %q in %q", tfCmd, path)
@@ -300,20 +353,21 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 		wg.Add(2)
 
 		// Asynchronously copy from stdout/err to outCh.
-		go func() {
-			s := bufio.NewScanner(stdout)
+		var scanErrs [2]error
+		scan := func(r io.Reader, i int) {
+			defer wg.Done()
+			s := bufio.NewScanner(r)
+			s.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), maxLineLength)
 			for s.Scan() {
 				outCh <- Line{Line: s.Text()}
 			}
-			wg.Done()
-		}()
-		go func() {
-			s := bufio.NewScanner(stderr)
-			for s.Scan() {
-				outCh <- Line{Line: s.Text()}
+			if scanErrs[i] = s.Err(); scanErrs[i] != nil {
+				// Keep draining so terraform doesn't block on a full pipe.
+				io.Copy(ioutil.Discard, r) // nolint: errcheck
 			}
-			wg.Done()
-		}()
+		}
+		go scan(stdout, 0)
+		go scan(stderr, 1)
 
 		// Wait for our copying to complete. This *must* be done before
 		// calling cmd.Wait(). (see https://github.com/golang/go/issues/19685)
@@ -321,6 +375,12 @@ func (c *DefaultClient) RunCommandAsync(log *logging.SimpleLogger, path string,
 
 		// Wait for the command to complete.
 		err = cmd.Wait()
+		c.observe(v, args, start)
+		for _, scanErr := range scanErrs {
+			if err == nil && scanErr != nil {
+				err = errors.Wrap(scanErr, "reading output")
+			}
+		}
 
 		// We're done now. Send an error if there was one.
 		if err != nil {
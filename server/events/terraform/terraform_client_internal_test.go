@@ -1,6 +1,7 @@
 package terraform
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/hashicorp/go-version"
 	"github.com/runatlantis/atlantis/server/logging"
@@ -254,3 +255,42 @@ func waitCh(ch <-chan Line) (string, error) {
 	}
 	return strings.Join(ls, "\n"), nil
 }
+
+func TestDefaultClient_RunCommandWithOutput(t *testing.T) {
+	v, err := version.NewVersion("0.11.11")
+	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	client := &DefaultClient{
+		defaultVersion:          v,
+		terraformPluginCacheDir: tmp,
+		overrideTF:              "echo",
+	}
+	log := logging.NewSimpleLogger("test", false, logging.Debug)
+	var streamed bytes.Buffer
+	out, err := client.RunCommandWithOutput(log, tmp, []string{"out", "&&", "echo", "err", ">&2"}, nil, "workspace", &streamed)
+	Ok(t, err)
+	Equals(t, "out\nerr\n", out)
+	Equals(t, "out\nerr\n", streamed.String())
+}
+
+func TestDefaultClient_RunCommandAsync_LongLine(t *testing.T) {
+	v, err := version.NewVersion("0.11.11")
+	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	client := &DefaultClient{
+		defaultVersion:          v,
+		terraformPluginCacheDir: tmp,
+		overrideTF:              "cat",
+	}
+	// The line is longer than bufio.Scanner's default limit of 64KB.
+	exp := strings.Repeat("0", 100*1024)
+	filename := filepath.Join(tmp, "data")
+	Ok(t, ioutil.WriteFile(filename, []byte(exp+"\nnext\n"), 0600))
+	_, outCh := client.RunCommandAsync(nil, tmp, []string{filename}, nil, "workspace")
+
+	out, err := waitCh(outCh)
+	Ok(t, err)
+	Equals(t, exp+"\nnext", out)
+}
@@ -0,0 +1,11 @@
+// Package azuredevops holds code for Azure DevOps Repos (dev.azure.com) and
+// Azure DevOps Server.
+//
+// Repos are named after the path of their clone URL, ex.
+// https://dev.azure.com/org/project/_git/repo has the full name
+// "org/project/repo", so the API URLs for a repo are built from its clone URL
+// rather than a base URL.
+package azuredevops
+
+// APIVersion is the version of the Azure DevOps REST API that's used.
+const APIVersion = "5.1"
@@ -0,0 +1,606 @@
+package azuredevops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/common"
+	"gopkg.in/go-playground/validator.v9"
+)
+
+// maxCommentLength is the maximum number of chars allowed by Azure DevOps in a
+// single comment.
+const maxCommentLength = 150000
+
+// gitNamespaceID is the ID of the "Git Repositories" security namespace.
+const gitNamespaceID = "2e9eb7ed-3c0a-47d4-87c1-0ffdd275fd87"
+
+// Permission bits of the "Git Repositories" security namespace.
+const (
+	administerBit        = 1
+	readBit              = 2
+	contributeBit        = 4
+	managePermissionsBit = 8192
+)
+
+// DefaultBaseURL is the URL that CheckAuth authenticates against when using
+// Azure DevOps. Personal access tokens for any organization can be checked
+// against it.
+const DefaultBaseURL = "https://app.vssps.visualstudio.com"
+
+type Client struct {
+	HTTPClient  *http.Client
+	Username    string
+	Token       string
+	AtlantisURL string
+	// BaseURL is the URL that CheckAuth authenticates against. For Azure
+	// DevOps Server it's the collection URL, ex.
+	// https://azuredevops.example.com/DefaultCollection, and all requests
+	// must be under it.
+	BaseURL string
+}
+
+// NewClient builds an Azure DevOps client. username and token, a personal
+// access token, are used as basic auth in the requests. atlantisURL is the URL
+// for Atlantis that will be linked to from the pull request statuses.
+func NewClient(httpClient *http.Client, username string, token string, atlantisURL string) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{
+		HTTPClient:  httpClient,
+		Username:    username,
+		Token:       token,
+		AtlantisURL: atlantisURL,
+		BaseURL:     DefaultBaseURL,
+	}
+}
+
+// GetModifiedFiles returns the names of files that were modified in the pull
+// request, compared to its base branch. The names include the path to the
+// file from the repo root, ex. parent/child/file.txt.
+func (a *Client) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	pullURL, err := pullRequestURL(repo, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.makeRequest("GET", apiURL(pullURL+"/iterations", nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	var iterations Iterations
+	if err := json.Unmarshal(resp, &iterations); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(iterations); err != nil {
+		return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	if len(iterations.Value) == 0 {
+		return nil, nil
+	}
+	// Each push to the source branch is a new iteration. Comparing the latest
+	// iteration to iteration 0, the base branch, gives all the changes.
+	latest := *iterations.Value[len(iterations.Value)-1].ID
+
+	var files []string
+	query := url.Values{"$compareTo": {"0"}}
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := a.makeRequest("GET", apiURL(fmt.Sprintf("%s/iterations/%d/changes", pullURL, latest), query), nil)
+		if err != nil {
+			return nil, err
+		}
+		var changes IterationChanges
+		if err := json.Unmarshal(resp, &changes); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		for _, c := range changes.ChangeEntries {
+			if err := validator.New().Struct(c); err != nil {
+				return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+			}
+			if c.Item.IsFolder {
+				continue
+			}
+			files = append(files, strings.TrimPrefix(*c.Item.Path, "/"))
+			if c.OriginalPath != nil && *c.OriginalPath != "" {
+				files = append(files, strings.TrimPrefix(*c.OriginalPath, "/"))
+			}
+		}
+		if changes.NextSkip == 0 {
+			break
+		}
+		query.Set("$skip", fmt.Sprintf("%d", changes.NextSkip))
+		query.Set("$top", fmt.Sprintf("%d", changes.NextTop))
+	}
+
+	// Now ensure all files are unique.
+	hash := make(map[string]bool)
+	var unique []string
+	for _, f := range files {
+		if !hash[f] {
+			unique = append(unique, f)
+			hash[f] = true
+		}
+	}
+	return unique, nil
+}
+
+// CreateComment creates a comment thread on the pull request. It will write
+// multiple comments if a single comment is too long.
+func (a *Client) CreateComment(repo models.Repo, pullNum int, comment string) error {
+	sepEnd := "\n```\n</details>" +
+		"\n<br>\n\n**Warning**: Output length greater than max comment size. Continued in next comment."
+	sepStart := "Continued from previous comment.\n<details><summary>Show Output</summary>\n\n" +
+		"```diff\n"
+	pullURL, err := pullRequestURL(repo, pullNum)
+	if err != nil {
+		return err
+	}
+	for _, c := range common.SplitComment(comment, maxCommentLength, sepEnd, sepStart) {
+		// The thread is closed so that it doesn't block completing the pull
+		// request when the "Check for comment resolution" policy is on.
+		bodyBytes, err := json.Marshal(map[string]interface{}{
+			"comments": []map[string]interface{}{{
+				"parentCommentId": 0,
+				"content":         c,
+				"commentType":     "text",
+			}},
+			"status": "closed",
+		})
+		if err != nil {
+			return errors.Wrap(err, "json encoding")
+		}
+		if _, err := a.makeRequest("POST", apiURL(pullURL+"/threads", nil), bytes.NewBuffer(bodyBytes)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PullIsApproved returns true if a reviewer other than the author voted to
+// approve the pull request.
+func (a *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	approvals, err := a.GetApprovals(repo, pull)
+	if err != nil {
+		return false, err
+	}
+	for _, approval := range approvals {
+		// Azure DevOps allows the author to approve their own pull request.
+		// This defeats the purpose of approvals so we don't count that
+		// approval.
+		if !strings.EqualFold(approval.Username, pull.Author) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetApprovals returns the reviewers that voted "approved" or "approved with
+// suggestions". Groups are skipped since their vote is the vote of one of
+// their members. Azure DevOps doesn't say which commit was approved but it can
+// be configured to reset votes when new commits are pushed.
+func (a *Client) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	pullResp, err := a.getPullRequest(repo, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []models.Approval
+	for _, r := range pullResp.Reviewers {
+		if err := validator.New().Struct(r); err != nil {
+			return nil, errors.Wrap(err, "API response was missing fields")
+		}
+		if !r.IsContainer && *r.Vote >= 5 {
+			approvals = append(approvals, models.Approval{Username: *r.UniqueName})
+		}
+	}
+	return approvals, nil
+}
+
+// PullIsMergeable returns true if the pull request has no conflicts and can be
+// merged.
+func (a *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
+	pullResp, err := a.getPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return pullResp.MergeStatus != nil && *pullResp.MergeStatus == "succeeded", nil
+}
+
+// PullIsClosed returns true if the pull request has been completed or
+// abandoned.
+func (a *Client) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	pullResp, err := a.getPullRequest(repo, pull.Num)
+	if err != nil {
+		return false, err
+	}
+	return *pullResp.Status != "active", nil
+}
+
+// UpdateStatus adds a status to the pull request. src is split on its last
+// "/" into the status' genre and name, ex. "atlantis/plan" is shown as the
+// "plan" status of the "atlantis" genre.
+func (a *Client) UpdateStatus(repo models.Repo, pull models.PullRequest, status models.CommitStatus, src string, description string, url string) error {
+	adState := "error"
+	switch status {
+	case models.PendingCommitStatus:
+		adState = "pending"
+	case models.SuccessCommitStatus:
+		adState = "succeeded"
+	case models.FailedCommitStatus:
+		adState = "failed"
+	}
+
+	// We default to the Atlantis server's URL so the status links somewhere.
+	if url == "" {
+		url = a.AtlantisURL
+	}
+
+	context := map[string]string{"name": src}
+	if i := strings.LastIndex(src, "/"); i != -1 {
+		context = map[string]string{"genre": src[:i], "name": src[i+1:]}
+	}
+	bodyBytes, err := json.Marshal(map[string]interface{}{
+		"state":       adState,
+		"description": description,
+		"targetUrl":   url,
+		"context":     context,
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	pullURL, err := pullRequestURL(repo, pull.Num)
+	if err != nil {
+		return err
+	}
+	_, err = a.makeRequest("POST", apiURL(pullURL+"/statuses", nil), bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// GetStatusChecks returns the latest status of each context on the pull
+// request.
+func (a *Client) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	pullURL, err := pullRequestURL(repo, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := a.makeRequest("GET", apiURL(pullURL+"/statuses", nil), nil)
+	if err != nil {
+		return nil, err
+	}
+	var statuses PullRequestStatuses
+	if err := json.Unmarshal(resp, &statuses); err != nil {
+		return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+
+	// Every update adds a new status so we only keep the latest status, the
+	// one with the highest ID, of each context.
+	var checks []models.StatusCheck
+	latestIDs := make(map[string]int)
+	indexes := make(map[string]int)
+	for _, s := range statuses.Value {
+		if err := validator.New().Struct(s); err != nil {
+			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		name := *s.Context.Name
+		if s.Context.Genre != nil && *s.Context.Genre != "" {
+			name = *s.Context.Genre + "/" + name
+		}
+		check := models.StatusCheck{Name: name, Status: commitStatus(*s.State)}
+		i, ok := indexes[name]
+		if !ok {
+			indexes[name] = len(checks)
+			latestIDs[name] = *s.ID
+			checks = append(checks, check)
+		} else if *s.ID > latestIDs[name] {
+			latestIDs[name] = *s.ID
+			checks[i] = check
+		}
+	}
+	return checks, nil
+}
+
+// commitStatus converts an Azure DevOps status state to a commit status.
+func commitStatus(state string) models.CommitStatus {
+	switch state {
+	case "succeeded", "notApplicable":
+		return models.SuccessCommitStatus
+	case "pending", "notSet":
+		return models.PendingCommitStatus
+	}
+	return models.FailedCommitStatus
+}
+
+// MergePull completes the pull request, which merges it into its base branch.
+func (a *Client) MergePull(pull models.PullRequest) error {
+	pullURL, err := pullRequestURL(pull.BaseRepo, pull.Num)
+	if err != nil {
+		return err
+	}
+	// The last merge source commit must be set so that we don't merge commits
+	// that were pushed after the plans were applied.
+	bodyBytes, err := json.Marshal(map[string]interface{}{
+		"status":                "completed",
+		"lastMergeSourceCommit": map[string]string{"commitId": pull.HeadCommit},
+		"completionOptions": map[string]interface{}{
+			"mergeStrategy":      "noFastForward",
+			"deleteSourceBranch": false,
+		},
+	})
+	if err != nil {
+		return errors.Wrap(err, "json encoding")
+	}
+	_, err = a.makeRequest("PATCH", apiURL(pullURL, nil), bytes.NewBuffer(bodyBytes))
+	return err
+}
+
+// IsTeamMember returns true if username is a member of the team. team is
+// either "project/team" or "team", in which case the project is the repo's
+// project.
+func (a *Client) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	collectionURL, project, _, err := splitCloneURL(repo)
+	if err != nil {
+		return false, err
+	}
+	if i := strings.Index(team, "/"); i != -1 {
+		project, team = url.PathEscape(team[:i]), team[i+1:]
+	}
+	membersURL := fmt.Sprintf("%s/_apis/projects/%s/teams/%s/members", collectionURL, project, url.PathEscape(team))
+	pageSize := 100
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		query := url.Values{"$top": {fmt.Sprintf("%d", pageSize)}, "$skip": {fmt.Sprintf("%d", i*pageSize)}}
+		resp, err := a.makeRequest("GET", apiURL(membersURL, query), nil)
+		if err != nil {
+			return false, err
+		}
+		var members TeamMembers
+		if err := json.Unmarshal(resp, &members); err != nil {
+			return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		for _, m := range members.Value {
+			if err := validator.New().Struct(m); err != nil {
+				return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+			}
+			if strings.EqualFold(*m.Identity.UniqueName, username) {
+				return true, nil
+			}
+		}
+		if len(members.Value) < pageSize {
+			break
+		}
+	}
+	return false, nil
+}
+
+// GetUserPermission returns username's permission on repo from the
+// permissions of the "Git Repositories" security namespace that are given to
+// them directly or through one of their groups. Manage permissions or
+// administer is admin, contribute is write and read is read. Reading
+// permissions requires the Atlantis user to have the "View identities" and
+// "Read permissions" permissions.
+func (a *Client) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	collectionURL, project, name, err := splitCloneURL(repo)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	resp, err := a.makeRequest("GET", apiURL(fmt.Sprintf("%s/%s/_apis/git/repositories/%s", collectionURL, project, name), nil), nil)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	var repository Repository
+	if err := json.Unmarshal(resp, &repository); err != nil {
+		return models.NoPermission, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if repository.ID == nil || repository.Project == nil || repository.Project.ID == nil {
+		return models.NoPermission, fmt.Errorf("API response %q was missing fields", string(resp))
+	}
+
+	query := url.Values{"searchFilter": {"General"}, "filterValue": {username}, "queryMembership": {"Expanded"}}
+	resp, err = a.makeRequest("GET", apiURL(identitiesURL(collectionURL)+"/_apis/identities", query), nil)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	var identities Identities
+	if err := json.Unmarshal(resp, &identities); err != nil {
+		return models.NoPermission, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	var descriptors []string
+	for _, i := range identities.Value {
+		if err := validator.New().Struct(i); err != nil {
+			return models.NoPermission, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		if i.Properties.Account != nil && i.Properties.Account.Value != nil && strings.EqualFold(*i.Properties.Account.Value, username) {
+			descriptors = append(append(descriptors, *i.Descriptor), i.MemberOf...)
+			break
+		}
+	}
+	if len(descriptors) == 0 {
+		return models.NoPermission, nil
+	}
+
+	query = url.Values{
+		"token":               {fmt.Sprintf("repoV2/%s/%s", *repository.Project.ID, *repository.ID)},
+		"descriptors":         {strings.Join(descriptors, ",")},
+		"includeExtendedInfo": {"true"},
+	}
+	resp, err = a.makeRequest("GET", apiURL(fmt.Sprintf("%s/_apis/accesscontrollists/%s", collectionURL, gitNamespaceID), query), nil)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	var acls AccessControlLists
+	if err := json.Unmarshal(resp, &acls); err != nil {
+		return models.NoPermission, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	var allow, deny int
+	for _, acl := range acls.Value {
+		for _, descriptor := range descriptors {
+			ace, ok := acl.AcesDictionary[descriptor]
+			if !ok {
+				continue
+			}
+			if ace.ExtendedInfo != nil {
+				allow |= ace.ExtendedInfo.EffectiveAllow
+				deny |= ace.ExtendedInfo.EffectiveDeny
+			} else {
+				allow |= ace.Allow
+				deny |= ace.Deny
+			}
+		}
+	}
+	// Deny takes precedence over allow.
+	bits := allow &^ deny
+	switch {
+	case bits&(administerBit|managePermissionsBit) != 0:
+		return models.AdminPermission, nil
+	case bits&contributeBit != 0:
+		return models.WritePermission, nil
+	case bits&readBit != 0:
+		return models.ReadPermission, nil
+	}
+	return models.NoPermission, nil
+}
+
+// CheckAuth returns an error if the client's username and token can't be used
+// to authenticate with Azure DevOps.
+func (a *Client) CheckAuth() error {
+	_, err := a.makeRequest("GET", strings.TrimSuffix(a.BaseURL, "/")+"/_apis/connectionData", nil)
+	return errors.Wrap(err, "getting connection data")
+}
+
+func (a *Client) getPullRequest(repo models.Repo, pullNum int) (PullRequest, error) {
+	pullURL, err := pullRequestURL(repo, pullNum)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	resp, err := a.makeRequest("GET", apiURL(pullURL, nil), nil)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return PullRequest{}, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(pullResp); err != nil {
+		return PullRequest{}, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	return pullResp, nil
+}
+
+// splitCloneURL returns the URL of repo's organization or collection, ex.
+// https://dev.azure.com/org, and the URL escaped names of its project and
+// repo, from its clone URL, ex. https://dev.azure.com/org/project/_git/repo.
+func splitCloneURL(repo models.Repo) (collectionURL string, project string, name string, err error) {
+	cloneURL := repo.SanitizedCloneURL
+	notAzureErr := fmt.Errorf("clone url %q isn't an Azure DevOps clone url, ex. https://dev.azure.com/org/project/_git/repo", cloneURL)
+	i := strings.LastIndex(cloneURL, "/_git/")
+	if i == -1 {
+		return "", "", "", notAzureErr
+	}
+	projectURL := cloneURL[:i]
+	j := strings.LastIndex(projectURL, "/")
+	if j == -1 {
+		return "", "", "", notAzureErr
+	}
+	return projectURL[:j], projectURL[j+1:], cloneURL[i+len("/_git/"):], nil
+}
+
+// pullRequestURL returns the API URL of the pull request.
+func pullRequestURL(repo models.Repo, pullNum int) (string, error) {
+	collectionURL, project, name, err := splitCloneURL(repo)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s/pullrequests/%d", collectionURL, project, name, pullNum), nil
+}
+
+// identitiesURL returns the URL that serves the identities API for the
+// organization or collection. Azure DevOps serves it from a separate host,
+// Azure DevOps Server serves it from the collection.
+func identitiesURL(collectionURL string) string {
+	return strings.Replace(collectionURL, "://dev.azure.com/", "://vssps.dev.azure.com/", 1)
+}
+
+// ValidateURL returns an error if u isn't an Azure DevOps URL. If baseURL is
+// an Azure DevOps Server collection URL, u must be under it. Otherwise u must
+// be on dev.azure.com or visualstudio.com.
+func ValidateURL(u string, baseURL string) error {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return errors.Wrapf(err, "parsing url %q", u)
+	}
+	if baseURL != "" && baseURL != DefaultBaseURL {
+		base, err := url.Parse(strings.TrimSuffix(baseURL, "/"))
+		if err != nil {
+			return errors.Wrapf(err, "parsing base url %q", baseURL)
+		}
+		if parsed.Scheme == base.Scheme && parsed.Host == base.Host && (parsed.Path == base.Path || strings.HasPrefix(parsed.Path, base.Path+"/")) {
+			return nil
+		}
+		return fmt.Errorf("url %q isn't under the Azure DevOps Server collection url %q", u, baseURL)
+	}
+	host := parsed.Hostname()
+	if parsed.Scheme == "https" && (host == "dev.azure.com" || strings.HasSuffix(host, ".dev.azure.com") || strings.HasSuffix(host, ".visualstudio.com")) {
+		return nil
+	}
+	return fmt.Errorf("url %q isn't on Azure DevOps, ex. https://dev.azure.com/org; Azure DevOps Server collection urls must be configured", u)
+}
+
+// apiURL adds the API version and query to path.
+func apiURL(path string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("api-version", APIVersion)
+	return path + "?" + q.Encode()
+}
+
+func (a *Client) prepRequest(method string, path string, body io.Reader) (*http.Request, error) {
+	// Repos' URLs come from webhooks so we check them before sending our
+	// token.
+	if err := ValidateURL(path, a.BaseURL); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(a.Username, a.Token)
+	if body != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func (a *Client) makeRequest(method string, path string, reqBody io.Reader) ([]byte, error) {
+	req, err := a.prepRequest(method, path, reqBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "constructing request")
+	}
+	resp, err := a.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() // nolint: errcheck
+	requestStr := fmt.Sprintf("%s %s", method, path)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		respBody, _ := ioutil.ReadAll(resp.Body)
+		return nil, fmt.Errorf("making request %q unexpected status code: %d, body: %s", requestStr, resp.StatusCode, string(respBody))
+	}
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading response from request %q", requestStr)
+	}
+	return respBody, nil
+}
@@ -0,0 +1,409 @@
+package azuredevops_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs/azuredevops"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+const pullPath = "/org/project/_apis/git/repositories/repo/pullrequests/1"
+
+// newTestRepo returns a repo whose clone URL points at the test server.
+func newTestRepo(t *testing.T, serverURL string) models.Repo {
+	repo, err := models.NewRepo(models.AzureDevops, "org/project/repo", serverURL+"/org/project/_git/repo", "user", "token")
+	Ok(t, err)
+	return repo
+}
+
+// newTestClient returns a client for the Azure DevOps Server collection "org"
+// at serverURL.
+func newTestClient(serverURL string) *azuredevops.Client {
+	client := azuredevops.NewClient(http.DefaultClient, "user", "token", "https://atlantis.example.com")
+	client.BaseURL = serverURL + "/org"
+	return client
+}
+
+// newTestServer returns a server that responds to requests for the paths in
+// responses with the contents of the testdata files they map to.
+func newTestServer(t *testing.T, responses map[string]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, azuredevops.APIVersion, r.URL.Query().Get("api-version"))
+		user, pass, _ := r.BasicAuth()
+		Equals(t, "user", user)
+		Equals(t, "token", pass)
+		file, ok := responses[r.URL.Path]
+		if !ok {
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		contents, err := ioutil.ReadFile(filepath.Join("testdata", file))
+		Ok(t, err)
+		w.Write(contents) // nolint: errcheck
+	}))
+}
+
+// Should use the changes of the latest iteration and follow pagination.
+func TestClient_GetModifiedFiles(t *testing.T) {
+	iterations, err := ioutil.ReadFile(filepath.Join("testdata", "iterations.json"))
+	Ok(t, err)
+	page1, err := ioutil.ReadFile(filepath.Join("testdata", "iteration-changes-page-1.json"))
+	Ok(t, err)
+	page2, err := ioutil.ReadFile(filepath.Join("testdata", "iteration-changes-page-2.json"))
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pullPath + "/iterations":
+			w.Write(iterations) // nolint: errcheck
+		case pullPath + "/iterations/2/changes":
+			Equals(t, "0", r.URL.Query().Get("$compareTo"))
+			if r.URL.Query().Get("$skip") == "2" {
+				w.Write(page2) // nolint: errcheck
+				return
+			}
+			w.Write(page1) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+	files, err := client.GetModifiedFiles(repo, models.PullRequest{Num: 1, BaseRepo: repo})
+	Ok(t, err)
+	Equals(t, []string{"main.tf", "modules/bucket/variables.tf", "modules/bucket/vars.tf"}, files)
+}
+
+func TestClient_CreateComment(t *testing.T) {
+	var thread struct {
+		Comments []struct {
+			Content string `json:"content"`
+		} `json:"comments"`
+		Status string `json:"status"`
+	}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pullPath + "/threads":
+			Equals(t, "POST", r.Method)
+			Ok(t, json.NewDecoder(r.Body).Decode(&thread))
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte(`{"id": 1}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := newTestClient(testServer.URL)
+	Ok(t, client.CreateComment(newTestRepo(t, testServer.URL), 1, "comment"))
+	Equals(t, 1, len(thread.Comments))
+	Equals(t, "comment", thread.Comments[0].Content)
+	Equals(t, "closed", thread.Status)
+}
+
+func TestClient_PullIsApproved(t *testing.T) {
+	testServer := newTestServer(t, map[string]string{pullPath: "pull-request.json"})
+	defer testServer.Close()
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+
+	cases := []struct {
+		author string
+		exp    bool
+	}{
+		// approver@example.com approved with suggestions.
+		{"author@example.com", true},
+		// The author's own approval doesn't count.
+		{"approver@example.com", true},
+	}
+	for _, c := range cases {
+		t.Run(c.author, func(t *testing.T) {
+			approved, err := client.PullIsApproved(repo, models.PullRequest{Num: 1, Author: c.author, BaseRepo: repo})
+			Ok(t, err)
+			Equals(t, c.exp, approved)
+		})
+	}
+}
+
+func TestClient_GetApprovals(t *testing.T) {
+	testServer := newTestServer(t, map[string]string{pullPath: "pull-request.json"})
+	defer testServer.Close()
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+
+	approvals, err := client.GetApprovals(repo, models.PullRequest{Num: 1, Author: "author@example.com", BaseRepo: repo})
+	Ok(t, err)
+	// The author's approval is included, it's up to the caller to ignore it.
+	// Groups and reviewers waiting for the author aren't approvals.
+	Equals(t, []models.Approval{{Username: "author@example.com"}, {Username: "approver@example.com"}}, approvals)
+}
+
+func TestClient_PullIsMergeable(t *testing.T) {
+	cases := []struct {
+		mergeStatus string
+		exp         bool
+	}{
+		{"succeeded", true},
+		{"conflicts", false},
+		{"queued", false},
+		{"rejectedByPolicy", false},
+	}
+	pull, err := ioutil.ReadFile(filepath.Join("testdata", "pull-request.json"))
+	Ok(t, err)
+	for _, c := range cases {
+		t.Run(c.mergeStatus, func(t *testing.T) {
+			var resp map[string]interface{}
+			Ok(t, json.Unmarshal(pull, &resp))
+			resp["mergeStatus"] = c.mergeStatus
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case pullPath:
+					Ok(t, json.NewEncoder(w).Encode(resp))
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client := newTestClient(testServer.URL)
+			repo := newTestRepo(t, testServer.URL)
+			mergeable, err := client.PullIsMergeable(repo, models.PullRequest{Num: 1, BaseRepo: repo})
+			Ok(t, err)
+			Equals(t, c.exp, mergeable)
+		})
+	}
+}
+
+func TestClient_PullIsClosed(t *testing.T) {
+	pull, err := ioutil.ReadFile(filepath.Join("testdata", "pull-request.json"))
+	Ok(t, err)
+	for _, status := range []string{"active", "completed", "abandoned"} {
+		t.Run(status, func(t *testing.T) {
+			var resp map[string]interface{}
+			Ok(t, json.Unmarshal(pull, &resp))
+			resp["status"] = status
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case pullPath:
+					Ok(t, json.NewEncoder(w).Encode(resp))
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client := newTestClient(testServer.URL)
+			repo := newTestRepo(t, testServer.URL)
+			closed, err := client.PullIsClosed(repo, models.PullRequest{Num: 1, BaseRepo: repo})
+			Ok(t, err)
+			Equals(t, status != "active", closed)
+		})
+	}
+}
+
+func TestClient_UpdateStatus(t *testing.T) {
+	var status map[string]interface{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pullPath + "/statuses":
+			Equals(t, "POST", r.Method)
+			Ok(t, json.NewDecoder(r.Body).Decode(&status))
+			w.Write([]byte(`{"id": 1}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+	err := client.UpdateStatus(repo, models.PullRequest{Num: 1, BaseRepo: repo}, models.PendingCommitStatus, "atlantis/plan", "Plan in progress...", "")
+	Ok(t, err)
+	Equals(t, map[string]interface{}{
+		"state":       "pending",
+		"description": "Plan in progress...",
+		"targetUrl":   "https://atlantis.example.com",
+		"context":     map[string]interface{}{"genre": "atlantis", "name": "plan"},
+	}, status)
+}
+
+func TestClient_GetStatusChecks(t *testing.T) {
+	testServer := newTestServer(t, map[string]string{pullPath + "/statuses": "statuses.json"})
+	defer testServer.Close()
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+
+	checks, err := client.GetStatusChecks(repo, models.PullRequest{Num: 1, BaseRepo: repo})
+	Ok(t, err)
+	Equals(t, []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.SuccessCommitStatus},
+		{Name: "tests", Status: models.FailedCommitStatus},
+		{Name: "lint", Status: models.PendingCommitStatus},
+	}, checks)
+}
+
+func TestClient_MergePull(t *testing.T) {
+	var update map[string]interface{}
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case pullPath:
+			Equals(t, "PATCH", r.Method)
+			Ok(t, json.NewDecoder(r.Body).Decode(&update))
+			w.Write([]byte(`{"pullRequestId": 1}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+	Ok(t, client.MergePull(models.PullRequest{Num: 1, HeadCommit: "sha", BaseRepo: repo}))
+	Equals(t, "completed", update["status"])
+	Equals(t, map[string]interface{}{"commitId": "sha"}, update["lastMergeSourceCommit"])
+}
+
+func TestClient_IsTeamMember(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/org/_apis/projects/project/teams/platform/members":
+			contents, err := ioutil.ReadFile(filepath.Join("testdata", "team-members.json"))
+			Ok(t, err)
+			w.Write(contents) // nolint: errcheck
+		case "/org/_apis/projects/other/teams/platform/members":
+			w.Write([]byte(`{"value": [], "count": 0}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+
+	// Unique names are compared case-insensitively.
+	member, err := client.IsTeamMember(repo, "platform", "alice@example.com")
+	Ok(t, err)
+	Equals(t, true, member)
+
+	member, err = client.IsTeamMember(repo, "other/platform", "alice@example.com")
+	Ok(t, err)
+	Equals(t, false, member)
+}
+
+func TestClient_GetUserPermission(t *testing.T) {
+	testServer := newTestServer(t, map[string]string{
+		"/org/project/_apis/git/repositories/repo":                           "repository.json",
+		"/org/_apis/identities":                                              "identities.json",
+		"/org/_apis/accesscontrollists/2e9eb7ed-3c0a-47d4-87c1-0ffdd275fd87": "access-control-lists.json",
+	})
+	defer testServer.Close()
+	client := newTestClient(testServer.URL)
+	repo := newTestRepo(t, testServer.URL)
+
+	cases := []struct {
+		username      string
+		expPermission models.PermissionLevel
+	}{
+		// reader can read directly but can contribute through a group.
+		{"reader@example.com", models.WritePermission},
+		// readerly matches the search but has no permissions on the repo.
+		{"readerly@example.com", models.NoPermission},
+		{"stranger@example.com", models.NoPermission},
+	}
+	for _, c := range cases {
+		t.Run(c.username, func(t *testing.T) {
+			permission, err := client.GetUserPermission(repo, c.username)
+			Ok(t, err)
+			Equals(t, c.expPermission, permission)
+		})
+	}
+}
+
+func TestClient_CheckAuth(t *testing.T) {
+	connectionData, err := ioutil.ReadFile(filepath.Join("testdata", "connection-data.json"))
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/org/_apis/connectionData", r.URL.Path)
+		if _, pass, _ := r.BasicAuth(); pass != "token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write(connectionData) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	client := azuredevops.NewClient(http.DefaultClient, "user", "token", "https://atlantis.example.com")
+	Equals(t, azuredevops.DefaultBaseURL, client.BaseURL)
+	client.BaseURL = testServer.URL + "/org/"
+	Ok(t, client.CheckAuth())
+
+	client.Token = "bad-token"
+	ErrContains(t, "getting connection data", client.CheckAuth())
+	ErrContains(t, "unexpected status code: 401", client.CheckAuth())
+}
+
+func TestClient_NotAzureDevopsRepo(t *testing.T) {
+	client := azuredevops.NewClient(http.DefaultClient, "user", "token", "https://atlantis.example.com")
+	repo := models.Repo{FullName: "owner/repo", SanitizedCloneURL: "https://github.com/owner/repo.git"}
+	_, err := client.GetModifiedFiles(repo, models.PullRequest{Num: 1})
+	ErrEquals(t, `clone url "https://github.com/owner/repo.git" isn't an Azure DevOps clone url, ex. https://dev.azure.com/org/project/_git/repo`, err)
+}
+
+// Repos come from webhooks so requests for repos that aren't on the
+// configured Azure DevOps host shouldn't be made.
+func TestClient_RepoNotOnBaseURL(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("got unexpected request at %q", r.RequestURI)
+	}))
+	defer testServer.Close()
+	repo := newTestRepo(t, testServer.URL)
+
+	client := azuredevops.NewClient(http.DefaultClient, "user", "token", "https://atlantis.example.com")
+	err := client.CreateComment(repo, 1, "comment")
+	ErrContains(t, "isn't on Azure DevOps", err)
+
+	client.BaseURL = testServer.URL + "/other"
+	err = client.CreateComment(repo, 1, "comment")
+	ErrContains(t, "isn't under the Azure DevOps Server collection url", err)
+}
+
+func TestValidateURL(t *testing.T) {
+	cases := []struct {
+		url     string
+		baseURL string
+		valid   bool
+	}{
+		{"https://dev.azure.com/org/project/_git/repo", "", true},
+		{"https://dev.azure.com/org/project/_git/repo", azuredevops.DefaultBaseURL, true},
+		{"https://vssps.dev.azure.com/org/_apis/identities", "", true},
+		{"https://org.visualstudio.com/project/_git/repo", "", true},
+		{"http://dev.azure.com/org/project/_git/repo", "", false},
+		{"https://attacker.example.com/org/project/_git/repo", "", false},
+		{"https://dev.azure.com.example.com/org/project/_git/repo", "", false},
+		{"https://azuredevops.example.com/DefaultCollection/project/_git/repo", "https://azuredevops.example.com/DefaultCollection/", true},
+		{"https://azuredevops.example.com/OtherCollection/project/_git/repo", "https://azuredevops.example.com/DefaultCollection", false},
+		{"https://dev.azure.com/org/project/_git/repo", "https://azuredevops.example.com/DefaultCollection", false},
+	}
+	for _, c := range cases {
+		t.Run(c.url, func(t *testing.T) {
+			err := azuredevops.ValidateURL(c.url, c.baseURL)
+			Assert(t, (err == nil) == c.valid, "exp valid to be %t, got err %v", c.valid, err)
+		})
+	}
+}
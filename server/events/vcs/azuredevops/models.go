@@ -0,0 +1,152 @@
+package azuredevops
+
+// Event types of the service hooks that Atlantis handles.
+const (
+	PullCreatedEvent   = "git.pullrequest.created"
+	PullUpdatedEvent   = "git.pullrequest.updated"
+	PullMergedEvent    = "git.pullrequest.merged"
+	PullCommentedEvent = "ms.vss-code.git-pullrequest-comment-event"
+)
+
+// Event is the envelope that all service hook payloads share.
+type Event struct {
+	EventType *string  `json:"eventType,omitempty" validate:"required"`
+	Message   *Message `json:"message,omitempty"`
+}
+
+// Message is the human readable description of an event, ex.
+// "Jamal Hartnett updated the source branch of pull request 1".
+type Message struct {
+	Text *string `json:"text,omitempty"`
+}
+
+type PullRequestEvent struct {
+	Event
+	Resource *PullRequest `json:"resource,omitempty" validate:"required"`
+}
+
+type CommentEvent struct {
+	Event
+	Resource *CommentResource `json:"resource,omitempty" validate:"required"`
+}
+
+type CommentResource struct {
+	Comment     *Comment     `json:"comment,omitempty" validate:"required"`
+	PullRequest *PullRequest `json:"pullRequest,omitempty" validate:"required"`
+}
+
+type PullRequest struct {
+	PullRequestID         *int        `json:"pullRequestId,omitempty" validate:"required"`
+	Status                *string     `json:"status,omitempty" validate:"required"`
+	CreatedBy             *Identity   `json:"createdBy,omitempty" validate:"required"`
+	SourceRefName         *string     `json:"sourceRefName,omitempty" validate:"required"`
+	TargetRefName         *string     `json:"targetRefName,omitempty" validate:"required"`
+	LastMergeSourceCommit *Commit     `json:"lastMergeSourceCommit,omitempty" validate:"required"`
+	Repository            *Repository `json:"repository,omitempty" validate:"required"`
+	ForkSource            *ForkRef    `json:"forkSource,omitempty"`
+	MergeStatus           *string     `json:"mergeStatus,omitempty"`
+	Reviewers             []Reviewer  `json:"reviewers,omitempty"`
+}
+type ForkRef struct {
+	Repository *Repository `json:"repository,omitempty" validate:"required"`
+}
+type Repository struct {
+	ID        *string  `json:"id,omitempty"`
+	RemoteURL *string  `json:"remoteUrl,omitempty" validate:"required"`
+	Project   *Project `json:"project,omitempty"`
+}
+type Project struct {
+	ID *string `json:"id,omitempty" validate:"required"`
+}
+type Identity struct {
+	UniqueName *string `json:"uniqueName,omitempty" validate:"required"`
+}
+type Reviewer struct {
+	UniqueName *string `json:"uniqueName,omitempty" validate:"required"`
+	// Vote is 10 for approved, 5 for approved with suggestions, 0 for no
+	// vote, -5 for waiting for author and -10 for rejected.
+	Vote *int `json:"vote,omitempty" validate:"required"`
+	// IsContainer is true if the reviewer is a group rather than a user.
+	IsContainer bool `json:"isContainer,omitempty"`
+}
+type Commit struct {
+	CommitID *string `json:"commitId,omitempty" validate:"required"`
+}
+type Comment struct {
+	Content *string   `json:"content,omitempty" validate:"required"`
+	Author  *Identity `json:"author,omitempty" validate:"required"`
+}
+
+type Iterations struct {
+	Value []Iteration `json:"value,omitempty" validate:"required"`
+}
+type Iteration struct {
+	ID *int `json:"id,omitempty" validate:"required"`
+}
+type IterationChanges struct {
+	ChangeEntries []ChangeEntry `json:"changeEntries,omitempty"`
+	NextSkip      int           `json:"nextSkip,omitempty"`
+	NextTop       int           `json:"nextTop,omitempty"`
+}
+type ChangeEntry struct {
+	Item *ChangeItem `json:"item,omitempty" validate:"required"`
+	// OriginalPath is the path the file was renamed from, if it was renamed.
+	OriginalPath *string `json:"originalPath,omitempty"`
+}
+type ChangeItem struct {
+	Path     *string `json:"path,omitempty" validate:"required"`
+	IsFolder bool    `json:"isFolder,omitempty"`
+}
+
+type PullRequestStatuses struct {
+	Value []PullRequestStatus `json:"value,omitempty"`
+}
+type PullRequestStatus struct {
+	ID      *int           `json:"id,omitempty" validate:"required"`
+	State   *string        `json:"state,omitempty" validate:"required"`
+	Context *StatusContext `json:"context,omitempty" validate:"required"`
+}
+type StatusContext struct {
+	Name  *string `json:"name,omitempty" validate:"required"`
+	Genre *string `json:"genre,omitempty"`
+}
+
+type TeamMembers struct {
+	Value []TeamMember `json:"value,omitempty"`
+}
+type TeamMember struct {
+	Identity *Identity `json:"identity,omitempty" validate:"required"`
+}
+
+type Identities struct {
+	Value []IdentityDescriptor `json:"value,omitempty"`
+}
+type IdentityDescriptor struct {
+	Descriptor *string `json:"descriptor,omitempty" validate:"required"`
+	// MemberOf are the descriptors of the groups the identity is a member of,
+	// directly or through other groups.
+	MemberOf   []string           `json:"memberOf,omitempty"`
+	Properties IdentityProperties `json:"properties,omitempty"`
+}
+type IdentityProperties struct {
+	Account *PropertyValue `json:"Account,omitempty"`
+}
+type PropertyValue struct {
+	Value *string `json:"$value,omitempty"`
+}
+
+type AccessControlLists struct {
+	Value []AccessControlList `json:"value,omitempty"`
+}
+type AccessControlList struct {
+	AcesDictionary map[string]AccessControlEntry `json:"acesDictionary,omitempty"`
+}
+type AccessControlEntry struct {
+	Allow        int              `json:"allow,omitempty"`
+	Deny         int              `json:"deny,omitempty"`
+	ExtendedInfo *AceExtendedInfo `json:"extendedInfo,omitempty"`
+}
+type AceExtendedInfo struct {
+	EffectiveAllow int `json:"effectiveAllow,omitempty"`
+	EffectiveDeny  int `json:"effectiveDeny,omitempty"`
+}
@@ -0,0 +1,24 @@
+package azuredevops
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// ValidateBasicAuth returns an error if the request's basic auth credentials
+// don't match user and password. Azure DevOps service hooks don't sign their
+// payloads so basic auth is the only way to authenticate them.
+func ValidateBasicAuth(r *http.Request, user []byte, password []byte) error {
+	reqUser, reqPassword, ok := r.BasicAuth()
+	if !ok {
+		return errors.New("missing basic auth credentials")
+	}
+	userMatch := subtle.ConstantTimeCompare([]byte(reqUser), user) == 1
+	passwordMatch := subtle.ConstantTimeCompare([]byte(reqPassword), password) == 1
+	if !userMatch || !passwordMatch {
+		return errors.New("basic auth credentials did not match")
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package azuredevops_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/vcs/azuredevops"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestValidateBasicAuth(t *testing.T) {
+	req, err := http.NewRequest("POST", "/events", nil)
+	Ok(t, err)
+	req.SetBasicAuth("user", "password")
+	err = azuredevops.ValidateBasicAuth(req, []byte("user"), []byte("password"))
+	Ok(t, err)
+}
+
+func TestValidateBasicAuth_Missing(t *testing.T) {
+	req, err := http.NewRequest("POST", "/events", nil)
+	Ok(t, err)
+	err = azuredevops.ValidateBasicAuth(req, []byte("user"), []byte("password"))
+	ErrEquals(t, "missing basic auth credentials", err)
+}
+
+func TestValidateBasicAuth_Invalid(t *testing.T) {
+	cases := []struct {
+		user     string
+		password string
+	}{
+		{"user", "wrong"},
+		{"wrong", "password"},
+		{"", ""},
+	}
+	for _, c := range cases {
+		t.Run(c.user+":"+c.password, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/events", nil)
+			Ok(t, err)
+			req.SetBasicAuth(c.user, c.password)
+			err = azuredevops.ValidateBasicAuth(req, []byte("user"), []byte("password"))
+			ErrEquals(t, "basic auth credentials did not match", err)
+		})
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"strings"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -102,18 +103,10 @@ func (b *Client) CreateComment(repo models.Repo, pullNum int, comment string) er
 
 // PullIsApproved returns true if the merge request was approved.
 func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
-	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
-	resp, err := b.makeRequest("GET", path, nil)
+	pullResp, err := b.getPullRequest(repo, pull)
 	if err != nil {
 		return false, err
 	}
-	var pullResp PullRequest
-	if err := json.Unmarshal(resp, &pullResp); err != nil {
-		return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
-	}
-	if err := validator.New().Struct(pullResp); err != nil {
-		return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
-	}
 	for _, participant := range pullResp.Participants {
 		// Bitbucket allows the author to approve their own pull request. This
 		// defeats the purpose of approvals so we don't count that approval.
@@ -124,6 +117,177 @@ func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool
 	return false, nil
 }
 
+// GetApprovals returns the participants that approved the pull request.
+// Bitbucket doesn't say which commit was approved but it removes approvals
+// when new commits are pushed.
+func (b *Client) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	pullResp, err := b.getPullRequest(repo, pull)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []models.Approval
+	for _, participant := range pullResp.Participants {
+		if *participant.Approved {
+			approvals = append(approvals, models.Approval{Username: *participant.User.Username})
+		}
+	}
+	return approvals, nil
+}
+
+// IsTeamMember returns true if username is a member of the group team. team
+// is either "owner/group-slug" or "group-slug", in which case the owner is the
+// repo's owner.
+func (b *Client) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	owner, slug := repo.Owner, team
+	if i := strings.Index(team, "/"); i != -1 {
+		owner, slug = team[:i], team[i+1:]
+	}
+	// NOTE: Groups are only available in the 1.0 API.
+	path := fmt.Sprintf("%s/1.0/groups/%s/%s/members", b.BaseURL, owner, slug)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return false, err
+	}
+	var members []Account
+	if err := json.Unmarshal(resp, &members); err != nil {
+		return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	return containsAccount(members, username), nil
+}
+
+// GetUserPermission returns username's permission on repo, either given to
+// them directly or through one of their groups. Listing privileges requires
+// the Atlantis user to be an admin of the repo.
+func (b *Client) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	// NOTE: Privileges are only available in the 1.0 API.
+	path := fmt.Sprintf("%s/1.0/privileges/%s", b.BaseURL, repo.FullName)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	var repoPrivileges []RepoPrivilege
+	if err := json.Unmarshal(resp, &repoPrivileges); err != nil {
+		return models.NoPermission, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	permission := models.NoPermission
+	for _, p := range repoPrivileges {
+		if err := validator.New().Struct(p); err != nil {
+			return models.NoPermission, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		if containsAccount([]Account{*p.User}, username) {
+			permission = maxPermission(permission, *p.Privilege)
+		}
+	}
+
+	path = fmt.Sprintf("%s/1.0/group-privileges/%s", b.BaseURL, repo.FullName)
+	resp, err = b.makeRequest("GET", path, nil)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	var groupPrivileges []GroupPrivilege
+	if err := json.Unmarshal(resp, &groupPrivileges); err != nil {
+		return models.NoPermission, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	for _, p := range groupPrivileges {
+		if err := validator.New().Struct(p); err != nil {
+			return models.NoPermission, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		if containsAccount(p.Group.Members, username) {
+			permission = maxPermission(permission, *p.Privilege)
+		}
+	}
+	return permission, nil
+}
+
+// containsAccount returns true if one of accounts has the name username.
+// Bitbucket has replaced usernames with nicknames so we check both.
+func containsAccount(accounts []Account, username string) bool {
+	for _, a := range accounts {
+		if (a.Username != nil && *a.Username == username) || (a.Nickname != nil && *a.Nickname == username) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxPermission returns the higher of current and the Bitbucket privilege,
+// which is one of "read", "write" or "admin".
+func maxPermission(current models.PermissionLevel, privilege string) models.PermissionLevel {
+	p, err := models.ParsePermissionLevel(privilege)
+	if err != nil || p < current {
+		return current
+	}
+	return p
+}
+
+// GetStatusChecks returns the build statuses of pull's head commit.
+func (b *Client) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	var checks []models.StatusCheck
+	nextPageURL := fmt.Sprintf("%s/2.0/repositories/%s/commit/%s/statuses", b.BaseURL, repo.FullName, pull.HeadCommit)
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", nextPageURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		var statuses CommitStatuses
+		if err := json.Unmarshal(resp, &statuses); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(statuses); err != nil {
+			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		for _, s := range statuses.Values {
+			if err := validator.New().Struct(s); err != nil {
+				return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+			}
+			checks = append(checks, models.StatusCheck{Name: *s.Key, Status: commitStatus(*s.State)})
+		}
+		if statuses.Next == nil || *statuses.Next == "" {
+			break
+		}
+		nextPageURL = *statuses.Next
+	}
+	return checks, nil
+}
+
+// commitStatus converts a Bitbucket build state to a commit status.
+func commitStatus(state string) models.CommitStatus {
+	switch state {
+	case "SUCCESSFUL":
+		return models.SuccessCommitStatus
+	case "INPROGRESS":
+		return models.PendingCommitStatus
+	}
+	return models.FailedCommitStatus
+}
+
+func (b *Client) getPullRequest(repo models.Repo, pull models.PullRequest) (PullRequest, error) {
+	path := fmt.Sprintf("%s/2.0/repositories/%s/pullrequests/%d", b.BaseURL, repo.FullName, pull.Num)
+	resp, err := b.makeRequest("GET", path, nil)
+	if err != nil {
+		return PullRequest{}, err
+	}
+	var pullResp PullRequest
+	if err := json.Unmarshal(resp, &pullResp); err != nil {
+		return PullRequest{}, errors.Wrapf(err, "Could not parse response %q", string(resp))
+	}
+	if err := validator.New().Struct(pullResp); err != nil {
+		return PullRequest{}, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+	}
+	return pullResp, nil
+}
+
+// PullIsClosed returns true if the pull request has been merged or declined.
+func (b *Client) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	pullResp, err := b.getPullRequest(repo, pull)
+	if err != nil {
+		return false, err
+	}
+	return *pullResp.State != "OPEN", nil
+}
+
 // PullIsMergeable returns true if the merge request has no conflicts and can be merged.
 func (b *Client) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
 	// NOTE: The 1.0 API is deprecated, but the 2.0 API does not provide this endpoint.
@@ -185,6 +349,13 @@ func (b *Client) MergePull(pull models.PullRequest) error {
 	return err
 }
 
+// CheckAuth returns an error if the client's username and password can't be
+// used to authenticate with Bitbucket Cloud.
+func (b *Client) CheckAuth() error {
+	_, err := b.makeRequest("GET", fmt.Sprintf("%s/2.0/user", b.BaseURL), nil)
+	return errors.Wrap(err, "getting current user")
+}
+
 // prepRequest adds auth and necessary headers.
 func (b *Client) prepRequest(method string, path string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequest(method, path, body)
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -213,3 +214,151 @@ func TestClient_PullIsApproved(t *testing.T) {
 		})
 	}
 }
+
+func TestClient_PullIsClosed(t *testing.T) {
+	pull, err := ioutil.ReadFile(filepath.Join("testdata", "pull-approved.json"))
+	Ok(t, err)
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED", "SUPERSEDED"} {
+		t.Run(state, func(t *testing.T) {
+			resp := strings.Replace(string(pull), `"state": "OPEN"`, fmt.Sprintf(`"state": %q`, state), 1)
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/2.0/repositories/owner/repo/pullrequests/1":
+					w.Write([]byte(resp)) // nolint: errcheck
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+			client.BaseURL = testServer.URL
+			repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+			Ok(t, err)
+			closed, err := client.PullIsClosed(repo, models.PullRequest{Num: 1, BaseRepo: repo})
+			Ok(t, err)
+			Equals(t, state != "OPEN", closed)
+		})
+	}
+}
+
+func TestClient_GetApprovals(t *testing.T) {
+	json, err := ioutil.ReadFile(filepath.Join("testdata", "pull-approved-multiple.json"))
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/owner/repo/pullrequests/1":
+			w.Write(json) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+
+	repo, err := models.NewRepo(models.BitbucketCloud, "owner/repo", "https://bitbucket.org/owner/repo.git", "user", "token")
+	Ok(t, err)
+	approvals, err := client.GetApprovals(repo, models.PullRequest{Num: 1, Author: "author", BaseRepo: repo})
+	Ok(t, err)
+	// The author's approval is included, it's up to the caller to ignore it.
+	Equals(t, []models.Approval{{Username: "author"}, {Username: "approver"}}, approvals)
+}
+
+func TestClient_IsTeamMember(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/1.0/groups/owner/platform/members":
+			w.Write([]byte(`[{"username": "alice", "display_name": "Alice"}]`)) // nolint: errcheck
+		case "/1.0/groups/other/platform/members":
+			w.Write([]byte(`[]`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+
+	member, err := client.IsTeamMember(repo, "platform", "alice")
+	Ok(t, err)
+	Equals(t, true, member)
+
+	member, err = client.IsTeamMember(repo, "other/platform", "alice")
+	Ok(t, err)
+	Equals(t, false, member)
+}
+
+func TestClient_GetUserPermission(t *testing.T) {
+	privileges, err := ioutil.ReadFile(filepath.Join("testdata", "privileges.json"))
+	Ok(t, err)
+	groupPrivileges, err := ioutil.ReadFile(filepath.Join("testdata", "group-privileges.json"))
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/1.0/privileges/owner/repo":
+			w.Write(privileges) // nolint: errcheck
+		case "/1.0/group-privileges/owner/repo":
+			w.Write(groupPrivileges) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+
+	cases := []struct {
+		username      string
+		expPermission models.PermissionLevel
+	}{
+		// reader can read directly but can write through the developers group.
+		{"reader", models.WritePermission},
+		{"admin", models.AdminPermission},
+		{"stranger", models.NoPermission},
+	}
+	for _, c := range cases {
+		t.Run(c.username, func(t *testing.T) {
+			permission, err := client.GetUserPermission(repo, c.username)
+			Ok(t, err)
+			Equals(t, c.expPermission, permission)
+		})
+	}
+}
+
+func TestClient_GetStatusChecks(t *testing.T) {
+	var serverURL string
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/2.0/repositories/owner/repo/commit/sha/statuses":
+			fmt.Fprintf(w, `{"values": [{"key": "atlantis/plan", "state": "SUCCESSFUL"}, {"key": "tests", "state": "FAILED"}], "next": "%s/2.0/repositories/owner/repo/commit/sha/statuses?page=2"}`, serverURL) // nolint: errcheck
+		case "/2.0/repositories/owner/repo/commit/sha/statuses?page=2":
+			w.Write([]byte(`{"values": [{"key": "lint", "state": "INPROGRESS"}, {"key": "tfsec", "state": "STOPPED"}]}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+	serverURL = testServer.URL
+
+	client := bitbucketcloud.NewClient(http.DefaultClient, "user", "pass", "runatlantis.io")
+	client.BaseURL = testServer.URL
+	checks, err := client.GetStatusChecks(models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, models.PullRequest{HeadCommit: "sha"})
+	Ok(t, err)
+	Equals(t, []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.SuccessCommitStatus},
+		{Name: "tests", Status: models.FailedCommitStatus},
+		{Name: "lint", Status: models.PendingCommitStatus},
+		{Name: "tfsec", Status: models.FailedCommitStatus},
+	}, checks)
+}
@@ -86,3 +86,25 @@ type ConflictStatus struct {
 	MergeImpossible *bool `json:"mergeimpossible,omitempty" validate:"required"`
 	IsConflicted    *bool `json:"isconflicted,omitempty" validate:"required"`
 }
+type Account struct {
+	Username *string `json:"username,omitempty"`
+	Nickname *string `json:"nickname,omitempty"`
+}
+type RepoPrivilege struct {
+	Privilege *string  `json:"privilege,omitempty" validate:"required"`
+	User      *Account `json:"user,omitempty" validate:"required"`
+}
+type GroupPrivilege struct {
+	Privilege *string `json:"privilege,omitempty" validate:"required"`
+	Group     *struct {
+		Members []Account `json:"members,omitempty"`
+	} `json:"group,omitempty" validate:"required"`
+}
+type CommitStatuses struct {
+	Values []CommitStatus `json:"values,omitempty" validate:"required"`
+	Next   *string        `json:"next,omitempty"`
+}
+type CommitStatus struct {
+	Key   *string `json:"key,omitempty" validate:"required"`
+	State *string `json:"state,omitempty" validate:"required"`
+}
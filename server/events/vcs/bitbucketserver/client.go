@@ -158,28 +158,228 @@ func (b *Client) postComment(repo models.Repo, pullNum int, comment string) erro
 
 // PullIsApproved returns true if the merge request was approved.
 func (b *Client) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
-	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	pullResp, err := b.getPullRequest(repo, pull)
 	if err != nil {
 		return false, err
 	}
+	for _, reviewer := range pullResp.Reviewers {
+		if *reviewer.Approved {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetApprovals returns the reviewers that approved the pull request along with
+// the last commit they reviewed.
+func (b *Client) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	pullResp, err := b.getPullRequest(repo, pull)
+	if err != nil {
+		return nil, err
+	}
+	var approvals []models.Approval
+	for _, reviewer := range pullResp.Reviewers {
+		if !*reviewer.Approved || reviewer.User == nil || reviewer.User.Name == nil {
+			continue
+		}
+		approval := models.Approval{Username: *reviewer.User.Name}
+		if reviewer.LastReviewedCommit != nil {
+			approval.CommitSHA = *reviewer.LastReviewedCommit
+		}
+		approvals = append(approvals, approval)
+	}
+	return approvals, nil
+}
+
+// IsTeamMember returns true if username is a member of the group team.
+func (b *Client) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	nextPageStart := 0
+	baseURL := fmt.Sprintf("%s/rest/api/1.0/admin/groups/more-members?context=%s&filter=%s",
+		b.BaseURL, url.QueryEscape(team), url.QueryEscape(username))
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", fmt.Sprintf("%s&start=%d", baseURL, nextPageStart), nil)
+		if err != nil {
+			return false, err
+		}
+		var members GroupMembers
+		if err := json.Unmarshal(resp, &members); err != nil {
+			return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(members); err != nil {
+			return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		// The filter also matches users whose names contain username.
+		for _, m := range members.Values {
+			if m.Name != nil && *m.Name == username {
+				return true, nil
+			}
+		}
+		if *members.IsLastPage {
+			break
+		}
+		nextPageStart = *members.NextPageStart
+	}
+	return false, nil
+}
+
+// GetUserPermission returns username's permission on repo, either given to
+// them directly or through one of their groups, on the repo or its project.
+// Listing permissions requires the Atlantis user to be an admin of the repo.
+func (b *Client) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return models.NoPermission, err
+	}
+	permission := models.NoPermission
+	for _, scope := range []string{
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s", b.BaseURL, projectKey, repo.Name),
+		fmt.Sprintf("%s/rest/api/1.0/projects/%s", b.BaseURL, projectKey),
+	} {
+		users, err := b.listPermissions(fmt.Sprintf("%s/permissions/users?filter=%s", scope, url.QueryEscape(username)))
+		if err != nil {
+			return models.NoPermission, err
+		}
+		for _, p := range users {
+			// The filter also matches users whose names contain username.
+			if p.User != nil && p.User.Name != nil && *p.User.Name == username {
+				permission = maxPermission(permission, p.Permission)
+			}
+		}
+
+		groups, err := b.listPermissions(fmt.Sprintf("%s/permissions/groups", scope))
+		if err != nil {
+			return models.NoPermission, err
+		}
+		for _, p := range groups {
+			if p.Group == nil || p.Group.Name == nil || maxPermission(permission, p.Permission) == permission {
+				continue
+			}
+			member, err := b.IsTeamMember(repo, *p.Group.Name, username)
+			if err != nil {
+				return models.NoPermission, err
+			}
+			if member {
+				permission = maxPermission(permission, p.Permission)
+			}
+		}
+	}
+	return permission, nil
+}
+
+// listPermissions returns all the pages of the permissions at path.
+func (b *Client) listPermissions(path string) ([]Permission, error) {
+	var permissions []Permission
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	nextPageStart := 0
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		resp, err := b.makeRequest("GET", fmt.Sprintf("%s%sstart=%d", path, sep, nextPageStart), nil)
+		if err != nil {
+			return nil, err
+		}
+		var page Permissions
+		if err := json.Unmarshal(resp, &page); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(page); err != nil {
+			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		permissions = append(permissions, page.Values...)
+		if *page.IsLastPage {
+			break
+		}
+		nextPageStart = *page.NextPageStart
+	}
+	return permissions, nil
+}
+
+// maxPermission returns the higher of current and the Bitbucket Server
+// permission, ex. REPO_WRITE or PROJECT_ADMIN.
+func maxPermission(current models.PermissionLevel, permission *string) models.PermissionLevel {
+	if permission == nil {
+		return current
+	}
+	level := strings.ToLower(strings.TrimPrefix(strings.TrimPrefix(*permission, "REPO_"), "PROJECT_"))
+	p, err := models.ParsePermissionLevel(level)
+	if err != nil || p < current {
+		return current
+	}
+	return p
+}
+
+// GetStatusChecks returns the build statuses of pull's head commit.
+func (b *Client) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	var checks []models.StatusCheck
+	nextPageStart := 0
+	// We'll only loop 1000 times as a safety measure.
+	maxLoops := 1000
+	for i := 0; i < maxLoops; i++ {
+		path := fmt.Sprintf("%s/rest/build-status/1.0/commits/%s?start=%d", b.BaseURL, pull.HeadCommit, nextPageStart)
+		resp, err := b.makeRequest("GET", path, nil)
+		if err != nil {
+			return nil, err
+		}
+		var statuses BuildStatuses
+		if err := json.Unmarshal(resp, &statuses); err != nil {
+			return nil, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		}
+		if err := validator.New().Struct(statuses); err != nil {
+			return nil, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		}
+		for _, s := range statuses.Values {
+			if s.Key == nil || s.State == nil {
+				return nil, fmt.Errorf("API response %q was missing fields", string(resp))
+			}
+			status := models.FailedCommitStatus
+			switch *s.State {
+			case "SUCCESSFUL":
+				status = models.SuccessCommitStatus
+			case "INPROGRESS":
+				status = models.PendingCommitStatus
+			}
+			checks = append(checks, models.StatusCheck{Name: *s.Key, Status: status})
+		}
+		if *statuses.IsLastPage {
+			break
+		}
+		nextPageStart = *statuses.NextPageStart
+	}
+	return checks, nil
+}
+
+func (b *Client) getPullRequest(repo models.Repo, pull models.PullRequest) (PullRequest, error) {
+	projectKey, err := b.GetProjectKey(repo.Name, repo.SanitizedCloneURL)
+	if err != nil {
+		return PullRequest{}, err
+	}
 	path := fmt.Sprintf("%s/rest/api/1.0/projects/%s/repos/%s/pull-requests/%d", b.BaseURL, projectKey, repo.Name, pull.Num)
 	resp, err := b.makeRequest("GET", path, nil)
 	if err != nil {
-		return false, err
+		return PullRequest{}, err
 	}
 	var pullResp PullRequest
 	if err := json.Unmarshal(resp, &pullResp); err != nil {
-		return false, errors.Wrapf(err, "Could not parse response %q", string(resp))
+		return PullRequest{}, errors.Wrapf(err, "Could not parse response %q", string(resp))
 	}
 	if err := validator.New().Struct(pullResp); err != nil {
-		return false, errors.Wrapf(err, "API response %q was missing fields", string(resp))
+		return PullRequest{}, errors.Wrapf(err, "API response %q was missing fields", string(resp))
 	}
-	for _, reviewer := range pullResp.Reviewers {
-		if *reviewer.Approved {
-			return true, nil
-		}
+	return pullResp, nil
+}
+
+// PullIsClosed returns true if the pull request has been merged or declined.
+func (b *Client) PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error) {
+	pullResp, err := b.getPullRequest(repo, pull)
+	if err != nil {
+		return false, err
 	}
-	return false, nil
+	return *pullResp.State != "OPEN", nil
 }
 
 // PullIsMergeable returns true if the merge request has no conflicts and can be merged.
@@ -264,6 +464,13 @@ func (b *Client) MergePull(pull models.PullRequest) error {
 	return err
 }
 
+// CheckAuth returns an error if the client's username and password can't be
+// used to authenticate with Bitbucket Server.
+func (b *Client) CheckAuth() error {
+	_, err := b.makeRequest("GET", fmt.Sprintf("%s/rest/api/1.0/users/%s", b.BaseURL, url.PathEscape(b.Username)), nil)
+	return errors.Wrap(err, "getting current user")
+}
+
 // prepRequest adds auth and necessary headers.
 func (b *Client) prepRequest(method string, path string, body io.Reader) (*http.Request, error) {
 	req, err := http.NewRequest(method, path, body)
@@ -180,3 +180,181 @@ func TestClient_MergePull(t *testing.T) {
 	})
 	Ok(t, err)
 }
+
+func TestClient_CheckAuth(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Equals(t, "/rest/api/1.0/users/user", r.RequestURI)
+		if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"name": "user"}`)) // nolint: errcheck
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+	Ok(t, client.CheckAuth())
+
+	t.Log("should error if the credentials are invalid")
+	client, err = bitbucketserver.NewClient(http.DefaultClient, "user", "wrong", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+	ErrContains(t, "unexpected status code: 401", client.CheckAuth())
+}
+
+func TestClient_GetApprovals(t *testing.T) {
+	pullRequest, err := ioutil.ReadFile(filepath.Join("testdata", "pull-request-approved.json"))
+	Ok(t, err)
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/rest/api/1.0/projects/ow/repos/repo/pull-requests/1":
+			w.Write(pullRequest) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+
+	approvals, err := client.GetApprovals(models.Repo{
+		FullName:          "owner/repo",
+		Owner:             "owner",
+		Name:              "repo",
+		SanitizedCloneURL: fmt.Sprintf("%s/scm/ow/repo.git", testServer.URL),
+	}, models.PullRequest{Num: 1})
+	Ok(t, err)
+	Equals(t, []models.Approval{
+		{Username: "approver", CommitSHA: "bdcaa224f4b65edb853a689404ef79cf47d8cdda"},
+		{Username: "stale-approver", CommitSHA: "0a1b2c3d4e5f60718293a4b5c6d7e8f901234567"},
+	}, approvals)
+}
+
+func TestClient_PullIsClosed(t *testing.T) {
+	pullRequest, err := ioutil.ReadFile(filepath.Join("testdata", "pull-request.json"))
+	Ok(t, err)
+	for _, state := range []string{"OPEN", "MERGED", "DECLINED"} {
+		t.Run(state, func(t *testing.T) {
+			resp := strings.Replace(string(pullRequest), `"state": "MERGED"`, fmt.Sprintf(`"state": %q`, state), 1)
+			testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.RequestURI {
+				case "/rest/api/1.0/projects/ow/repos/repo/pull-requests/1":
+					w.Write([]byte(resp)) // nolint: errcheck
+				default:
+					t.Errorf("got unexpected request at %q", r.RequestURI)
+					http.Error(w, "not found", http.StatusNotFound)
+				}
+			}))
+			defer testServer.Close()
+
+			client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+			Ok(t, err)
+			closed, err := client.PullIsClosed(models.Repo{
+				FullName:          "owner/repo",
+				Owner:             "owner",
+				Name:              "repo",
+				SanitizedCloneURL: fmt.Sprintf("%s/scm/ow/repo.git", testServer.URL),
+			}, models.PullRequest{Num: 1})
+			Ok(t, err)
+			Equals(t, state != "OPEN", closed)
+		})
+	}
+}
+
+func TestClient_IsTeamMember(t *testing.T) {
+	firstPage := `{"values": [{"name": "alice2"}], "isLastPage": false, "nextPageStart": 1}`
+	secondPage := `{"values": [{"name": "alice"}], "isLastPage": true}`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/rest/api/1.0/admin/groups/more-members?context=platform+team&filter=alice&start=0":
+			w.Write([]byte(firstPage)) // nolint: errcheck
+		case "/rest/api/1.0/admin/groups/more-members?context=platform+team&filter=alice&start=1":
+			w.Write([]byte(secondPage)) // nolint: errcheck
+		case "/rest/api/1.0/admin/groups/more-members?context=infra&filter=alice&start=0":
+			w.Write([]byte(`{"values": [{"name": "alice2"}], "isLastPage": true}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+
+	member, err := client.IsTeamMember(models.Repo{}, "platform team", "alice")
+	Ok(t, err)
+	Equals(t, true, member)
+
+	t.Log("the filter matches substrings so names must match exactly")
+	member, err = client.IsTeamMember(models.Repo{}, "infra", "alice")
+	Ok(t, err)
+	Equals(t, false, member)
+}
+
+func TestClient_GetUserPermission(t *testing.T) {
+	repoUsers, err := ioutil.ReadFile(filepath.Join("testdata", "repo-user-permissions.json"))
+	Ok(t, err)
+	projectGroups, err := ioutil.ReadFile(filepath.Join("testdata", "project-group-permissions.json"))
+	Ok(t, err)
+	empty := `{"values": [], "isLastPage": true}`
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/rest/api/1.0/projects/ow/repos/repo/permissions/users?filter=reader&start=0":
+			w.Write(repoUsers) // nolint: errcheck
+		case "/rest/api/1.0/projects/ow/repos/repo/permissions/groups?start=0",
+			"/rest/api/1.0/projects/ow/permissions/users?filter=reader&start=0",
+			"/rest/api/1.0/admin/groups/more-members?context=admins&filter=reader&start=0":
+			w.Write([]byte(empty)) // nolint: errcheck
+		case "/rest/api/1.0/projects/ow/permissions/groups?start=0":
+			w.Write(projectGroups) // nolint: errcheck
+		case "/rest/api/1.0/admin/groups/more-members?context=developers&filter=reader&start=0":
+			w.Write([]byte(`{"values": [{"name": "reader"}], "isLastPage": true}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+
+	// reader can read the repo directly but can write through the developers
+	// group's permission on the project.
+	permission, err := client.GetUserPermission(models.Repo{
+		FullName:          "owner/repo",
+		Owner:             "owner",
+		Name:              "repo",
+		SanitizedCloneURL: fmt.Sprintf("%s/scm/ow/repo.git", testServer.URL),
+	}, "reader")
+	Ok(t, err)
+	Equals(t, models.WritePermission, permission)
+}
+
+func TestClient_GetStatusChecks(t *testing.T) {
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/rest/build-status/1.0/commits/sha?start=0":
+			w.Write([]byte(`{"values": [{"key": "atlantis/plan", "state": "SUCCESSFUL"}, {"key": "tests", "state": "FAILED"}], "nextPageStart": 2, "isLastPage": false}`)) // nolint: errcheck
+		case "/rest/build-status/1.0/commits/sha?start=2":
+			w.Write([]byte(`{"values": [{"key": "lint", "state": "INPROGRESS"}], "isLastPage": true}`)) // nolint: errcheck
+		default:
+			t.Errorf("got unexpected request at %q", r.RequestURI)
+			http.Error(w, "not found", http.StatusNotFound)
+		}
+	}))
+	defer testServer.Close()
+
+	client, err := bitbucketserver.NewClient(http.DefaultClient, "user", "pass", testServer.URL, "runatlantis.io")
+	Ok(t, err)
+	checks, err := client.GetStatusChecks(models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, models.PullRequest{HeadCommit: "sha"})
+	Ok(t, err)
+	Equals(t, []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.SuccessCommitStatus},
+		{Name: "tests", Status: models.FailedCommitStatus},
+		{Name: "lint", Status: models.PendingCommitStatus},
+	}, checks)
+}
@@ -31,6 +31,10 @@ type PullRequest struct {
 	State     *string `json:"state,omitempty" validate:"required"`
 	Reviewers []struct {
 		Approved *bool `json:"approved,omitempty" validate:"required"`
+		User     *struct {
+			Name *string `json:"name,omitempty"`
+		} `json:"user,omitempty"`
+		LastReviewedCommit *string `json:"lastReviewedCommit,omitempty"`
 	} `json:"reviewers,omitempty" validate:"required"`
 }
 
@@ -75,3 +79,36 @@ type MergeStatus struct {
 	CanMerge   *bool `json:"canMerge,omitempty" validate:"required"`
 	Conflicted *bool `json:"conflicted,omitempty" validate:"required"`
 }
+
+type GroupMembers struct {
+	Values []struct {
+		Name *string `json:"name,omitempty"`
+	} `json:"values,omitempty" validate:"required"`
+	NextPageStart *int  `json:"nextPageStart,omitempty"`
+	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
+}
+
+type Permissions struct {
+	Values        []Permission `json:"values,omitempty" validate:"required"`
+	NextPageStart *int         `json:"nextPageStart,omitempty"`
+	IsLastPage    *bool        `json:"isLastPage,omitempty" validate:"required"`
+}
+
+type Permission struct {
+	User *struct {
+		Name *string `json:"name,omitempty"`
+	} `json:"user,omitempty"`
+	Group *struct {
+		Name *string `json:"name,omitempty"`
+	} `json:"group,omitempty"`
+	Permission *string `json:"permission,omitempty"`
+}
+
+type BuildStatuses struct {
+	Values []struct {
+		Key   *string `json:"key,omitempty" validate:"required"`
+		State *string `json:"state,omitempty" validate:"required"`
+	} `json:"values,omitempty" validate:"required"`
+	NextPageStart *int  `json:"nextPageStart,omitempty"`
+	IsLastPage    *bool `json:"isLastPage,omitempty" validate:"required"`
+}
@@ -24,6 +24,8 @@ type Client interface {
 	GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error)
 	CreateComment(repo models.Repo, pullNum int, comment string) error
 	PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error)
+	// GetApprovals returns the current approvals of pull, at most one per user.
+	GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error)
 	PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error)
 	// UpdateStatus updates the commit status to state for pull. src is the
 	// source of this status. This should be relatively static across runs,
@@ -34,4 +36,13 @@ type Client interface {
 	// about this status.
 	UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error
 	MergePull(pull models.PullRequest) error
+	// IsTeamMember returns true if username is a member of team. Teams are
+	// GitHub teams, GitLab groups or Bitbucket groups. See each client for
+	// how team is formatted.
+	IsTeamMember(repo models.Repo, team string, username string) (bool, error)
+	// GetUserPermission returns username's level of access to repo.
+	GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error)
+	// GetStatusChecks returns the latest status of each check on pull's head
+	// commit, including Atlantis's own.
+	GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error)
 }
@@ -16,6 +16,7 @@ package vcs
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strings"
 
@@ -127,6 +128,55 @@ func (g *GithubClient) PullIsApproved(repo models.Repo, pull models.PullRequest)
 	return false, nil
 }
 
+// GetApprovals returns the users whose latest review of the pull request is an
+// approval, along with the commit they reviewed.
+func (g *GithubClient) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	// Reviews are listed oldest first so later reviews by the same user
+	// replace earlier ones. Comments don't change whether a user approved.
+	var users []string
+	latest := make(map[string]*github.PullRequestReview)
+	nextPage := 0
+	for {
+		opts := github.ListOptions{
+			PerPage: 100,
+		}
+		if nextPage != 0 {
+			opts.Page = nextPage
+		}
+		reviews, resp, err := g.client.PullRequests.ListReviews(g.ctx, repo.Owner, repo.Name, pull.Num, &opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting reviews")
+		}
+		for _, review := range reviews {
+			switch review.GetState() {
+			case "APPROVED", "CHANGES_REQUESTED", "DISMISSED":
+			default:
+				continue
+			}
+			username := review.GetUser().GetLogin()
+			if _, ok := latest[username]; !ok {
+				users = append(users, username)
+			}
+			latest[username] = review
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+
+	var approvals []models.Approval
+	for _, username := range users {
+		if review := latest[username]; review.GetState() == "APPROVED" {
+			approvals = append(approvals, models.Approval{
+				Username:  username,
+				CommitSHA: review.GetCommitID(),
+			})
+		}
+	}
+	return approvals, nil
+}
+
 // PullIsMergeable returns true if the pull request is mergeable.
 func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
 	githubPR, err := g.GetPullRequest(repo, pull.Num)
@@ -149,6 +199,13 @@ func (g *GithubClient) PullIsMergeable(repo models.Repo, pull models.PullRequest
 	return true, nil
 }
 
+// CheckAuth returns an error if the client's credentials can't be used to
+// authenticate with GitHub.
+func (g *GithubClient) CheckAuth() error {
+	_, _, err := g.client.Users.Get(g.ctx, "")
+	return errors.Wrap(err, "getting authenticated user")
+}
+
 // GetPullRequest returns the pull request.
 func (g *GithubClient) GetPullRequest(repo models.Repo, num int) (*github.PullRequest, error) {
 	pull, _, err := g.client.PullRequests.Get(g.ctx, repo.Owner, repo.Name, num)
@@ -219,3 +276,123 @@ func (g *GithubClient) MergePull(pull models.PullRequest) error {
 	}
 	return nil
 }
+
+// IsTeamMember returns true if username is an active member of team. team is
+// either "org/team-slug" or "team-slug", in which case the org is the repo's
+// owner.
+func (g *GithubClient) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	org, slug := repo.Owner, team
+	if i := strings.Index(team, "/"); i != -1 {
+		org, slug = team[:i], team[i+1:]
+	}
+	req, err := g.client.NewRequest("GET", fmt.Sprintf("orgs/%s/teams/%s/memberships/%s", org, slug, username), nil)
+	if err != nil {
+		return false, err
+	}
+	var membership github.Membership
+	resp, err := g.client.Do(g.ctx, req, &membership)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// GitHub returns a 404 if the user isn't a member.
+		return false, nil
+	}
+	if err != nil {
+		return false, errors.Wrapf(err, "getting membership of %s in team %s/%s", username, org, slug)
+	}
+	return membership.GetState() == "active", nil
+}
+
+// GetUserPermission returns username's permission on repo. Users that aren't
+// collaborators have no permission, even on public repos.
+func (g *GithubClient) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	level, resp, err := g.client.Repositories.GetPermissionLevel(g.ctx, repo.Owner, repo.Name, username)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		// GitHub returns a 404 if the user doesn't exist.
+		return models.NoPermission, nil
+	}
+	if err != nil {
+		return models.NoPermission, errors.Wrapf(err, "getting permission of %s", username)
+	}
+	switch level.GetPermission() {
+	case "admin":
+		return models.AdminPermission, nil
+	case "write":
+		return models.WritePermission, nil
+	case "read":
+		return models.ReadPermission, nil
+	}
+	return models.NoPermission, nil
+}
+
+// GetStatusChecks returns the latest status of each context and the latest
+// run of each check on pull's head commit.
+func (g *GithubClient) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	var checks []models.StatusCheck
+	nextPage := 0
+	for {
+		opts := github.ListOptions{
+			PerPage: 100,
+		}
+		if nextPage != 0 {
+			opts.Page = nextPage
+		}
+		combined, resp, err := g.client.Repositories.GetCombinedStatus(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, &opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting commit statuses")
+		}
+		for _, status := range combined.Statuses {
+			checks = append(checks, models.StatusCheck{
+				Name:   status.GetContext(),
+				Status: githubStatus(status.GetState()),
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+
+	nextPage = 0
+	for {
+		opts := github.ListCheckRunsOptions{
+			Filter: github.String("latest"),
+			ListOptions: github.ListOptions{
+				PerPage: 100,
+			},
+		}
+		if nextPage != 0 {
+			opts.Page = nextPage
+		}
+		results, resp, err := g.client.Checks.ListCheckRunsForRef(g.ctx, repo.Owner, repo.Name, pull.HeadCommit, &opts)
+		if err != nil {
+			return nil, errors.Wrap(err, "getting check runs")
+		}
+		for _, run := range results.CheckRuns {
+			status := models.PendingCommitStatus
+			if run.GetStatus() == "completed" {
+				status = githubStatus(run.GetConclusion())
+			}
+			checks = append(checks, models.StatusCheck{
+				Name:   run.GetName(),
+				Status: status,
+			})
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		nextPage = resp.NextPage
+	}
+	return checks, nil
+}
+
+// githubStatus converts a status's state or a completed check run's
+// conclusion to a commit status. Neutral and skipped check runs don't block
+// merges on GitHub so they count as successes.
+func githubStatus(state string) models.CommitStatus {
+	switch state {
+	case "success", "neutral", "skipped":
+		return models.SuccessCommitStatus
+	case "pending":
+		return models.PendingCommitStatus
+	}
+	return models.FailedCommitStatus
+}
@@ -195,6 +195,162 @@ func TestGithubClient_UpdateStatus(t *testing.T) {
 	}
 }
 
+// GetApprovals should page through reviews and use each user's latest review.
+func TestGithubClient_GetApprovals(t *testing.T) {
+	firstPage := `[
+  {"id": 1, "user": {"login": "approver"}, "state": "APPROVED", "commit_id": "sha1"},
+  {"id": 2, "user": {"login": "changed-mind"}, "state": "APPROVED", "commit_id": "sha1"},
+  {"id": 3, "user": {"login": "commenter"}, "state": "COMMENTED", "commit_id": "sha1"}
+]`
+	secondPage := `[
+  {"id": 4, "user": {"login": "changed-mind"}, "state": "CHANGES_REQUESTED", "commit_id": "sha2"},
+  {"id": 5, "user": {"login": "approver"}, "state": "COMMENTED", "commit_id": "sha2"},
+  {"id": 6, "user": {"login": "late-approver"}, "state": "APPROVED", "commit_id": "sha2"}
+]`
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/repos/owner/repo/pulls/1/reviews?per_page=100":
+				w.Header().Add("Link", `<https://api.github.com/resource?page=2>; rel="next",
+      <https://api.github.com/resource?page=2>; rel="last"`)
+				w.Write([]byte(firstPage)) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/pulls/1/reviews?page=2&per_page=100":
+				w.Write([]byte(secondPage)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	approvals, err := client.GetApprovals(models.Repo{
+		FullName: "owner/repo",
+		Owner:    "owner",
+		Name:     "repo",
+	}, models.PullRequest{
+		Num: 1,
+	})
+	Ok(t, err)
+	Equals(t, []models.Approval{
+		{Username: "approver", CommitSHA: "sha1"},
+		{Username: "late-approver", CommitSHA: "sha2"},
+	}, approvals)
+}
+
+func TestGithubClient_IsTeamMember(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v3/orgs/owner/teams/platform/memberships/alice":
+				w.Write([]byte(`{"role": "member", "state": "active"}`)) // nolint: errcheck
+			case "/api/v3/orgs/other-org/teams/platform/memberships/bob":
+				w.Write([]byte(`{"role": "member", "state": "pending"}`)) // nolint: errcheck
+			case "/api/v3/orgs/owner/teams/platform/memberships/carol":
+				http.Error(w, `{"message": "Not Found"}`, http.StatusNotFound)
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+	Ok(t, err)
+	defer disableSSLVerification()()
+	repo := models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}
+
+	member, err := client.IsTeamMember(repo, "platform", "alice")
+	Ok(t, err)
+	Equals(t, true, member)
+
+	t.Log("pending members aren't members yet")
+	member, err = client.IsTeamMember(repo, "other-org/platform", "bob")
+	Ok(t, err)
+	Equals(t, false, member)
+
+	member, err = client.IsTeamMember(repo, "platform", "carol")
+	Ok(t, err)
+	Equals(t, false, member)
+}
+
+func TestGithubClient_GetUserPermission(t *testing.T) {
+	jsBytes, err := ioutil.ReadFile("fixtures/github-collaborator-permission.json")
+	Ok(t, err)
+	cases := []struct {
+		permission    string
+		expPermission models.PermissionLevel
+	}{
+		{"admin", models.AdminPermission},
+		{"write", models.WritePermission},
+		{"read", models.ReadPermission},
+		{"none", models.NoPermission},
+	}
+	for _, c := range cases {
+		t.Run(c.permission, func(t *testing.T) {
+			response := strings.Replace(string(jsBytes), `"permission": "write"`, fmt.Sprintf(`"permission": %q`, c.permission), 1)
+			testServer := httptest.NewTLSServer(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.RequestURI {
+					case "/api/v3/repos/owner/repo/collaborators/octocat/permission":
+						w.Write([]byte(response)) // nolint: errcheck
+					default:
+						t.Errorf("got unexpected request at %q", r.RequestURI)
+						http.Error(w, "not found", http.StatusNotFound)
+					}
+				}))
+
+			testServerURL, err := url.Parse(testServer.URL)
+			Ok(t, err)
+			client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+			Ok(t, err)
+			defer disableSSLVerification()()
+
+			permission, err := client.GetUserPermission(models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, "octocat")
+			Ok(t, err)
+			Equals(t, c.expPermission, permission)
+		})
+	}
+}
+
+func TestGithubClient_GetStatusChecks(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v3/repos/owner/repo/commits/sha/status":
+				w.Write([]byte(`{"state": "failure", "statuses": [{"context": "atlantis/plan", "state": "success"}, {"context": "ci/test", "state": "error"}, {"context": "ci/lint", "state": "pending"}]}`)) // nolint: errcheck
+			case "/api/v3/repos/owner/repo/commits/sha/check-runs":
+				Equals(t, "latest", r.URL.Query().Get("filter"))
+				w.Write([]byte(`{"total_count": 3, "check_runs": [{"name": "tflint", "status": "completed", "conclusion": "success"}, {"name": "tfsec", "status": "in_progress"}, {"name": "docs", "status": "completed", "conclusion": "neutral"}]}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	checks, err := client.GetStatusChecks(models.Repo{FullName: "owner/repo", Owner: "owner", Name: "repo"}, models.PullRequest{HeadCommit: "sha"})
+	Ok(t, err)
+	Equals(t, []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.SuccessCommitStatus},
+		{Name: "ci/test", Status: models.FailedCommitStatus},
+		{Name: "ci/lint", Status: models.PendingCommitStatus},
+		{Name: "tflint", Status: models.SuccessCommitStatus},
+		{Name: "tfsec", Status: models.PendingCommitStatus},
+		{Name: "docs", Status: models.SuccessCommitStatus},
+	}, checks)
+}
+
 func TestGithubClient_PullIsMergeable(t *testing.T) {
 	cases := []struct {
 		state        string
@@ -504,3 +660,28 @@ func disableSSLVerification() func() {
 		http.DefaultTransport.(*http.Transport).TLSClientConfig = orig
 	}
 }
+
+func TestGithubClient_CheckAuth(t *testing.T) {
+	testServer := httptest.NewTLSServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Equals(t, "/api/v3/user", r.RequestURI)
+			if user, pass, _ := r.BasicAuth(); user != "user" || pass != "pass" {
+				http.Error(w, `{"message": "Bad credentials"}`, http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(`{"login": "user"}`)) // nolint: errcheck
+		}))
+	defer testServer.Close()
+	testServerURL, err := url.Parse(testServer.URL)
+	Ok(t, err)
+	defer disableSSLVerification()()
+
+	client, err := vcs.NewGithubClient(testServerURL.Host, "user", "pass")
+	Ok(t, err)
+	Ok(t, client.CheckAuth())
+
+	t.Log("should error if the credentials are invalid")
+	client, err = vcs.NewGithubClient(testServerURL.Host, "user", "wrong")
+	Ok(t, err)
+	ErrContains(t, "getting authenticated user", client.CheckAuth())
+}
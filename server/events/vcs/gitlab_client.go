@@ -151,6 +151,21 @@ func (g *GitlabClient) PullIsApproved(repo models.Repo, pull models.PullRequest)
 	return true, nil
 }
 
+// GetApprovals returns the users that approved the merge request. GitLab
+// doesn't say which commit was approved. To remove approvals when new commits
+// are pushed, enable that in the project's merge request approval settings.
+func (g *GitlabClient) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	approvals, _, err := g.Client.MergeRequests.GetMergeRequestApprovals(repo.FullName, pull.Num)
+	if err != nil {
+		return nil, err
+	}
+	var result []models.Approval
+	for _, a := range approvals.ApprovedBy {
+		result = append(result, models.Approval{Username: a.User.Username})
+	}
+	return result, nil
+}
+
 // PullIsMergeable returns true if the merge request can be merged.
 // In GitLab, there isn't a single field that tells us if the pull request is
 // mergeable so for now we check the merge_status and approvals_before_merge
@@ -212,6 +227,13 @@ func (g *GitlabClient) MergePull(pull models.PullRequest) error {
 	return errors.Wrap(err, "unable to merge merge request, it may not be in a mergeable state")
 }
 
+// CheckAuth returns an error if the client's token can't be used to
+// authenticate with GitLab.
+func (g *GitlabClient) CheckAuth() error {
+	_, _, err := g.Client.Users.CurrentUser()
+	return errors.Wrap(err, "getting current user")
+}
+
 // GetVersion returns the version of the Gitlab server this client is using.
 func (g *GitlabClient) GetVersion() (*version.Version, error) {
 	req, err := g.Client.NewRequest("GET", "/version", nil, nil)
@@ -254,3 +276,90 @@ func MustConstraint(constraint string) version.Constraints {
 	}
 	return c
 }
+
+// IsTeamMember returns true if username is an active member of the group
+// team, including through a parent group. team is the group's full path, ex.
+// "group/subgroup".
+func (g *GitlabClient) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	nextPage := 1
+	for {
+		members, resp, err := g.Client.Groups.ListAllGroupMembers(team, &gitlab.ListGroupMembersOptions{
+			ListOptions: gitlab.ListOptions{Page: nextPage, PerPage: 100},
+			Query:       gitlab.String(username),
+		})
+		if err != nil {
+			return false, errors.Wrapf(err, "listing members of group %s", team)
+		}
+		for _, m := range members {
+			// The query also matches users whose names contain username.
+			if m.Username == username && m.State == "active" {
+				return true, nil
+			}
+		}
+		if resp.NextPage == 0 {
+			return false, nil
+		}
+		nextPage = resp.NextPage
+	}
+}
+
+// GetUserPermission returns username's permission on repo, including access
+// inherited from its groups. Reporters and guests can read, developers can
+// write and maintainers and owners are admins.
+func (g *GitlabClient) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	var accessLevel gitlab.AccessLevelValue
+	nextPage := 1
+	for nextPage != 0 {
+		members, resp, err := g.Client.ProjectMembers.ListAllProjectMembers(repo.FullName, &gitlab.ListProjectMembersOptions{
+			ListOptions: gitlab.ListOptions{Page: nextPage, PerPage: 100},
+			Query:       gitlab.String(username),
+		})
+		if err != nil {
+			return models.NoPermission, errors.Wrapf(err, "listing members of %s", repo.FullName)
+		}
+		for _, m := range members {
+			// A user can be a member through more than one group so we use
+			// their highest access level.
+			if m.Username == username && m.State == "active" && m.AccessLevel > accessLevel {
+				accessLevel = m.AccessLevel
+			}
+		}
+		nextPage = resp.NextPage
+	}
+	switch {
+	case accessLevel >= gitlab.MaintainerPermissions:
+		return models.AdminPermission, nil
+	case accessLevel >= gitlab.DeveloperPermissions:
+		return models.WritePermission, nil
+	case accessLevel >= gitlab.GuestPermissions:
+		return models.ReadPermission, nil
+	}
+	return models.NoPermission, nil
+}
+
+// GetStatusChecks returns the latest status of each job in the head commit's
+// pipelines and of each external status, ex. from Atlantis, on the commit.
+func (g *GitlabClient) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	var checks []models.StatusCheck
+	nextPage := 1
+	for nextPage != 0 {
+		statuses, resp, err := g.Client.Commits.GetCommitStatuses(repo.FullName, pull.HeadCommit, &gitlab.GetCommitStatusesOptions{
+			ListOptions: gitlab.ListOptions{Page: nextPage, PerPage: 100},
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "getting statuses of commit %s", pull.HeadCommit)
+		}
+		for _, s := range statuses {
+			status := models.PendingCommitStatus
+			switch s.Status {
+			case "success", "skipped":
+				status = models.SuccessCommitStatus
+			case "failed", "canceled":
+				status = models.FailedCommitStatus
+			}
+			checks = append(checks, models.StatusCheck{Name: s.Name, Status: status})
+		}
+		nextPage = resp.NextPage
+	}
+	return checks, nil
+}
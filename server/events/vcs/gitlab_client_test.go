@@ -170,6 +170,123 @@ func TestGitlabClient_MergePull(t *testing.T) {
 	}
 }
 
+func TestGitlabClient_GetApprovals(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.RequestURI {
+			case "/api/v4/projects/runatlantis%2Fatlantis/merge_requests/1/approvals":
+				w.Write([]byte(`{"id": 1, "approvals_left": 0, "approved_by": [{"user": {"username": "alice"}}, {"user": {"username": "bob"}}]}`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient := gitlab.NewClient(nil, "token")
+	Ok(t, internalClient.SetBaseURL(testServer.URL))
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	approvals, err := client.GetApprovals(models.Repo{FullName: "runatlantis/atlantis"}, models.PullRequest{Num: 1})
+	Ok(t, err)
+	Equals(t, []models.Approval{{Username: "alice"}, {Username: "bob"}}, approvals)
+}
+
+func TestGitlabClient_IsTeamMember(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v4/groups/platform/members/all":
+				// The query matches on substrings so alice2 is returned too.
+				Equals(t, "alice", r.URL.Query().Get("query"))
+				w.Write([]byte(`[{"id": 1, "username": "alice2", "state": "active"}, {"id": 2, "username": "alice", "state": "active"}]`)) // nolint: errcheck
+			case "/api/v4/groups/infra/members/all":
+				w.Write([]byte(`[{"id": 2, "username": "alice2", "state": "active"}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient := gitlab.NewClient(nil, "token")
+	Ok(t, internalClient.SetBaseURL(testServer.URL))
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	member, err := client.IsTeamMember(models.Repo{}, "platform", "alice")
+	Ok(t, err)
+	Equals(t, true, member)
+
+	member, err = client.IsTeamMember(models.Repo{}, "infra", "alice")
+	Ok(t, err)
+	Equals(t, false, member)
+}
+
+func TestGitlabClient_GetUserPermission(t *testing.T) {
+	members, err := ioutil.ReadFile("fixtures/gitlab-project-members.json")
+	Ok(t, err)
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v4/projects/runatlantis/atlantis/members/all":
+				w.Write(members) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient := gitlab.NewClient(nil, "token")
+	Ok(t, internalClient.SetBaseURL(testServer.URL))
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	t.Log("the highest access level of the user with the exact username is used")
+	permission, err := client.GetUserPermission(models.Repo{FullName: "runatlantis/atlantis"}, "raymond_smith")
+	Ok(t, err)
+	Equals(t, models.WritePermission, permission)
+
+	permission, err = client.GetUserPermission(models.Repo{FullName: "runatlantis/atlantis"}, "raymond")
+	Ok(t, err)
+	Equals(t, models.NoPermission, permission)
+}
+
+func TestGitlabClient_GetStatusChecks(t *testing.T) {
+	testServer := httptest.NewServer(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/v4/projects/runatlantis/atlantis/repository/commits/sha/statuses":
+				w.Write([]byte(`[{"name": "atlantis/plan", "status": "success"}, {"name": "test", "status": "failed"}, {"name": "lint", "status": "running"}, {"name": "docs", "status": "skipped"}, {"name": "deploy", "status": "manual"}]`)) // nolint: errcheck
+			default:
+				t.Errorf("got unexpected request at %q", r.RequestURI)
+				http.Error(w, "not found", http.StatusNotFound)
+			}
+		}))
+
+	internalClient := gitlab.NewClient(nil, "token")
+	Ok(t, internalClient.SetBaseURL(testServer.URL))
+	client := &GitlabClient{
+		Client:  internalClient,
+		Version: nil,
+	}
+
+	checks, err := client.GetStatusChecks(models.Repo{FullName: "runatlantis/atlantis"}, models.PullRequest{HeadCommit: "sha"})
+	Ok(t, err)
+	Equals(t, []models.StatusCheck{
+		{Name: "atlantis/plan", Status: models.SuccessCommitStatus},
+		{Name: "test", Status: models.FailedCommitStatus},
+		{Name: "lint", Status: models.PendingCommitStatus},
+		{Name: "docs", Status: models.SuccessCommitStatus},
+		{Name: "deploy", Status: models.PendingCommitStatus},
+	}, checks)
+}
+
 func TestGitlabClient_UpdateStatus(t *testing.T) {
 	cases := []struct {
 		status   models.CommitStatus
@@ -0,0 +1,105 @@
+package vcs
+
+import (
+	"os/exec"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/models"
+)
+
+// LocalClient is used to run Atlantis commands on a local git repo without a
+// VCS host, ex. by atlantis run. The pull request is the difference between
+// its base branch and head branch in RepoDir.
+type LocalClient struct {
+	// RepoDir is the path to the local repo.
+	RepoDir string
+	// Approvers are the users that the pull request is treated as approved by.
+	Approvers []string
+	// Comments are the comments that would have been created, in order.
+	Comments []string
+}
+
+// GetModifiedFiles returns the files changed on pull's head branch since it
+// branched off of the base branch.
+func (l *LocalClient) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
+	mergeBase, err := l.git("merge-base", pull.BaseBranch, pull.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	out, err := l.git("diff", "--name-only", mergeBase, pull.HeadBranch)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, f := range strings.Split(out, "\n") {
+		if f != "" {
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+// CreateComment records comment in Comments.
+func (l *LocalClient) CreateComment(repo models.Repo, pullNum int, comment string) error {
+	l.Comments = append(l.Comments, comment)
+	return nil
+}
+
+// PullIsApproved returns true if there are any Approvers.
+func (l *LocalClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
+	return len(l.Approvers) > 0, nil
+}
+
+// GetApprovals returns an approval of pull's head commit from each of the
+// Approvers.
+func (l *LocalClient) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	var approvals []models.Approval
+	for _, a := range l.Approvers {
+		approvals = append(approvals, models.Approval{Username: a, CommitSHA: pull.HeadCommit})
+	}
+	return approvals, nil
+}
+
+// PullIsMergeable always returns true.
+func (l *LocalClient) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
+	return true, nil
+}
+
+// UpdateStatus does nothing since there's no commit status to update.
+func (l *LocalClient) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
+	return nil
+}
+
+// MergePull returns an error because local pull requests can't be merged.
+func (l *LocalClient) MergePull(pull models.PullRequest) error {
+	return errors.New("local pull requests can't be merged")
+}
+
+// IsTeamMember always returns false since there are no teams.
+func (l *LocalClient) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	return false, nil
+}
+
+// GetUserPermission returns admin permission since the user owns the local
+// repo.
+func (l *LocalClient) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	return models.AdminPermission, nil
+}
+
+// GetStatusChecks returns no status checks since nothing runs on local
+// commits.
+func (l *LocalClient) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	return nil, nil
+}
+
+// git runs a git command in RepoDir and returns its trimmed output.
+func (l *LocalClient) git(args ...string) (string, error) {
+	cmd := exec.Command("git", args...) // nolint: gosec
+	cmd.Dir = l.RepoDir
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return "", errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), string(out))
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,71 @@
+package vcs_test
+
+import (
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/events/vcs"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestLocalClient_GetModifiedFiles(t *testing.T) {
+	repoDir, cleanup := TempDir(t)
+	defer cleanup()
+	runGit(t, repoDir, "init")
+	runGit(t, repoDir, "config", "--local", "user.email", "atlantisbot@runatlantis.io")
+	runGit(t, repoDir, "config", "--local", "user.name", "atlantisbot")
+	runGit(t, repoDir, "checkout", "-b", "master")
+	writeFile(t, filepath.Join(repoDir, "main.tf"))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "initial commit")
+	runGit(t, repoDir, "checkout", "-b", "branch")
+	writeFile(t, filepath.Join(repoDir, "staging.tf"))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "branch commit")
+
+	t.Log("changes on the base branch after the head branch was created are ignored")
+	runGit(t, repoDir, "checkout", "master")
+	writeFile(t, filepath.Join(repoDir, "prod.tf"))
+	runGit(t, repoDir, "add", ".")
+	runGit(t, repoDir, "commit", "-m", "master commit")
+
+	client := &vcs.LocalClient{RepoDir: repoDir}
+	files, err := client.GetModifiedFiles(models.Repo{}, models.PullRequest{BaseBranch: "master", HeadBranch: "branch"})
+	Ok(t, err)
+	Equals(t, []string{"staging.tf"}, files)
+
+	_, err = client.GetModifiedFiles(models.Repo{}, models.PullRequest{BaseBranch: "master", HeadBranch: "missing"})
+	ErrContains(t, "running git merge-base master missing", err)
+}
+
+func TestLocalClient_Approvals(t *testing.T) {
+	client := &vcs.LocalClient{}
+	approved, err := client.PullIsApproved(models.Repo{}, models.PullRequest{})
+	Ok(t, err)
+	Equals(t, false, approved)
+
+	client.Approvers = []string{"alice"}
+	approved, err = client.PullIsApproved(models.Repo{}, models.PullRequest{})
+	Ok(t, err)
+	Equals(t, true, approved)
+	approvals, err := client.GetApprovals(models.Repo{}, models.PullRequest{HeadCommit: "sha"})
+	Ok(t, err)
+	Equals(t, []models.Approval{{Username: "alice", CommitSHA: "sha"}}, approvals)
+}
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput()
+	Assert(t, err == nil, "err running git %s: %s", strings.Join(args, " "), out)
+}
+
+func writeFile(t *testing.T, path string) {
+	t.Helper()
+	Ok(t, ioutil.WriteFile(path, nil, 0600))
+}
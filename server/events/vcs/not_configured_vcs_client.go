@@ -35,6 +35,9 @@ func (a *NotConfiguredVCSClient) CreateComment(repo models.Repo, pullNum int, co
 func (a *NotConfiguredVCSClient) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
 	return false, a.err()
 }
+func (a *NotConfiguredVCSClient) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	return nil, a.err()
+}
 func (a *NotConfiguredVCSClient) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
 	return false, a.err()
 }
@@ -44,6 +47,15 @@ func (a *NotConfiguredVCSClient) UpdateStatus(repo models.Repo, pull models.Pull
 func (a *NotConfiguredVCSClient) MergePull(pull models.PullRequest) error {
 	return a.err()
 }
+func (a *NotConfiguredVCSClient) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	return false, a.err()
+}
+func (a *NotConfiguredVCSClient) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	return models.NoPermission, a.err()
+}
+func (a *NotConfiguredVCSClient) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	return nil, a.err()
+}
 func (a *NotConfiguredVCSClient) err() error {
 	return fmt.Errorf("atlantis was not configured to support repos from %s", a.Host.String())
 }
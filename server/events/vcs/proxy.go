@@ -14,7 +14,10 @@
 package vcs
 
 import (
+	"time"
+
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 // ClientProxy proxies calls to the correct VCS client depending on which
@@ -25,7 +28,7 @@ type ClientProxy struct {
 	clients map[models.VCSHostType]Client
 }
 
-func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClient Client, bitbucketServerClient Client) *ClientProxy {
+func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClient Client, bitbucketServerClient Client, azureDevopsClient Client) *ClientProxy {
 	if githubClient == nil {
 		githubClient = &NotConfiguredVCSClient{}
 	}
@@ -38,36 +41,95 @@ func NewClientProxy(githubClient Client, gitlabClient Client, bitbucketCloudClie
 	if bitbucketServerClient == nil {
 		bitbucketServerClient = &NotConfiguredVCSClient{}
 	}
+	if azureDevopsClient == nil {
+		azureDevopsClient = &NotConfiguredVCSClient{}
+	}
 	return &ClientProxy{
 		clients: map[models.VCSHostType]Client{
 			models.Github:          githubClient,
 			models.Gitlab:          gitlabClient,
 			models.BitbucketCloud:  bitbucketCloudClient,
 			models.BitbucketServer: bitbucketServerClient,
+			models.AzureDevops:     azureDevopsClient,
 		},
 	}
 }
 
 func (d *ClientProxy) GetModifiedFiles(repo models.Repo, pull models.PullRequest) ([]string, error) {
-	return d.clients[repo.VCSHost.Type].GetModifiedFiles(repo, pull)
+	start := time.Now()
+	files, err := d.clients[repo.VCSHost.Type].GetModifiedFiles(repo, pull)
+	observe(repo.VCSHost.Type, "GetModifiedFiles", start, err)
+	return files, err
 }
 
 func (d *ClientProxy) CreateComment(repo models.Repo, pullNum int, comment string) error {
-	return d.clients[repo.VCSHost.Type].CreateComment(repo, pullNum, comment)
+	start := time.Now()
+	err := d.clients[repo.VCSHost.Type].CreateComment(repo, pullNum, comment)
+	observe(repo.VCSHost.Type, "CreateComment", start, err)
+	return err
 }
 
 func (d *ClientProxy) PullIsApproved(repo models.Repo, pull models.PullRequest) (bool, error) {
-	return d.clients[repo.VCSHost.Type].PullIsApproved(repo, pull)
+	start := time.Now()
+	approved, err := d.clients[repo.VCSHost.Type].PullIsApproved(repo, pull)
+	observe(repo.VCSHost.Type, "PullIsApproved", start, err)
+	return approved, err
+}
+
+func (d *ClientProxy) GetApprovals(repo models.Repo, pull models.PullRequest) ([]models.Approval, error) {
+	start := time.Now()
+	approvals, err := d.clients[repo.VCSHost.Type].GetApprovals(repo, pull)
+	observe(repo.VCSHost.Type, "GetApprovals", start, err)
+	return approvals, err
 }
 
 func (d *ClientProxy) PullIsMergeable(repo models.Repo, pull models.PullRequest) (bool, error) {
-	return d.clients[repo.VCSHost.Type].PullIsMergeable(repo, pull)
+	start := time.Now()
+	mergeable, err := d.clients[repo.VCSHost.Type].PullIsMergeable(repo, pull)
+	observe(repo.VCSHost.Type, "PullIsMergeable", start, err)
+	return mergeable, err
 }
 
 func (d *ClientProxy) UpdateStatus(repo models.Repo, pull models.PullRequest, state models.CommitStatus, src string, description string, url string) error {
-	return d.clients[repo.VCSHost.Type].UpdateStatus(repo, pull, state, src, description, url)
+	start := time.Now()
+	err := d.clients[repo.VCSHost.Type].UpdateStatus(repo, pull, state, src, description, url)
+	observe(repo.VCSHost.Type, "UpdateStatus", start, err)
+	return err
 }
 
 func (d *ClientProxy) MergePull(pull models.PullRequest) error {
-	return d.clients[pull.BaseRepo.VCSHost.Type].MergePull(pull)
+	start := time.Now()
+	err := d.clients[pull.BaseRepo.VCSHost.Type].MergePull(pull)
+	observe(pull.BaseRepo.VCSHost.Type, "MergePull", start, err)
+	return err
+}
+
+func (d *ClientProxy) IsTeamMember(repo models.Repo, team string, username string) (bool, error) {
+	start := time.Now()
+	member, err := d.clients[repo.VCSHost.Type].IsTeamMember(repo, team, username)
+	observe(repo.VCSHost.Type, "IsTeamMember", start, err)
+	return member, err
+}
+
+func (d *ClientProxy) GetUserPermission(repo models.Repo, username string) (models.PermissionLevel, error) {
+	start := time.Now()
+	permission, err := d.clients[repo.VCSHost.Type].GetUserPermission(repo, username)
+	observe(repo.VCSHost.Type, "GetUserPermission", start, err)
+	return permission, err
+}
+
+func (d *ClientProxy) GetStatusChecks(repo models.Repo, pull models.PullRequest) ([]models.StatusCheck, error) {
+	start := time.Now()
+	checks, err := d.clients[repo.VCSHost.Type].GetStatusChecks(repo, pull)
+	observe(repo.VCSHost.Type, "GetStatusChecks", start, err)
+	return checks, err
+}
+
+// observe records the latency of a call to method that started at start and
+// whether it errored.
+func observe(hostType models.VCSHostType, method string, start time.Time, err error) {
+	metrics.VCSRequestDuration.WithLabelValues(hostType.String(), method).Observe(time.Since(start).Seconds())
+	if err != nil {
+		metrics.VCSRequestErrors.WithLabelValues(hostType.String(), method).Inc()
+	}
 }
@@ -16,6 +16,7 @@ package webhooks
 import (
 	"fmt"
 	"regexp"
+	"sync"
 
 	"errors"
 
@@ -46,6 +47,10 @@ type ApplyResult struct {
 // MultiWebhookSender sends multiple webhooks for each one it's configured for.
 type MultiWebhookSender struct {
 	Webhooks []Sender
+
+	// mutex guards Webhooks so they can be replaced while the server is
+	// running.
+	mutex sync.RWMutex
 }
 
 type Config struct {
@@ -91,9 +96,39 @@ func NewMultiWebhookSender(configs []Config, client SlackClient) (*MultiWebhookS
 	}, nil
 }
 
+// Replace replaces w's webhooks with other's, ex. when the server's config is
+// reloaded.
+func (w *MultiWebhookSender) Replace(other *MultiWebhookSender) {
+	w.LockSettings()
+	defer w.UnlockSettings()
+	w.ReplaceLocked(other)
+}
+
+// LockSettings blocks Send until UnlockSettings is called so that the
+// webhooks can be replaced at the same time as other settings.
+func (w *MultiWebhookSender) LockSettings() {
+	w.mutex.Lock()
+}
+
+// UnlockSettings undoes LockSettings.
+func (w *MultiWebhookSender) UnlockSettings() {
+	w.mutex.Unlock()
+}
+
+// ReplaceLocked is like Replace but must be called between LockSettings and
+// UnlockSettings.
+func (w *MultiWebhookSender) ReplaceLocked(other *MultiWebhookSender) {
+	other.mutex.RLock()
+	defer other.mutex.RUnlock()
+	w.Webhooks = other.Webhooks
+}
+
 // Send sends the webhook using its Webhooks.
 func (w *MultiWebhookSender) Send(log *logging.SimpleLogger, result ApplyResult) error {
-	for _, w := range w.Webhooks {
+	w.mutex.RLock()
+	webhooks := w.Webhooks
+	w.mutex.RUnlock()
+	for _, w := range webhooks {
 		if err := w.Send(log, result); err != nil {
 			log.Warn("error sending slack webhook: %s", err)
 		}
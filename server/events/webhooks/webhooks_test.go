@@ -186,3 +186,22 @@ func TestSend_MultipleSuccess(t *testing.T) {
 		s.VerifyWasCalledOnce().Send(logger, result)
 	}
 }
+
+func TestSend_AfterReplace(t *testing.T) {
+	t.Log("Sending after the webhooks are replaced should only use the new webhooks")
+	RegisterMockTestingT(t)
+	oldSender := mocks.NewMockSender()
+	newSender := mocks.NewMockSender()
+	manager := webhooks.MultiWebhookSender{
+		Webhooks: []webhooks.Sender{oldSender},
+	}
+	manager.Replace(&webhooks.MultiWebhookSender{
+		Webhooks: []webhooks.Sender{newSender},
+	})
+	logger := logging.NewNoopLogger()
+	result := webhooks.ApplyResult{}
+	err := manager.Send(logger, result)
+	Ok(t, err)
+	oldSender.VerifyWasCalled(Never()).Send(logger, result)
+	newSender.VerifyWasCalledOnce().Send(logger, result)
+}
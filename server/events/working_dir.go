@@ -20,10 +20,12 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 const workingDirPrefix = "repos"
@@ -113,6 +115,9 @@ func (w *FileWorkspace) forceClone(log *logging.SimpleLogger,
 	headRepo models.Repo,
 	p models.PullRequest) (string, error) {
 
+	start := time.Now()
+	defer func() { metrics.CloneDuration.Observe(time.Since(start).Seconds()) }()
+
 	err := os.RemoveAll(cloneDir)
 	if err != nil {
 		return "", errors.Wrapf(err, "deleting dir %q before cloning", cloneDir)
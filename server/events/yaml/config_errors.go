@@ -0,0 +1,239 @@
+package yaml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+)
+
+// ConfigError is a single problem in an atlantis.yaml file.
+type ConfigError struct {
+	// Path is the keys leading to the problem, ex. ["projects", "0", "dir"].
+	// It's empty if the problem isn't with a specific key.
+	Path []string
+	// Line is the line of the file that the problem is on, starting at 1. It's
+	// 0 if the line isn't known.
+	Line int
+	Msg  string
+}
+
+func (c ConfigError) Error() string {
+	var prefix string
+	if c.Line > 0 {
+		prefix = fmt.Sprintf("line %d: ", c.Line)
+	}
+	if len(c.Path) > 0 {
+		prefix += c.pathString() + ": "
+	}
+	return prefix + c.Msg
+}
+
+// pathString formats the path like projects[0].dir.
+func (c ConfigError) pathString() string {
+	var s string
+	for _, key := range c.Path {
+		if _, err := strconv.Atoi(key); err == nil {
+			s += "[" + key + "]"
+			continue
+		}
+		if s != "" {
+			s += "."
+		}
+		s += key
+	}
+	return s
+}
+
+// yamlLineRegex matches the line numbers in errors from the YAML parser, ex.
+// "yaml: line 3: did not find expected key".
+var yamlLineRegex = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// unknownFieldRegex matches errors from the YAML parser about unknown fields.
+var unknownFieldRegex = regexp.MustCompile(`^field (\S+) not found in`)
+
+// ConfigErrors splits err, an error returned by ReadConfig when parsing
+// configData, into one error per problem and finds the line of configData that
+// each problem is on.
+func ConfigErrors(err error, configData []byte) []ConfigError {
+	cause := errors.Cause(err)
+	msg := strings.TrimPrefix(err.Error(), fmt.Sprintf("parsing %s: ", AtlantisYAMLFilename))
+
+	switch e := cause.(type) {
+	case *yaml.TypeError:
+		lines := newYAMLLines(configData)
+		var configErrs []ConfigError
+		for _, m := range e.Errors {
+			configErr := parseYAMLError(m)
+			// The YAML parser reports the line of the mapping that unknown
+			// fields are in so look for the field's own line.
+			if match := unknownFieldRegex.FindStringSubmatch(configErr.Msg); match != nil {
+				configErr.Line = lines.findKeyFrom(match[1], configErr.Line)
+			}
+			configErrs = append(configErrs, configErr)
+		}
+		return configErrs
+	case validation.Errors:
+		// Only split the errors if they weren't wrapped with more context
+		// that would be lost.
+		if msg == e.Error() {
+			lines := newYAMLLines(configData)
+			var configErrs []ConfigError
+			flattenValidationErrors(e, nil, lines, &configErrs)
+			return configErrs
+		}
+	}
+	return []ConfigError{parseYAMLError(msg)}
+}
+
+func parseYAMLError(msg string) ConfigError {
+	match := yamlLineRegex.FindStringSubmatch(msg)
+	if match == nil {
+		return ConfigError{Msg: msg}
+	}
+	line, _ := strconv.Atoi(match[1])
+	return ConfigError{Line: line, Msg: match[2]}
+}
+
+// flattenValidationErrors appends an error for each leaf of errs to configErrs,
+// sorted by key.
+func flattenValidationErrors(errs validation.Errors, path []string, lines yamlLines, configErrs *[]ConfigError) {
+	var keys []string
+	for k := range errs {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		// Sort indexes numerically so projects[2] comes before projects[10].
+		a, errA := strconv.Atoi(keys[i])
+		b, errB := strconv.Atoi(keys[j])
+		if errA == nil && errB == nil {
+			return a < b
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, k := range keys {
+		keyPath := append(append([]string{}, path...), k)
+		if nested, ok := errs[k].(validation.Errors); ok {
+			flattenValidationErrors(nested, keyPath, lines, configErrs)
+			continue
+		}
+		*configErrs = append(*configErrs, ConfigError{
+			Path: keyPath,
+			Line: lines.find(keyPath),
+			Msg:  errs[k].Error(),
+		})
+	}
+}
+
+// yamlLine is a line of a YAML file. Lines of sequence items, ex. "- dir: .",
+// are split into a line for the dash and a line for the item's content
+// indented past the dash.
+type yamlLine struct {
+	num    int
+	indent int
+	text   string
+	dash   bool
+}
+
+type yamlLines []yamlLine
+
+// newYAMLLines splits data into lines, skipping blank lines and comments.
+// It only understands block style YAML, which is what atlantis.yaml files
+// use in practice.
+func newYAMLLines(data []byte) yamlLines {
+	var lines yamlLines
+	for i, l := range strings.Split(string(data), "\n") {
+		text := strings.TrimLeft(l, " ")
+		indent := len(l) - len(text)
+		text = strings.TrimSpace(text)
+		for text == "-" || strings.HasPrefix(text, "- ") {
+			lines = append(lines, yamlLine{num: i + 1, indent: indent, dash: true})
+			rest := strings.TrimPrefix(text, "-")
+			text = strings.TrimLeft(rest, " ")
+			indent += 1 + len(rest) - len(text)
+		}
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		lines = append(lines, yamlLine{num: i + 1, indent: indent, text: text})
+	}
+	return lines
+}
+
+// find returns the line number of the key at path, where numeric keys are
+// sequence indexes. If the key doesn't exist, ex. because the error is that a
+// required key is missing, it returns the line of its deepest ancestor that
+// does. It returns 0 if no key on the path exists.
+func (y yamlLines) find(path []string) int {
+	start := 0
+	parentIndent := -1
+	parentIsKey := false
+	found := 0
+	for _, key := range path {
+		index, err := strconv.Atoi(key)
+		isIndex := err == nil
+
+		match := -1
+		childIndent := -1
+		items := 0
+		for i := start; i < len(y); i++ {
+			line := y[i]
+			// Sequences can be indented at the same level as their key.
+			sameLevelItem := isIndex && parentIsKey && line.dash && line.indent == parentIndent
+			if line.indent < parentIndent || (line.indent == parentIndent && !sameLevelItem) {
+				break
+			}
+			if childIndent == -1 {
+				childIndent = line.indent
+			}
+			if line.indent != childIndent {
+				continue
+			}
+			if isIndex && line.dash {
+				if items == index {
+					match = i
+					break
+				}
+				items++
+			}
+			if !isIndex && !line.dash && yamlKey(line.text) == key {
+				match = i
+				break
+			}
+		}
+		if match == -1 {
+			return found
+		}
+		found = y[match].num
+		parentIndent = y[match].indent
+		parentIsKey = !y[match].dash
+		start = match + 1
+	}
+	return found
+}
+
+// findKeyFrom returns the number of the first line at or after line that has
+// key. If there isn't one it returns line.
+func (y yamlLines) findKeyFrom(key string, line int) int {
+	for _, l := range y {
+		if l.num >= line && !l.dash && yamlKey(l.text) == key {
+			return l.num
+		}
+	}
+	return line
+}
+
+// yamlKey returns the key of a "key: value" line.
+func yamlKey(text string) string {
+	i := strings.Index(text, ":")
+	if i == -1 {
+		return ""
+	}
+	return strings.Trim(text[:i], `"'`)
+}
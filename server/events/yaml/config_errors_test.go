@@ -0,0 +1,81 @@
+package yaml_test
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/yaml"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestConfigErrors(t *testing.T) {
+	cases := []struct {
+		description string
+		input       string
+		expErrs     []string
+	}{
+		{
+			description: "yaml syntax error",
+			input:       "version: 2\nprojects:\n- dir: .\n workspace: [\n",
+			expErrs:     []string{"line 3: did not find expected key"},
+		},
+		{
+			description: "unknown keys",
+			input:       "version: 2\nprojects:\n- dir: .\n  unknown: true\n  other: true\n",
+			expErrs: []string{
+				"line 4: field unknown not found in struct raw.Project",
+				"line 5: field other not found in struct raw.Project",
+			},
+		},
+		{
+			description: "validation errors",
+			input: `version: 2
+projects:
+- dir: .
+  apply_requirements: [unsupported]
+# A comment.
+- name: missing-dir
+
+  workspace: staging
+workflows:
+  custom:
+    plan:
+      steps:
+      - init
+      - unsupported
+`,
+			expErrs: []string{
+				`line 4: projects[0].apply_requirements: "unsupported" not supported, only approved, mergeable, independently_approved, codeowners_approved and status_checks_passed are supported`,
+				"line 6: projects[1].dir: cannot be blank",
+				`line 14: workflows.custom.plan.steps[1]: "unsupported" is not a valid step type, maybe you omitted the 'run' key`,
+			},
+		},
+		{
+			description: "indented sequences",
+			input:       "version: 2\nprojects:\n  - dir: .\n  -   workspace: staging\n",
+			expErrs:     []string{"line 4: projects[1].dir: cannot be blank"},
+		},
+		{
+			description: "errors without lines",
+			input:       "version: 2\nprojects:\n- dir: .\n  workflow: missing\n",
+			expErrs:     []string{`workflow "missing" is not defined`},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			tmpDir, cleanup := TempDir(t)
+			defer cleanup()
+			Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(c.input), 0600))
+
+			r := yaml.ParserValidator{}
+			_, err := r.ReadConfig(tmpDir)
+			Assert(t, err != nil, "exp error")
+			var actErrs []string
+			for _, e := range yaml.ConfigErrors(err, []byte(c.input)) {
+				actErrs = append(actErrs, e.Error())
+			}
+			Equals(t, c.expErrs, actErrs)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/go-ozzo/ozzo-validation"
 	"github.com/pkg/errors"
@@ -23,6 +24,12 @@ type ParserValidator struct{}
 // of error: os.IsNotExist(error) but it's instead preferred to check with
 // HasConfigFile.
 func (p *ParserValidator) ReadConfig(repoDir string) (valid.Config, error) {
+	return p.ReadConfigWithServerWorkflows(repoDir, nil)
+}
+
+// ReadConfigWithServerWorkflows is like ReadConfig except projects can also
+// use serverWorkflows, the workflows defined in the server-side repo config.
+func (p *ParserValidator) ReadConfigWithServerWorkflows(repoDir string, serverWorkflows map[string]valid.Workflow) (valid.Config, error) {
 	configFile := p.configFilePath(repoDir)
 	configData, err := ioutil.ReadFile(configFile) // nolint: gosec
 
@@ -38,13 +45,34 @@ func (p *ParserValidator) ReadConfig(repoDir string) (valid.Config, error) {
 	}
 
 	// If the config file exists, parse it.
-	config, err := p.parseAndValidate(configData)
+	config, err := p.parseAndValidate(configData, repoDir, serverWorkflows)
 	if err != nil {
 		return valid.Config{}, errors.Wrapf(err, "parsing %s", AtlantisYAMLFilename)
 	}
 	return config, err
 }
 
+// ReadServerConfig returns the parsed and validated server-side repo config
+// at path.
+func (p *ParserValidator) ReadServerConfig(path string) (valid.ServerConfig, error) {
+	configData, err := ioutil.ReadFile(path) // nolint: gosec
+	if err != nil {
+		return valid.ServerConfig{}, errors.Wrapf(err, "unable to read %s", path)
+	}
+	var rawConfig raw.ServerConfig
+	if err := yaml.UnmarshalStrict(configData, &rawConfig); err != nil {
+		return valid.ServerConfig{}, errors.Wrapf(err, "parsing %s", path)
+	}
+
+	// Set ErrorTag to yaml so it uses the YAML field names in error messages.
+	validation.ErrorTag = "yaml"
+
+	if err := rawConfig.Validate(); err != nil {
+		return valid.ServerConfig{}, errors.Wrapf(err, "parsing %s", path)
+	}
+	return rawConfig.ToValid(), nil
+}
+
 func (p *ParserValidator) HasConfigFile(repoDir string) (bool, error) {
 	_, err := os.Stat(p.configFilePath(repoDir))
 	if os.IsNotExist(err) {
@@ -60,7 +88,7 @@ func (p *ParserValidator) configFilePath(repoDir string) string {
 	return filepath.Join(repoDir, AtlantisYAMLFilename)
 }
 
-func (p *ParserValidator) parseAndValidate(configData []byte) (valid.Config, error) {
+func (p *ParserValidator) parseAndValidate(configData []byte, repoDir string, serverWorkflows map[string]valid.Workflow) (valid.Config, error) {
 	var rawConfig raw.Config
 	if err := yaml.UnmarshalStrict(configData, &rawConfig); err != nil {
 		return valid.Config{}, err
@@ -73,8 +101,13 @@ func (p *ParserValidator) parseAndValidate(configData []byte) (valid.Config, err
 		return valid.Config{}, err
 	}
 
+	rawConfig, err := p.expandProjects(rawConfig, repoDir)
+	if err != nil {
+		return valid.Config{}, err
+	}
+
 	// Top level validation.
-	if err := p.validateWorkflows(rawConfig); err != nil {
+	if err := p.validateWorkflows(rawConfig, serverWorkflows); err != nil {
 		return valid.Config{}, err
 	}
 
@@ -86,6 +119,58 @@ func (p *ParserValidator) parseAndValidate(configData []byte) (valid.Config, err
 	return validConfig, nil
 }
 
+// expandProjects applies the project templates and replaces projects that use
+// dir_glob with a project for each directory in repoDir matching the glob.
+// Hidden directories aren't matched.
+func (p *ParserValidator) expandProjects(config raw.Config, repoDir string) (raw.Config, error) {
+	var projects []raw.Project
+	for _, project := range config.Projects {
+		if project.Template != nil {
+			project = project.ApplyTemplate(config.ProjectTemplates[*project.Template])
+		}
+		if project.DirGlob == nil {
+			projects = append(projects, project)
+			continue
+		}
+
+		glob := *project.DirGlob
+		matches, err := filepath.Glob(filepath.Join(repoDir, glob))
+		if err != nil {
+			return config, errors.Wrapf(err, "expanding dir_glob %q", glob)
+		}
+		for _, match := range matches {
+			info, err := os.Stat(match)
+			if err != nil {
+				return config, errors.Wrapf(err, "expanding dir_glob %q", glob)
+			}
+			if !info.IsDir() || strings.HasPrefix(info.Name(), ".") {
+				continue
+			}
+			relDir, err := filepath.Rel(repoDir, match)
+			if err != nil {
+				return config, errors.Wrapf(err, "expanding dir_glob %q", glob)
+			}
+			dir := filepath.ToSlash(relDir)
+			// Projects in other workspaces are named after their workspace
+			// too so the same dirs can be globbed once per workspace.
+			name := dir
+			if project.Workspace != nil && *project.Workspace != raw.DefaultWorkspace {
+				name = fmt.Sprintf("%s-%s", dir, *project.Workspace)
+			}
+			expanded := project
+			expanded.Dir = &dir
+			expanded.Name = &name
+			expanded.DirGlob = nil
+			if err := expanded.Validate(); err != nil {
+				return config, errors.Wrapf(err, "project for dir %q from dir_glob %q", dir, glob)
+			}
+			projects = append(projects, expanded)
+		}
+	}
+	config.Projects = projects
+	return config, nil
+}
+
 func (p *ParserValidator) validateProjectNames(config valid.Config) error {
 	// First, validate that all names are unique.
 	seen := make(map[string]bool)
@@ -123,16 +208,16 @@ func (p *ParserValidator) validateProjectNames(config valid.Config) error {
 	return nil
 }
 
-func (p *ParserValidator) validateWorkflows(config raw.Config) error {
+func (p *ParserValidator) validateWorkflows(config raw.Config, serverWorkflows map[string]valid.Workflow) error {
 	for _, project := range config.Projects {
-		if err := p.validateWorkflowExists(project, config.Workflows); err != nil {
+		if err := p.validateWorkflowExists(project, config.Workflows, serverWorkflows); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func (p *ParserValidator) validateWorkflowExists(project raw.Project, workflows map[string]raw.Workflow) error {
+func (p *ParserValidator) validateWorkflowExists(project raw.Project, workflows map[string]raw.Workflow, serverWorkflows map[string]valid.Workflow) error {
 	if project.Workflow == nil {
 		return nil
 	}
@@ -142,5 +227,8 @@ func (p *ParserValidator) validateWorkflowExists(project raw.Project, workflows
 			return nil
 		}
 	}
+	if _, ok := serverWorkflows[workflow]; ok {
+		return nil
+	}
 	return fmt.Errorf("workflow %q is not defined", workflow)
 }
@@ -695,3 +695,152 @@ workflows:
 // String is a helper routine that allocates a new string value
 // to store v and returns a pointer to it.
 func String(v string) *string { return &v }
+
+func TestReadConfigWithServerWorkflows(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	err := ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+projects:
+- dir: .
+  workflow: custom
+`), 0600)
+	Ok(t, err)
+
+	r := yaml.ParserValidator{}
+	_, err = r.ReadConfig(tmpDir)
+	ErrEquals(t, "parsing atlantis.yaml: workflow \"custom\" is not defined", err)
+
+	t.Log("projects can use the server's workflows")
+	act, err := r.ReadConfigWithServerWorkflows(tmpDir, map[string]valid.Workflow{"custom": {}})
+	Ok(t, err)
+	Equals(t, "custom", *act.Projects[0].Workflow)
+}
+
+func TestReadConfig_ProjectTemplatesAndDirGlobs(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	for _, dir := range []string{"envs/prod/services/api", "envs/prod/services/web", "envs/staging/services/api", "envs/staging/services/.terraform"} {
+		Ok(t, os.MkdirAll(filepath.Join(tmpDir, dir), 0700))
+	}
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "envs/prod/services/README.md"), nil, 0600))
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+project_templates:
+  service:
+    workflow: custom
+    terraform_version: v0.11.10
+    apply_requirements: [approved]
+projects:
+- dir_glob: envs/*/services/*
+  template: service
+- dir: modules
+  template: service
+  apply_requirements: [mergeable]
+workflows:
+  custom: ~
+`), 0600))
+
+	r := yaml.ParserValidator{}
+	act, err := r.ReadConfig(tmpDir)
+	Ok(t, err)
+	var names, dirs []string
+	for _, p := range act.Projects {
+		if p.Name != nil {
+			names = append(names, *p.Name)
+		}
+		dirs = append(dirs, p.Dir)
+		Equals(t, "custom", *p.Workflow)
+		Equals(t, "0.11.10", p.TerraformVersion.String())
+	}
+	Equals(t, []string{"envs/prod/services/api", "envs/prod/services/web", "envs/staging/services/api"}, names)
+	Equals(t, []string{"envs/prod/services/api", "envs/prod/services/web", "envs/staging/services/api", "modules"}, dirs)
+	Equals(t, []string{"approved"}, act.Projects[0].ApplyRequirements)
+	Equals(t, []string{"mergeable"}, act.Projects[3].ApplyRequirements)
+
+	t.Log("project keys can depend on apply_requirements from the template")
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+project_templates:
+  prod:
+    apply_requirements: [independently_approved, status_checks_passed]
+projects:
+- dir: modules
+  template: prod
+  independent_approvals:
+    count: 2
+  required_status_checks: [ci/*]
+`), 0600))
+	act, err = r.ReadConfig(tmpDir)
+	Ok(t, err)
+	Equals(t, []string{"independently_approved", "status_checks_passed"}, act.Projects[0].ApplyRequirements)
+	Equals(t, 2, act.Projects[0].IndependentApprovals.Count)
+	Equals(t, []string{"ci/*"}, act.Projects[0].RequiredStatusChecks)
+
+	t.Log("expanded projects must have unique names")
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+projects:
+- dir_glob: envs/prod/services/*
+- dir_glob: envs/*/services/api
+`), 0600))
+	_, err = r.ReadConfig(tmpDir)
+	ErrEquals(t, "parsing atlantis.yaml: found two or more projects with name \"envs/prod/services/api\"; project names must be unique", err)
+
+	t.Log("the same dirs can be expanded once per workspace")
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+projects:
+- dir_glob: envs/prod/services/*
+- dir_glob: envs/prod/services/*
+  workspace: staging
+`), 0600))
+	act, err = r.ReadConfig(tmpDir)
+	Ok(t, err)
+	names = nil
+	for _, p := range act.Projects {
+		names = append(names, *p.Name)
+	}
+	Equals(t, []string{"envs/prod/services/api", "envs/prod/services/web", "envs/prod/services/api-staging", "envs/prod/services/web-staging"}, names)
+
+	t.Log("templates must use defined workflows")
+	Ok(t, ioutil.WriteFile(filepath.Join(tmpDir, "atlantis.yaml"), []byte(`
+version: 2
+project_templates:
+  service:
+    workflow: missing
+projects:
+- dir_glob: envs/prod/services/*
+  template: service
+`), 0600))
+	_, err = r.ReadConfig(tmpDir)
+	ErrEquals(t, "parsing atlantis.yaml: workflow \"missing\" is not defined", err)
+}
+
+func TestReadServerConfig(t *testing.T) {
+	tmpDir, cleanup := TempDir(t)
+	defer cleanup()
+	path := filepath.Join(tmpDir, "repos.yaml")
+	r := yaml.ParserValidator{}
+
+	_, err := r.ReadServerConfig(path)
+	ErrContains(t, "unable to read "+path, err)
+
+	Ok(t, ioutil.WriteFile(path, []byte("repos:\n- id: runatlantis/atlantis\n  unknown: true\n"), 0600))
+	_, err = r.ReadServerConfig(path)
+	ErrContains(t, "parsing "+path+": yaml: unmarshal errors", err)
+
+	Ok(t, ioutil.WriteFile(path, []byte("repos:\n- id: runatlantis/atlantis\n  workflow: custom\n"), 0600))
+	_, err = r.ReadServerConfig(path)
+	ErrEquals(t, "parsing "+path+": repos: workflow \"custom\" is not defined.", err)
+
+	Ok(t, ioutil.WriteFile(path, []byte("repos:\n- id: runatlantis/atlantis\n  apply_requirements: [approved]\n"), 0600))
+	cfg, err := r.ReadServerConfig(path)
+	Ok(t, err)
+	Equals(t, valid.ServerConfig{
+		Repos: []valid.RepoConfig{
+			{ID: "runatlantis/atlantis", ApplyRequirements: []string{"approved"}},
+		},
+		Workflows: map[string]valid.Workflow{},
+	}, cfg)
+}
@@ -0,0 +1,153 @@
+package raw
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
+
+const DefaultApplyWindowsTimezone = "UTC"
+
+// ApplyWindows restricts applies to certain days and times, ex.
+//
+//	timezone: America/New_York
+//	windows:
+//	- days: [mon-thu]
+//	  start: "09:00"
+//	  end: "16:00"
+type ApplyWindows struct {
+	Timezone *string       `yaml:"timezone,omitempty"`
+	Windows  []ApplyWindow `yaml:"windows,omitempty"`
+}
+
+// ApplyWindow is a time range on certain days of the week.
+type ApplyWindow struct {
+	// Days are days of the week, ex. "mon", or ranges of days, ex. "mon-thu".
+	// If empty, the window is open every day.
+	Days []string `yaml:"days,omitempty"`
+	// Start and End are 24-hour times, ex. "09:00" and "16:00". If End is
+	// before Start, the window is overnight and closes the next day.
+	Start *string `yaml:"start,omitempty"`
+	End   *string `yaml:"end,omitempty"`
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+func (a ApplyWindows) Validate() error {
+	if a.Timezone != nil {
+		if _, err := time.LoadLocation(*a.Timezone); err != nil {
+			return fmt.Errorf("timezone: %q is not a valid timezone", *a.Timezone)
+		}
+	}
+	if len(a.Windows) == 0 {
+		return fmt.Errorf("windows: at least one window must be specified")
+	}
+	for i, w := range a.Windows {
+		if err := w.Validate(); err != nil {
+			return fmt.Errorf("windows: %d: %s", i, err)
+		}
+	}
+	return nil
+}
+
+func (a ApplyWindows) ToValid() valid.ApplyWindows {
+	timezone := DefaultApplyWindowsTimezone
+	if a.Timezone != nil {
+		timezone = *a.Timezone
+	}
+	// Validate has already checked that the timezone and windows parse.
+	loc, _ := time.LoadLocation(timezone)
+	v := valid.ApplyWindows{Location: loc}
+	for _, w := range a.Windows {
+		v.Windows = append(v.Windows, w.ToValid())
+	}
+	return v
+}
+
+func (w ApplyWindow) Validate() error {
+	if _, err := parseDays(w.Days); err != nil {
+		return fmt.Errorf("days: %s", err)
+	}
+	if w.Start == nil || w.End == nil {
+		return fmt.Errorf("start and end must be set")
+	}
+	start, err := parseClock(*w.Start)
+	if err != nil {
+		return fmt.Errorf("start: %s", err)
+	}
+	end, err := parseClock(*w.End)
+	if err != nil {
+		return fmt.Errorf("end: %s", err)
+	}
+	// If end is before start, the window is overnight, ex. 22:00-02:00.
+	if end == start {
+		return fmt.Errorf("end %q must not be the same as start %q", *w.End, *w.Start)
+	}
+	if start == 24*time.Hour {
+		return fmt.Errorf("start %q must be before 24:00", *w.Start)
+	}
+	return nil
+}
+
+func (w ApplyWindow) ToValid() valid.ApplyWindow {
+	days, _ := parseDays(w.Days)
+	start, _ := parseClock(*w.Start)
+	end, _ := parseClock(*w.End)
+	return valid.ApplyWindow{Days: days, Start: start, End: end}
+}
+
+// parseDays parses days of the week and ranges of days. If days is empty,
+// all days are returned.
+func parseDays(days []string) ([]time.Weekday, error) {
+	if len(days) == 0 {
+		return []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday}, nil
+	}
+	var parsed []time.Weekday
+	for _, d := range days {
+		bounds := strings.Split(strings.ToLower(d), "-")
+		if len(bounds) > 2 {
+			return nil, fmt.Errorf("%q is not a day or range of days", d)
+		}
+		first, ok := weekdays[bounds[0]]
+		if !ok {
+			return nil, fmt.Errorf("%q is not a day, must be one of sun, mon, tue, wed, thu, fri or sat", bounds[0])
+		}
+		last := first
+		if len(bounds) == 2 {
+			if last, ok = weekdays[bounds[1]]; !ok {
+				return nil, fmt.Errorf("%q is not a day, must be one of sun, mon, tue, wed, thu, fri or sat", bounds[1])
+			}
+		}
+		// Ranges can wrap around the end of the week, ex. fri-mon.
+		for day := first; ; day = (day + 1) % 7 {
+			parsed = append(parsed, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return parsed, nil
+}
+
+// parseClock parses a 24-hour time, ex. "09:30", into the time since
+// midnight. "24:00" is allowed so windows can last until the end of the day.
+func parseClock(clock string) (time.Duration, error) {
+	var hours, minutes int
+	if n, err := fmt.Sscanf(clock, "%d:%d", &hours, &minutes); err != nil || n != 2 || len(clock) != 5 {
+		return 0, fmt.Errorf("%q must be a 24-hour time, ex. 09:00", clock)
+	}
+	if hours < 0 || minutes < 0 || minutes > 59 || hours > 24 || (hours == 24 && minutes != 0) {
+		return 0, fmt.Errorf("%q must be a 24-hour time, ex. 09:00", clock)
+	}
+	return time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute, nil
+}
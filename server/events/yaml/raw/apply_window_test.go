@@ -0,0 +1,311 @@
+package raw_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	. "github.com/runatlantis/atlantis/testing"
+	"gopkg.in/yaml.v2"
+)
+
+func TestApplyWindows_UnmarshalYAML(t *testing.T) {
+	input := `
+timezone: America/New_York
+windows:
+- days: [mon-thu, sat]
+  start: "09:00"
+  end: "16:00"
+`
+	var a raw.ApplyWindows
+	err := yaml.UnmarshalStrict([]byte(input), &a)
+	Ok(t, err)
+	Equals(t, raw.ApplyWindows{
+		Timezone: String("America/New_York"),
+		Windows: []raw.ApplyWindow{
+			{
+				Days:  []string{"mon-thu", "sat"},
+				Start: String("09:00"),
+				End:   String("16:00"),
+			},
+		},
+	}, a)
+}
+
+func TestApplyWindows_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.ApplyWindows
+		expErr      string
+	}{
+		{
+			description: "valid",
+			input: raw.ApplyWindows{
+				Timezone: String("America/New_York"),
+				Windows: []raw.ApplyWindow{
+					{Days: []string{"mon-fri"}, Start: String("09:00"), End: String("16:30")},
+					{Start: String("00:00"), End: String("24:00")},
+				},
+			},
+		},
+		{
+			description: "no windows",
+			input:       raw.ApplyWindows{},
+			expErr:      "windows: at least one window must be specified",
+		},
+		{
+			description: "invalid timezone",
+			input: raw.ApplyWindows{
+				Timezone: String("Mars/Olympus_Mons"),
+				Windows:  []raw.ApplyWindow{{Start: String("09:00"), End: String("16:00")}},
+			},
+			expErr: "timezone: \"Mars/Olympus_Mons\" is not a valid timezone",
+		},
+		{
+			description: "invalid day",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Days: []string{"monday"}, Start: String("09:00"), End: String("16:00")}},
+			},
+			expErr: "windows: 0: days: \"monday\" is not a day, must be one of sun, mon, tue, wed, thu, fri or sat",
+		},
+		{
+			description: "invalid range",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Days: []string{"mon-tue-wed"}, Start: String("09:00"), End: String("16:00")}},
+			},
+			expErr: "windows: 0: days: \"mon-tue-wed\" is not a day or range of days",
+		},
+		{
+			description: "missing end",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("09:00")}},
+			},
+			expErr: "windows: 0: start and end must be set",
+		},
+		{
+			description: "invalid time",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("9am"), End: String("16:00")}},
+			},
+			expErr: "windows: 0: start: \"9am\" must be a 24-hour time, ex. 09:00",
+		},
+		{
+			description: "time out of range",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("09:00"), End: String("24:30")}},
+			},
+			expErr: "windows: 0: end: \"24:30\" must be a 24-hour time, ex. 09:00",
+		},
+		{
+			description: "overnight",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("22:00"), End: String("02:00")}},
+			},
+		},
+		{
+			description: "end same as start",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{
+					{Start: String("09:00"), End: String("16:00")},
+					{Start: String("16:00"), End: String("16:00")},
+				},
+			},
+			expErr: "windows: 1: end \"16:00\" must not be the same as start \"16:00\"",
+		},
+		{
+			description: "start at end of day",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("24:00"), End: String("02:00")}},
+			},
+			expErr: "windows: 0: start \"24:00\" must be before 24:00",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestApplyWindows_ToValid(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	Ok(t, err)
+	cases := []struct {
+		description string
+		input       raw.ApplyWindows
+		exp         valid.ApplyWindows
+	}{
+		{
+			description: "defaults to UTC and all days",
+			input: raw.ApplyWindows{
+				Windows: []raw.ApplyWindow{{Start: String("09:00"), End: String("24:00")}},
+			},
+			exp: valid.ApplyWindows{
+				Location: time.UTC,
+				Windows: []valid.ApplyWindow{
+					{
+						Days:  []time.Weekday{time.Sunday, time.Monday, time.Tuesday, time.Wednesday, time.Thursday, time.Friday, time.Saturday},
+						Start: 9 * time.Hour,
+						End:   24 * time.Hour,
+					},
+				},
+			},
+		},
+		{
+			description: "day ranges",
+			input: raw.ApplyWindows{
+				Timezone: String("America/New_York"),
+				Windows: []raw.ApplyWindow{
+					{Days: []string{"Mon-Wed", "fri-sun"}, Start: String("09:30"), End: String("16:00")},
+				},
+			},
+			exp: valid.ApplyWindows{
+				Location: newYork,
+				Windows: []valid.ApplyWindow{
+					{
+						Days:  []time.Weekday{time.Monday, time.Tuesday, time.Wednesday, time.Friday, time.Saturday, time.Sunday},
+						Start: 9*time.Hour + 30*time.Minute,
+						End:   16 * time.Hour,
+					},
+				},
+			},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Ok(t, c.input.Validate())
+			Equals(t, c.exp, c.input.ToValid())
+		})
+	}
+}
+
+func TestApplyWindows_IsOpenAndNextOpen(t *testing.T) {
+	windows := raw.ApplyWindows{
+		Timezone: String("America/New_York"),
+		Windows: []raw.ApplyWindow{
+			{Days: []string{"mon-thu"}, Start: String("09:00"), End: String("16:00")},
+		},
+	}
+	Ok(t, windows.Validate())
+	v := windows.ToValid()
+	Equals(t, "Mon,Tue,Wed,Thu 09:00-16:00 America/New_York", v.String())
+
+	cases := []struct {
+		description string
+		now         string
+		expOpen     bool
+		expNext     string
+	}{
+		{
+			description: "within window",
+			now:         "2019-06-04T10:00:00-04:00", // Tuesday.
+			expOpen:     true,
+			expNext:     "2019-06-05T09:00:00-04:00",
+		},
+		{
+			description: "before window",
+			now:         "2019-06-04T08:59:00-04:00",
+			expOpen:     false,
+			expNext:     "2019-06-04T09:00:00-04:00",
+		},
+		{
+			description: "end is exclusive",
+			now:         "2019-06-04T16:00:00-04:00",
+			expOpen:     false,
+			expNext:     "2019-06-05T09:00:00-04:00",
+		},
+		{
+			description: "converts to the window's timezone",
+			now:         "2019-06-04T14:30:00Z",
+			expOpen:     true,
+			expNext:     "2019-06-05T09:00:00-04:00",
+		},
+		{
+			description: "weekend",
+			now:         "2019-06-07T12:00:00-04:00", // Friday.
+			expOpen:     false,
+			expNext:     "2019-06-10T09:00:00-04:00",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, c.now)
+			Ok(t, err)
+			expNext, err := time.Parse(time.RFC3339, c.expNext)
+			Ok(t, err)
+			Equals(t, c.expOpen, v.IsOpen(now))
+			Assert(t, expNext.Equal(v.NextOpen(now)), "exp next open %s, got %s", expNext, v.NextOpen(now))
+		})
+	}
+}
+
+func TestApplyWindows_NextOpenNever(t *testing.T) {
+	v := valid.ApplyWindows{Location: time.UTC}
+	Equals(t, false, v.IsOpen(time.Now()))
+	Assert(t, v.NextOpen(time.Now()).IsZero(), "exp no next open")
+}
+
+func TestApplyWindows_Overnight(t *testing.T) {
+	windows := raw.ApplyWindows{
+		Windows: []raw.ApplyWindow{
+			{Days: []string{"fri"}, Start: String("22:00"), End: String("02:00")},
+		},
+	}
+	Ok(t, windows.Validate())
+	v := windows.ToValid()
+	Equals(t, "Fri 22:00-02:00 UTC", v.String())
+
+	cases := []struct {
+		description string
+		now         string
+		expOpen     bool
+		expNext     string
+	}{
+		{
+			description: "before window",
+			now:         "2019-06-07T21:59:00Z", // Friday.
+			expOpen:     false,
+			expNext:     "2019-06-07T22:00:00Z",
+		},
+		{
+			description: "before midnight",
+			now:         "2019-06-07T23:00:00Z",
+			expOpen:     true,
+			expNext:     "2019-06-14T22:00:00Z",
+		},
+		{
+			description: "after midnight",
+			now:         "2019-06-08T01:59:00Z", // Saturday.
+			expOpen:     true,
+			expNext:     "2019-06-14T22:00:00Z",
+		},
+		{
+			description: "end is exclusive",
+			now:         "2019-06-08T02:00:00Z",
+			expOpen:     false,
+			expNext:     "2019-06-14T22:00:00Z",
+		},
+		{
+			description: "early on the day it opens",
+			now:         "2019-06-07T01:00:00Z", // Friday.
+			expOpen:     false,
+			expNext:     "2019-06-07T22:00:00Z",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			now, err := time.Parse(time.RFC3339, c.now)
+			Ok(t, err)
+			expNext, err := time.Parse(time.RFC3339, c.expNext)
+			Ok(t, err)
+			Equals(t, c.expOpen, v.IsOpen(now))
+			Assert(t, expNext.Equal(v.NextOpen(now)), "exp next open %s, got %s", expNext, v.NextOpen(now))
+		})
+	}
+}
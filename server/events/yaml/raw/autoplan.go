@@ -1,6 +1,13 @@
 package raw
 
-import "github.com/runatlantis/atlantis/server/events/yaml/valid"
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
 
 const DefaultAutoPlanWhenModified = "**/*.tf*"
 const DefaultAutoPlanEnabled = true
@@ -28,6 +35,23 @@ func (a Autoplan) ToValid() valid.Autoplan {
 }
 
 func (a Autoplan) Validate() error {
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.WhenModified, validation.By(validWhenModified)),
+	)
+}
+
+// validWhenModified returns an error if value, a []string, contains patterns
+// that can't be matched. Patterns starting with ! exclude the files they match.
+func validWhenModified(value interface{}) error {
+	for _, pattern := range value.([]string) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "!" {
+			return fmt.Errorf("%q is not a valid pattern: nothing to exclude", pattern)
+		}
+		if _, err := filepath.Match(strings.TrimPrefix(pattern, "!"), ""); err != nil {
+			return fmt.Errorf("%q is not a valid pattern", pattern)
+		}
+	}
 	return nil
 }
 
@@ -3,6 +3,7 @@ package raw_test
 import (
 	"testing"
 
+	"github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/events/yaml/raw"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	. "github.com/runatlantis/atlantis/testing"
@@ -90,6 +91,12 @@ func TestAutoplan_Validate(t *testing.T) {
 				Enabled: Bool(false),
 			},
 		},
+		{
+			description: "exclusion",
+			input: raw.Autoplan{
+				WhenModified: []string{"**/*.tf", "!test/**/*.tf"},
+			},
+		},
 	}
 	for _, c := range cases {
 		t.Run(c.description, func(t *testing.T) {
@@ -98,6 +105,21 @@ func TestAutoplan_Validate(t *testing.T) {
 	}
 }
 
+func TestAutoplan_ValidateErrs(t *testing.T) {
+	validation.ErrorTag = "yaml"
+	cases := map[string]string{
+		"!":      `when_modified: "!" is not a valid pattern: nothing to exclude.`,
+		"[":      `when_modified: "[" is not a valid pattern.`,
+		"!dir/[": `when_modified: "!dir/[" is not a valid pattern.`,
+	}
+	for pattern, expErr := range cases {
+		t.Run(pattern, func(t *testing.T) {
+			a := raw.Autoplan{WhenModified: []string{"*.tf", pattern}}
+			ErrEquals(t, expErr, a.Validate())
+		})
+	}
+}
+
 func TestAutoplan_ToValid(t *testing.T) {
 	cases := []struct {
 		description string
@@ -2,6 +2,7 @@ package raw
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/go-ozzo/ozzo-validation"
 	"github.com/runatlantis/atlantis/server/events/yaml/valid"
@@ -16,6 +17,11 @@ type Config struct {
 	Projects  []Project           `yaml:"projects,omitempty"`
 	Workflows map[string]Workflow `yaml:"workflows,omitempty"`
 	Automerge *bool               `yaml:"automerge,omitempty"`
+	// ProjectTemplates are defaults that projects can reference by name.
+	ProjectTemplates map[string]ProjectTemplate `yaml:"project_templates,omitempty"`
+	// Branch is the base branch that pull requests must be into for Atlantis
+	// to act on them, or a regex between slashes that matches base branches.
+	Branch *string `yaml:"branch,omitempty"`
 }
 
 func (c Config) Validate() error {
@@ -29,10 +35,36 @@ func (c Config) Validate() error {
 		}
 		return nil
 	}
+	templatesExist := func(value interface{}) error {
+		for _, p := range value.([]Project) {
+			if p.Template == nil {
+				continue
+			}
+			if _, ok := c.ProjectTemplates[*p.Template]; !ok {
+				return fmt.Errorf("template %q is not defined in project_templates", *p.Template)
+			}
+		}
+		return nil
+	}
+	// Projects are validated with their templates applied because some keys,
+	// ex. independent_approvals, depend on apply_requirements which can come
+	// from the template.
+	if c.Projects != nil {
+		projects := make([]Project, len(c.Projects))
+		for i, p := range c.Projects {
+			if p.Template != nil {
+				p = p.ApplyTemplate(c.ProjectTemplates[*p.Template])
+			}
+			projects[i] = p
+		}
+		c.Projects = projects
+	}
 	return validation.ValidateStruct(&c,
 		validation.Field(&c.Version, validation.By(equals2)),
-		validation.Field(&c.Projects),
+		validation.Field(&c.Projects, validation.By(templatesExist)),
 		validation.Field(&c.Workflows),
+		validation.Field(&c.ProjectTemplates),
+		validation.Field(&c.Branch, validation.NilOrNotEmpty, validation.By(validBranch)),
 	)
 }
 
@@ -53,9 +85,11 @@ func (c Config) ToValid() valid.Config {
 	}
 
 	return valid.Config{
-		Version:   *c.Version,
-		Projects:  validProjects,
-		Workflows: validWorkflows,
-		Automerge: automerge,
+		Version:     *c.Version,
+		Projects:    validProjects,
+		Workflows:   validWorkflows,
+		Automerge:   automerge,
+		Branch:      c.Branch,
+		BranchRegex: branchRegex(c.Branch),
 	}
 }
@@ -1,6 +1,7 @@
 package raw_test
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/go-ozzo/ozzo-validation"
@@ -107,6 +108,7 @@ func TestConfig_UnmarshalYAML(t *testing.T) {
 			input: `
 version: 2
 automerge: true
+branch: /^main$/
 projects:
 - dir: mydir
   workspace: myworkspace
@@ -125,6 +127,7 @@ workflows:
 			exp: raw.Config{
 				Version:   Int(2),
 				Automerge: Bool(true),
+				Branch:    String("/^main$/"),
 				Projects: []raw.Project{
 					{
 						Dir:              String("mydir"),
@@ -185,6 +188,47 @@ func TestConfig_Validate(t *testing.T) {
 			},
 			expErr: "version: must equal 2.",
 		},
+		{
+			description: "undefined template",
+			input: raw.Config{
+				Version: Int(2),
+				Projects: []raw.Project{
+					{Dir: String("."), Template: String("missing")},
+				},
+			},
+			expErr: "projects: template \"missing\" is not defined in project_templates.",
+		},
+		{
+			description: "invalid template",
+			input: raw.Config{
+				Version: Int(2),
+				ProjectTemplates: map[string]raw.ProjectTemplate{
+					"default": {ApplyRequirements: []string{"unsupported"}},
+				},
+			},
+			expErr: "project_templates: (default: (apply_requirements: \"unsupported\" not supported, only approved, mergeable, independently_approved, codeowners_approved and status_checks_passed are supported.).).",
+		},
+		{
+			description: "invalid branch regex",
+			input: raw.Config{
+				Version: Int(2),
+				Branch:  String("/[/"),
+			},
+			expErr: "branch: parsing /[/: error parsing regexp: missing closing ]: `[`.",
+		},
+		{
+			description: "valid template",
+			input: raw.Config{
+				Version: Int(2),
+				Projects: []raw.Project{
+					{Dir: String("."), Template: String("default")},
+				},
+				ProjectTemplates: map[string]raw.ProjectTemplate{
+					"default": {TerraformVersion: String("0.11.10")},
+				},
+			},
+			expErr: "",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -261,6 +305,19 @@ func TestConfig_ToValid(t *testing.T) {
 				Workflows: map[string]valid.Workflow{},
 			},
 		},
+		{
+			description: "branch",
+			input: raw.Config{
+				Version: Int(2),
+				Branch:  String("/^release-.*$/"),
+			},
+			exp: valid.Config{
+				Version:     2,
+				Workflows:   map[string]valid.Workflow{},
+				Branch:      String("/^release-.*$/"),
+				BranchRegex: regexp.MustCompile("^release-.*$"),
+			},
+		},
 		{
 			description: "everything set",
 			input: raw.Config{
@@ -330,3 +387,23 @@ func TestConfig_ToValid(t *testing.T) {
 		})
 	}
 }
+
+func TestProject_ApplyTemplate(t *testing.T) {
+	template := raw.ProjectTemplate{
+		Workflow:          String("custom"),
+		TerraformVersion:  String("0.11.10"),
+		ApplyRequirements: []string{"approved"},
+		Branch:            String("/^main$/"),
+	}
+	act := raw.Project{
+		Dir:              String("."),
+		TerraformVersion: String("0.12.0"),
+	}.ApplyTemplate(template)
+	Equals(t, raw.Project{
+		Dir:               String("."),
+		Workflow:          String("custom"),
+		TerraformVersion:  String("0.12.0"),
+		ApplyRequirements: []string{"approved"},
+		Branch:            String("/^main$/"),
+	}, act)
+}
@@ -0,0 +1,40 @@
+package raw
+
+import (
+	"errors"
+
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
+
+// DefaultIndependentApprovalsCount is how many independent approvals are
+// required if count isn't set.
+const DefaultIndependentApprovalsCount = 1
+
+// IndependentApprovals configures the independently_approved apply
+// requirement.
+type IndependentApprovals struct {
+	// Count is how many approvals are required from users other than the
+	// pull request's author and the user running apply.
+	Count *int `yaml:"count,omitempty"`
+	// InvalidateOnNewCommits is whether approvals of earlier commits are
+	// ignored.
+	InvalidateOnNewCommits *bool `yaml:"invalidate_on_new_commits,omitempty"`
+}
+
+func (i IndependentApprovals) Validate() error {
+	if i.Count != nil && *i.Count < 1 {
+		return errors.New("count: must be at least 1")
+	}
+	return nil
+}
+
+func (i IndependentApprovals) ToValid() valid.IndependentApprovals {
+	v := valid.IndependentApprovals{Count: DefaultIndependentApprovalsCount}
+	if i.Count != nil {
+		v.Count = *i.Count
+	}
+	if i.InvalidateOnNewCommits != nil {
+		v.InvalidateOnNewCommits = *i.InvalidateOnNewCommits
+	}
+	return v
+}
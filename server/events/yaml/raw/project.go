@@ -3,7 +3,9 @@ package raw
 import (
 	"fmt"
 	"net/url"
+	"path"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"github.com/go-ozzo/ozzo-validation"
@@ -13,44 +15,70 @@ import (
 )
 
 const (
-	DefaultWorkspace          = "default"
-	ApprovedApplyRequirement  = "approved"
-	MergeableApplyRequirement = "mergeable"
+	DefaultWorkspace                      = "default"
+	ApprovedApplyRequirement              = "approved"
+	MergeableApplyRequirement             = "mergeable"
+	IndependentlyApprovedApplyRequirement = "independently_approved"
+	CodeownersApprovedApplyRequirement    = "codeowners_approved"
+	StatusChecksPassedApplyRequirement    = "status_checks_passed"
 )
 
 type Project struct {
-	Name              *string   `yaml:"name,omitempty"`
-	Dir               *string   `yaml:"dir,omitempty"`
-	Workspace         *string   `yaml:"workspace,omitempty"`
-	Workflow          *string   `yaml:"workflow,omitempty"`
-	TerraformVersion  *string   `yaml:"terraform_version,omitempty"`
-	Autoplan          *Autoplan `yaml:"autoplan,omitempty"`
-	ApplyRequirements []string  `yaml:"apply_requirements,omitempty"`
+	Name              *string       `yaml:"name,omitempty"`
+	Dir               *string       `yaml:"dir,omitempty"`
+	Workspace         *string       `yaml:"workspace,omitempty"`
+	Workflow          *string       `yaml:"workflow,omitempty"`
+	TerraformVersion  *string       `yaml:"terraform_version,omitempty"`
+	Autoplan          *Autoplan     `yaml:"autoplan,omitempty"`
+	ApplyRequirements []string      `yaml:"apply_requirements,omitempty"`
+	ApplyWindows      *ApplyWindows `yaml:"apply_windows,omitempty"`
+	// IndependentApprovals configures the independently_approved apply
+	// requirement.
+	IndependentApprovals *IndependentApprovals `yaml:"independent_approvals,omitempty"`
+	// RequiredStatusChecks are name patterns of the status checks that the
+	// status_checks_passed apply requirement waits for.
+	RequiredStatusChecks []string `yaml:"required_status_checks,omitempty"`
+	// Template is the name of the project template that the keys this
+	// project doesn't set are taken from.
+	Template *string `yaml:"template,omitempty"`
+	// DirGlob is expanded into a project for each directory that matches it.
+	// The projects are named after their directories, followed by
+	// -<workspace> if their workspace isn't default. It can't be set with dir
+	// or name.
+	DirGlob *string `yaml:"dir_glob,omitempty"`
+	// Branch is the base branch that pull requests must be into for the
+	// project to be planned or applied, or a regex between slashes that
+	// matches base branches, ex. /^release-.*$/.
+	Branch *string `yaml:"branch,omitempty"`
 }
 
 func (p Project) Validate() error {
 	hasDotDot := func(value interface{}) error {
-		if strings.Contains(*value.(*string), "..") {
+		strPtr := value.(*string)
+		if strPtr != nil && strings.Contains(*strPtr, "..") {
 			return errors.New("cannot contain '..'")
 		}
 		return nil
 	}
-	validApplyReq := func(value interface{}) error {
-		reqs := value.([]string)
-		for _, r := range reqs {
-			if r != ApprovedApplyRequirement && r != MergeableApplyRequirement {
-				return fmt.Errorf("%q not supported, only %s and %s are supported", r, ApprovedApplyRequirement, MergeableApplyRequirement)
-			}
-		}
-		return nil
+	dirRules := []validation.Rule{validation.By(hasDotDot)}
+	if p.DirGlob == nil {
+		dirRules = append([]validation.Rule{validation.Required}, dirRules...)
 	}
-	validTFVersion := func(value interface{}) error {
-		strPtr := value.(*string)
-		if strPtr == nil {
+	validDirGlob := func(value interface{}) error {
+		glob := value.(*string)
+		if glob == nil {
 			return nil
 		}
-		_, err := version.NewVersion(*strPtr)
-		return errors.Wrapf(err, "version %q could not be parsed", *strPtr)
+		if p.Dir != nil {
+			return errors.New("cannot be set with dir")
+		}
+		if p.Name != nil {
+			return errors.New("cannot be set with name because projects are named after their directories")
+		}
+		if _, err := filepath.Match(*glob, ""); err != nil {
+			return fmt.Errorf("%q is not a valid glob", *glob)
+		}
+		return nil
 	}
 	validName := func(value interface{}) error {
 		strPtr := value.(*string)
@@ -65,11 +93,57 @@ func (p Project) Validate() error {
 		}
 		return nil
 	}
+	validApplyWindows := func(value interface{}) error {
+		windows := value.(*ApplyWindows)
+		if windows == nil {
+			return nil
+		}
+		return windows.Validate()
+	}
+	validIndependentApprovals := func(value interface{}) error {
+		approvals := value.(*IndependentApprovals)
+		if approvals == nil {
+			return nil
+		}
+		for _, r := range p.ApplyRequirements {
+			if r == IndependentlyApprovedApplyRequirement {
+				return approvals.Validate()
+			}
+		}
+		return fmt.Errorf("can only be set if apply_requirements contains %s", IndependentlyApprovedApplyRequirement)
+	}
+	validRequiredStatusChecks := func(value interface{}) error {
+		patterns := value.([]string)
+		if len(patterns) == 0 {
+			return nil
+		}
+		hasReq := false
+		for _, r := range p.ApplyRequirements {
+			if r == StatusChecksPassedApplyRequirement {
+				hasReq = true
+			}
+		}
+		if !hasReq {
+			return fmt.Errorf("can only be set if apply_requirements contains %s", StatusChecksPassedApplyRequirement)
+		}
+		for _, pattern := range patterns {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return fmt.Errorf("%q is not a valid pattern", pattern)
+			}
+		}
+		return nil
+	}
 	return validation.ValidateStruct(&p,
-		validation.Field(&p.Dir, validation.Required, validation.By(hasDotDot)),
-		validation.Field(&p.ApplyRequirements, validation.By(validApplyReq)),
+		validation.Field(&p.Dir, dirRules...),
+		validation.Field(&p.DirGlob, validation.NilOrNotEmpty, validation.By(hasDotDot), validation.By(validDirGlob)),
+		validation.Field(&p.ApplyRequirements, validation.By(validApplyReqs)),
 		validation.Field(&p.TerraformVersion, validation.By(validTFVersion)),
 		validation.Field(&p.Name, validation.By(validName)),
+		validation.Field(&p.ApplyWindows, validation.By(validApplyWindows)),
+		validation.Field(&p.IndependentApprovals, validation.By(validIndependentApprovals)),
+		validation.Field(&p.RequiredStatusChecks, validation.By(validRequiredStatusChecks)),
+		validation.Field(&p.Autoplan),
+		validation.Field(&p.Branch, validation.NilOrNotEmpty, validation.By(validBranch)),
 	)
 }
 
@@ -102,9 +176,80 @@ func (p Project) ToValid() valid.Project {
 
 	v.Name = p.Name
 
+	if p.ApplyWindows != nil {
+		windows := p.ApplyWindows.ToValid()
+		v.ApplyWindows = &windows
+	}
+
+	if p.IndependentApprovals != nil {
+		approvals := p.IndependentApprovals.ToValid()
+		v.IndependentApprovals = &approvals
+	}
+
+	v.RequiredStatusChecks = p.RequiredStatusChecks
+
+	v.Branch = p.Branch
+	v.BranchRegex = branchRegex(p.Branch)
+
 	return v
 }
 
+// supportedApplyReqs returns the apply requirements that can be configured.
+func supportedApplyReqs() []string {
+	return []string{ApprovedApplyRequirement, MergeableApplyRequirement, IndependentlyApprovedApplyRequirement, CodeownersApprovedApplyRequirement, StatusChecksPassedApplyRequirement}
+}
+
+// validApplyReqs returns an error if value, a []string, contains unsupported
+// apply requirements.
+func validApplyReqs(value interface{}) error {
+	reqs := value.([]string)
+	supported := supportedApplyReqs()
+	for _, r := range reqs {
+		isSupported := false
+		for _, s := range supported {
+			if r == s {
+				isSupported = true
+			}
+		}
+		if !isSupported {
+			last := len(supported) - 1
+			return fmt.Errorf("%q not supported, only %s and %s are supported", r, strings.Join(supported[:last], ", "), supported[last])
+		}
+	}
+	return nil
+}
+
+// validTFVersion returns an error if value, a *string, isn't a valid
+// Terraform version.
+func validTFVersion(value interface{}) error {
+	strPtr := value.(*string)
+	if strPtr == nil {
+		return nil
+	}
+	_, err := version.NewVersion(*strPtr)
+	return errors.Wrapf(err, "version %q could not be parsed", *strPtr)
+}
+
+// validBranch returns an error if value, a *string, is a regex between
+// slashes that can't be compiled.
+func validBranch(value interface{}) error {
+	branch := value.(*string)
+	if branch == nil || !isRegexID(*branch) {
+		return nil
+	}
+	_, err := regexp.Compile((*branch)[1 : len(*branch)-1])
+	return errors.Wrapf(err, "parsing %s", *branch)
+}
+
+// branchRegex returns the compiled regex if branch is a regex between slashes
+// and nil otherwise.
+func branchRegex(branch *string) *regexp.Regexp {
+	if branch == nil || !isRegexID(*branch) {
+		return nil
+	}
+	return regexp.MustCompile((*branch)[1 : len(*branch)-1])
+}
+
 // validProjectName returns true if the project name is valid.
 // Since the name might be used in URLs and definitely in files we don't
 // support any characters that must be url escaped *except* for '/' because
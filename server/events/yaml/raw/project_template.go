@@ -0,0 +1,45 @@
+package raw
+
+import (
+	"github.com/go-ozzo/ozzo-validation"
+)
+
+// ProjectTemplate holds defaults shared by the projects that reference it
+// with the template key.
+type ProjectTemplate struct {
+	Workflow          *string   `yaml:"workflow,omitempty"`
+	TerraformVersion  *string   `yaml:"terraform_version,omitempty"`
+	Autoplan          *Autoplan `yaml:"autoplan,omitempty"`
+	ApplyRequirements []string  `yaml:"apply_requirements,omitempty"`
+	Branch            *string   `yaml:"branch,omitempty"`
+}
+
+func (t ProjectTemplate) Validate() error {
+	return validation.ValidateStruct(&t,
+		validation.Field(&t.TerraformVersion, validation.By(validTFVersion)),
+		validation.Field(&t.Autoplan),
+		validation.Field(&t.ApplyRequirements, validation.By(validApplyReqs)),
+		validation.Field(&t.Branch, validation.NilOrNotEmpty, validation.By(validBranch)),
+	)
+}
+
+// ApplyTemplate returns a copy of p where the keys p doesn't set are taken
+// from t.
+func (p Project) ApplyTemplate(t ProjectTemplate) Project {
+	if p.Workflow == nil {
+		p.Workflow = t.Workflow
+	}
+	if p.TerraformVersion == nil {
+		p.TerraformVersion = t.TerraformVersion
+	}
+	if p.Autoplan == nil {
+		p.Autoplan = t.Autoplan
+	}
+	if p.ApplyRequirements == nil {
+		p.ApplyRequirements = t.ApplyRequirements
+	}
+	if p.Branch == nil {
+		p.Branch = t.Branch
+	}
+	return p
+}
@@ -1,6 +1,7 @@
 package raw_test
 
 import (
+	"regexp"
 	"testing"
 
 	"github.com/go-ozzo/ozzo-validation"
@@ -101,7 +102,75 @@ func TestProject_Validate(t *testing.T) {
 				Dir:               String("."),
 				ApplyRequirements: []string{"unsupported"},
 			},
-			expErr: "apply_requirements: \"unsupported\" not supported, only approved and mergeable are supported.",
+			expErr: "apply_requirements: \"unsupported\" not supported, only approved, mergeable, independently_approved, codeowners_approved and status_checks_passed are supported.",
+		},
+		{
+			description: "codeowners approved",
+			input: raw.Project{
+				Dir:               String("."),
+				ApplyRequirements: []string{"codeowners_approved", "mergeable"},
+			},
+			expErr: "",
+		},
+		{
+			description: "independent approvals",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &raw.IndependentApprovals{Count: Int(2), InvalidateOnNewCommits: Bool(true)},
+			},
+			expErr: "",
+		},
+		{
+			description: "independent approvals without requirement",
+			input: raw.Project{
+				Dir:                  String("."),
+				IndependentApprovals: &raw.IndependentApprovals{Count: Int(2)},
+			},
+			expErr: "independent_approvals: can only be set if apply_requirements contains independently_approved.",
+		},
+		{
+			description: "independent approvals with invalid count",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &raw.IndependentApprovals{Count: Int(0)},
+			},
+			expErr: "independent_approvals: count: must be at least 1.",
+		},
+		{
+			description: "required status checks",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"status_checks_passed"},
+				RequiredStatusChecks: []string{"ci/*", "tflint"},
+			},
+			expErr: "",
+		},
+		{
+			description: "required status checks without requirement",
+			input: raw.Project{
+				Dir:                  String("."),
+				RequiredStatusChecks: []string{"ci/*"},
+			},
+			expErr: "required_status_checks: can only be set if apply_requirements contains status_checks_passed.",
+		},
+		{
+			description: "required status checks with invalid pattern",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"status_checks_passed"},
+				RequiredStatusChecks: []string{"ci/["},
+			},
+			expErr: "required_status_checks: \"ci/[\" is not a valid pattern.",
+		},
+		{
+			description: "invalid apply windows",
+			input: raw.Project{
+				Dir:          String("."),
+				ApplyWindows: &raw.ApplyWindows{},
+			},
+			expErr: "apply_windows: windows: at least one window must be specified.",
 		},
 		{
 			description: "apply reqs with approved requirement",
@@ -199,6 +268,59 @@ func TestProject_Validate(t *testing.T) {
 			},
 			expErr: `name: "namewith\\" is not allowed: must contain only URL safe characters.`,
 		},
+		{
+			description: "dir_glob without dir",
+			input: raw.Project{
+				DirGlob: String("envs/*"),
+			},
+			expErr: "",
+		},
+		{
+			description: "dir_glob with dir",
+			input: raw.Project{
+				Dir:     String("."),
+				DirGlob: String("envs/*"),
+			},
+			expErr: "dir_glob: cannot be set with dir.",
+		},
+		{
+			description: "dir_glob with name",
+			input: raw.Project{
+				Name:    String("envs"),
+				DirGlob: String("envs/*"),
+			},
+			expErr: "dir_glob: cannot be set with name because projects are named after their directories.",
+		},
+		{
+			description: "dir_glob with ..",
+			input: raw.Project{
+				DirGlob: String("../envs/*"),
+			},
+			expErr: "dir_glob: cannot contain '..'.",
+		},
+		{
+			description: "invalid dir_glob",
+			input: raw.Project{
+				DirGlob: String("envs/["),
+			},
+			expErr: "dir_glob: \"envs/[\" is not a valid glob.",
+		},
+		{
+			description: "branch",
+			input: raw.Project{
+				Dir:    String("."),
+				Branch: String("/^release-.*$/"),
+			},
+			expErr: "",
+		},
+		{
+			description: "invalid branch regex",
+			input: raw.Project{
+				Dir:    String("."),
+				Branch: String("/[/"),
+			},
+			expErr: "branch: parsing /[/: error parsing regexp: missing closing ]: `[`.",
+		},
 	}
 	validation.ErrorTag = "yaml"
 	for _, c := range cases {
@@ -309,6 +431,42 @@ func TestProject_ToValid(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "independent approvals defaults",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &raw.IndependentApprovals{},
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: []string{"**/*.tf*"},
+					Enabled:      true,
+				},
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &valid.IndependentApprovals{Count: 1},
+			},
+		},
+		{
+			description: "independent approvals set",
+			input: raw.Project{
+				Dir:                  String("."),
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &raw.IndependentApprovals{Count: Int(2), InvalidateOnNewCommits: Bool(true)},
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: []string{"**/*.tf*"},
+					Enabled:      true,
+				},
+				ApplyRequirements:    []string{"independently_approved"},
+				IndependentApprovals: &valid.IndependentApprovals{Count: 2, InvalidateOnNewCommits: true},
+			},
+		},
 		{
 			description: "unclean dir",
 			input: raw.Project{
@@ -324,6 +482,39 @@ func TestProject_ToValid(t *testing.T) {
 				},
 			},
 		},
+		{
+			description: "branch name",
+			input: raw.Project{
+				Dir:    String("."),
+				Branch: String("main"),
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: []string{"**/*.tf*"},
+					Enabled:      true,
+				},
+				Branch: String("main"),
+			},
+		},
+		{
+			description: "branch regex",
+			input: raw.Project{
+				Dir:    String("."),
+				Branch: String("/^release-.*$/"),
+			},
+			exp: valid.Project{
+				Dir:       ".",
+				Workspace: "default",
+				Autoplan: valid.Autoplan{
+					WhenModified: []string{"**/*.tf*"},
+					Enabled:      true,
+				},
+				Branch:      String("/^release-.*$/"),
+				BranchRegex: regexp.MustCompile("^release-.*$"),
+			},
+		},
 		{
 			description: "workspace set to empty string",
 			input: raw.Project{
@@ -0,0 +1,150 @@
+package raw
+
+import (
+	"reflect"
+	"strings"
+)
+
+// SchemaURL is where the JSON Schema for atlantis.yaml is published.
+const SchemaURL = "https://www.runatlantis.io/schemas/atlantis.json"
+
+// schemaOverrides replace or add to the schemas generated for struct fields,
+// keyed by "<struct name>.<yaml key>", for constraints that can't be derived
+// from the field's type.
+var schemaOverrides = map[string]map[string]interface{}{
+	"Config.version": {"const": 2},
+	"Project.apply_requirements": {
+		"items": map[string]interface{}{"enum": supportedApplyReqs()},
+	},
+	"ProjectTemplate.apply_requirements": {
+		"items": map[string]interface{}{"enum": supportedApplyReqs()},
+	},
+}
+
+// schemaRequired are the keys that must be set in each struct.
+var schemaRequired = map[string][]string{
+	"Config":       {"version"},
+	"ApplyWindows": {"windows"},
+	"ApplyWindow":  {"start", "end"},
+}
+
+// JSONSchema returns a JSON Schema for atlantis.yaml files. It's derived from
+// Config's yaml struct tags so it stays in sync with what we parse.
+func JSONSchema() map[string]interface{} {
+	definitions := make(map[string]interface{})
+	schema := structSchema(reflect.TypeOf(Config{}), definitions)
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["$id"] = SchemaURL
+	schema["title"] = "atlantis.yaml"
+	schema["definitions"] = definitions
+	return schema
+}
+
+// typeSchema returns the schema for values of type t. Structs other than
+// Config are added to definitions and referenced.
+func typeSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), definitions)
+	case reflect.Struct:
+		if t == reflect.TypeOf(Step{}) {
+			return stepSchema()
+		}
+		if _, ok := definitions[t.Name()]; !ok {
+			// Add a placeholder first in case the struct references itself.
+			definitions[t.Name()] = nil
+			definitions[t.Name()] = structSchema(t, definitions)
+		}
+		return map[string]interface{}{"$ref": "#/definitions/" + t.Name()}
+	case reflect.Slice:
+		return map[string]interface{}{
+			"type":  "array",
+			"items": typeSchema(t.Elem(), definitions),
+		}
+	case reflect.Map:
+		return map[string]interface{}{
+			"type":                 "object",
+			"additionalProperties": typeSchema(t.Elem(), definitions),
+		}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int:
+		return map[string]interface{}{"type": "integer"}
+	default:
+		return map[string]interface{}{"type": "string"}
+	}
+}
+
+// structSchema returns the schema for struct type t. Unknown keys aren't
+// allowed because we parse atlantis.yaml strictly.
+func structSchema(t reflect.Type, definitions map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	for i := 0; i < t.NumField(); i++ {
+		key := strings.Split(t.Field(i).Tag.Get("yaml"), ",")[0]
+		if key == "" || key == "-" {
+			continue
+		}
+		fieldSchema := typeSchema(t.Field(i).Type, definitions)
+		for k, v := range schemaOverrides[t.Name()+"."+key] {
+			if items, ok := fieldSchema[k].(map[string]interface{}); ok {
+				for itemKey, itemVal := range v.(map[string]interface{}) {
+					items[itemKey] = itemVal
+				}
+				continue
+			}
+			fieldSchema[k] = v
+		}
+		properties[key] = fieldSchema
+	}
+	schema := map[string]interface{}{
+		"type":                 "object",
+		"properties":           properties,
+		"additionalProperties": false,
+	}
+	if required, ok := schemaRequired[t.Name()]; ok {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// stepSchema returns the schema for a Step, which has a custom YAML format.
+// See Step.
+func stepSchema() map[string]interface{} {
+	extraArgs := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			ExtraArgsKey: map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "string"},
+			},
+		},
+		"additionalProperties": false,
+	}
+	return map[string]interface{}{
+		"oneOf": []interface{}{
+			map[string]interface{}{
+				"type": "string",
+				"enum": []string{InitStepName, PlanStepName, ApplyStepName},
+			},
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					InitStepName:  extraArgs,
+					PlanStepName:  extraArgs,
+					ApplyStepName: extraArgs,
+				},
+				"additionalProperties": false,
+				"minProperties":        1,
+				"maxProperties":        1,
+			},
+			map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					RunStepName: map[string]interface{}{"type": "string"},
+				},
+				"required":             []string{RunStepName},
+				"additionalProperties": false,
+			},
+		},
+	}
+}
@@ -0,0 +1,39 @@
+package raw_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"testing"
+
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+// The published schema must be regenerated when the raw structs change with
+// atlantis validate-config --print-schema > runatlantis.io/.vuepress/public/schemas/atlantis.json
+func TestJSONSchema_MatchesPublished(t *testing.T) {
+	published, err := ioutil.ReadFile("../../../../runatlantis.io/.vuepress/public/schemas/atlantis.json")
+	Ok(t, err)
+	exp, err := json.MarshalIndent(raw.JSONSchema(), "", "  ")
+	Ok(t, err)
+	Equals(t, string(exp)+"\n", string(published))
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema := raw.JSONSchema()
+	Equals(t, []string{"version"}, schema["required"])
+	Equals(t, false, schema["additionalProperties"])
+
+	definitions := schema["definitions"].(map[string]interface{})
+	for _, name := range []string{"Project", "ProjectTemplate", "Autoplan", "Workflow", "Stage", "ApplyWindows", "ApplyWindow", "IndependentApprovals"} {
+		_, ok := definitions[name]
+		Assert(t, ok, "exp definition for %s", name)
+	}
+
+	project := definitions["Project"].(map[string]interface{})["properties"].(map[string]interface{})
+	for _, key := range []string{"name", "dir", "dir_glob", "template", "workspace", "workflow", "apply_requirements"} {
+		_, ok := project[key]
+		Assert(t, ok, "exp project property %s", key)
+	}
+	Equals(t, map[string]interface{}{"$ref": "#/definitions/Autoplan"}, project["autoplan"])
+}
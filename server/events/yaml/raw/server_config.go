@@ -0,0 +1,126 @@
+package raw
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-ozzo/ozzo-validation"
+	"github.com/hashicorp/go-version"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+)
+
+// ServerConfig is the server-side repo config file. It configures repos
+// before their atlantis.yaml files are read.
+type ServerConfig struct {
+	Repos []RepoConfig `yaml:"repos,omitempty"`
+	// Workflows can be used by the repos without defining them in their
+	// atlantis.yaml files.
+	Workflows map[string]Workflow `yaml:"workflows,omitempty"`
+}
+
+// RepoConfig configures the repos that match ID.
+type RepoConfig struct {
+	// ID is a repo's full name, ex. runatlantis/atlantis, or a regex between
+	// slashes that matches full names, ex. /runatlantis/.*/.
+	ID                string   `yaml:"id,omitempty"`
+	Workflow          *string  `yaml:"workflow,omitempty"`
+	ApplyRequirements []string `yaml:"apply_requirements,omitempty"`
+	TerraformVersion  *string  `yaml:"terraform_version,omitempty"`
+	// AllowedOverrides are the keys that the repos' atlantis.yaml files can
+	// set.
+	AllowedOverrides []string `yaml:"allowed_overrides,omitempty"`
+	// Branch is the base branch that pull requests must be into for Atlantis
+	// to act on them, or a regex between slashes that matches base branches,
+	// ex. /^(main|master)$/.
+	Branch *string `yaml:"branch,omitempty"`
+}
+
+func (s ServerConfig) Validate() error {
+	workflowExists := func(value interface{}) error {
+		for _, r := range value.([]RepoConfig) {
+			if r.Workflow == nil {
+				continue
+			}
+			if _, ok := s.Workflows[*r.Workflow]; !ok {
+				return fmt.Errorf("workflow %q is not defined", *r.Workflow)
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&s,
+		validation.Field(&s.Repos, validation.By(workflowExists)),
+		validation.Field(&s.Workflows),
+	)
+}
+
+func (s ServerConfig) ToValid() valid.ServerConfig {
+	var repos []valid.RepoConfig
+	for _, r := range s.Repos {
+		repos = append(repos, r.ToValid())
+	}
+	workflows := make(map[string]valid.Workflow)
+	for k, v := range s.Workflows {
+		workflows[k] = v.ToValid()
+	}
+	return valid.ServerConfig{
+		Repos:     repos,
+		Workflows: workflows,
+	}
+}
+
+func (r RepoConfig) Validate() error {
+	validID := func(value interface{}) error {
+		id := value.(string)
+		if !isRegexID(id) {
+			return nil
+		}
+		_, err := regexp.Compile(id[1 : len(id)-1])
+		return errors.Wrapf(err, "parsing %s", id)
+	}
+	validOverrides := func(value interface{}) error {
+		for _, o := range value.([]string) {
+			supported := false
+			for _, k := range valid.OverrideKeys {
+				if o == k {
+					supported = true
+				}
+			}
+			if !supported {
+				return fmt.Errorf("%q not supported, only %s are supported", o, strings.Join(valid.OverrideKeys, ", "))
+			}
+		}
+		return nil
+	}
+	return validation.ValidateStruct(&r,
+		validation.Field(&r.ID, validation.Required, validation.By(validID)),
+		validation.Field(&r.ApplyRequirements, validation.By(validApplyReqs)),
+		validation.Field(&r.TerraformVersion, validation.By(validTFVersion)),
+		validation.Field(&r.AllowedOverrides, validation.By(validOverrides)),
+		validation.Field(&r.Branch, validation.NilOrNotEmpty, validation.By(validBranch)),
+	)
+}
+
+func (r RepoConfig) ToValid() valid.RepoConfig {
+	v := valid.RepoConfig{
+		ID:                r.ID,
+		Workflow:          r.Workflow,
+		ApplyRequirements: r.ApplyRequirements,
+		AllowedOverrides:  r.AllowedOverrides,
+		Branch:            r.Branch,
+		BranchRegex:       branchRegex(r.Branch),
+	}
+	if isRegexID(r.ID) {
+		v.IDRegex = regexp.MustCompile(r.ID[1 : len(r.ID)-1])
+	}
+	if r.TerraformVersion != nil {
+		v.TerraformVersion, _ = version.NewVersion(*r.TerraformVersion)
+	}
+	return v
+}
+
+// isRegexID returns true if id is a regex between slashes.
+func isRegexID(id string) bool {
+	return len(id) > 1 && strings.HasPrefix(id, "/") && strings.HasSuffix(id, "/")
+}
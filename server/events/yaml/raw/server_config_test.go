@@ -0,0 +1,187 @@
+package raw_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/go-ozzo/ozzo-validation"
+	"github.com/hashicorp/go-version"
+	"github.com/runatlantis/atlantis/server/events/yaml/raw"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
+	. "github.com/runatlantis/atlantis/testing"
+	"gopkg.in/yaml.v2"
+)
+
+func TestServerConfig_UnmarshalYAML(t *testing.T) {
+	input := `
+repos:
+- id: /.*/
+  workflow: custom
+  apply_requirements: [approved]
+  terraform_version: v0.12.0
+  allowed_overrides: [workflow]
+workflows:
+  custom:
+    plan:
+      steps: [plan]
+`
+	var c raw.ServerConfig
+	err := yaml.UnmarshalStrict([]byte(input), &c)
+	Ok(t, err)
+	Equals(t, raw.ServerConfig{
+		Repos: []raw.RepoConfig{
+			{
+				ID:                "/.*/",
+				Workflow:          String("custom"),
+				ApplyRequirements: []string{"approved"},
+				TerraformVersion:  String("v0.12.0"),
+				AllowedOverrides:  []string{"workflow"},
+			},
+		},
+		Workflows: map[string]raw.Workflow{
+			"custom": {
+				Plan: &raw.Stage{
+					Steps: []raw.Step{{Key: String("plan")}},
+				},
+			},
+		},
+	}, c)
+}
+
+func TestServerConfig_Validate(t *testing.T) {
+	cases := []struct {
+		description string
+		input       raw.ServerConfig
+		expErr      string
+	}{
+		{
+			description: "valid",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{
+					{ID: "runatlantis/atlantis", Workflow: String("custom"), AllowedOverrides: []string{"apply_requirements", "workflow", "terraform_version", "workflows"}},
+				},
+				Workflows: map[string]raw.Workflow{"custom": {}},
+			},
+		},
+		{
+			description: "no id",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{}},
+			},
+			expErr: "repos: (0: (id: cannot be blank.).).",
+		},
+		{
+			description: "invalid id regex",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "/(/"}},
+			},
+			expErr: "repos: (0: (id: parsing /(/: error parsing regexp: missing closing ): `(`.).).",
+		},
+		{
+			description: "undefined workflow",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", Workflow: String("custom")}},
+			},
+			expErr: "repos: workflow \"custom\" is not defined.",
+		},
+		{
+			description: "unsupported override",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", AllowedOverrides: []string{"name"}}},
+			},
+			expErr: "repos: (0: (allowed_overrides: \"name\" not supported, only apply_requirements, workflow, terraform_version, apply_windows, independent_approvals, required_status_checks, branch, workflows are supported.).).",
+		},
+		{
+			description: "unsupported apply requirement",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", ApplyRequirements: []string{"reviewed"}}},
+			},
+			expErr: "repos: (0: (apply_requirements: \"reviewed\" not supported, only approved, mergeable, independently_approved, codeowners_approved and status_checks_passed are supported.).).",
+		},
+		{
+			description: "branch regex",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", Branch: String("/^(main|master)$/")}},
+			},
+		},
+		{
+			description: "empty branch",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", Branch: String("")}},
+			},
+			expErr: "repos: (0: (branch: cannot be blank.).).",
+		},
+		{
+			description: "invalid branch regex",
+			input: raw.ServerConfig{
+				Repos: []raw.RepoConfig{{ID: "runatlantis/atlantis", Branch: String("/(/")}},
+			},
+			expErr: "repos: (0: (branch: parsing /(/: error parsing regexp: missing closing ): `(`.).).",
+		},
+	}
+	validation.ErrorTag = "yaml"
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			err := c.input.Validate()
+			if c.expErr == "" {
+				Ok(t, err)
+				return
+			}
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
+func TestServerConfig_ToValid(t *testing.T) {
+	tfVersion, _ := version.NewVersion("v0.12.0")
+	input := raw.ServerConfig{
+		Repos: []raw.RepoConfig{
+			{ID: "/runatlantis/.*/", TerraformVersion: String("v0.12.0")},
+			{ID: "runatlantis/atlantis", AllowedOverrides: []string{"workflow"}},
+			{ID: "runatlantis/other", Branch: String("/^main$/")},
+		},
+	}
+	Equals(t, valid.ServerConfig{
+		Repos: []valid.RepoConfig{
+			{ID: "/runatlantis/.*/", IDRegex: regexp.MustCompile("runatlantis/.*"), TerraformVersion: tfVersion},
+			{ID: "runatlantis/atlantis", AllowedOverrides: []string{"workflow"}},
+			{ID: "runatlantis/other", Branch: String("/^main$/"), BranchRegex: regexp.MustCompile("^main$")},
+		},
+		Workflows: map[string]valid.Workflow{},
+	}, input.ToValid())
+}
+
+func TestServerConfig_MatchesBranch(t *testing.T) {
+	cfg := raw.ServerConfig{
+		Repos: []raw.RepoConfig{
+			{ID: "/.*/", Branch: String("master")},
+			{ID: "/runatlantis/.*/", Branch: String("/^(main|release-.*)$/")},
+			{ID: "runatlantis/any", Workflow: String("custom")},
+		},
+		Workflows: map[string]raw.Workflow{"custom": {}},
+	}.ToValid()
+	cases := []struct {
+		repo      string
+		branch    string
+		expMatch  bool
+		expBranch string
+	}{
+		{"owner/repo", "master", true, ""},
+		{"owner/repo", "feature", false, "master"},
+		{"runatlantis/atlantis", "main", true, ""},
+		{"runatlantis/atlantis", "release-1", true, ""},
+		{"runatlantis/atlantis", "master", false, "/^(main|release-.*)$/"},
+		// Later repo configs without a branch keep the earlier branch.
+		{"runatlantis/any", "feature", false, "/^(main|release-.*)$/"},
+	}
+	for _, c := range cases {
+		t.Run(c.repo+" "+c.branch, func(t *testing.T) {
+			match, branch := cfg.MatchesBranch(c.repo, c.branch)
+			Equals(t, c.expMatch, match)
+			Equals(t, c.expBranch, branch)
+		})
+	}
+
+	match, _ := valid.ServerConfig{}.MatchesBranch("owner/repo", "feature")
+	Equals(t, true, match)
+}
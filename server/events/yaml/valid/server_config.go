@@ -0,0 +1,206 @@
+package valid
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hashicorp/go-version"
+)
+
+// Keys that the server-side repo config can allow repos' atlantis.yaml files
+// to set.
+const (
+	ApplyRequirementsKey    = "apply_requirements"
+	WorkflowKey             = "workflow"
+	TerraformVersionKey     = "terraform_version"
+	ApplyWindowsKey         = "apply_windows"
+	IndependentApprovalsKey = "independent_approvals"
+	RequiredStatusChecksKey = "required_status_checks"
+	BranchKey               = "branch"
+	// WorkflowsKey allows repos to define their own workflows, which can run
+	// any command.
+	WorkflowsKey = "workflows"
+)
+
+// OverrideKeys are all the keys that can be in a server-side repo config's
+// allowed overrides.
+var OverrideKeys = []string{
+	ApplyRequirementsKey,
+	WorkflowKey,
+	TerraformVersionKey,
+	ApplyWindowsKey,
+	IndependentApprovalsKey,
+	RequiredStatusChecksKey,
+	BranchKey,
+	WorkflowsKey,
+}
+
+// ServerConfig is the server-side repo config after it's been parsed and
+// validated.
+type ServerConfig struct {
+	Repos     []RepoConfig
+	Workflows map[string]Workflow
+}
+
+// RepoConfig is the server-side config for the repos matching ID.
+type RepoConfig struct {
+	// ID is a repo's full name or, if IDRegex is set, a regex between
+	// slashes.
+	ID      string
+	IDRegex *regexp.Regexp
+	// Workflow, ApplyRequirements and TerraformVersion are the defaults for
+	// projects that don't set them. If nil, they aren't set.
+	Workflow          *string
+	ApplyRequirements []string
+	TerraformVersion  *version.Version
+	// AllowedOverrides are the keys that the repos' atlantis.yaml files can
+	// set, ex. workflow.
+	AllowedOverrides []string
+	// Branch is the base branch that pull requests must be into for Atlantis
+	// to act on them or, if BranchRegex is set, a regex between slashes. If
+	// nil, pull requests into any branch are acted on.
+	Branch      *string
+	BranchRegex *regexp.Regexp
+}
+
+// ForRepo returns the config for repoFullName. If more than one repo config
+// matches, keys set by later configs override earlier ones. It returns false
+// if no repo config matches.
+func (s ServerConfig) ForRepo(repoFullName string) (RepoConfig, bool) {
+	var merged RepoConfig
+	matched := false
+	for _, r := range s.Repos {
+		if !r.Matches(repoFullName) {
+			continue
+		}
+		matched = true
+		merged.ID = r.ID
+		merged.IDRegex = r.IDRegex
+		if r.Workflow != nil {
+			merged.Workflow = r.Workflow
+		}
+		if r.ApplyRequirements != nil {
+			merged.ApplyRequirements = r.ApplyRequirements
+		}
+		if r.TerraformVersion != nil {
+			merged.TerraformVersion = r.TerraformVersion
+		}
+		if r.AllowedOverrides != nil {
+			merged.AllowedOverrides = r.AllowedOverrides
+		}
+		if r.Branch != nil {
+			merged.Branch = r.Branch
+			merged.BranchRegex = r.BranchRegex
+		}
+	}
+	return merged, matched
+}
+
+// MatchesBranch returns true if Atlantis should act on repoFullName's pull
+// requests into baseBranch. If it shouldn't, it also returns the repo's
+// branch config.
+func (s ServerConfig) MatchesBranch(repoFullName string, baseBranch string) (bool, string) {
+	r, ok := s.ForRepo(repoFullName)
+	if !ok || r.MatchesBranch(baseBranch) {
+		return true, ""
+	}
+	return false, *r.Branch
+}
+
+// Matches returns true if repoFullName matches the config's ID.
+func (r RepoConfig) Matches(repoFullName string) bool {
+	if r.IDRegex != nil {
+		return r.IDRegex.MatchString(repoFullName)
+	}
+	return r.ID == repoFullName
+}
+
+// MatchesBranch returns true if Atlantis should act on the repo's pull
+// requests into baseBranch.
+func (r RepoConfig) MatchesBranch(baseBranch string) bool {
+	return matchesBranch(r.Branch, r.BranchRegex, baseBranch)
+}
+
+// AllowsOverride returns true if atlantis.yaml files can set key.
+func (r RepoConfig) AllowsOverride(key string) bool {
+	for _, o := range r.AllowedOverrides {
+		if o == key {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateRepoCfg returns an error if the repo's atlantis.yaml config sets
+// keys that aren't in the allowed overrides.
+func (r RepoConfig) ValidateRepoCfg(repoCfg Config) error {
+	if len(repoCfg.Workflows) > 0 && !r.AllowsOverride(WorkflowsKey) {
+		return overrideErr(WorkflowsKey, "")
+	}
+	if repoCfg.Branch != nil && !r.AllowsOverride(BranchKey) {
+		return overrideErr(BranchKey, "")
+	}
+	for _, p := range repoCfg.Projects {
+		project := fmt.Sprintf(" in project at dir %q workspace %q", p.Dir, p.Workspace)
+		if p.ApplyRequirements != nil && !r.AllowsOverride(ApplyRequirementsKey) {
+			return overrideErr(ApplyRequirementsKey, project)
+		}
+		if p.Workflow != nil && !r.AllowsOverride(WorkflowKey) {
+			return overrideErr(WorkflowKey, project)
+		}
+		if p.TerraformVersion != nil && !r.AllowsOverride(TerraformVersionKey) {
+			return overrideErr(TerraformVersionKey, project)
+		}
+		if p.ApplyWindows != nil && !r.AllowsOverride(ApplyWindowsKey) {
+			return overrideErr(ApplyWindowsKey, project)
+		}
+		if p.IndependentApprovals != nil && !r.AllowsOverride(IndependentApprovalsKey) {
+			return overrideErr(IndependentApprovalsKey, project)
+		}
+		if p.RequiredStatusChecks != nil && !r.AllowsOverride(RequiredStatusChecksKey) {
+			return overrideErr(RequiredStatusChecksKey, project)
+		}
+		if p.Branch != nil && !r.AllowsOverride(BranchKey) {
+			return overrideErr(BranchKey, project)
+		}
+	}
+	return nil
+}
+
+// MergeProject returns project with the keys it doesn't set set to the
+// server-side defaults.
+func (r RepoConfig) MergeProject(project Project) Project {
+	if project.ApplyRequirements == nil {
+		project.ApplyRequirements = r.ApplyRequirements
+	}
+	if project.Workflow == nil {
+		project.Workflow = r.Workflow
+	}
+	if project.TerraformVersion == nil {
+		project.TerraformVersion = r.TerraformVersion
+	}
+	return project
+}
+
+// MergeConfig returns the repo's atlantis.yaml config merged with the
+// server-side config. Workflows defined in repoCfg replace server-side
+// workflows with the same name.
+func (s ServerConfig) MergeConfig(r RepoConfig, repoCfg Config) Config {
+	merged := repoCfg
+	merged.Workflows = make(map[string]Workflow)
+	for k, v := range s.Workflows {
+		merged.Workflows[k] = v
+	}
+	for k, v := range repoCfg.Workflows {
+		merged.Workflows[k] = v
+	}
+	merged.Projects = nil
+	for _, p := range repoCfg.Projects {
+		merged.Projects = append(merged.Projects, r.MergeProject(p))
+	}
+	return merged
+}
+
+func overrideErr(key string, where string) error {
+	return fmt.Errorf("repo config not allowed to set %q key%s: server-side config needs \"allowed_overrides: [%s]\"", key, where, key)
+}
@@ -2,7 +2,14 @@
 // after it's been parsed and validated.
 package valid
 
-import "github.com/hashicorp/go-version"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-version"
+)
 
 // Config is the atlantis.yaml config after it's been parsed and validated.
 type Config struct {
@@ -12,6 +19,17 @@ type Config struct {
 	Projects  []Project
 	Workflows map[string]Workflow
 	Automerge bool
+	// Branch is the base branch that pull requests must be into for Atlantis
+	// to act on them or, if BranchRegex is set, a regex between slashes. If
+	// nil, pull requests into any branch are acted on.
+	Branch      *string
+	BranchRegex *regexp.Regexp
+}
+
+// MatchesBranch returns true if Atlantis should act on the repo's pull
+// requests into baseBranch.
+func (c Config) MatchesBranch(baseBranch string) bool {
+	return matchesBranch(c.Branch, c.BranchRegex, baseBranch)
 }
 
 func (c Config) GetPlanStage(workflowName string) *Stage {
@@ -70,6 +88,32 @@ type Project struct {
 	TerraformVersion  *version.Version
 	Autoplan          Autoplan
 	ApplyRequirements []string
+	// ApplyWindows restricts when the project can be applied. If nil, it can
+	// be applied at any time.
+	ApplyWindows *ApplyWindows
+	// IndependentApprovals configures the independently_approved apply
+	// requirement. If nil, the defaults are used.
+	IndependentApprovals *IndependentApprovals
+	// RequiredStatusChecks are name patterns of the status checks that must
+	// pass for the status_checks_passed apply requirement. If empty, all
+	// status checks must pass.
+	RequiredStatusChecks []string
+	// Branch is the base branch that pull requests must be into for the
+	// project to be planned or applied or, if BranchRegex is set, a regex
+	// between slashes. If nil, pull requests into any branch are acted on.
+	Branch      *string
+	BranchRegex *regexp.Regexp
+}
+
+// IndependentApprovals configures the independently_approved apply
+// requirement.
+type IndependentApprovals struct {
+	// Count is how many approvals are required from users other than the
+	// pull request's author and the user running apply.
+	Count int
+	// InvalidateOnNewCommits is whether approvals of earlier commits are
+	// ignored.
+	InvalidateOnNewCommits bool
 }
 
 // GetName returns the name of the project or an empty string if there is no
@@ -81,11 +125,124 @@ func (p Project) GetName() string {
 	return ""
 }
 
+// MatchesBranch returns true if the project should be planned and applied on
+// pull requests into baseBranch.
+func (p Project) MatchesBranch(baseBranch string) bool {
+	return matchesBranch(p.Branch, p.BranchRegex, baseBranch)
+}
+
+// matchesBranch returns true if baseBranch matches branch, or branchRegex if
+// it's set. If branch is nil, every branch matches.
+func matchesBranch(branch *string, branchRegex *regexp.Regexp, baseBranch string) bool {
+	if branch == nil {
+		return true
+	}
+	if branchRegex != nil {
+		return branchRegex.MatchString(baseBranch)
+	}
+	return *branch == baseBranch
+}
+
 type Autoplan struct {
 	WhenModified []string
 	Enabled      bool
 }
 
+// ApplyWindows are the times at which a project can be applied.
+type ApplyWindows struct {
+	Location *time.Location
+	Windows  []ApplyWindow
+}
+
+// ApplyWindow is a time range on certain days of the week.
+type ApplyWindow struct {
+	Days []time.Weekday
+	// Start and End are the time since midnight that the window opens and
+	// closes. If End is before Start, the window is overnight and closes on
+	// the day after it opens.
+	Start time.Duration
+	End   time.Duration
+}
+
+// IsOpen returns true if t is within one of the windows.
+func (a ApplyWindows) IsOpen(t time.Time) bool {
+	local := t.In(a.Location)
+	clock := time.Duration(local.Hour())*time.Hour + time.Duration(local.Minute())*time.Minute + time.Duration(local.Second())*time.Second
+	for _, w := range a.Windows {
+		if w.isOpen(local.Weekday(), clock) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next window after t opens. It returns the zero
+// time if no window will ever open.
+func (a ApplyWindows) NextOpen(t time.Time) time.Time {
+	local := t.In(a.Location)
+	var next time.Time
+	// Windows repeat weekly so one will open within the next 8 days.
+	for i := 0; i <= 7; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, a.Location)
+		for _, w := range a.Windows {
+			if !w.hasDay(day.Weekday()) {
+				continue
+			}
+			open := time.Date(day.Year(), day.Month(), day.Day(), int(w.Start.Hours()), int(w.Start.Minutes())%60, 0, 0, a.Location)
+			if open.After(local) && (next.IsZero() || open.Before(next)) {
+				next = open
+			}
+		}
+		if !next.IsZero() {
+			return next
+		}
+	}
+	return next
+}
+
+// String returns the windows in a human readable format, ex.
+// "Mon-Thu 09:00-16:00 America/New_York".
+func (a ApplyWindows) String() string {
+	var windows []string
+	for _, w := range a.Windows {
+		windows = append(windows, w.String())
+	}
+	return fmt.Sprintf("%s %s", strings.Join(windows, ", "), a.Location)
+}
+
+// String returns the window in a human readable format, ex. "Mon,Wed 09:00-16:00".
+func (w ApplyWindow) String() string {
+	var days []string
+	for _, d := range w.Days {
+		days = append(days, d.String()[:3])
+	}
+	return fmt.Sprintf("%s %s-%s", strings.Join(days, ","), formatClock(w.Start), formatClock(w.End))
+}
+
+// isOpen returns true if the window is open at clock on day.
+func (w ApplyWindow) isOpen(day time.Weekday, clock time.Duration) bool {
+	if w.Start < w.End {
+		return w.hasDay(day) && clock >= w.Start && clock < w.End
+	}
+	// Overnight windows are open from Start on their days until End on the
+	// following days.
+	yesterday := (day + 6) % 7
+	return (w.hasDay(day) && clock >= w.Start) || (w.hasDay(yesterday) && clock < w.End)
+}
+
+func (w ApplyWindow) hasDay(day time.Weekday) bool {
+	for _, d := range w.Days {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
+
+func formatClock(d time.Duration) string {
+	return fmt.Sprintf("%02d:%02d", int(d.Hours()), int(d.Minutes())%60)
+}
+
 type Stage struct {
 	Steps []Step
 }
@@ -14,6 +14,7 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -24,9 +25,12 @@ import (
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/azuredevops"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
 )
 
 const githubHeader = "X-Github-Event"
@@ -38,6 +42,11 @@ const bitbucketCloudRequestIDHeader = "X-Request-UUID"
 const bitbucketServerRequestIDHeader = "X-Request-ID"
 const bitbucketServerSignatureHeader = "X-Hub-Signature"
 
+// azuredevopsRequestIDHeader is sent with every Azure DevOps service hook
+// request. Azure DevOps doesn't send an event type header so the event type is
+// read from the body.
+const azuredevopsRequestIDHeader = "Request-Id"
+
 // EventsController handles all webhook requests which signify 'events' in the
 // VCS host, ex. GitHub.
 type EventsController struct {
@@ -57,6 +66,10 @@ type EventsController struct {
 	// request validation is done.
 	GitlabWebhookSecret  []byte
 	RepoWhitelistChecker *events.RepoWhitelistChecker
+	// CommenterPermissionChecker checks that users have enough permission on
+	// the repo to run commands and to have their pull requests autoplanned.
+	// If nil, anyone who can comment or open a pull request can run them.
+	CommenterPermissionChecker *events.CommenterPermissionChecker
 	// SilenceWhitelistErrors controls whether we write an error comment on
 	// pull requests from non-whitelisted repos.
 	SilenceWhitelistErrors bool
@@ -69,6 +82,16 @@ type EventsController struct {
 	// UI that identifies this call as coming from Bitbucket. If empty, no
 	// request validation is done.
 	BitbucketWebhookSecret []byte
+	// AzureDevopsWebhookUser and AzureDevopsWebhookPassword are the basic auth
+	// credentials added to the Azure DevOps service hooks that identify calls
+	// as coming from Azure DevOps. If both are empty, no request validation is
+	// done.
+	AzureDevopsWebhookUser     []byte
+	AzureDevopsWebhookPassword []byte
+	// ServerConfig is the server-side repo config. It's used to ignore pull
+	// requests into base branches that don't match the repo's branch config.
+	// If nil, pull requests into any branch are acted on.
+	ServerConfig *valid.ServerConfig
 }
 
 // Post handles POST webhook requests.
@@ -109,6 +132,14 @@ func (e *EventsController) Post(w http.ResponseWriter, r *http.Request) {
 			e.handleBitbucketServerPost(w, r)
 			return
 		}
+	} else if r.Header.Get(azuredevopsRequestIDHeader) != "" {
+		if !e.supportsHost(models.AzureDevops) {
+			e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request since not configured to support Azure DevOps")
+			return
+		}
+		e.Logger.Debug("handling Azure DevOps post")
+		e.handleAzureDevopsPost(w, r)
+		return
 	}
 	e.respond(w, logging.Debug, http.StatusBadRequest, "Ignoring request")
 }
@@ -121,6 +152,7 @@ func (e *EventsController) handleGithubPost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	e.Logger.Debug("request valid")
+	metrics.WebhookEvents.WithLabelValues(models.Github.String(), github.WebHookType(r)).Inc()
 
 	githubReqID := "X-Github-Delivery=" + r.Header.Get("X-Github-Delivery")
 	event, _ := github.ParseWebHook(github.WebHookType(r), payload)
@@ -145,6 +177,7 @@ func (e *EventsController) handleBitbucketCloudPost(w http.ResponseWriter, r *ht
 		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s %s=%s", err, bitbucketCloudRequestIDHeader, reqID)
 		return
 	}
+	metrics.WebhookEvents.WithLabelValues(models.BitbucketCloud.String(), eventType).Inc()
 	switch eventType {
 	case bitbucketcloud.PullCreatedHeader, bitbucketcloud.PullUpdatedHeader, bitbucketcloud.PullFulfilledHeader, bitbucketcloud.PullRejectedHeader:
 		e.Logger.Debug("handling as pull request state changed event")
@@ -181,6 +214,7 @@ func (e *EventsController) handleBitbucketServerPost(w http.ResponseWriter, r *h
 			return
 		}
 	}
+	metrics.WebhookEvents.WithLabelValues(models.BitbucketServer.String(), eventType).Inc()
 	switch eventType {
 	case bitbucketserver.PullCreatedHeader, bitbucketserver.PullMergedHeader, bitbucketserver.PullDeclinedHeader, bitbucketserver.PullDeletedHeader:
 		e.Logger.Debug("handling as pull request state changed event")
@@ -195,6 +229,44 @@ func (e *EventsController) handleBitbucketServerPost(w http.ResponseWriter, r *h
 	}
 }
 
+func (e *EventsController) handleAzureDevopsPost(w http.ResponseWriter, r *http.Request) {
+	reqID := r.Header.Get(azuredevopsRequestIDHeader)
+	// If basic auth isn't configured, we warn at startup. Anyone could then
+	// send us events but the repos in them must still be on the Azure DevOps
+	// host we're configured for, since we send our token to it.
+	if len(e.AzureDevopsWebhookUser) > 0 || len(e.AzureDevopsWebhookPassword) > 0 {
+		if err := azuredevops.ValidateBasicAuth(r, e.AzureDevopsWebhookUser, e.AzureDevopsWebhookPassword); err != nil {
+			e.respond(w, logging.Warn, http.StatusBadRequest, "request did not pass validation: %s", err)
+			return
+		}
+	}
+	defer r.Body.Close() // nolint: errcheck
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read body: %s %s=%s", err, azuredevopsRequestIDHeader, reqID)
+		return
+	}
+	var event azuredevops.Event
+	if err := json.Unmarshal(body, &event); err != nil || event.EventType == nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Unable to read event type from body %s=%s", azuredevopsRequestIDHeader, reqID)
+		return
+	}
+	eventType := *event.EventType
+	metrics.WebhookEvents.WithLabelValues(models.AzureDevops.String(), eventType).Inc()
+	switch eventType {
+	case azuredevops.PullCreatedEvent, azuredevops.PullUpdatedEvent, azuredevops.PullMergedEvent:
+		e.Logger.Debug("handling as pull request state changed event")
+		e.handleAzureDevopsPullRequestEvent(w, body, reqID)
+		return
+	case azuredevops.PullCommentedEvent:
+		e.Logger.Debug("handling as comment created event")
+		e.HandleAzureDevopsCommentEvent(w, body, reqID)
+		return
+	default:
+		e.respond(w, logging.Debug, http.StatusOK, "Ignoring unsupported event type %s %s=%s", eventType, azuredevopsRequestIDHeader, reqID)
+	}
+}
+
 // HandleGithubCommentEvent handles comment events from GitHub where Atlantis
 // commands can come from. It's exported to make testing easier.
 func (e *EventsController) HandleGithubCommentEvent(w http.ResponseWriter, event *github.IssueCommentEvent, githubReqID string) {
@@ -234,6 +306,16 @@ func (e *EventsController) HandleBitbucketServerCommentEvent(w http.ResponseWrit
 	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.BitbucketCloud)
 }
 
+// HandleAzureDevopsCommentEvent handles comment events from Azure DevOps.
+func (e *EventsController) HandleAzureDevopsCommentEvent(w http.ResponseWriter, body []byte, reqID string) {
+	pull, baseRepo, headRepo, user, comment, err := e.Parser.ParseAzureDevopsPullCommentEvent(body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, azuredevopsRequestIDHeader, reqID)
+		return
+	}
+	e.handleCommentEvent(w, baseRepo, &headRepo, &pull, user, pull.Num, comment, models.AzureDevops)
+}
+
 func (e *EventsController) handleBitbucketCloudPullRequestEvent(w http.ResponseWriter, eventType string, body []byte, reqID string) {
 	pull, baseRepo, headRepo, user, err := e.Parser.ParseBitbucketCloudPullEvent(body)
 	if err != nil {
@@ -256,6 +338,16 @@ func (e *EventsController) handleBitbucketServerPullRequestEvent(w http.Response
 	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
 }
 
+func (e *EventsController) handleAzureDevopsPullRequestEvent(w http.ResponseWriter, body []byte, reqID string) {
+	pull, pullEventType, baseRepo, headRepo, user, err := e.Parser.ParseAzureDevopsPullEvent(body)
+	if err != nil {
+		e.respond(w, logging.Error, http.StatusBadRequest, "Error parsing pull data: %s %s=%s", err, azuredevopsRequestIDHeader, reqID)
+		return
+	}
+	e.Logger.Info("identified event as type %q", pullEventType.String())
+	e.handlePullRequestEvent(w, baseRepo, headRepo, pull, user, pullEventType)
+}
+
 // HandleGithubPullRequestEvent will delete any locks associated with the pull
 // request if the event is a pull request closed event. It's exported to make
 // testing easier.
@@ -284,7 +376,38 @@ func (e *EventsController) handlePullRequestEvent(w http.ResponseWriter, baseRep
 
 	switch eventType {
 	case models.OpenedPullEvent, models.UpdatedPullEvent:
-		// If the pull request was opened or updated, we will try to autoplan.
+		// If the pull request was opened or updated, we will try to autoplan
+		// unless it's into a branch that Atlantis shouldn't act on. The branch
+		// config of the repo's atlantis.yaml file can only be read once it's
+		// cloned so it's checked when autoplanning.
+		if e.ServerConfig != nil {
+			if ok, branch := e.ServerConfig.MatchesBranch(baseRepo.FullName, pull.BaseBranch); !ok {
+				e.respond(w, logging.Debug, http.StatusOK, "Ignoring pull request event into branch %q because it doesn't match the repo's branch config %q", pull.BaseBranch, branch)
+				return
+			}
+		}
+
+		// Autoplanning runs a plan so whoever opened or pushed to the pull
+		// request needs the same permission as if they'd commented.
+		if e.CommenterPermissionChecker != nil {
+			allowed, required, err := e.CommenterPermissionChecker.HasPermission(baseRepo, user.Username, models.PlanCommand)
+			if err != nil {
+				if eventType == models.OpenedPullEvent {
+					e.commentPermissionCheckFailed(baseRepo, pull.Num, user, required, err)
+				}
+				e.respond(w, logging.Error, http.StatusInternalServerError, "Error checking %s's permission: %s", user.Username, err)
+				return
+			}
+			if !allowed {
+				// Like with non-whitelisted repos, we only comment when the
+				// pull request is opened so we don't comment on every push.
+				if eventType == models.OpenedPullEvent {
+					e.commentNoAutoplanPermission(baseRepo, pull.Num, user, required)
+				}
+				e.respond(w, logging.Warn, http.StatusForbidden, "Not autoplanning because user %s does not have %s permission", user.Username, required)
+				return
+			}
+		}
 
 		// Respond with success and then actually execute the command asynchronously.
 		// We use a goroutine so that this function returns and the connection is
@@ -322,6 +445,7 @@ func (e *EventsController) handleGitlabPost(w http.ResponseWriter, r *http.Reque
 		return
 	}
 	e.Logger.Debug("request valid")
+	metrics.WebhookEvents.WithLabelValues(models.Gitlab.String(), r.Header.Get(gitlabHeader)).Inc()
 
 	switch event := event.(type) {
 	case gitlab.MergeCommentEvent:
@@ -384,6 +508,20 @@ func (e *EventsController) handleCommentEvent(w http.ResponseWriter, baseRepo mo
 		return
 	}
 
+	if e.CommenterPermissionChecker != nil && parseResult.Command != nil {
+		allowed, required, err := e.CommenterPermissionChecker.HasPermission(baseRepo, user.Username, parseResult.Command.Name)
+		if err != nil {
+			e.commentPermissionCheckFailed(baseRepo, pullNum, user, required, err)
+			e.respond(w, logging.Error, http.StatusInternalServerError, "Error checking %s's permission: %s", user.Username, err)
+			return
+		}
+		if !allowed {
+			e.commentNoPermission(baseRepo, pullNum, user, parseResult.Command.Name, required)
+			e.respond(w, logging.Warn, http.StatusForbidden, "User %s does not have %s permission", user.Username, required)
+			return
+		}
+	}
+
 	e.Logger.Debug("executing command")
 	fmt.Fprintln(w, "Processing...")
 	if !e.TestingMode {
@@ -427,6 +565,33 @@ func (e *EventsController) respond(w http.ResponseWriter, lvl logging.LogLevel,
 	fmt.Fprintln(w, response)
 }
 
+// commentNoPermission comments on the pull request that user doesn't have
+// enough permission to run cmd.
+func (e *EventsController) commentNoPermission(baseRepo models.Repo, pullNum int, user models.User, cmd models.CommandName, required models.PermissionLevel) {
+	msg := fmt.Sprintf("Sorry @%s, you need %s permission on this repo to run `atlantis %s`. Please ask a repo admin for access.", user.Username, required, cmd)
+	if err := e.VCSClient.CreateComment(baseRepo, pullNum, msg); err != nil {
+		e.Logger.Err("unable to comment on pull request: %s", err)
+	}
+}
+
+// commentNoAutoplanPermission comments on the pull request that user doesn't
+// have enough permission for it to be autoplanned.
+func (e *EventsController) commentNoAutoplanPermission(baseRepo models.Repo, pullNum int, user models.User, required models.PermissionLevel) {
+	msg := fmt.Sprintf("Sorry @%s, you need %s permission on this repo for Atlantis to autoplan your pull request. A user with that permission can comment `atlantis plan` instead.", user.Username, required)
+	if err := e.VCSClient.CreateComment(baseRepo, pullNum, msg); err != nil {
+		e.Logger.Err("unable to comment on pull request: %s", err)
+	}
+}
+
+// commentPermissionCheckFailed comments on the pull request that user's
+// permission couldn't be looked up so the command didn't run.
+func (e *EventsController) commentPermissionCheckFailed(baseRepo models.Repo, pullNum int, user models.User, required models.PermissionLevel, checkErr error) {
+	msg := fmt.Sprintf("Sorry @%s, Atlantis couldn't check that you have %s permission on this repo so it didn't run:\n```\n%s\n```\nOn Bitbucket, the Atlantis user must be an admin of the repo to look up permissions.", user.Username, required, checkErr)
+	if err := e.VCSClient.CreateComment(baseRepo, pullNum, msg); err != nil {
+		e.Logger.Err("unable to comment on pull request: %s", err)
+	}
+}
+
 // commentNotWhitelisted comments on the pull request that the repo is not
 // whitelisted unless whitelist error comments are disabled.
 func (e *EventsController) commentNotWhitelisted(baseRepo models.Repo, pullNum int) {
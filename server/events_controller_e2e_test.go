@@ -435,6 +435,7 @@ func setupE2E(t *testing.T) (server.EventsController, *vcsmocks.MockClient, *moc
 			AllowRepoConfig:     true,
 			PendingPlanFinder:   &events.DefaultPendingPlanFinder{},
 			CommentBuilder:      commentParser,
+			AutoplanVerbose:     true,
 		},
 		DB:                boltdb,
 		PendingPlanFinder: &events.DefaultPendingPlanFinder{},
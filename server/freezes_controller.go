@@ -0,0 +1,93 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// NewFreezeSchedule validates the user's freeze schedule and converts it
+// into freezes.
+func NewFreezeSchedule(configs []FreezeScheduleConfig) ([]models.Freeze, error) {
+	var freezes []models.Freeze
+	for i, c := range configs {
+		start, err := time.Parse(time.RFC3339, c.Start)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled freeze at index %d has invalid start %q: must be an RFC3339 timestamp, ex. 2019-12-20T00:00:00Z", i, c.Start)
+		}
+		end, err := time.Parse(time.RFC3339, c.End)
+		if err != nil {
+			return nil, fmt.Errorf("scheduled freeze at index %d has invalid end %q: must be an RFC3339 timestamp, ex. 2019-12-20T00:00:00Z", i, c.End)
+		}
+		if !end.After(start) {
+			return nil, fmt.Errorf("scheduled freeze at index %d must end after it starts", i)
+		}
+		if c.Reason == "" {
+			return nil, fmt.Errorf("scheduled freeze at index %d must have a reason", i)
+		}
+		freezes = append(freezes, models.Freeze{
+			ID:           fmt.Sprintf("schedule-%d", i),
+			RepoFullName: c.Repo,
+			ProjectGlob:  c.Projects,
+			Reason:       c.Reason,
+			CreatedBy:    events.ScheduledFreezeCreator,
+			CreatedAt:    start,
+			Start:        start,
+			End:          end,
+		})
+	}
+	return freezes, nil
+}
+
+// FreezesController handles the UI's requests to freeze and unfreeze applies.
+type FreezesController struct {
+	Freezes *events.FreezeManager
+	Logger  *logging.SimpleLogger
+}
+
+// CreateFreeze is the POST /freezes route. It freezes applies for the repo
+// and projects in the request's JSON body.
+func (f *FreezesController) CreateFreeze(w http.ResponseWriter, r *http.Request) {
+	var req APIFreezeRequest
+	if err := decodeJSONBody(r, &req); err != nil {
+		f.respond(w, logging.Warn, http.StatusBadRequest, "Invalid request: %s", err)
+		return
+	}
+	createdBy := auth.Username(r)
+	if createdBy == "" {
+		createdBy = "the Atlantis UI"
+	}
+	freeze, err := f.Freezes.Freeze(req.Repo, req.Projects, req.Reason, createdBy)
+	if err != nil {
+		f.respond(w, logging.Warn, http.StatusBadRequest, "Failed freezing applies: %s", err)
+		return
+	}
+	f.respond(w, logging.Info, http.StatusOK, "Froze applies for %s: %s", freeze.Scope(), freeze.Reason)
+}
+
+// DeleteFreeze is the DELETE /freezes?id={id} route.
+func (f *FreezesController) DeleteFreeze(w http.ResponseWriter, r *http.Request) {
+	id := r.URL.Query().Get("id")
+	freeze, err := f.Freezes.Unfreeze(id)
+	if err != nil {
+		f.respond(w, logging.Error, http.StatusInternalServerError, "Failed deleting freeze: %s", err)
+		return
+	}
+	if freeze == nil {
+		f.respond(w, logging.Info, http.StatusNotFound, "No freeze found at id %q", id)
+		return
+	}
+	f.respond(w, logging.Info, http.StatusOK, "Unfroze applies for %s", freeze.Scope())
+}
+
+func (f *FreezesController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	f.Logger.Log(lvl, "%s", response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
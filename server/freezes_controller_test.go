@@ -0,0 +1,131 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestNewFreezeSchedule(t *testing.T) {
+	cases := []struct {
+		description string
+		config      server.FreezeScheduleConfig
+		expErr      string
+	}{
+		{
+			"invalid start",
+			server.FreezeScheduleConfig{Start: "2019-12-20", End: "2020-01-02T00:00:00Z", Reason: "holidays"},
+			"scheduled freeze at index 0 has invalid start \"2019-12-20\": must be an RFC3339 timestamp, ex. 2019-12-20T00:00:00Z",
+		},
+		{
+			"invalid end",
+			server.FreezeScheduleConfig{Start: "2019-12-20T00:00:00Z", End: "", Reason: "holidays"},
+			"scheduled freeze at index 0 has invalid end \"\": must be an RFC3339 timestamp, ex. 2019-12-20T00:00:00Z",
+		},
+		{
+			"end before start",
+			server.FreezeScheduleConfig{Start: "2020-01-02T00:00:00Z", End: "2019-12-20T00:00:00Z", Reason: "holidays"},
+			"scheduled freeze at index 0 must end after it starts",
+		},
+		{
+			"no reason",
+			server.FreezeScheduleConfig{Start: "2019-12-20T00:00:00Z", End: "2020-01-02T00:00:00Z"},
+			"scheduled freeze at index 0 must have a reason",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			_, err := server.NewFreezeSchedule([]server.FreezeScheduleConfig{c.config})
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+
+	freezes, err := server.NewFreezeSchedule([]server.FreezeScheduleConfig{
+		{Start: "2019-12-20T00:00:00Z", End: "2020-01-02T00:00:00Z", Repo: "owner/repo", Projects: "prod/*", Reason: "holidays"},
+	})
+	Ok(t, err)
+	start := time.Date(2019, 12, 20, 0, 0, 0, 0, time.UTC)
+	Equals(t, []models.Freeze{
+		{
+			ID:           "schedule-0",
+			RepoFullName: "owner/repo",
+			ProjectGlob:  "prod/*",
+			Reason:       "holidays",
+			CreatedBy:    events.ScheduledFreezeCreator,
+			CreatedAt:    start,
+			Start:        start,
+			End:          time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC),
+		},
+	}, freezes)
+}
+
+func setupFreezesController(t *testing.T) (server.FreezesController, func()) {
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	return server.FreezesController{
+		Freezes: &events.FreezeManager{DB: boltdb},
+		Logger:  logging.NewNoopLogger(),
+	}, cleanup
+}
+
+func TestCreateFreeze(t *testing.T) {
+	fc, cleanup := setupFreezesController(t)
+	defer cleanup()
+
+	req, _ := http.NewRequest("POST", "/freezes", strings.NewReader(`{"repo": "owner/repo", "reason": "migration"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	fc.CreateFreeze(w, auth.SetUsername(req, "alice"))
+	responseContains(t, w, http.StatusOK, "Froze applies for owner/repo: migration")
+
+	freezes, err := fc.Freezes.ActiveFreezes(time.Now())
+	Ok(t, err)
+	Equals(t, 1, len(freezes))
+	Equals(t, "alice", freezes[0].CreatedBy)
+
+	req, _ = http.NewRequest("POST", "/freezes", strings.NewReader(`{"repo": "owner/repo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w = httptest.NewRecorder()
+	fc.CreateFreeze(w, req)
+	responseContains(t, w, http.StatusBadRequest, "Failed freezing applies: a reason must be given")
+
+	// Form-encoded requests can be sent cross-origin without a preflight so
+	// they must be rejected.
+	form := url.Values{"repo": {"owner/repo"}, "reason": {"migration"}}
+	req, _ = http.NewRequest("POST", "/freezes", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w = httptest.NewRecorder()
+	fc.CreateFreeze(w, req)
+	responseContains(t, w, http.StatusBadRequest, "Invalid request: Content-Type must be application/json")
+	freezes, err = fc.Freezes.ActiveFreezes(time.Now())
+	Ok(t, err)
+	Equals(t, 1, len(freezes))
+}
+
+func TestDeleteFreeze(t *testing.T) {
+	fc, cleanup := setupFreezesController(t)
+	defer cleanup()
+	freeze, err := fc.Freezes.Freeze("", "", "migration", "alice")
+	Ok(t, err)
+
+	req, _ := http.NewRequest("DELETE", "/freezes?id="+freeze.ID, nil)
+	w := httptest.NewRecorder()
+	fc.DeleteFreeze(w, req)
+	responseContains(t, w, http.StatusOK, "Unfroze applies for all repos")
+
+	w = httptest.NewRecorder()
+	fc.DeleteFreeze(w, req)
+	responseContains(t, w, http.StatusNotFound, "No freeze found")
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// streamKeepaliveInterval is how often we send a comment down an idle output
+// stream so that proxies don't time out the connection.
+const streamKeepaliveInterval = 15 * time.Second
+
+// JobsController handles requests for the output of the commands Atlantis is
+// running.
+type JobsController struct {
+	AtlantisVersion string
+	AtlantisURL     *url.URL
+	Jobs            *events.JobTracker
+	Logger          *logging.SimpleLogger
+	JobTemplate     TemplateWriter
+}
+
+// GetJob is the GET /jobs/{id} route. It renders a page that tails the output
+// of running jobs or shows the full output of finished jobs.
+func (j *JobsController) GetJob(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	job, ok := j.Jobs.Get(id)
+	if ok && job.Status == events.RunningJobStatus {
+		j.renderJob(w, job, "", true)
+		return
+	}
+
+	// Finished jobs are rendered from the store if we have one because the
+	// tracker doesn't keep their output in memory once it's stored.
+	if store := j.Jobs.Store(); store != nil {
+		storedJob, found, err := store.Get(id)
+		if err != nil {
+			j.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting job: %s", err)
+			return
+		}
+		if found {
+			output, err := j.readOutput(store, id)
+			if err != nil {
+				j.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting job output: %s", err)
+				return
+			}
+			j.renderJob(w, storedJob, output, false)
+			return
+		}
+	}
+	if ok {
+		j.renderJob(w, job, "", true)
+		return
+	}
+	j.respond(w, logging.Info, http.StatusNotFound, "No job found at id %q", id)
+}
+
+func (j *JobsController) renderJob(w http.ResponseWriter, job events.Job, output string, live bool) {
+	viewData := JobDetailData{
+		JobID:           job.ID,
+		RepoFullName:    job.RepoFullName,
+		PullNum:         job.PullNum,
+		Command:         job.Command.String(),
+		Username:        job.Username,
+		Status:          job.Status.String(),
+		StartTime:       job.StartTime,
+		Live:            live,
+		Output:          output,
+		AtlantisVersion: j.AtlantisVersion,
+		CleanedBasePath: j.AtlantisURL.Path,
+	}
+	if err := j.JobTemplate.Execute(w, viewData); err != nil {
+		j.Logger.Err("rendering job template: %s", err)
+	}
+}
+
+func (j *JobsController) readOutput(store *events.JobOutputStore, id string) (string, error) {
+	out, err := store.Output(id)
+	if err != nil {
+		return "", err
+	}
+	defer out.Close() // nolint: errcheck
+	bytes, err := ioutil.ReadAll(out)
+	return string(bytes), err
+}
+
+// StreamOutput is the GET /jobs/{id}/stream route. It streams the job's output
+// as server-sent events, one event per line, with the line number as the
+// event id so browsers that reconnect pick up where they left off. Once the
+// job has finished and all its output has been sent, a "done" event is sent.
+func (j *JobsController) StreamOutput(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		j.respond(w, logging.Error, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	from := 0
+	if lastID := r.Header.Get("Last-Event-ID"); lastID != "" {
+		if num, err := strconv.Atoi(lastID); err == nil {
+			from = num + 1
+		}
+	}
+	lines, updates, unsubscribe, ok := j.Jobs.SubscribeOutput(id, from)
+	if !ok {
+		j.respond(w, logging.Info, http.StatusNotFound, "No job found at id %q", id)
+		return
+	}
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	// Stop nginx from buffering the stream.
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	for _, line := range lines {
+		writeOutputEvent(w, line)
+	}
+	flusher.Flush()
+
+	keepalive := time.NewTicker(streamKeepaliveInterval)
+	defer keepalive.Stop()
+	for {
+		select {
+		case line, open := <-updates:
+			if !open {
+				// The channel is also closed if we fell behind, in which case
+				// the browser will reconnect and catch up.
+				if job, ok := j.Jobs.Get(id); !ok || job.Status != events.RunningJobStatus {
+					fmt.Fprint(w, "event: done\ndata: \n\n") // nolint: errcheck
+					flusher.Flush()
+				}
+				return
+			}
+			writeOutputEvent(w, line)
+			flusher.Flush()
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n") // nolint: errcheck
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeOutputEvent writes line as a server-sent event.
+func writeOutputEvent(w http.ResponseWriter, line events.OutputLine) {
+	// Lines can't contain newlines but they might contain carriage returns
+	// which the event stream format treats as line endings.
+	text := strings.Replace(line.Text, "\r", "", -1)
+	fmt.Fprintf(w, "id: %d\ndata: %s\n\n", line.Num, text) // nolint: errcheck
+}
+
+func (j *JobsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	j.Logger.Log(lvl, "%s", response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
@@ -0,0 +1,170 @@
+package server_test
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	sMocks "github.com/runatlantis/atlantis/server/mocks"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestGetJob_NotFound(t *testing.T) {
+	jc := server.JobsController{
+		Jobs:   events.NewJobTracker(),
+		Logger: logging.NewNoopLogger(),
+	}
+	req, _ := http.NewRequest("GET", "", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "nope"})
+	w := httptest.NewRecorder()
+	jc.GetJob(w, req)
+	responseContains(t, w, http.StatusNotFound, `No job found at id "nope"`)
+}
+
+func TestGetJob_RendersTemplate(t *testing.T) {
+	RegisterMockTestingT(t)
+	jobs := events.NewJobTracker()
+	id := jobs.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	tmpl := sMocks.NewMockTemplateWriter()
+	atlantisURL, _ := url.Parse("https://example.com/basepath")
+	jc := server.JobsController{
+		AtlantisVersion: "1300135",
+		AtlantisURL:     atlantisURL,
+		Jobs:            jobs,
+		Logger:          logging.NewNoopLogger(),
+		JobTemplate:     tmpl,
+	}
+	req, _ := http.NewRequest("GET", "", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	jc.GetJob(w, req)
+
+	job, _ := jobs.Get(id)
+	tmpl.VerifyWasCalledOnce().Execute(w, server.JobDetailData{
+		JobID:           id,
+		RepoFullName:    "owner/repo",
+		PullNum:         1,
+		Command:         "plan",
+		Username:        "user",
+		Status:          "running",
+		StartTime:       job.StartTime,
+		Live:            true,
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "/basepath",
+	})
+}
+
+func TestGetJob_RendersStoredOutput(t *testing.T) {
+	t.Log("finished jobs should be rendered with their full output from the store")
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	store, err := events.NewJobOutputStore(tmp, 0)
+	Ok(t, err)
+	jobs := events.NewJobTrackerWithStore(store, logging.NewNoopLogger())
+	id := jobs.Start("owner/repo", 1, models.ApplyCommand, false, "user")
+	fmt.Fprint(jobs.OutputWriter(id), "line 0\nline 1\n")
+	jobs.Finish(id)
+	storedJob, _, err := store.Get(id)
+	Ok(t, err)
+
+	tmpl := sMocks.NewMockTemplateWriter()
+	atlantisURL, _ := url.Parse("https://example.com")
+	jc := server.JobsController{
+		AtlantisVersion: "1300135",
+		AtlantisURL:     atlantisURL,
+		Jobs:            jobs,
+		Logger:          logging.NewNoopLogger(),
+		JobTemplate:     tmpl,
+	}
+	req, _ := http.NewRequest("GET", "", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	jc.GetJob(w, req)
+
+	tmpl.VerifyWasCalledOnce().Execute(w, server.JobDetailData{
+		JobID:           id,
+		RepoFullName:    "owner/repo",
+		PullNum:         1,
+		Command:         "apply",
+		Username:        "user",
+		Status:          "succeeded",
+		StartTime:       storedJob.StartTime,
+		Output:          "line 0\nline 1\n",
+		AtlantisVersion: "1300135",
+		CleanedBasePath: "",
+	})
+}
+
+func TestStreamOutput_NotFound(t *testing.T) {
+	jc := server.JobsController{
+		Jobs:   events.NewJobTracker(),
+		Logger: logging.NewNoopLogger(),
+	}
+	req, _ := http.NewRequest("GET", "", nil)
+	req = mux.SetURLVars(req, map[string]string{"id": "nope"})
+	w := httptest.NewRecorder()
+	jc.StreamOutput(w, req)
+	responseContains(t, w, http.StatusNotFound, `No job found at id "nope"`)
+}
+
+func TestStreamOutput_FinishedJob(t *testing.T) {
+	jobs := events.NewJobTracker()
+	id := jobs.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	fmt.Fprint(jobs.OutputWriter(id), "line 0\nline 1\nline 2\n")
+	jobs.Finish(id)
+	jc := server.JobsController{
+		Jobs:   jobs,
+		Logger: logging.NewNoopLogger(),
+	}
+
+	t.Log("browsers that reconnect should only be sent the lines they haven't seen")
+	req, _ := http.NewRequest("GET", "", nil)
+	req.Header.Set("Last-Event-ID", "0")
+	req = mux.SetURLVars(req, map[string]string{"id": id})
+	w := httptest.NewRecorder()
+	jc.StreamOutput(w, req)
+	Equals(t, http.StatusOK, w.Code)
+	Equals(t, "text/event-stream", w.Header().Get("Content-Type"))
+	Equals(t, "id: 1\ndata: line 1\n\nid: 2\ndata: line 2\n\nevent: done\ndata: \n\n", w.Body.String())
+}
+
+func TestStreamOutput_RunningJob(t *testing.T) {
+	jobs := events.NewJobTracker()
+	id := jobs.Start("owner/repo", 1, models.PlanCommand, false, "user")
+	out := jobs.OutputWriter(id)
+	fmt.Fprintln(out, "line 0")
+	jc := server.JobsController{
+		Jobs:   jobs,
+		Logger: logging.NewNoopLogger(),
+	}
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		jc.StreamOutput(w, mux.SetURLVars(r, map[string]string{"id": id}))
+	}))
+	defer s.Close()
+
+	resp, err := http.Get(s.URL)
+	Ok(t, err)
+	defer resp.Body.Close() // nolint: errcheck
+	go func() {
+		// Give the stream time to start before writing more output.
+		time.Sleep(50 * time.Millisecond)
+		fmt.Fprintln(out, "line 1")
+		jobs.Finish(id)
+	}()
+	buf := new(strings.Builder)
+	_, err = io.Copy(buf, resp.Body)
+	Ok(t, err)
+	Equals(t, "id: 0\ndata: line 0\n\nid: 1\ndata: line 1\n\nevent: done\ndata: \n\n", buf.String())
+}
@@ -1,12 +1,19 @@
 package server
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/runatlantis/atlantis/server/events/db"
+	"mime"
 	"net/http"
 	"net/url"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -82,46 +89,197 @@ func (l *LocksController) DeleteLock(w http.ResponseWriter, r *http.Request) {
 		l.respond(w, logging.Warn, http.StatusBadRequest, "Invalid lock id %q. Failed with error: %s", id, err)
 		return
 	}
-	lock, err := l.Locker.Unlock(idUnencoded)
+	via := "the Atlantis UI"
+	if username := auth.Username(r); username != "" {
+		via = fmt.Sprintf("the Atlantis UI by `%s`", username)
+	}
+	lock, err := l.DeleteLockByID(idUnencoded, via)
 	if err != nil {
-		l.respond(w, logging.Error, http.StatusInternalServerError, "deleting lock failed with: %s", err)
+		l.respond(w, logging.Error, http.StatusInternalServerError, "%s", err)
 		return
 	}
 	if lock == nil {
 		l.respond(w, logging.Info, http.StatusNotFound, "No lock found at id %q", idUnencoded)
 		return
 	}
+	l.respond(w, logging.Info, http.StatusOK, "Deleted lock id %q", id)
+}
 
-	// NOTE: Because BaseRepo was added to the PullRequest model later, previous
-	// installations of Atlantis will have locks in their DB that do not have
-	// this field on PullRequest. We skip commenting and deleting the working dir in this case.
-	if lock.Pull.BaseRepo != (models.Repo{}) {
-		unlock, err := l.WorkingDirLocker.TryLock(lock.Pull.BaseRepo.FullName, lock.Pull.Num, lock.Workspace)
-		if err != nil {
-			l.Logger.Err("unable to obtain working dir lock when trying to delete old plans: %s", err)
-		} else {
-			defer unlock()
-			// nolint: vetshadow
-			if err := l.WorkingDir.DeleteForWorkspace(lock.Pull.BaseRepo, lock.Pull, lock.Workspace); err != nil {
-				l.Logger.Err("unable to delete workspace: %s", err)
+// DeleteLocks is the POST /locks/unlock route. It deletes all the locks that
+// match the filter in the request's JSON body. If dry_run is set, it only
+// lists the locks that would be deleted.
+func (l *LocksController) DeleteLocks(w http.ResponseWriter, r *http.Request) {
+	var req APIUnlockRequest
+	if err := decodeJSONBody(r, &req); err != nil {
+		l.respond(w, logging.Warn, http.StatusBadRequest, "Invalid request: %s", err)
+		return
+	}
+	if req.PullNum < 0 {
+		l.respond(w, logging.Warn, http.StatusBadRequest, "Invalid pull request number %d", req.PullNum)
+		return
+	}
+	if req.OlderThanDays < 0 {
+		l.respond(w, logging.Warn, http.StatusBadRequest, "Invalid number of days %d", req.OlderThanDays)
+		return
+	}
+	filter := LockFilter{
+		IDs:          req.IDs,
+		RepoFullName: req.Repo,
+		PullNum:      req.PullNum,
+		Workspace:    req.Workspace,
+		OlderThan:    time.Duration(req.OlderThanDays) * 24 * time.Hour,
+	}
+	if filter.IsEmpty() {
+		l.respond(w, logging.Warn, http.StatusBadRequest, "At least one lock or filter must be selected")
+		return
+	}
+	dryRun := req.DryRun
+
+	via := "the Atlantis UI"
+	if username := auth.Username(r); username != "" {
+		via = fmt.Sprintf("the Atlantis UI by `%s`", username)
+	}
+	locks, err := l.DeleteLocksMatching(filter, dryRun, via)
+	if err != nil {
+		l.respond(w, logging.Error, http.StatusInternalServerError, "%s", err)
+		return
+	}
+	var ids []string
+	for id := range locks {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+	}
+	l.respond(w, logging.Info, http.StatusOK, "%s %d locks:\n%s", verb, len(ids), strings.Join(ids, "\n"))
+}
+
+// LockFilter selects locks to delete in bulk. A lock must match every field
+// that's set.
+type LockFilter struct {
+	// IDs are the ids of specific locks.
+	IDs          []string
+	RepoFullName string
+	PullNum      int
+	Workspace    string
+	// OlderThan matches locks that were created more than OlderThan ago.
+	OlderThan time.Duration
+}
+
+// IsEmpty returns true if no fields are set, in which case the filter would
+// match every lock.
+func (f LockFilter) IsEmpty() bool {
+	return len(f.IDs) == 0 && f.RepoFullName == "" && f.PullNum == 0 && f.Workspace == "" && f.OlderThan == 0
+}
+
+// Matches returns true if the lock at id matches the filter.
+func (f LockFilter) Matches(id string, lock models.ProjectLock, now time.Time) bool {
+	if len(f.IDs) > 0 {
+		found := false
+		for _, filterID := range f.IDs {
+			if filterID == id {
+				found = true
+				break
 			}
 		}
-		if err := l.DB.DeleteProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path); err != nil {
-			l.Logger.Err("unable to delete project status: %s", err)
+		if !found {
+			return false
+		}
+	}
+	if f.RepoFullName != "" && lock.Project.RepoFullName != f.RepoFullName {
+		return false
+	}
+	if f.PullNum != 0 && lock.Pull.Num != f.PullNum {
+		return false
+	}
+	if f.Workspace != "" && lock.Workspace != f.Workspace {
+		return false
+	}
+	if f.OlderThan != 0 && now.Sub(lock.Time) < f.OlderThan {
+		return false
+	}
+	return true
+}
+
+// DeleteLocksMatching deletes every lock that matches filter the same way as
+// DeleteLockByID and returns the deleted locks keyed by id. If dryRun is
+// true, the matching locks are returned without being deleted. Failing to
+// delete one lock doesn't stop us from deleting the rest; the failure is
+// logged and, unless only commenting failed, the lock isn't returned.
+func (l *LocksController) DeleteLocksMatching(filter LockFilter, dryRun bool, via string) (map[string]models.ProjectLock, error) {
+	locks, err := l.Locker.List()
+	if err != nil {
+		return nil, errors.Wrap(err, "listing locks failed with")
+	}
+	now := time.Now()
+	matched := make(map[string]models.ProjectLock)
+	for id, lock := range locks {
+		if filter.Matches(id, lock, now) {
+			matched[id] = lock
 		}
+	}
+	if dryRun {
+		return matched, nil
+	}
 
-		// Once the lock has been deleted, comment back on the pull request.
-		comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via the Atlantis UI.\n\n"+
-			"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace)
-		err = l.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment)
+	deleted := make(map[string]models.ProjectLock)
+	for id := range matched {
+		lock, err := l.DeleteLockByID(id, via)
 		if err != nil {
-			l.respond(w, logging.Error, http.StatusInternalServerError, "Failed commenting on pull request: %s", err)
-			return
+			l.Logger.Err("deleting lock %q: %s", id, err)
 		}
-	} else {
+		if lock != nil {
+			deleted[id] = *lock
+		}
+	}
+	return deleted, nil
+}
+
+// DeleteLockByID deletes the lock at id. If a lock was deleted, its plan and
+// project status are also deleted and we comment back on the pull request
+// that the plan was discarded. via describes where the lock was deleted from,
+// ex. "the Atlantis UI". It returns a nil lock if there was no lock at id. If
+// only commenting failed, the deleted lock is returned along with the error.
+func (l *LocksController) DeleteLockByID(id string, via string) (*models.ProjectLock, error) {
+	lock, err := l.Locker.Unlock(id)
+	if err != nil {
+		return nil, errors.Wrap(err, "deleting lock failed with")
+	}
+	if lock == nil {
+		return nil, nil
+	}
+
+	// NOTE: Because BaseRepo was added to the PullRequest model later, previous
+	// installations of Atlantis will have locks in their DB that do not have
+	// this field on PullRequest. We skip commenting and deleting the working dir in this case.
+	if lock.Pull.BaseRepo == (models.Repo{}) {
 		l.Logger.Debug("skipping commenting on pull request and deleting workspace because BaseRepo field is empty")
+		return lock, nil
 	}
-	l.respond(w, logging.Info, http.StatusOK, "Deleted lock id %q", id)
+
+	unlock, err := l.WorkingDirLocker.TryLock(lock.Pull.BaseRepo.FullName, lock.Pull.Num, lock.Workspace)
+	if err != nil {
+		l.Logger.Err("unable to obtain working dir lock when trying to delete old plans: %s", err)
+	} else {
+		defer unlock()
+		// nolint: vetshadow
+		if err := l.WorkingDir.DeleteForWorkspace(lock.Pull.BaseRepo, lock.Pull, lock.Workspace); err != nil {
+			l.Logger.Err("unable to delete workspace: %s", err)
+		}
+	}
+	if err := l.DB.DeleteProjectStatus(lock.Pull, lock.Workspace, lock.Project.Path); err != nil {
+		l.Logger.Err("unable to delete project status: %s", err)
+	}
+
+	// Once the lock has been deleted, comment back on the pull request.
+	comment := fmt.Sprintf("**Warning**: The plan for dir: `%s` workspace: `%s` was **discarded** via %s.\n\n"+
+		"To `apply` this plan you must run `plan` again.", lock.Project.Path, lock.Workspace, via)
+	if err = l.VCSClient.CreateComment(lock.Pull.BaseRepo, lock.Pull.Num, comment); err != nil {
+		return lock, errors.Wrap(err, "Failed commenting on pull request")
+	}
+	return lock, nil
 }
 
 // respond is a helper function to respond and log the response. lvl is the log
@@ -132,3 +290,17 @@ func (l *LocksController) respond(w http.ResponseWriter, lvl logging.LogLevel, r
 	w.WriteHeader(responseCode)
 	fmt.Fprintln(w, response)
 }
+
+// decodeJSONBody decodes the JSON body of r into v. The UI's POST routes use
+// it instead of form values because browsers won't send a cross-origin
+// application/json request without a CORS preflight, so another site can't
+// make a logged-in user's browser submit them.
+func decodeJSONBody(r *http.Request, v interface{}) error {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "application/json" {
+		return errors.New("Content-Type must be application/json")
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
@@ -8,12 +8,16 @@ import (
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/locking/mocks"
 	mocks2 "github.com/runatlantis/atlantis/server/events/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
@@ -265,3 +269,202 @@ func TestDeleteLock_CommentSuccess(t *testing.T) {
 			"To `apply` this plan you must run `plan` again.")
 	workingDir.VerifyWasCalledOnce().DeleteForWorkspace(pull.BaseRepo, pull, "workspace")
 }
+
+func TestDeleteLock_CommentsWithUsername(t *testing.T) {
+	t.Log("if the UI is authenticated, the comment should say who discarded the plan")
+	RegisterMockTestingT(t)
+	cp := vcsmocks.NewMockClient()
+	l := mocks.NewMockLocker()
+	pull := models.PullRequest{
+		BaseRepo: models.Repo{FullName: "owner/repo"},
+	}
+	When(l.Unlock("id")).ThenReturn(&models.ProjectLock{
+		Pull:      pull,
+		Workspace: "workspace",
+		Project: models.Project{
+			Path:         "path",
+			RepoFullName: "owner/repo",
+		},
+	}, nil)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	db, err := db.New(tmp)
+	Ok(t, err)
+	lc := server.LocksController{
+		Locker:           l,
+		Logger:           logging.NewNoopLogger(),
+		VCSClient:        cp,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		WorkingDir:       mocks2.NewMockWorkingDir(),
+		DB:               db,
+	}
+	req, _ := http.NewRequest("GET", "", bytes.NewBuffer(nil))
+	req = mux.SetURLVars(auth.SetUsername(req, "alice"), map[string]string{"id": "id"})
+	w := httptest.NewRecorder()
+	lc.DeleteLock(w, req)
+	responseContains(t, w, http.StatusOK, "Deleted lock id \"id\"")
+	cp.VerifyWasCalled(Once()).CreateComment(pull.BaseRepo, pull.Num,
+		"**Warning**: The plan for dir: `path` workspace: `workspace` was **discarded** via the Atlantis UI by `alice`.\n\n"+
+			"To `apply` this plan you must run `plan` again.")
+}
+
+func TestLockFilter_Matches(t *testing.T) {
+	now := time.Now()
+	lock := models.ProjectLock{
+		Project:   models.Project{RepoFullName: "owner/repo", Path: "path"},
+		Pull:      models.PullRequest{Num: 1},
+		Workspace: "default",
+		Time:      now.Add(-48 * time.Hour),
+	}
+	cases := []struct {
+		description string
+		filter      server.LockFilter
+		exp         bool
+	}{
+		{"empty", server.LockFilter{}, true},
+		{"matching id", server.LockFilter{IDs: []string{"other", "owner/repo/path/default"}}, true},
+		{"other id", server.LockFilter{IDs: []string{"other"}}, false},
+		{"matching repo", server.LockFilter{RepoFullName: "owner/repo"}, true},
+		{"other repo", server.LockFilter{RepoFullName: "owner/other"}, false},
+		{"matching pull", server.LockFilter{PullNum: 1}, true},
+		{"other pull", server.LockFilter{PullNum: 2}, false},
+		{"matching workspace", server.LockFilter{Workspace: "default"}, true},
+		{"other workspace", server.LockFilter{Workspace: "staging"}, false},
+		{"older than", server.LockFilter{OlderThan: 24 * time.Hour}, true},
+		{"not older than", server.LockFilter{OlderThan: 72 * time.Hour}, false},
+		{"all must match", server.LockFilter{RepoFullName: "owner/repo", Workspace: "staging"}, false},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			Equals(t, c.exp, c.filter.Matches("owner/repo/path/default", lock, now))
+		})
+	}
+}
+
+// setupBulkUnlock returns a LocksController with locks for pull 1 on dir "."
+// in workspaces default and staging, and for pull 2 on dir "other" in
+// workspace default.
+func setupBulkUnlock(t *testing.T) (server.LocksController, *vcsmocks.MockClient, func()) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	locker := locking.NewClient(boltdb)
+	repo := models.Repo{FullName: "owner/repo"}
+	for _, l := range []struct {
+		pullNum   int
+		path      string
+		workspace string
+	}{{1, ".", "default"}, {1, ".", "staging"}, {2, "other", "default"}} {
+		_, err := locker.TryLock(models.Project{RepoFullName: "owner/repo", Path: l.path}, l.workspace, models.PullRequest{Num: l.pullNum, BaseRepo: repo}, models.User{})
+		Ok(t, err)
+	}
+	cp := vcsmocks.NewMockClient()
+	return server.LocksController{
+		Locker:           locker,
+		Logger:           logging.NewNoopLogger(),
+		VCSClient:        cp,
+		WorkingDirLocker: events.NewDefaultWorkingDirLocker(),
+		WorkingDir:       mocks2.NewMockWorkingDir(),
+		DB:               boltdb,
+	}, cp, cleanup
+}
+
+func TestDeleteLocks(t *testing.T) {
+	cases := []struct {
+		description string
+		body        string
+		expCode     int
+		expBody     string
+		expComments int
+		expLeft     int
+	}{
+		{
+			"no filter",
+			`{}`,
+			http.StatusBadRequest,
+			"At least one lock or filter must be selected",
+			0,
+			3,
+		},
+		{
+			"invalid pull",
+			`{"pull_num": -1}`,
+			http.StatusBadRequest,
+			"Invalid pull request number -1",
+			0,
+			3,
+		},
+		{
+			"dry run",
+			`{"pull_num": 1, "dry_run": true}`,
+			http.StatusOK,
+			"Would delete 2 locks:\nowner/repo/./default\nowner/repo/./staging",
+			0,
+			3,
+		},
+		{
+			"by pull",
+			`{"pull_num": 1}`,
+			http.StatusOK,
+			"Deleted 2 locks:\nowner/repo/./default\nowner/repo/./staging",
+			2,
+			1,
+		},
+		{
+			"by workspace",
+			`{"repo": "owner/repo", "workspace": "default"}`,
+			http.StatusOK,
+			"Deleted 2 locks:",
+			2,
+			1,
+		},
+		{
+			"selected ids",
+			`{"ids": ["owner/repo/./staging", "owner/repo/./missing"]}`,
+			http.StatusOK,
+			"Deleted 1 locks:\nowner/repo/./staging",
+			1,
+			2,
+		},
+		{
+			"older than",
+			`{"older_than_days": 7}`,
+			http.StatusOK,
+			"Deleted 0 locks:",
+			0,
+			3,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			lc, cp, cleanup := setupBulkUnlock(t)
+			defer cleanup()
+			req, _ := http.NewRequest("POST", "/locks/unlock", strings.NewReader(c.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			lc.DeleteLocks(w, req)
+			responseContains(t, w, c.expCode, c.expBody)
+			cp.VerifyWasCalled(Times(c.expComments)).CreateComment(AnyRepo(), AnyInt(), AnyString())
+			locks, err := lc.Locker.List()
+			Ok(t, err)
+			Equals(t, c.expLeft, len(locks))
+		})
+	}
+}
+
+// Form-encoded requests can be sent cross-origin without a preflight so they
+// must be rejected.
+func TestDeleteLocks_RequiresJSON(t *testing.T) {
+	lc, cp, cleanup := setupBulkUnlock(t)
+	defer cleanup()
+	req, _ := http.NewRequest("POST", "/locks/unlock", strings.NewReader(url.Values{"pull_num": {"1"}}.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	lc.DeleteLocks(w, req)
+	responseContains(t, w, http.StatusBadRequest, "Invalid request: Content-Type must be application/json")
+	cp.VerifyWasCalled(Never()).CreateComment(AnyRepo(), AnyInt(), AnyString())
+	locks, err := lc.Locker.List()
+	Ok(t, err)
+	Equals(t, 3, len(locks))
+}
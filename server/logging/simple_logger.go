@@ -21,6 +21,7 @@ import (
 	"log"
 	"os"
 	"runtime"
+	"sync"
 	"time"
 	"unicode"
 )
@@ -56,7 +57,12 @@ type SimpleLogger struct {
 	History     bytes.Buffer
 	Logger      *log.Logger
 	KeepHistory bool
-	Level       LogLevel
+	// Level should only be set directly before the logger is in use. After
+	// that, use SetLevel and GetLevel.
+	Level LogLevel
+	// levelMutex guards Level since it can be changed by SetLevel while
+	// the logger is in use.
+	levelMutex sync.RWMutex
 }
 
 type LogLevel int
@@ -113,6 +119,8 @@ func (l *SimpleLogger) NewLogger(source string, keepHistory bool, lvl LogLevel)
 // SetLevel changes the level that this logger is writing at to lvl.
 func (l *SimpleLogger) SetLevel(lvl LogLevel) {
 	if l != nil {
+		l.levelMutex.Lock()
+		defer l.levelMutex.Unlock()
 		l.Level = lvl
 	}
 }
@@ -152,10 +160,11 @@ func (l *SimpleLogger) Log(level LogLevel, format string, a ...interface{}) {
 		msg := l.capitalizeFirstLetter(fmt.Sprintf(format, a...))
 
 		// Only log this message if configured to log at this level.
-		if l.Level <= level {
+		currentLevel := l.GetLevel()
+		if currentLevel <= level {
 			datetime := time.Now().Format("2006/01/02 15:04:05-0700")
 			var caller string
-			if l.Level <= Debug {
+			if currentLevel <= Debug {
 				file, line := l.callSite(3)
 				caller = fmt.Sprintf(" %s:%d", file, line)
 			}
@@ -176,6 +185,8 @@ func (l *SimpleLogger) Underlying() *log.Logger {
 
 // GetLevel returns the current log level of the logger.
 func (l *SimpleLogger) GetLevel() LogLevel {
+	l.levelMutex.RLock()
+	defer l.levelMutex.RUnlock()
 	return l.Level
 }
 
@@ -0,0 +1,147 @@
+// Package metrics contains the Prometheus metrics that Atlantis exposes on
+// /metrics.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes all our metric names.
+const namespace = "atlantis"
+
+var (
+	// WebhookEvents counts the webhooks we've received and validated, by the
+	// VCS host type they came from and their event type, ex. "pull_request".
+	WebhookEvents = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "webhook_events_total",
+		Help:      "Number of webhook events received by VCS host type and event type.",
+	}, []string{"vcs", "event"})
+
+	// ProjectCommandDuration records how long running plan or apply on a
+	// single project took, by command and by result, ex. "success".
+	ProjectCommandDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "project_command_duration_seconds",
+		Help:      "Duration of running a command on a project by command and result.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"command", "result"})
+
+	// TerraformDuration records how long terraform commands took, by terraform
+	// version and by subcommand, ex. "plan".
+	TerraformDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "terraform_duration_seconds",
+		Help:      "Duration of terraform executions by terraform version and subcommand.",
+		Buckets:   prometheus.ExponentialBuckets(1, 2, 12),
+	}, []string{"version", "command"})
+
+	// LockAcquisitions counts the times a project lock was acquired.
+	LockAcquisitions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lock_acquisitions_total",
+		Help:      "Number of times a project lock was acquired.",
+	})
+
+	// LockContentions counts the times a project lock couldn't be acquired
+	// because another pull request held it.
+	LockContentions = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "lock_contentions_total",
+		Help:      "Number of times a project lock was held by another pull request.",
+	})
+
+	// VCSRequestDuration records the latency of calls to the VCS host's API by
+	// VCS host type and client method, ex. "CreateComment".
+	VCSRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "vcs_request_duration_seconds",
+		Help:      "Duration of VCS API calls by VCS host type and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"vcs", "method"})
+
+	// VCSRequestErrors counts the calls to the VCS host's API that errored.
+	VCSRequestErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "vcs_request_errors_total",
+		Help:      "Number of VCS API calls that errored by VCS host type and method.",
+	}, []string{"vcs", "method"})
+
+	// CloneDuration records how long cloning a pull request's repo took.
+	CloneDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "clone_duration_seconds",
+		Help:      "Duration of cloning repos.",
+		Buckets:   prometheus.ExponentialBuckets(0.5, 2, 10),
+	})
+)
+
+// collectors are the metrics above, which are updated directly by the code
+// they instrument.
+var collectors = []prometheus.Collector{
+	WebhookEvents,
+	ProjectCommandDuration,
+	TerraformDuration,
+	LockAcquisitions,
+	LockContentions,
+	VCSRequestDuration,
+	VCSRequestErrors,
+	CloneDuration,
+}
+
+// NewRegistry returns a registry containing all of our metrics as well as the
+// standard Go runtime and process metrics. activeLocks is called every time
+// metrics are collected to get the number of locks currently held.
+func NewRegistry(activeLocks func() (int, error)) (*prometheus.Registry, error) {
+	reg := prometheus.NewRegistry()
+	all := append([]prometheus.Collector{
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		newActiveLocksCollector(activeLocks),
+	}, collectors...)
+	for _, c := range all {
+		if err := reg.Register(c); err != nil {
+			return nil, err
+		}
+	}
+	return reg, nil
+}
+
+// Handler serves the metrics in reg in the Prometheus text format. If some
+// metrics can't be collected, the rest are still served.
+func Handler(reg *prometheus.Registry) http.Handler {
+	return promhttp.HandlerFor(reg, promhttp.HandlerOpts{ErrorHandling: promhttp.ContinueOnError})
+}
+
+// activeLocksCollector reports the number of locks currently held. We count
+// them when collecting rather than keeping a gauge up to date because locks
+// are deleted from many places.
+type activeLocksCollector struct {
+	desc  *prometheus.Desc
+	count func() (int, error)
+}
+
+func newActiveLocksCollector(count func() (int, error)) *activeLocksCollector {
+	return &activeLocksCollector{
+		desc:  prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "active_locks"), "Number of project locks currently held.", nil, nil),
+		count: count,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (a *activeLocksCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- a.desc
+}
+
+// Collect implements prometheus.Collector.
+func (a *activeLocksCollector) Collect(ch chan<- prometheus.Metric) {
+	n, err := a.count()
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(a.desc, err)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(a.desc, prometheus.GaugeValue, float64(n))
+}
@@ -0,0 +1,55 @@
+package metrics_test
+
+import (
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/runatlantis/atlantis/server/metrics"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestHandler(t *testing.T) {
+	reg, err := metrics.NewRegistry(func() (int, error) { return 2, nil })
+	Ok(t, err)
+	metrics.WebhookEvents.WithLabelValues("Github", "pull_request").Inc()
+	metrics.VCSRequestDuration.WithLabelValues("Github", "CreateComment").Observe(0.1)
+
+	body := scrape(t, reg)
+	for _, exp := range []string{
+		"atlantis_active_locks 2",
+		`atlantis_webhook_events_total{event="pull_request",vcs="Github"} 1`,
+		`atlantis_vcs_request_duration_seconds_count{method="CreateComment",vcs="Github"} 1`,
+		"atlantis_lock_acquisitions_total 0",
+		"atlantis_clone_duration_seconds_count 0",
+		"go_goroutines",
+	} {
+		Assert(t, strings.Contains(body, exp), "exp %q to be in\n%s", exp, body)
+	}
+}
+
+func TestHandler_ActiveLocksErr(t *testing.T) {
+	t.Log("should still serve the other metrics if we can't count the locks")
+	reg, err := metrics.NewRegistry(func() (int, error) { return 0, errors.New("err") })
+	Ok(t, err)
+
+	body := scrape(t, reg)
+	Assert(t, !strings.Contains(body, "atlantis_active_locks"), "exp active locks to not be reported, got\n%s", body)
+	Assert(t, strings.Contains(body, "atlantis_lock_contentions_total"), "exp other metrics to be reported, got\n%s", body)
+}
+
+// scrape returns the body served by the metrics handler for reg.
+func scrape(t *testing.T, reg *prometheus.Registry) string {
+	t.Helper()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/metrics", nil)
+	metrics.Handler(reg).ServeHTTP(w, req)
+	Equals(t, http.StatusOK, w.Code)
+	body, err := ioutil.ReadAll(w.Body)
+	Ok(t, err)
+	return string(body)
+}
@@ -0,0 +1,189 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/runatlantis/atlantis/server/events"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+)
+
+// maxConcurrentPullChecks is how many pull requests ListClosed checks at
+// once so that a lot of saved pull requests don't make it slow or get us rate
+// limited.
+const maxConcurrentPullChecks = 10
+
+// PullClosedChecker checks if a pull request has been closed. It's
+// implemented by the VCS clients that don't have a more specific getter.
+type PullClosedChecker interface {
+	PullIsClosed(repo models.Repo, pull models.PullRequest) (bool, error)
+}
+
+// PullsController handles requests relating to the pull requests Atlantis
+// has saved the status of.
+type PullsController struct {
+	DB *db.BoltDB
+	// GithubPullGetter, GitlabMergeRequestGetter and the PullClosedCheckers
+	// are used to check if pull requests have been closed. They're nil if
+	// their VCS host isn't configured.
+	GithubPullGetter                 events.GithubPullGetter
+	GitlabMergeRequestGetter         events.GitlabMergeRequestGetter
+	BitbucketCloudPullClosedChecker  PullClosedChecker
+	BitbucketServerPullClosedChecker PullClosedChecker
+	AzureDevopsPullClosedChecker     PullClosedChecker
+	// PullCleaner cleans up closed pull requests the same way as when we get
+	// a webhook saying a pull request was closed.
+	PullCleaner events.PullCleaner
+	Logger      *logging.SimpleLogger
+}
+
+// PullID identifies a pull request whose status we've saved.
+type PullID struct {
+	Hostname     string `json:"hostname"`
+	RepoFullName string `json:"repo"`
+	PullNum      int    `json:"num"`
+}
+
+// ListClosed is the GET /pulls/closed route. It returns the pull requests
+// we have statuses for that have since been closed, ex. because we missed the
+// webhook. Pull requests on VCS hosts that we can't check are skipped.
+func (p *PullsController) ListClosed(w http.ResponseWriter, _ *http.Request) {
+	statuses, err := p.DB.ListPullStatuses()
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed listing pull requests: %s", err)
+		return
+	}
+	isClosed := make([]bool, len(statuses))
+	sem := make(chan struct{}, maxConcurrentPullChecks)
+	var wg sync.WaitGroup
+	for i, s := range statuses {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, pull models.PullRequest) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			closed, err := p.isClosed(pull)
+			if err != nil {
+				p.Logger.Warn("unable to check if %s#%d is closed: %s", pull.BaseRepo.FullName, pull.Num, err)
+				return
+			}
+			isClosed[i] = closed
+		}(i, s.Pull)
+	}
+	wg.Wait()
+
+	closed := []PullID{}
+	for i, s := range statuses {
+		if isClosed[i] {
+			closed = append(closed, PullID{
+				Hostname:     s.Pull.BaseRepo.VCSHost.Hostname,
+				RepoFullName: s.Pull.BaseRepo.FullName,
+				PullNum:      s.Pull.Num,
+			})
+		}
+	}
+	data, err := json.Marshal(closed)
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed serializing pull requests: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(data) // nolint: errcheck
+}
+
+// DeleteClosed is the DELETE /pulls route. It deletes the status, locks and
+// plans of a pull request, but only if the pull request has been closed.
+func (p *PullsController) DeleteClosed(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	num, err := strconv.Atoi(query.Get("num"))
+	if err != nil || query.Get("hostname") == "" || query.Get("repo") == "" {
+		p.respond(w, logging.Warn, http.StatusBadRequest, "hostname, repo and num must be set")
+		return
+	}
+	status, err := p.DB.GetPullStatus(models.PullRequest{
+		Num: num,
+		BaseRepo: models.Repo{
+			FullName: query.Get("repo"),
+			VCSHost:  models.VCSHost{Hostname: query.Get("hostname")},
+		},
+	})
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed getting pull request: %s", err)
+		return
+	}
+	if status == nil {
+		p.respond(w, logging.Info, http.StatusNotFound, "No pull request found for %s#%d", query.Get("repo"), num)
+		return
+	}
+
+	pull := status.Pull
+	isClosed, err := p.isClosed(pull)
+	if err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed checking if %s#%d is closed: %s", pull.BaseRepo.FullName, pull.Num, err)
+		return
+	}
+	if !isClosed {
+		p.respond(w, logging.Warn, http.StatusBadRequest, "%s#%d is not closed", pull.BaseRepo.FullName, pull.Num)
+		return
+	}
+	if err := p.PullCleaner.CleanUpPull(pull.BaseRepo, pull); err != nil {
+		p.respond(w, logging.Error, http.StatusInternalServerError, "Failed cleaning up %s#%d: %s", pull.BaseRepo.FullName, pull.Num, err)
+		return
+	}
+	p.respond(w, logging.Info, http.StatusOK, "Deleted closed pull request %s#%d", pull.BaseRepo.FullName, pull.Num)
+}
+
+// isClosed returns true if pull has been closed or merged. It returns false
+// if we can't check pull requests on pull's VCS host.
+func (p *PullsController) isClosed(pull models.PullRequest) (bool, error) {
+	switch pull.BaseRepo.VCSHost.Type {
+	case models.Github:
+		if p.GithubPullGetter == nil {
+			return false, nil
+		}
+		ghPull, err := p.GithubPullGetter.GetPullRequest(pull.BaseRepo, pull.Num)
+		if err != nil {
+			return false, err
+		}
+		return ghPull.GetState() == "closed", nil
+	case models.Gitlab:
+		if p.GitlabMergeRequestGetter == nil {
+			return false, nil
+		}
+		mr, err := p.GitlabMergeRequestGetter.GetMergeRequest(pull.BaseRepo.FullName, pull.Num)
+		if err != nil {
+			return false, err
+		}
+		return mr.State == "closed" || mr.State == "merged", nil
+	case models.BitbucketCloud:
+		return p.checkClosed(p.BitbucketCloudPullClosedChecker, pull)
+	case models.BitbucketServer:
+		return p.checkClosed(p.BitbucketServerPullClosedChecker, pull)
+	case models.AzureDevops:
+		return p.checkClosed(p.AzureDevopsPullClosedChecker, pull)
+	}
+	return false, nil
+}
+
+// checkClosed returns true if checker says pull is closed. It returns false
+// if checker is nil.
+func (p *PullsController) checkClosed(checker PullClosedChecker, pull models.PullRequest) (bool, error) {
+	if checker == nil {
+		return false, nil
+	}
+	return checker.PullIsClosed(pull.BaseRepo, pull)
+}
+
+func (p *PullsController) respond(w http.ResponseWriter, lvl logging.LogLevel, responseCode int, format string, args ...interface{}) {
+	response := fmt.Sprintf(format, args...)
+	p.Logger.Log(lvl, "%s", response)
+	w.WriteHeader(responseCode)
+	fmt.Fprintln(w, response)
+}
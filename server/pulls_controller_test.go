@@ -0,0 +1,135 @@
+package server_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/google/go-github/github"
+	. "github.com/petergtz/pegomock"
+	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/events/db"
+	"github.com/runatlantis/atlantis/server/events/mocks"
+	"github.com/runatlantis/atlantis/server/events/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+var closedPull = models.PullRequest{
+	Num: 1,
+	BaseRepo: models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+	},
+}
+
+var openPull = models.PullRequest{
+	Num:      2,
+	BaseRepo: closedPull.BaseRepo,
+}
+
+func setupPullsController(t *testing.T) (server.PullsController, *mocks.MockPullCleaner, func()) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+	for _, pull := range []models.PullRequest{closedPull, openPull} {
+		_, err = boltdb.UpdatePullWithResults(pull, []models.ProjectResult{
+			{
+				RepoRelDir:  ".",
+				Workspace:   "default",
+				Command:     models.PlanCommand,
+				PlanSuccess: &models.PlanSuccess{},
+			},
+		})
+		Ok(t, err)
+	}
+
+	getter := mocks.NewMockGithubPullGetter()
+	When(getter.GetPullRequest(closedPull.BaseRepo, closedPull.Num)).ThenReturn(&github.PullRequest{State: github.String("closed")}, nil)
+	When(getter.GetPullRequest(openPull.BaseRepo, openPull.Num)).ThenReturn(&github.PullRequest{State: github.String("open")}, nil)
+	cleaner := mocks.NewMockPullCleaner()
+	return server.PullsController{
+		DB:               boltdb,
+		GithubPullGetter: getter,
+		PullCleaner:      cleaner,
+		Logger:           logging.NewNoopLogger(),
+	}, cleaner, cleanup
+}
+
+func TestListClosed(t *testing.T) {
+	pc, _, cleanup := setupPullsController(t)
+	defer cleanup()
+	req, _ := http.NewRequest("GET", "/pulls/closed", nil)
+	w := httptest.NewRecorder()
+	pc.ListClosed(w, req)
+	responseContains(t, w, http.StatusOK, `[{"hostname":"github.com","repo":"runatlantis/atlantis","num":1}]`)
+}
+
+func TestListClosed_Bitbucket(t *testing.T) {
+	pc, _, cleanup := setupPullsController(t)
+	defer cleanup()
+	bbPull := models.PullRequest{
+		Num: 3,
+		BaseRepo: models.Repo{
+			FullName: "owner/repo",
+			VCSHost:  models.VCSHost{Hostname: "bitbucket.org", Type: models.BitbucketCloud},
+		},
+	}
+	_, err := pc.DB.UpdatePullWithResults(bbPull, []models.ProjectResult{{RepoRelDir: ".", Workspace: "default", Command: models.PlanCommand, PlanSuccess: &models.PlanSuccess{}}})
+	Ok(t, err)
+
+	t.Log("should skip pull requests on hosts that aren't configured")
+	req, _ := http.NewRequest("GET", "/pulls/closed", nil)
+	w := httptest.NewRecorder()
+	pc.ListClosed(w, req)
+	responseContains(t, w, http.StatusOK, `[{"hostname":"github.com","repo":"runatlantis/atlantis","num":1}]`)
+
+	checker := &pullClosedCheckerFake{closed: true}
+	pc.BitbucketCloudPullClosedChecker = checker
+	w = httptest.NewRecorder()
+	pc.ListClosed(w, req)
+	responseContains(t, w, http.StatusOK, `[{"hostname":"bitbucket.org","repo":"owner/repo","num":3},{"hostname":"github.com","repo":"runatlantis/atlantis","num":1}]`)
+	Equals(t, bbPull.Num, checker.pull.Num)
+}
+
+func TestDeleteClosed(t *testing.T) {
+	cases := []struct {
+		description string
+		query       string
+		expCode     int
+		expBody     string
+		expCleanup  bool
+	}{
+		{"missing params", "?hostname=github.com&repo=runatlantis/atlantis", http.StatusBadRequest, "hostname, repo and num must be set", false},
+		{"unknown pull", "?hostname=github.com&repo=runatlantis/atlantis&num=3", http.StatusNotFound, "No pull request found for runatlantis/atlantis#3", false},
+		{"open pull", "?hostname=github.com&repo=runatlantis/atlantis&num=2", http.StatusBadRequest, "runatlantis/atlantis#2 is not closed", false},
+		{"closed pull", "?hostname=github.com&repo=runatlantis/atlantis&num=1", http.StatusOK, "Deleted closed pull request runatlantis/atlantis#1", true},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			pc, cleaner, cleanup := setupPullsController(t)
+			defer cleanup()
+			req, _ := http.NewRequest("DELETE", "/pulls"+c.query, nil)
+			w := httptest.NewRecorder()
+			pc.DeleteClosed(w, req)
+			responseContains(t, w, c.expCode, c.expBody)
+			if c.expCleanup {
+				cleaner.VerifyWasCalledOnce().CleanUpPull(closedPull.BaseRepo, closedPull)
+			} else {
+				cleaner.VerifyWasCalled(Never()).CleanUpPull(matchers.AnyModelsRepo(), matchers.AnyModelsPullRequest())
+			}
+		})
+	}
+}
+
+type pullClosedCheckerFake struct {
+	closed bool
+	pull   models.PullRequest
+}
+
+func (p *pullClosedCheckerFake) PullIsClosed(_ models.Repo, pull models.PullRequest) (bool, error) {
+	p.pull = pull
+	return p.closed, nil
+}
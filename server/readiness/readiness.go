@@ -0,0 +1,161 @@
+// Package readiness checks whether Atlantis is able to handle requests.
+package readiness
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultTimeout is how long a check can take before we consider it failed.
+const DefaultTimeout = 10 * time.Second
+
+// Check is a single readiness check.
+type Check struct {
+	// Name identifies the check, ex. "db".
+	Name string
+	// Run returns an error if the check fails.
+	Run func() error
+}
+
+// Result is the result of running a Check.
+type Result struct {
+	Name string `json:"name"`
+	// Status is either "ok" or "failing".
+	Status string `json:"status"`
+	// Error is set if the check failed.
+	Error string `json:"error,omitempty"`
+}
+
+// Checker runs readiness checks.
+type Checker struct {
+	Checks []Check
+	// Timeout is how long each check can take. If it's 0, DefaultTimeout is
+	// used.
+	Timeout time.Duration
+}
+
+// Run runs all the checks concurrently and returns their results sorted by
+// name. The bool is true if every check passed.
+func (c *Checker) Run() ([]Result, bool) {
+	timeout := c.Timeout
+	if timeout == 0 {
+		timeout = DefaultTimeout
+	}
+
+	results := make([]Result, len(c.Checks))
+	var wg sync.WaitGroup
+	for i, check := range c.Checks {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			results[i] = Result{Name: check.Name, Status: "ok"}
+			if err := runWithTimeout(check.Run, timeout); err != nil {
+				results[i].Status = "failing"
+				results[i].Error = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(a, b int) bool { return results[a].Name < results[b].Name })
+	ready := true
+	for _, r := range results {
+		if r.Error != "" {
+			ready = false
+		}
+	}
+	return results, ready
+}
+
+// runWithTimeout runs fn and returns its error, or an error if it doesn't
+// return within timeout. fn is left running if it times out because we have
+// no way of cancelling it, ex. if it's waiting on a DB lock.
+func runWithTimeout(fn func() error, timeout time.Duration) error {
+	errCh := make(chan error, 1)
+	go func() { errCh <- fn() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("timed out after %s", timeout)
+	}
+}
+
+// Cached wraps fn so that it's only called if it hasn't succeeded within ttl.
+// This is used for checks that make API calls so we don't get rate limited.
+// Failures aren't cached so the check passes as soon as the problem is fixed.
+// If fn is already running, callers wait for its result instead of calling it
+// again.
+func Cached(ttl time.Duration, fn func() error) func() error {
+	var mutex sync.Mutex
+	var lastSuccess time.Time
+	var inFlight *cachedCall
+	return func() error {
+		mutex.Lock()
+		if !lastSuccess.IsZero() && time.Since(lastSuccess) < ttl {
+			mutex.Unlock()
+			return nil
+		}
+		if call := inFlight; call != nil {
+			mutex.Unlock()
+			<-call.done
+			return call.err
+		}
+		call := &cachedCall{done: make(chan struct{})}
+		inFlight = call
+		mutex.Unlock()
+
+		// fn is called without holding the mutex so a slow call doesn't
+		// block callers once there's a cached success.
+		call.err = fn()
+
+		mutex.Lock()
+		if call.err == nil {
+			lastSuccess = time.Now()
+		}
+		inFlight = nil
+		mutex.Unlock()
+		close(call.done)
+		return call.err
+	}
+}
+
+// cachedCall is a call to the function wrapped by Cached that's in progress.
+type cachedCall struct {
+	// done is closed once err is set.
+	done chan struct{}
+	err  error
+}
+
+// DataDirCheck returns a check function that fails if we can't write to dir
+// or if dir has less than minFreeMB megabytes of disk space available.
+func DataDirCheck(dir string, minFreeMB uint64) func() error {
+	return func() error {
+		f, err := ioutil.TempFile(dir, ".readyz")
+		if err != nil {
+			return errors.Wrapf(err, "writing to %q", dir)
+		}
+		f.Close()           // nolint: errcheck
+		os.Remove(f.Name()) // nolint: errcheck
+
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(dir, &stat); err != nil {
+			return errors.Wrapf(err, "getting free disk space of %q", dir)
+		}
+		freeMB := uint64(stat.Bavail) * uint64(stat.Bsize) / bytesPerMB // nolint: unconvert
+		if freeMB < minFreeMB {
+			return fmt.Errorf("%q has %dMB of free disk space, less than the required %dMB", dir, freeMB, minFreeMB)
+		}
+		return nil
+	}
+}
+
+// bytesPerMB is used to convert bytes to megabytes.
+const bytesPerMB = 1024 * 1024
@@ -0,0 +1,136 @@
+package readiness_test
+
+import (
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/runatlantis/atlantis/server/readiness"
+	. "github.com/runatlantis/atlantis/testing"
+)
+
+func TestChecker_Run(t *testing.T) {
+	c := readiness.Checker{
+		Checks: []readiness.Check{
+			{Name: "b", Run: func() error { return nil }},
+			{Name: "a", Run: func() error { return errors.New("err") }},
+		},
+	}
+	results, ready := c.Run()
+	Equals(t, false, ready)
+	Equals(t, []readiness.Result{
+		{Name: "a", Status: "failing", Error: "err"},
+		{Name: "b", Status: "ok"},
+	}, results)
+}
+
+func TestChecker_RunAllPass(t *testing.T) {
+	c := readiness.Checker{
+		Checks: []readiness.Check{
+			{Name: "a", Run: func() error { return nil }},
+		},
+	}
+	results, ready := c.Run()
+	Equals(t, true, ready)
+	Equals(t, []readiness.Result{{Name: "a", Status: "ok"}}, results)
+}
+
+func TestChecker_RunTimeout(t *testing.T) {
+	t.Log("a check that hangs should fail")
+	block := make(chan struct{})
+	defer close(block)
+	c := readiness.Checker{
+		Checks: []readiness.Check{
+			{Name: "hangs", Run: func() error { <-block; return nil }},
+		},
+		Timeout: 10 * time.Millisecond,
+	}
+	results, ready := c.Run()
+	Equals(t, false, ready)
+	Equals(t, []readiness.Result{{Name: "hangs", Status: "failing", Error: "timed out after 10ms"}}, results)
+}
+
+func TestCached(t *testing.T) {
+	calls := 0
+	check := readiness.Cached(time.Hour, func() error {
+		calls++
+		return nil
+	})
+	Ok(t, check())
+	Ok(t, check())
+	Equals(t, 1, calls)
+
+	t.Log("should not cache failures")
+	calls = 0
+	check = readiness.Cached(time.Hour, func() error {
+		calls++
+		return errors.New("err")
+	})
+	ErrEquals(t, "err", check())
+	ErrEquals(t, "err", check())
+	Equals(t, 2, calls)
+
+	t.Log("should call again once the ttl expires")
+	calls = 0
+	check = readiness.Cached(0, func() error {
+		calls++
+		return nil
+	})
+	Ok(t, check())
+	Ok(t, check())
+	Equals(t, 2, calls)
+}
+
+func TestCached_Concurrent(t *testing.T) {
+	release := make(chan struct{})
+	var calls int32
+	check := readiness.Cached(time.Hour, func() error {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		return errors.New("err")
+	})
+
+	errs := make(chan error, 3)
+	for i := 0; i < 3; i++ {
+		go func() { errs <- check() }()
+	}
+	// Give the goroutines time to start waiting on the first call.
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	for i := 0; i < 3; i++ {
+		ErrEquals(t, "err", <-errs)
+	}
+	Equals(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+func TestDataDirCheck(t *testing.T) {
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+
+	t.Log("should pass if writable with enough space")
+	Ok(t, readiness.DataDirCheck(tmp, 0)())
+
+	t.Log("should not leave files behind")
+	files, err := filepath.Glob(filepath.Join(tmp, "*"))
+	Ok(t, err)
+	hidden, err := filepath.Glob(filepath.Join(tmp, ".*"))
+	Ok(t, err)
+	Equals(t, 0, len(files)+len(hidden))
+
+	t.Log("should fail if there isn't enough free space")
+	err = readiness.DataDirCheck(tmp, math.MaxUint64/(1024*1024))()
+	Assert(t, err != nil, "exp err")
+	Assert(t, strings.Contains(err.Error(), "of free disk space, less than the required"), "got %q", err.Error())
+
+	t.Log("should fail if the dir doesn't exist")
+	err = readiness.DataDirCheck(filepath.Join(tmp, "nonexistent"), 0)()
+	Assert(t, err != nil, "exp err")
+	Assert(t, strings.HasPrefix(err.Error(), "writing to"), "got %q", err.Error())
+	_, err = os.Stat(filepath.Join(tmp, "nonexistent"))
+	Assert(t, os.IsNotExist(err), "exp dir to not be created")
+}
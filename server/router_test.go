@@ -60,3 +60,22 @@ func TestRouter_GenerateLockURL(t *testing.T) {
 		})
 	}
 }
+
+func TestRouter_GenerateJobURL(t *testing.T) {
+	routeName := "job"
+	underlyingRouter := mux.NewRouter()
+	underlyingRouter.HandleFunc("/jobs/{id}", func(_ http.ResponseWriter, _ *http.Request) {}).Methods("GET").Name(routeName)
+
+	for _, atlantisURL := range []string{"https://example.com", "https://example.com/basepath/"} {
+		t.Run(atlantisURL, func(t *testing.T) {
+			parsed, err := server.ParseAtlantisURL(atlantisURL)
+			Ok(t, err)
+			router := &server.Router{
+				AtlantisURL:      parsed,
+				JobViewRouteName: routeName,
+				Underlying:       underlyingRouter,
+			}
+			Equals(t, parsed.String()+"/jobs/1234", router.GenerateJobURL("1234"))
+		})
+	}
+}
@@ -26,6 +26,8 @@ import (
 	"net/url"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -33,17 +35,21 @@ import (
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/runatlantis/atlantis/server/auth"
 	"github.com/runatlantis/atlantis/server/events"
 	"github.com/runatlantis/atlantis/server/events/locking"
 	"github.com/runatlantis/atlantis/server/events/models"
 	"github.com/runatlantis/atlantis/server/events/runtime"
 	"github.com/runatlantis/atlantis/server/events/terraform"
 	"github.com/runatlantis/atlantis/server/events/vcs"
+	"github.com/runatlantis/atlantis/server/events/vcs/azuredevops"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketcloud"
 	"github.com/runatlantis/atlantis/server/events/vcs/bitbucketserver"
-	"github.com/runatlantis/atlantis/server/events/webhooks"
 	"github.com/runatlantis/atlantis/server/events/yaml"
+	"github.com/runatlantis/atlantis/server/events/yaml/valid"
 	"github.com/runatlantis/atlantis/server/logging"
+	"github.com/runatlantis/atlantis/server/metrics"
+	"github.com/runatlantis/atlantis/server/readiness"
 	"github.com/runatlantis/atlantis/server/static"
 	"github.com/urfave/cli"
 	"github.com/urfave/negroni"
@@ -58,8 +64,22 @@ const (
 	// route. ex:
 	//   mux.Router.Get(LockViewRouteName).URL(LockViewRouteIDQueryParam, "my id")
 	LockViewRouteIDQueryParam = "id"
+	// JobViewRouteName is the named route in mux.Router for the page that
+	// shows a job's output.
+	JobViewRouteName = "job-detail"
+	// jobOutputCleanupInterval is how often we delete job output that's past
+	// its retention period.
+	jobOutputCleanupInterval = time.Hour
+	// vcsAuthCheckTTL is how long we cache the result of checking that we can
+	// authenticate with a VCS host for /readyz so we don't get rate limited.
+	vcsAuthCheckTTL = 5 * time.Minute
 )
 
+// uiAuthExemptPaths don't require UI authentication. Webhooks are validated
+// with webhook secrets, health checks and metrics are used by load balancers
+// and monitoring and the API has its own token authentication.
+var uiAuthExemptPaths = []string{"/events", "/healthz", "/readyz", "/metrics", "/static/", "/api/v1/"}
+
 // Server runs the Atlantis web server.
 type Server struct {
 	AtlantisVersion    string
@@ -69,12 +89,23 @@ type Server struct {
 	CommandRunner      *events.DefaultCommandRunner
 	Logger             *logging.SimpleLogger
 	Locker             locking.Locker
+	DB                 *db.BoltDB
+	FreezeManager      *events.FreezeManager
 	EventsController   *EventsController
 	LocksController    *LocksController
+	APIController      *APIController
+	JobsController     *JobsController
+	PullsController    *PullsController
+	FreezesController  *FreezesController
+	JobOutputStore     *events.JobOutputStore
+	MetricsHandler     http.Handler
+	ReadinessChecker   *readiness.Checker
+	UIAuthenticator    auth.Authenticator
 	IndexTemplate      TemplateWriter
 	LockDetailTemplate TemplateWriter
 	SSLCertFile        string
 	SSLKeyFile         string
+	ConfigReloader     *ConfigReloader
 }
 
 // Config holds config for server that isn't passed in by the user.
@@ -84,6 +115,9 @@ type Config struct {
 	AtlantisURLFlag      string
 	AtlantisVersion      string
 	DefaultTFVersionFlag string
+	// LoadUserConfig re-reads the user's config so that it can be reloaded
+	// while the server is running. If nil, the config can't be reloaded.
+	LoadUserConfig func() (UserConfig, error)
 }
 
 // WebhookConfig is nested within UserConfig. It's used to configure webhooks.
@@ -101,6 +135,98 @@ type WebhookConfig struct {
 	Channel string `mapstructure:"channel"`
 }
 
+// APITokenConfig is nested within UserConfig. It's used to configure the
+// tokens that can call the API.
+type APITokenConfig struct {
+	// Name identifies who the token was issued to, ex. "ci".
+	Name string `mapstructure:"name"`
+	// Token is the secret that must be sent as a bearer token.
+	Token string `mapstructure:"token"`
+	// Scope is either "read" or "write". Write tokens can also read.
+	Scope string `mapstructure:"scope"`
+}
+
+// FreezeScheduleConfig is nested within UserConfig. It's used to schedule
+// freezes, ex. for a holiday change freeze.
+type FreezeScheduleConfig struct {
+	// Start and End are RFC3339 timestamps, ex. "2019-12-20T00:00:00Z".
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+	// Repo is the full name of the repo to freeze. If empty, all repos are
+	// frozen.
+	Repo string `mapstructure:"repo"`
+	// Projects is a glob matched against project names and dirs. If empty,
+	// all projects are frozen.
+	Projects string `mapstructure:"projects"`
+	Reason   string `mapstructure:"reason"`
+}
+
+// ApplyWindowsConfig is nested within UserConfig. It's used to restrict when
+// projects can be applied. Its format is the same as apply_windows in
+// atlantis.yaml files.
+type ApplyWindowsConfig struct {
+	// Timezone is the IANA timezone of the windows, ex. "America/New_York".
+	// Defaults to UTC.
+	Timezone string              `mapstructure:"timezone"`
+	Windows  []ApplyWindowConfig `mapstructure:"windows"`
+}
+
+// ApplyWindowConfig is nested within ApplyWindowsConfig.
+type ApplyWindowConfig struct {
+	// Days are days of the week, ex. "mon", or ranges of days, ex. "mon-fri".
+	// If empty, the window is open every day.
+	Days []string `mapstructure:"days"`
+	// Start and End are 24-hour times, ex. "09:00".
+	Start string `mapstructure:"start"`
+	End   string `mapstructure:"end"`
+}
+
+// AllowedAppliersConfig is nested within UserConfig. It's used to restrict
+// who can apply projects.
+type AllowedAppliersConfig struct {
+	// Repo is the full name of the repo the rule covers. If empty, it covers
+	// all repos.
+	Repo string `mapstructure:"repo"`
+	// Dir is a glob matched against project dirs. If empty, it covers all
+	// dirs.
+	Dir string `mapstructure:"dir"`
+	// Workspace is the workspace the rule covers. If empty, it covers all
+	// workspaces.
+	Workspace string `mapstructure:"workspace"`
+	// Users can apply the matching projects.
+	Users []string `mapstructure:"users"`
+	// Teams are GitHub teams, GitLab groups or Bitbucket groups whose
+	// members can apply the matching projects.
+	Teams []string `mapstructure:"teams"`
+}
+
+// UIAuthConfig is nested within UserConfig. It's used to configure how users
+// of the UI are authenticated. Only one of BasicAuth or OIDC can be set.
+type UIAuthConfig struct {
+	// BasicAuth are the users that can log in with HTTP basic auth.
+	BasicAuth []BasicAuthUserConfig `mapstructure:"basic-auth"`
+	// OIDC configures logging in with an OpenID Connect issuer.
+	OIDC UIOIDCConfig `mapstructure:"oidc"`
+}
+
+// BasicAuthUserConfig is nested within UIAuthConfig.
+type BasicAuthUserConfig struct {
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+}
+
+// UIOIDCConfig is nested within UIAuthConfig. See auth.OIDCConfig for what
+// each field does.
+type UIOIDCConfig struct {
+	IssuerURL      string   `mapstructure:"issuer-url"`
+	ClientID       string   `mapstructure:"client-id"`
+	ClientSecret   string   `mapstructure:"client-secret"`
+	UsernameClaim  string   `mapstructure:"username-claim"`
+	AllowedUsers   []string `mapstructure:"allowed-users"`
+	AllowedDomains []string `mapstructure:"allowed-domains"`
+	SessionSecret  string   `mapstructure:"session-secret"`
+}
+
 // NewServer returns a new server. If there are issues starting the server or
 // its dependencies an error will be returned. This is like the main() function
 // for the server CLI command because it injects all the dependencies.
@@ -111,6 +237,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	var gitlabClient *vcs.GitlabClient
 	var bitbucketCloudClient *bitbucketcloud.Client
 	var bitbucketServerClient *bitbucketserver.Client
+	var azureDevopsClient *azuredevops.Client
 	if userConfig.GithubUser != "" {
 		supportedVCSHosts = append(supportedVCSHosts, models.Github)
 		var err error
@@ -149,22 +276,23 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 			}
 		}
 	}
-
-	var webhooksConfig []webhooks.Config
-	for _, c := range userConfig.Webhooks {
-		config := webhooks.Config{
-			Channel:        c.Channel,
-			Event:          c.Event,
-			Kind:           c.Kind,
-			WorkspaceRegex: c.WorkspaceRegex,
+	if userConfig.AzureDevopsUser != "" {
+		supportedVCSHosts = append(supportedVCSHosts, models.AzureDevops)
+		azureDevopsClient = azuredevops.NewClient(
+			http.DefaultClient,
+			userConfig.AzureDevopsUser,
+			userConfig.AzureDevopsToken,
+			userConfig.AtlantisURL)
+		if userConfig.AzureDevopsBaseURL != "" {
+			azureDevopsClient.BaseURL = userConfig.AzureDevopsBaseURL
 		}
-		webhooksConfig = append(webhooksConfig, config)
 	}
-	webhooksManager, err := webhooks.NewMultiWebhookSender(webhooksConfig, webhooks.NewSlackClient(userConfig.SlackToken))
+
+	webhooksManager, err := newWebhooksSender(userConfig)
 	if err != nil {
 		return nil, errors.Wrap(err, "initializing webhooks")
 	}
-	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient)
+	vcsClient := vcs.NewClientProxy(githubClient, gitlabClient, bitbucketCloudClient, bitbucketServerClient, azureDevopsClient)
 	commitStatusUpdater := &events.DefaultCommitStatusUpdater{Client: vcsClient}
 	terraformClient, err := terraform.NewClient(logger, userConfig.DataDir, userConfig.TFEToken, userConfig.DefaultTFVersion, config.DefaultTFVersionFlag, &terraform.DefaultDownloader{})
 	// The flag.Lookup call is to detect if we're running in a unit test. If we
@@ -175,12 +303,35 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 	}
 	markdownRenderer := &events.MarkdownRenderer{
 		GitlabSupportsCommonMark: gitlabClient.SupportsCommonMark(),
+		MaxOutputChars:           userConfig.MaxCommentOutputChars,
 	}
 	boltdb, err := db.New(userConfig.DataDir)
 	if err != nil {
 		return nil, err
 	}
 	lockingClient := locking.NewClient(boltdb)
+	freezeSchedule, err := NewFreezeSchedule(userConfig.FreezeSchedule)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing freeze schedule")
+	}
+	freezeManager := &events.FreezeManager{DB: boltdb, Schedule: freezeSchedule}
+	defaultApplyWindows, err := NewDefaultApplyWindows(userConfig.DefaultApplyWindows)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing default apply windows")
+	}
+	applyAuthorizer, err := NewApplyAuthorizer(userConfig.AllowedAppliers, userConfig.DenyUnmatchedApplies, vcsClient, time.Duration(userConfig.TeamCacheTTLMinutes)*time.Minute)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing allowed appliers")
+	}
+	parserValidator := &yaml.ParserValidator{}
+	var serverRepoConfig *valid.ServerConfig
+	if userConfig.RepoConfig != "" {
+		cfg, err := parserValidator.ReadServerConfig(userConfig.RepoConfig)
+		if err != nil {
+			return nil, errors.Wrap(err, "reading server-side repo config")
+		}
+		serverRepoConfig = &cfg
+	}
 	workingDirLocker := events.NewDefaultWorkingDirLocker()
 	workingDir := &events.FileWorkspace{
 		DataDir:       userConfig.DataDir,
@@ -199,6 +350,7 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AtlantisURL:               parsedURL,
 		LockViewRouteIDQueryParam: LockViewRouteIDQueryParam,
 		LockViewRouteName:         LockViewRouteName,
+		JobViewRouteName:          JobViewRouteName,
 		Underlying:                underlyingRouter,
 	}
 	pullClosedExecutor := &events.PullClosedExecutor{
@@ -216,14 +368,66 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		BitbucketUser:      userConfig.BitbucketUser,
 		BitbucketToken:     userConfig.BitbucketToken,
 		BitbucketServerURL: userConfig.BitbucketBaseURL,
+		AzureDevopsUser:    userConfig.AzureDevopsUser,
+		AzureDevopsToken:   userConfig.AzureDevopsToken,
+		AzureDevopsBaseURL: userConfig.AzureDevopsBaseURL,
 	}
 	commentParser := &events.CommentParser{
-		GithubUser:    userConfig.GithubUser,
-		GitlabUser:    userConfig.GitlabUser,
-		BitbucketUser: userConfig.BitbucketUser,
+		GithubUser:      userConfig.GithubUser,
+		GitlabUser:      userConfig.GitlabUser,
+		BitbucketUser:   userConfig.BitbucketUser,
+		AzureDevopsUser: userConfig.AzureDevopsUser,
 	}
 	defaultTfVersion := terraformClient.Version()
 	pendingPlanFinder := &events.DefaultPendingPlanFinder{}
+	jobOutputStore, err := events.NewJobOutputStore(filepath.Join(userConfig.DataDir, "jobs"), time.Duration(userConfig.JobOutputRetentionDays)*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	jobTracker := events.NewJobTrackerWithStore(jobOutputStore, logger)
+	projectCommandRunner := &events.DefaultProjectCommandRunner{
+		Locker:           projectLocker,
+		LockURLGenerator: router,
+		InitStepRunner: &runtime.InitStepRunner{
+			TerraformExecutor: terraformClient,
+			DefaultTFVersion:  defaultTfVersion,
+			StreamingTFExec:   terraformClient,
+		},
+		PlanStepRunner: &runtime.PlanStepRunner{
+			TerraformExecutor:   terraformClient,
+			DefaultTFVersion:    defaultTfVersion,
+			CommitStatusUpdater: commitStatusUpdater,
+			AsyncTFExec:         terraformClient,
+			StreamingTFExec:     terraformClient,
+		},
+		ApplyStepRunner: &runtime.ApplyStepRunner{
+			TerraformExecutor:   terraformClient,
+			CommitStatusUpdater: commitStatusUpdater,
+			AsyncTFExec:         terraformClient,
+			StreamingTFExec:     terraformClient,
+		},
+		RunStepRunner: &runtime.RunStepRunner{
+			DefaultTFVersion: defaultTfVersion,
+		},
+		PullApprovedChecker:      vcsClient,
+		WorkingDir:               workingDir,
+		Webhooks:                 webhooksManager,
+		WorkingDirLocker:         workingDirLocker,
+		RequireApprovalOverride:  userConfig.RequireApproval,
+		RequireMergeableOverride: userConfig.RequireMergeable,
+		FreezeChecker:            freezeManager,
+		DefaultApplyWindows:      defaultApplyWindows,
+		ApplyWindowOverrideUsers: splitCommaList(userConfig.ApplyWindowOverrideUsers),
+		ApplyAuthorizer:          applyAuthorizer,
+		CodeownersChecker: &events.DefaultCodeownersChecker{
+			VCSClient: vcsClient,
+			Teams: &events.TeamMembershipCache{
+				VCSClient: vcsClient,
+				TTL:       time.Duration(userConfig.TeamCacheTTLMinutes) * time.Minute,
+			},
+		},
+		StatusCheckGetter: vcsClient,
+	}
 	commandRunner := &events.DefaultCommandRunner{
 		VCSClient:                vcsClient,
 		GithubPullGetter:         githubClient,
@@ -235,48 +439,27 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		AllowForkPRs:             userConfig.AllowForkPRs,
 		AllowForkPRsFlag:         config.AllowForkPRsFlag,
 		ProjectCommandBuilder: &events.DefaultProjectCommandBuilder{
-			ParserValidator:     &yaml.ParserValidator{},
-			ProjectFinder:       &events.DefaultProjectFinder{},
+			ParserValidator:     parserValidator,
+			ProjectFinder:       &events.DefaultProjectFinder{IgnorePatterns: splitCommaList(userConfig.AutoplanIgnore)},
 			VCSClient:           vcsClient,
 			WorkingDir:          workingDir,
 			WorkingDirLocker:    workingDirLocker,
 			AllowRepoConfig:     userConfig.AllowRepoConfig,
+			AutoplanModules:     userConfig.AutoplanModules,
+			AutoplanVerbose:     userConfig.AutoplanVerbose,
 			AllowRepoConfigFlag: config.AllowRepoConfigFlag,
 			PendingPlanFinder:   pendingPlanFinder,
 			CommentBuilder:      commentParser,
+			ServerConfig:        serverRepoConfig,
 		},
-		ProjectCommandRunner: &events.DefaultProjectCommandRunner{
-			Locker:           projectLocker,
-			LockURLGenerator: router,
-			InitStepRunner: &runtime.InitStepRunner{
-				TerraformExecutor: terraformClient,
-				DefaultTFVersion:  defaultTfVersion,
-			},
-			PlanStepRunner: &runtime.PlanStepRunner{
-				TerraformExecutor:   terraformClient,
-				DefaultTFVersion:    defaultTfVersion,
-				CommitStatusUpdater: commitStatusUpdater,
-				AsyncTFExec:         terraformClient,
-			},
-			ApplyStepRunner: &runtime.ApplyStepRunner{
-				TerraformExecutor:   terraformClient,
-				CommitStatusUpdater: commitStatusUpdater,
-				AsyncTFExec:         terraformClient,
-			},
-			RunStepRunner: &runtime.RunStepRunner{
-				DefaultTFVersion: defaultTfVersion,
-			},
-			PullApprovedChecker:      vcsClient,
-			WorkingDir:               workingDir,
-			Webhooks:                 webhooksManager,
-			WorkingDirLocker:         workingDirLocker,
-			RequireApprovalOverride:  userConfig.RequireApproval,
-			RequireMergeableOverride: userConfig.RequireMergeable,
-		},
-		WorkingDir:        workingDir,
-		PendingPlanFinder: pendingPlanFinder,
-		DB:                boltdb,
-		GlobalAutomerge:   userConfig.Automerge,
+		ProjectCommandRunner: projectCommandRunner,
+		WorkingDir:           workingDir,
+		PendingPlanFinder:    pendingPlanFinder,
+		DB:                   boltdb,
+		GlobalAutomerge:      userConfig.Automerge,
+		Jobs:                 jobTracker,
+		JobURLGenerator:      router,
+		ServerConfig:         serverRepoConfig,
 	}
 	repoWhitelist, err := events.NewRepoWhitelistChecker(userConfig.RepoWhitelist)
 	if err != nil {
@@ -293,6 +476,85 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		WorkingDirLocker:   workingDirLocker,
 		DB:                 boltdb,
 	}
+	jobsController := &JobsController{
+		AtlantisVersion: config.AtlantisVersion,
+		AtlantisURL:     parsedURL,
+		Jobs:            jobTracker,
+		Logger:          logger,
+		JobTemplate:     jobTemplate,
+	}
+	uiAuthenticator, err := NewUIAuthenticator(userConfig.UIAuth, parsedURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing ui authentication")
+	}
+	apiTokens, err := NewAPITokens(userConfig.APITokens)
+	if err != nil {
+		return nil, errors.Wrap(err, "initializing api tokens")
+	}
+	var apiVCSHosts []APIVCSHost
+	if userConfig.GithubUser != "" {
+		apiVCSHosts = append(apiVCSHosts, APIVCSHost{Type: models.Github, Hostname: userConfig.GithubHostname, User: userConfig.GithubUser, Token: userConfig.GithubToken})
+	}
+	if userConfig.GitlabUser != "" {
+		apiVCSHosts = append(apiVCSHosts, APIVCSHost{Type: models.Gitlab, Hostname: userConfig.GitlabHostname, User: userConfig.GitlabUser, Token: userConfig.GitlabToken})
+	}
+	configReloader := NewConfigReloader(userConfig, config.LoadUserConfig, logger, webhooksManager, repoWhitelist, projectCommandRunner)
+	apiController := &APIController{
+		Locker:                   lockingClient,
+		LocksController:          locksController,
+		DB:                       boltdb,
+		Freezes:                  freezeManager,
+		Jobs:                     jobTracker,
+		Logger:                   logger,
+		Tokens:                   apiTokens,
+		CommandRunner:            commandRunner,
+		CommentParser:            commentParser,
+		RepoWhitelistChecker:     repoWhitelist,
+		GitlabMergeRequestGetter: gitlabClient,
+		VCSHosts:                 apiVCSHosts,
+		User:                     models.User{Username: userConfig.APIUser},
+		ConfigReloader:           configReloader,
+	}
+	metricsRegistry, err := metrics.NewRegistry(func() (int, error) {
+		locks, err := lockingClient.List()
+		return len(locks), err
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "registering metrics")
+	}
+	readinessChecks := []readiness.Check{
+		{Name: "db", Run: boltdb.CheckReadWrite},
+		{Name: "data-dir", Run: readiness.DataDirCheck(userConfig.DataDir, uint64(userConfig.ReadyzMinFreeDiskMB))},
+	}
+	if terraformClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{
+			Name: "terraform",
+			Run:  func() error { return terraformClient.EnsureDefaultVersion(logger) },
+		})
+	}
+	if githubClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{Name: "github", Run: readiness.Cached(vcsAuthCheckTTL, githubClient.CheckAuth)})
+	}
+	if gitlabClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{Name: "gitlab", Run: readiness.Cached(vcsAuthCheckTTL, gitlabClient.CheckAuth)})
+	}
+	if bitbucketCloudClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{Name: "bitbucket-cloud", Run: readiness.Cached(vcsAuthCheckTTL, bitbucketCloudClient.CheckAuth)})
+	}
+	if bitbucketServerClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{Name: "bitbucket-server", Run: readiness.Cached(vcsAuthCheckTTL, bitbucketServerClient.CheckAuth)})
+	}
+	if azureDevopsClient != nil {
+		readinessChecks = append(readinessChecks, readiness.Check{Name: "azuredevops", Run: readiness.Cached(vcsAuthCheckTTL, azureDevopsClient.CheckAuth)})
+	}
+	planPermission, err := models.ParsePermissionLevel(userConfig.PlanMinPermission)
+	if err != nil {
+		return nil, err
+	}
+	applyPermission, err := models.ParsePermissionLevel(userConfig.ApplyMinPermission)
+	if err != nil {
+		return nil, err
+	}
 	eventsController := &EventsController{
 		CommandRunner:                commandRunner,
 		PullCleaner:                  pullClosedExecutor,
@@ -308,6 +570,36 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		SupportedVCSHosts:            supportedVCSHosts,
 		VCSClient:                    vcsClient,
 		BitbucketWebhookSecret:       []byte(userConfig.BitbucketWebhookSecret),
+		AzureDevopsWebhookUser:       []byte(userConfig.AzureDevopsWebhookUser),
+		AzureDevopsWebhookPassword:   []byte(userConfig.AzureDevopsWebhookPassword),
+		CommenterPermissionChecker: &events.CommenterPermissionChecker{
+			VCSClient:       vcsClient,
+			PlanPermission:  planPermission,
+			ApplyPermission: applyPermission,
+		},
+		ServerConfig: serverRepoConfig,
+	}
+	pullsController := &PullsController{
+		DB:          boltdb,
+		PullCleaner: pullClosedExecutor,
+		Logger:      logger,
+	}
+	// Only set the getters if they're configured so that the controller's nil
+	// checks work.
+	if githubClient != nil {
+		pullsController.GithubPullGetter = githubClient
+	}
+	if gitlabClient != nil {
+		pullsController.GitlabMergeRequestGetter = gitlabClient
+	}
+	if bitbucketCloudClient != nil {
+		pullsController.BitbucketCloudPullClosedChecker = bitbucketCloudClient
+	}
+	if bitbucketServerClient != nil {
+		pullsController.BitbucketServerPullClosedChecker = bitbucketServerClient
+	}
+	if azureDevopsClient != nil {
+		pullsController.AzureDevopsPullClosedChecker = azureDevopsClient
 	}
 	return &Server{
 		AtlantisVersion:    config.AtlantisVersion,
@@ -317,39 +609,38 @@ func NewServer(userConfig UserConfig, config Config) (*Server, error) {
 		CommandRunner:      commandRunner,
 		Logger:             logger,
 		Locker:             lockingClient,
+		DB:                 boltdb,
+		FreezeManager:      freezeManager,
 		EventsController:   eventsController,
 		LocksController:    locksController,
+		APIController:      apiController,
+		JobsController:     jobsController,
+		PullsController:    pullsController,
+		FreezesController:  &FreezesController{Freezes: freezeManager, Logger: logger},
+		JobOutputStore:     jobOutputStore,
+		MetricsHandler:     metrics.Handler(metricsRegistry),
+		ReadinessChecker:   &readiness.Checker{Checks: readinessChecks},
+		UIAuthenticator:    uiAuthenticator,
 		IndexTemplate:      indexTemplate,
 		LockDetailTemplate: lockTemplate,
 		SSLKeyFile:         userConfig.SSLKeyFile,
 		SSLCertFile:        userConfig.SSLCertFile,
+		ConfigReloader:     configReloader,
 	}, nil
 }
 
 // Start creates the routes and starts serving traffic.
 func (s *Server) Start() error {
-	s.Router.HandleFunc("/", s.Index).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
-		return r.URL.Path == "/" || r.URL.Path == "/index.html"
-	})
-	s.Router.HandleFunc("/healthz", s.Healthz).Methods("GET")
-	s.Router.PathPrefix("/static/").Handler(http.FileServer(&assetfs.AssetFS{Asset: static.Asset, AssetDir: static.AssetDir, AssetInfo: static.AssetInfo}))
-	s.Router.HandleFunc("/events", s.EventsController.Post).Methods("POST")
-	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
-	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
-		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
-	n := negroni.New(&negroni.Recovery{
-		Logger:     log.New(os.Stdout, "", log.LstdFlags),
-		PrintStack: false,
-		StackAll:   false,
-		StackSize:  1024 * 8,
-	}, NewRequestLogger(s.Logger))
-	n.UseHandler(s.Router)
+	n := s.Handler()
 
 	// Ensure server gracefully drains connections when stopped.
 	stop := make(chan os.Signal, 1)
 	// Stop on SIGINTs and SIGTERMs.
 	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
 
+	go s.deleteExpiredJobOutput()
+	go s.reloadConfigOnSIGHUP()
+
 	server := &http.Server{Addr: fmt.Sprintf(":%d", s.Port), Handler: n}
 	go func() {
 		s.Logger.Info("Atlantis started - listening on port %v", s.Port)
@@ -375,29 +666,203 @@ func (s *Server) Start() error {
 	return nil
 }
 
+// Handler creates the routes and returns them wrapped in the server's
+// middleware.
+func (s *Server) Handler() http.Handler {
+	s.Router.HandleFunc("/", s.Index).Methods("GET").MatcherFunc(func(r *http.Request, rm *mux.RouteMatch) bool {
+		return r.URL.Path == "/" || r.URL.Path == "/index.html"
+	})
+	s.Router.HandleFunc("/healthz", s.Healthz).Methods("GET")
+	s.Router.HandleFunc("/readyz", s.Readyz).Methods("GET")
+	s.Router.Handle("/metrics", s.MetricsHandler).Methods("GET")
+	s.Router.PathPrefix("/static/").Handler(http.FileServer(&assetfs.AssetFS{Asset: static.Asset, AssetDir: static.AssetDir, AssetInfo: static.AssetInfo}))
+	s.Router.HandleFunc("/events", s.EventsController.Post).Methods("POST")
+	s.Router.HandleFunc("/locks", s.LocksController.DeleteLock).Methods("DELETE").Queries("id", "{id:.*}")
+	s.Router.HandleFunc("/locks/unlock", s.LocksController.DeleteLocks).Methods("POST")
+	s.Router.HandleFunc("/lock", s.LocksController.GetLock).Methods("GET").
+		Queries(LockViewRouteIDQueryParam, fmt.Sprintf("{%s}", LockViewRouteIDQueryParam)).Name(LockViewRouteName)
+	s.Router.HandleFunc("/jobs/{id}", s.JobsController.GetJob).Methods("GET").Name(JobViewRouteName)
+	s.Router.HandleFunc("/jobs/{id}/stream", s.JobsController.StreamOutput).Methods("GET")
+	s.Router.HandleFunc("/freezes", s.FreezesController.CreateFreeze).Methods("POST")
+	s.Router.HandleFunc("/freezes", s.FreezesController.DeleteFreeze).Methods("DELETE").Queries("id", "{id}")
+	s.Router.HandleFunc("/pulls/closed", s.PullsController.ListClosed).Methods("GET")
+	s.Router.HandleFunc("/pulls", s.PullsController.DeleteClosed).Methods("DELETE")
+	api := s.Router.PathPrefix("/api/v1").Subrouter()
+	// Lock ids contain slashes and "." dirs so they're passed as a query
+	// parameter, otherwise the router would clean them out of the path.
+	api.HandleFunc("/locks", s.APIController.Authenticate(APIReadScope, s.APIController.GetLock)).Methods("GET").Queries("id", "{id:.*}")
+	api.HandleFunc("/locks", s.APIController.Authenticate(APIWriteScope, s.APIController.DeleteLock)).Methods("DELETE").Queries("id", "{id:.*}")
+	api.HandleFunc("/locks", s.APIController.Authenticate(APIReadScope, s.APIController.ListLocks)).Methods("GET")
+	api.HandleFunc("/locks/unlock", s.APIController.Authenticate(APIWriteScope, s.APIController.DeleteLocks)).Methods("POST")
+	api.HandleFunc("/repos/{hostname}/{repo:.+}/pulls/{num:[0-9]+}", s.APIController.Authenticate(APIReadScope, s.APIController.GetPullStatus)).Methods("GET")
+	api.HandleFunc("/jobs", s.APIController.Authenticate(APIReadScope, s.APIController.ListJobs)).Methods("GET")
+	api.HandleFunc("/jobs/{id}", s.APIController.Authenticate(APIReadScope, s.APIController.GetJob)).Methods("GET")
+	api.HandleFunc("/freezes", s.APIController.Authenticate(APIReadScope, s.APIController.ListFreezes)).Methods("GET")
+	api.HandleFunc("/freezes", s.APIController.Authenticate(APIWriteScope, s.APIController.CreateFreeze)).Methods("POST")
+	api.HandleFunc("/freezes/{id}", s.APIController.Authenticate(APIWriteScope, s.APIController.DeleteFreeze)).Methods("DELETE")
+	api.HandleFunc("/plan", s.APIController.Authenticate(APIWriteScope, s.APIController.Plan)).Methods("POST")
+	api.HandleFunc("/apply", s.APIController.Authenticate(APIWriteScope, s.APIController.Apply)).Methods("POST")
+	api.HandleFunc("/config/reload", s.APIController.Authenticate(APIWriteScope, s.APIController.ReloadConfig)).Methods("POST")
+	n := negroni.New(&negroni.Recovery{
+		Logger:     log.New(os.Stdout, "", log.LstdFlags),
+		PrintStack: false,
+		StackAll:   false,
+		StackSize:  1024 * 8,
+	}, NewRequestLogger(s.Logger))
+	if s.UIAuthenticator != nil {
+		n.Use(&auth.Middleware{
+			Authenticator: s.UIAuthenticator,
+			ExemptPaths:   uiAuthExemptPaths,
+			Logger:        s.Logger,
+		})
+	}
+	n.UseHandler(s.Router)
+	return n
+}
+
+// reloadConfigOnSIGHUP reloads the config each time the process receives a
+// SIGHUP. It never returns.
+func (s *Server) reloadConfigOnSIGHUP() {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	for range hup {
+		s.Logger.Info("received SIGHUP, reloading config")
+		// Reload logs the outcome so there's nothing more to do here.
+		s.ConfigReloader.Reload() // nolint: errcheck
+	}
+}
+
+// deleteExpiredJobOutput periodically deletes the job output that's past its
+// retention period. It never returns.
+func (s *Server) deleteExpiredJobOutput() {
+	ticker := time.NewTicker(jobOutputCleanupInterval)
+	defer ticker.Stop()
+	for {
+		deleted, err := s.JobOutputStore.DeleteExpired(time.Now())
+		if err != nil {
+			s.Logger.Warn("unable to delete expired job output: %s", err)
+		} else if deleted > 0 {
+			s.Logger.Info("deleted output of %d expired jobs", deleted)
+		}
+		<-ticker.C
+	}
+}
+
 // Index is the / route.
-func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
+func (s *Server) Index(w http.ResponseWriter, r *http.Request) {
 	locks, err := s.Locker.List()
 	if err != nil {
 		w.WriteHeader(http.StatusServiceUnavailable)
 		fmt.Fprintf(w, "Could not retrieve locks: %s", err)
 		return
 	}
+	pullStatuses, err := s.DB.ListPullStatuses()
+	if err != nil {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		fmt.Fprintf(w, "Could not retrieve pull requests: %s", err)
+		return
+	}
 
 	var lockResults []LockIndexData
+	// lockPaths maps the lock keys to their URLs so that we can link to the
+	// locks from their pull requests.
+	lockPaths := make(map[string]string)
 	for id, v := range locks {
 		lockURL, _ := s.Router.Get(LockViewRouteName).URL("id", url.QueryEscape(id))
 		lockResults = append(lockResults, LockIndexData{
 			// NOTE: must use .String() instead of .Path because we need the
 			// query params as part of the lock URL.
+			LockKey:      id,
 			LockPath:     lockURL.String(),
 			RepoFullName: v.Project.RepoFullName,
 			PullNum:      v.Pull.Num,
 			Time:         v.Time,
 		})
+		lockPaths[pullLockKey(v.Project.RepoFullName, v.Pull.Num, v.Project.Path, v.Workspace)] = lockURL.String()
 	}
+
+	var freezeResults []FreezeIndexData
+	if s.FreezeManager != nil {
+		freezes, err := s.FreezeManager.ActiveFreezes(time.Now())
+		if err != nil {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintf(w, "Could not retrieve freezes: %s", err)
+			return
+		}
+		for _, f := range freezes {
+			freezeResults = append(freezeResults, FreezeIndexData{
+				ID:        f.ID,
+				Scope:     f.Scope(),
+				Reason:    f.Reason,
+				CreatedBy: f.CreatedBy,
+				CreatedAt: f.CreatedAt,
+				End:       f.End,
+				Scheduled: !f.End.IsZero(),
+			})
+		}
+	}
+
+	query := r.URL.Query()
+	repoFilter := query.Get("repo")
+	statusFilter := query.Get("status")
+	repoSet := make(map[string]bool)
+	var pullResults []PullIndexData
+	for _, status := range pullStatuses {
+		pull := status.Pull
+		repoSet[pull.BaseRepo.FullName] = true
+		if repoFilter != "" && pull.BaseRepo.FullName != repoFilter {
+			continue
+		}
+		matchesStatus := statusFilter == ""
+		var projects []ProjectIndexData
+		for _, p := range status.Projects {
+			if p.Status.String() == statusFilter {
+				matchesStatus = true
+			}
+			projects = append(projects, ProjectIndexData{
+				RepoRelDir:  p.RepoRelDir,
+				Workspace:   p.Workspace,
+				ProjectName: p.ProjectName,
+				Status:      p.Status.String(),
+				LockPath:    lockPaths[pullLockKey(pull.BaseRepo.FullName, pull.Num, p.RepoRelDir, p.Workspace)],
+			})
+		}
+		if !matchesStatus {
+			continue
+		}
+		pullResults = append(pullResults, PullIndexData{
+			Hostname:     pull.BaseRepo.VCSHost.Hostname,
+			RepoFullName: pull.BaseRepo.FullName,
+			PullNum:      pull.Num,
+			PullURL:      pull.URL,
+			Author:       pull.Author,
+			UpdatedAt:    status.UpdatedAt,
+			Projects:     projects,
+		})
+	}
+	// Show the most recently updated pull requests first.
+	sort.SliceStable(pullResults, func(i, j int) bool {
+		return pullResults[i].UpdatedAt.After(pullResults[j].UpdatedAt)
+	})
+	var repos []string
+	for repo := range repoSet {
+		repos = append(repos, repo)
+	}
+	sort.Strings(repos)
+
 	err = s.IndexTemplate.Execute(w, IndexData{
-		Locks:           lockResults,
+		Locks:   lockResults,
+		Freezes: freezeResults,
+		Pulls:   pullResults,
+		Repos:   repos,
+		Statuses: []string{
+			models.PlannedPlanStatus.String(),
+			models.ErroredPlanStatus.String(),
+			models.ErroredApplyStatus.String(),
+			models.AppliedPlanStatus.String(),
+		},
+		RepoFilter:      repoFilter,
+		StatusFilter:    statusFilter,
 		AtlantisVersion: s.AtlantisVersion,
 		CleanedBasePath: s.AtlantisURL.Path,
 	})
@@ -406,6 +871,11 @@ func (s *Server) Index(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// pullLockKey is used to match projects in pull requests to their locks.
+func pullLockKey(repoFullName string, pullNum int, repoRelDir string, workspace string) string {
+	return fmt.Sprintf("%s/%d/%s/%s", repoFullName, pullNum, repoRelDir, workspace)
+}
+
 // Healthz returns the health check response. It always returns a 200 currently.
 func (s *Server) Healthz(w http.ResponseWriter, _ *http.Request) {
 	data, err := json.MarshalIndent(&struct {
@@ -422,6 +892,73 @@ func (s *Server) Healthz(w http.ResponseWriter, _ *http.Request) {
 	w.Write(data) // nolint: errcheck
 }
 
+// Readyz returns whether Atlantis is ready to handle requests. Unlike Healthz,
+// it checks that our dependencies are working. It returns a 503 if any of the
+// checks fail.
+func (s *Server) Readyz(w http.ResponseWriter, _ *http.Request) {
+	results, ready := s.ReadinessChecker.Run()
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "failing", http.StatusServiceUnavailable
+		for _, r := range results {
+			if r.Error != "" {
+				s.Logger.Warn("readiness check %q failed: %s", r.Name, r.Error)
+			}
+		}
+	}
+	data, err := json.MarshalIndent(&struct {
+		Status string             `json:"status"`
+		Checks []readiness.Result `json:"checks"`
+	}{
+		Status: status,
+		Checks: results,
+	}, "", "  ")
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		fmt.Fprintf(w, "Error creating status json response: %s", err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(data) // nolint: errcheck
+}
+
+// NewUIAuthenticator validates the user's UI authentication config and
+// returns the Authenticator it configures. It returns nil if UI
+// authentication isn't configured.
+func NewUIAuthenticator(config UIAuthConfig, atlantisURL *url.URL) (auth.Authenticator, error) {
+	oidc := config.OIDC
+	if len(config.BasicAuth) > 0 && oidc.IssuerURL != "" {
+		return nil, errors.New("only one of basic-auth or oidc can be configured")
+	}
+	if len(config.BasicAuth) > 0 {
+		users := make(map[string]string)
+		for i, u := range config.BasicAuth {
+			if u.Username == "" {
+				return nil, fmt.Errorf("basic-auth user at index %d must have a username", i)
+			}
+			if u.Password == "" {
+				return nil, fmt.Errorf("basic-auth user %q must have a password", u.Username)
+			}
+			users[u.Username] = u.Password
+		}
+		return &auth.BasicAuthenticator{Users: users}, nil
+	}
+	if oidc.IssuerURL != "" || oidc.ClientID != "" {
+		return auth.NewOIDCAuthenticator(auth.OIDCConfig{
+			IssuerURL:      oidc.IssuerURL,
+			ClientID:       oidc.ClientID,
+			ClientSecret:   oidc.ClientSecret,
+			RedirectURL:    atlantisURL.String() + auth.CallbackPath,
+			UsernameClaim:  oidc.UsernameClaim,
+			AllowedUsers:   oidc.AllowedUsers,
+			AllowedDomains: oidc.AllowedDomains,
+			SessionSecret:  oidc.SessionSecret,
+		})
+	}
+	return nil, nil
+}
+
 // ParseAtlantisURL parses the user-passed atlantis URL to ensure it is valid
 // and we can use it in our templates.
 // It removes any trailing slashes from the path so we can concatenate it
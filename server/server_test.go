@@ -27,9 +27,14 @@ import (
 	"github.com/gorilla/mux"
 	. "github.com/petergtz/pegomock"
 	"github.com/runatlantis/atlantis/server"
+	"github.com/runatlantis/atlantis/server/auth"
+	"github.com/runatlantis/atlantis/server/events/db"
 	"github.com/runatlantis/atlantis/server/events/locking/mocks"
 	"github.com/runatlantis/atlantis/server/events/models"
+	"github.com/runatlantis/atlantis/server/logging"
 	sMocks "github.com/runatlantis/atlantis/server/mocks"
+	"github.com/runatlantis/atlantis/server/mocks/matchers"
+	"github.com/runatlantis/atlantis/server/readiness"
 	. "github.com/runatlantis/atlantis/testing"
 )
 
@@ -96,8 +101,13 @@ func TestIndex_Success(t *testing.T) {
 		Queries("id", "{id}").Name(server.LockViewRouteName)
 	u, err := url.Parse("https://example.com")
 	Ok(t, err)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
 	s := server.Server{
 		Locker:          l,
+		DB:              boltdb,
 		IndexTemplate:   it,
 		Router:          r,
 		AtlantisVersion: atlantisVersion,
@@ -109,17 +119,133 @@ func TestIndex_Success(t *testing.T) {
 	it.VerifyWasCalledOnce().Execute(w, server.IndexData{
 		Locks: []server.LockIndexData{
 			{
+				LockKey:      "lkysow/atlantis-example/./default",
 				LockPath:     "/lock?id=lkysow%252Fatlantis-example%252F.%252Fdefault",
 				RepoFullName: "lkysow/atlantis-example",
 				PullNum:      9,
 				Time:         now,
 			},
 		},
+		Statuses:        []string{"planned", "plan_errored", "apply_errored", "applied"},
 		AtlantisVersion: atlantisVersion,
 	})
 	responseContains(t, w, http.StatusOK, "")
 }
 
+func TestIndex_Pulls(t *testing.T) {
+	RegisterMockTestingT(t)
+	tmp, cleanup := TempDir(t)
+	defer cleanup()
+	boltdb, err := db.New(tmp)
+	Ok(t, err)
+
+	repo := models.Repo{
+		FullName: "runatlantis/atlantis",
+		VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+	}
+	otherRepo := models.Repo{
+		FullName: "runatlantis/other",
+		VCSHost:  models.VCSHost{Hostname: "github.com", Type: models.Github},
+	}
+	pull := models.PullRequest{Num: 1, URL: "https://github.com/runatlantis/atlantis/pull/1", Author: "lkysow", BaseRepo: repo}
+	otherPull := models.PullRequest{Num: 2, BaseRepo: otherRepo}
+	_, err = boltdb.UpdatePullWithResults(pull, []models.ProjectResult{
+		{
+			RepoRelDir:  ".",
+			Workspace:   "default",
+			Command:     models.PlanCommand,
+			PlanSuccess: &models.PlanSuccess{},
+		},
+		{
+			RepoRelDir: "staging",
+			Workspace:  "default",
+			Command:    models.PlanCommand,
+			Error:      errors.New("err"),
+		},
+	})
+	Ok(t, err)
+	_, err = boltdb.UpdatePullWithResults(otherPull, []models.ProjectResult{
+		{
+			RepoRelDir:   ".",
+			Workspace:    "default",
+			Command:      models.ApplyCommand,
+			ApplySuccess: "success",
+		},
+	})
+	Ok(t, err)
+
+	l := mocks.NewMockLocker()
+	When(l.List()).ThenReturn(map[string]models.ProjectLock{
+		"runatlantis/atlantis/./default": {
+			Pull:      pull,
+			Project:   models.Project{RepoFullName: "runatlantis/atlantis", Path: "."},
+			Workspace: "default",
+		},
+	}, nil)
+	r := mux.NewRouter()
+	r.NewRoute().Path("/lock").
+		Queries("id", "{id}").Name(server.LockViewRouteName)
+	u, err := url.Parse("https://example.com")
+	Ok(t, err)
+
+	cases := []struct {
+		description string
+		query       string
+		expPulls    []int
+	}{
+		{"no filters", "", []int{2, 1}},
+		{"repo filter", "?repo=runatlantis/atlantis", []int{1}},
+		{"status filter", "?status=plan_errored", []int{1}},
+		{"status filter matches any project", "?status=planned", []int{1}},
+		{"repo and status filters", "?repo=runatlantis/other&status=planned", nil},
+	}
+	for _, c := range cases {
+		t.Run(c.description, func(t *testing.T) {
+			it := sMocks.NewMockTemplateWriter()
+			s := server.Server{
+				Locker:        l,
+				DB:            boltdb,
+				IndexTemplate: it,
+				Router:        r,
+				AtlantisURL:   u,
+			}
+			req, _ := http.NewRequest("GET", "/"+c.query, bytes.NewBuffer(nil))
+			w := httptest.NewRecorder()
+			s.Index(w, req)
+			_, data := it.VerifyWasCalledOnce().Execute(matchers.AnyIoWriter(), AnyInterface()).GetCapturedArguments()
+			indexData := data.(server.IndexData)
+			Equals(t, []string{"runatlantis/atlantis", "runatlantis/other"}, indexData.Repos)
+
+			var pullNums []int
+			for _, p := range indexData.Pulls {
+				pullNums = append(pullNums, p.PullNum)
+			}
+			Equals(t, c.expPulls, pullNums)
+			for _, p := range indexData.Pulls {
+				if p.PullNum != 1 {
+					continue
+				}
+				Equals(t, "https://github.com/runatlantis/atlantis/pull/1", p.PullURL)
+				Equals(t, "lkysow", p.Author)
+				Assert(t, !p.UpdatedAt.IsZero(), "exp updated at to be set")
+				Equals(t, []server.ProjectIndexData{
+					{
+						RepoRelDir: ".",
+						Workspace:  "default",
+						Status:     "planned",
+						LockPath:   "/lock?id=runatlantis%252Fatlantis%252F.%252Fdefault",
+					},
+					{
+						RepoRelDir: "staging",
+						Workspace:  "default",
+						Status:     "plan_errored",
+					},
+				}, p.Projects)
+			}
+		})
+	}
+}
+
 func TestHealthz(t *testing.T) {
 	s := server.Server{}
 	req, _ := http.NewRequest("GET", "/healthz", bytes.NewBuffer(nil))
@@ -134,6 +260,117 @@ func TestHealthz(t *testing.T) {
 }`, string(body))
 }
 
+func TestReadyz(t *testing.T) {
+	t.Log("should return 200 if all checks pass")
+	{
+		s := server.Server{
+			ReadinessChecker: &readiness.Checker{
+				Checks: []readiness.Check{{Name: "db", Run: func() error { return nil }}},
+			},
+		}
+		req, _ := http.NewRequest("GET", "/readyz", bytes.NewBuffer(nil))
+		w := httptest.NewRecorder()
+		s.Readyz(w, req)
+		Equals(t, http.StatusOK, w.Result().StatusCode)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		Equals(t, "application/json", w.Result().Header["Content-Type"][0])
+		Equals(t,
+			`{
+  "status": "ok",
+  "checks": [
+    {
+      "name": "db",
+      "status": "ok"
+    }
+  ]
+}`, string(body))
+	}
+
+	t.Log("should return 503 if any check fails")
+	{
+		s := server.Server{
+			Logger: logging.NewNoopLogger(),
+			ReadinessChecker: &readiness.Checker{
+				Checks: []readiness.Check{
+					{Name: "db", Run: func() error { return nil }},
+					{Name: "github", Run: func() error { return errors.New("bad credentials") }},
+				},
+			},
+		}
+		req, _ := http.NewRequest("GET", "/readyz", bytes.NewBuffer(nil))
+		w := httptest.NewRecorder()
+		s.Readyz(w, req)
+		Equals(t, http.StatusServiceUnavailable, w.Result().StatusCode)
+		body, _ := ioutil.ReadAll(w.Result().Body)
+		Equals(t,
+			`{
+  "status": "failing",
+  "checks": [
+    {
+      "name": "db",
+      "status": "ok"
+    },
+    {
+      "name": "github",
+      "status": "failing",
+      "error": "bad credentials"
+    }
+  ]
+}`, string(body))
+	}
+}
+
+func TestNewUIAuthenticator(t *testing.T) {
+	atlantisURL, err := url.Parse("https://atlantis.example.com")
+	Ok(t, err)
+
+	t.Log("should be nil if not configured")
+	a, err := server.NewUIAuthenticator(server.UIAuthConfig{}, atlantisURL)
+	Ok(t, err)
+	Assert(t, a == nil, "exp nil authenticator")
+
+	a, err = server.NewUIAuthenticator(server.UIAuthConfig{
+		BasicAuth: []server.BasicAuthUserConfig{{Username: "alice", Password: "password"}},
+	}, atlantisURL)
+	Ok(t, err)
+	Equals(t, &auth.BasicAuthenticator{Users: map[string]string{"alice": "password"}}, a)
+}
+
+func TestNewUIAuthenticator_Errs(t *testing.T) {
+	atlantisURL, err := url.Parse("https://atlantis.example.com")
+	Ok(t, err)
+	cases := []struct {
+		config server.UIAuthConfig
+		expErr string
+	}{
+		{
+			server.UIAuthConfig{
+				BasicAuth: []server.BasicAuthUserConfig{{Username: "alice", Password: "password"}},
+				OIDC:      server.UIOIDCConfig{IssuerURL: "https://accounts.google.com"},
+			},
+			"only one of basic-auth or oidc can be configured",
+		},
+		{
+			server.UIAuthConfig{BasicAuth: []server.BasicAuthUserConfig{{Password: "password"}}},
+			"basic-auth user at index 0 must have a username",
+		},
+		{
+			server.UIAuthConfig{BasicAuth: []server.BasicAuthUserConfig{{Username: "alice"}}},
+			`basic-auth user "alice" must have a password`,
+		},
+		{
+			server.UIAuthConfig{OIDC: server.UIOIDCConfig{ClientID: "id"}},
+			"oidc issuer-url must be set",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.expErr, func(t *testing.T) {
+			_, err := server.NewUIAuthenticator(c.config, atlantisURL)
+			ErrEquals(t, c.expErr, err)
+		})
+	}
+}
+
 func TestParseAtlantisURL(t *testing.T) {
 	cases := []struct {
 		In     string
@@ -6,27 +6,48 @@ import "github.com/runatlantis/atlantis/server/logging"
 // The mapstructure tags correspond to flags in cmd/server.go and are used when
 // the config is parsed from a YAML file.
 type UserConfig struct {
-	AllowForkPRs           bool   `mapstructure:"allow-fork-prs"`
-	AllowRepoConfig        bool   `mapstructure:"allow-repo-config"`
-	AtlantisURL            string `mapstructure:"atlantis-url"`
-	Automerge              bool   `mapstructure:"automerge"`
-	BitbucketBaseURL       string `mapstructure:"bitbucket-base-url"`
-	BitbucketToken         string `mapstructure:"bitbucket-token"`
-	BitbucketUser          string `mapstructure:"bitbucket-user"`
-	BitbucketWebhookSecret string `mapstructure:"bitbucket-webhook-secret"`
-	CheckoutStrategy       string `mapstructure:"checkout-strategy"`
-	DataDir                string `mapstructure:"data-dir"`
-	GithubHostname         string `mapstructure:"gh-hostname"`
-	GithubToken            string `mapstructure:"gh-token"`
-	GithubUser             string `mapstructure:"gh-user"`
-	GithubWebhookSecret    string `mapstructure:"gh-webhook-secret"`
-	GitlabHostname         string `mapstructure:"gitlab-hostname"`
-	GitlabToken            string `mapstructure:"gitlab-token"`
-	GitlabUser             string `mapstructure:"gitlab-user"`
-	GitlabWebhookSecret    string `mapstructure:"gitlab-webhook-secret"`
-	LogLevel               string `mapstructure:"log-level"`
-	Port                   int    `mapstructure:"port"`
-	RepoWhitelist          string `mapstructure:"repo-whitelist"`
+	AllowForkPRs    bool   `mapstructure:"allow-fork-prs"`
+	AllowRepoConfig bool   `mapstructure:"allow-repo-config"`
+	APIUser         string `mapstructure:"api-user"`
+	// ApplyMinPermission and PlanMinPermission are the minimum permissions
+	// users need on a repo to run apply and plan via comments.
+	ApplyMinPermission string `mapstructure:"apply-min-permission"`
+	// ApplyWindowOverrideUsers is a comma-separated list of users who can
+	// apply outside of apply windows.
+	ApplyWindowOverrideUsers   string `mapstructure:"apply-window-override-users"`
+	AtlantisURL                string `mapstructure:"atlantis-url"`
+	Automerge                  bool   `mapstructure:"automerge"`
+	AutoplanIgnore             string `mapstructure:"autoplan-ignore"`
+	AutoplanModules            bool   `mapstructure:"autoplan-modules"`
+	AutoplanVerbose            bool   `mapstructure:"autoplan-verbose"`
+	AzureDevopsBaseURL         string `mapstructure:"azuredevops-base-url"`
+	AzureDevopsToken           string `mapstructure:"azuredevops-token"`
+	AzureDevopsUser            string `mapstructure:"azuredevops-user"`
+	AzureDevopsWebhookPassword string `mapstructure:"azuredevops-webhook-password"`
+	AzureDevopsWebhookUser     string `mapstructure:"azuredevops-webhook-user"`
+	BitbucketBaseURL           string `mapstructure:"bitbucket-base-url"`
+	BitbucketToken             string `mapstructure:"bitbucket-token"`
+	BitbucketUser              string `mapstructure:"bitbucket-user"`
+	BitbucketWebhookSecret     string `mapstructure:"bitbucket-webhook-secret"`
+	CheckoutStrategy           string `mapstructure:"checkout-strategy"`
+	DataDir                    string `mapstructure:"data-dir"`
+	DenyUnmatchedApplies       bool   `mapstructure:"deny-unmatched-applies"`
+	GithubHostname             string `mapstructure:"gh-hostname"`
+	GithubToken                string `mapstructure:"gh-token"`
+	GithubUser                 string `mapstructure:"gh-user"`
+	GithubWebhookSecret        string `mapstructure:"gh-webhook-secret"`
+	GitlabHostname             string `mapstructure:"gitlab-hostname"`
+	GitlabToken                string `mapstructure:"gitlab-token"`
+	GitlabUser                 string `mapstructure:"gitlab-user"`
+	GitlabWebhookSecret        string `mapstructure:"gitlab-webhook-secret"`
+	JobOutputRetentionDays     int    `mapstructure:"job-output-retention-days"`
+	LogLevel                   string `mapstructure:"log-level"`
+	MaxCommentOutputChars      int    `mapstructure:"max-comment-output-chars"`
+	PlanMinPermission          string `mapstructure:"plan-min-permission"`
+	Port                       int    `mapstructure:"port"`
+	ReadyzMinFreeDiskMB        int    `mapstructure:"readyz-min-free-disk-mb"`
+	RepoConfig                 string `mapstructure:"repo-config"`
+	RepoWhitelist              string `mapstructure:"repo-whitelist"`
 	// RequireApproval is whether to require pull request approval before
 	// allowing terraform apply's to be run.
 	RequireApproval bool `mapstructure:"require-approval"`
@@ -37,9 +58,25 @@ type UserConfig struct {
 	SlackToken             string          `mapstructure:"slack-token"`
 	SSLCertFile            string          `mapstructure:"ssl-cert-file"`
 	SSLKeyFile             string          `mapstructure:"ssl-key-file"`
+	TeamCacheTTLMinutes    int             `mapstructure:"team-cache-ttl-minutes"`
 	TFEToken               string          `mapstructure:"tfe-token"`
 	DefaultTFVersion       string          `mapstructure:"default-tf-version"`
 	Webhooks               []WebhookConfig `mapstructure:"webhooks"`
+	// APITokens control access to the API. Like Webhooks, they can only be
+	// set in the config file.
+	APITokens []APITokenConfig `mapstructure:"api-tokens"`
+	// UIAuth configures authentication for the UI. It can only be set in the
+	// config file.
+	UIAuth UIAuthConfig `mapstructure:"ui-auth"`
+	// FreezeSchedule schedules freezes that block applies. It can only be set
+	// in the config file.
+	FreezeSchedule []FreezeScheduleConfig `mapstructure:"freeze-schedule"`
+	// DefaultApplyWindows are the apply windows for every project, in addition
+	// to the projects' own. It can only be set in the config file.
+	DefaultApplyWindows ApplyWindowsConfig `mapstructure:"default-apply-windows"`
+	// AllowedAppliers restrict who can apply projects. Since they can only be
+	// set in the config file, repos can't override them.
+	AllowedAppliers []AllowedAppliersConfig `mapstructure:"allowed-appliers"`
 }
 
 // ToLogLevel returns the LogLevel object corresponding to the user-passed
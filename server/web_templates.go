@@ -31,15 +31,64 @@ type TemplateWriter interface {
 
 // LockIndexData holds the fields needed to display the index view for locks.
 type LockIndexData struct {
+	// LockKey is the lock's id. It's used to select locks to delete in bulk.
+	LockKey      string
 	LockPath     string
 	RepoFullName string
 	PullNum      int
 	Time         time.Time
 }
 
+// FreezeIndexData holds the fields needed to display an active freeze on the
+// index page.
+type FreezeIndexData struct {
+	ID        string
+	Scope     string
+	Reason    string
+	CreatedBy string
+	CreatedAt time.Time
+	End       time.Time
+	// Scheduled is true if the freeze is from the freeze schedule, in which
+	// case it can't be deleted from the UI.
+	Scheduled bool
+}
+
+// PullIndexData holds the fields needed to display a pull request on the
+// index page.
+type PullIndexData struct {
+	Hostname     string
+	RepoFullName string
+	PullNum      int
+	PullURL      string
+	Author       string
+	UpdatedAt    time.Time
+	Projects     []ProjectIndexData
+}
+
+// ProjectIndexData holds the fields needed to display the status of a
+// project within a pull request on the index page.
+type ProjectIndexData struct {
+	RepoRelDir  string
+	Workspace   string
+	ProjectName string
+	Status      string
+	// LockPath is the path to the project's lock. It's empty if the project
+	// isn't locked.
+	LockPath string
+}
+
 // IndexData holds the data for rendering the index page
 type IndexData struct {
-	Locks           []LockIndexData
+	Locks   []LockIndexData
+	Freezes []FreezeIndexData
+	Pulls   []PullIndexData
+	// Repos are the repos that pulls can be filtered by.
+	Repos []string
+	// Statuses are the project statuses that pulls can be filtered by.
+	Statuses []string
+	// RepoFilter and StatusFilter are the filters that were applied to Pulls.
+	RepoFilter      string
+	StatusFilter    string
 	AtlantisVersion string
 	// CleanedBasePath is the path Atlantis is accessible at externally. If
 	// not using a path-based proxy, this will be an empty string. Never ends
@@ -83,11 +132,32 @@ var indexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
   </nav>
   <div class="navbar-spacer"></div>
   <br>
+  <section>
+    <p class="title-heading small"><strong>Apply Freezes</strong></p>
+    {{ if .Freezes }}
+    {{ range .Freezes }}
+      <div class="twelve columns content freeze-row">
+        <div class="list-title">Applies frozen for {{ .Scope }}: {{ .Reason }}</div>
+        <div class="list-timestamp"><span class="heading-font-size">Set by {{ .CreatedBy }} at {{ .CreatedAt }}{{ if .Scheduled }} until {{ .End }}{{ end }}</span></div>
+        {{ if not .Scheduled }}<input class="button js-unfreeze" type="button" data-id="{{ .ID }}" value="Unfreeze">{{ end }}
+      </div>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">Applies aren't frozen.</p>
+    {{ end }}
+    <form id="freeze">
+      <input type="text" name="repo" placeholder="owner/repo (all if empty)">
+      <input type="text" name="projects" placeholder="Project glob (all if empty)">
+      <input type="text" name="reason" placeholder="Reason" required>
+      <input class="button button-primary" type="submit" value="Freeze Applies">
+    </form>
+  </section>
   <section>
     <p class="title-heading small"><strong>Locks</strong></p>
     {{ if .Locks }}
     {{ $basePath := .CleanedBasePath }}
     {{ range .Locks }}
+      <input type="checkbox" class="js-lock-select" value="{{.LockKey}}" title="Select for bulk unlock">
       <a href="{{ $basePath }}{{.LockPath}}">
         <div class="twelve columns button content lock-row">
         <div class="list-title">{{.RepoFullName}} - <span class="heading-font-size">#{{.PullNum}}</span></div>
@@ -96,14 +166,166 @@ var indexTemplate = template.Must(template.New("index.html.tmpl").Parse(`
         </div>
       </a>
     {{ end }}
+    <p class="title-heading small"><strong>Bulk Unlock</strong></p>
+    <p>Unlocks the selected locks, or every lock that matches all the filters that are set.</p>
+    <form id="bulkUnlock">
+      <input type="text" name="repo" placeholder="owner/repo">
+      <input type="number" name="pull" placeholder="Pull request #" min="1">
+      <input type="text" name="workspace" placeholder="Workspace">
+      <input type="number" name="older_than_days" placeholder="Older than (days)" min="1">
+      <input class="button" type="button" id="previewUnlock" value="Preview">
+      <input class="button button-primary" type="button" id="bulkUnlockSubmit" value="Unlock">
+    </form>
     {{ else }}
     <p class="placeholder">No locks found.</p>
     {{ end }}
   </section>
+  <section>
+    <p class="title-heading small"><strong>Pull Requests</strong></p>
+    <form method="GET" action="{{ .CleanedBasePath }}/">
+      <select name="repo">
+        <option value="">All repos</option>
+        {{ $repoFilter := .RepoFilter }}
+        {{ range .Repos }}<option value="{{ . }}"{{ if eq . $repoFilter }} selected{{ end }}>{{ . }}</option>{{ end }}
+      </select>
+      <select name="status">
+        <option value="">All statuses</option>
+        {{ $statusFilter := .StatusFilter }}
+        {{ range $status := .Statuses }}<option value="{{ $status }}"{{ if eq $status $statusFilter }} selected{{ end }}>{{ $status }}</option>{{ end }}
+      </select>
+      <input class="button" type="submit" value="Filter">
+      <input class="button" type="button" id="findClosedPulls" value="Find Closed Pull Requests">
+    </form>
+    {{ if .Pulls }}
+    {{ $basePath := .CleanedBasePath }}
+    {{ range .Pulls }}
+      <div class="twelve columns content pull-row" data-hostname="{{ .Hostname }}" data-repo="{{ .RepoFullName }}" data-num="{{ .PullNum }}">
+        <div class="list-title"><a href="{{ .PullURL }}" target="_blank">{{ .RepoFullName }} - <span class="heading-font-size">#{{ .PullNum }}</span></a>{{ if .Author }} by {{ .Author }}{{ end }}</div>
+        <div class="list-timestamp"><span class="heading-font-size">{{ if not .UpdatedAt.IsZero }}Updated {{ .UpdatedAt.Format "02-01-2006 15:04:05" }}{{ end }}</span></div>
+        <div class="pull-closed" style="display: none">
+          <code>Closed</code> <input class="button js-remove-pull" type="button" value="Remove">
+        </div>
+        <table class="u-full-width">
+          <tbody>
+          {{ range .Projects }}
+            <tr>
+              <td>{{ if .ProjectName }}project: <code>{{ .ProjectName }}</code> {{ end }}dir: <code>{{ .RepoRelDir }}</code> workspace: <code>{{ .Workspace }}</code></td>
+              <td><code>{{ .Status }}</code></td>
+              <td>{{ if .LockPath }}<a href="{{ $basePath }}{{ .LockPath }}">Locked</a>{{ end }}</td>
+            </tr>
+          {{ end }}
+          </tbody>
+        </table>
+      </div>
+    {{ end }}
+    {{ else }}
+    <p class="placeholder">No pull requests found.</p>
+    {{ end }}
+  </section>
 </div>
 <footer>
 v{{ .AtlantisVersion }}
 </footer>
+<script>
+  $("#freeze").submit(function(event) {
+    event.preventDefault();
+    $.ajax({
+      url: "{{ .CleanedBasePath }}/freezes",
+      type: "POST",
+      contentType: "application/json",
+      data: JSON.stringify({
+        repo: $(this).find("[name=repo]").val(),
+        projects: $(this).find("[name=projects]").val(),
+        reason: $(this).find("[name=reason]").val()
+      }),
+      success: function() {
+        window.location.reload();
+      },
+      error: function(xhr) {
+        alert(xhr.responseText);
+      }
+    });
+  });
+
+  $(".js-unfreeze").click(function() {
+    $.ajax({
+      url: "{{ .CleanedBasePath }}/freezes?" + $.param({id: $(this).data("id")}),
+      type: "DELETE",
+      success: function() {
+        window.location.reload();
+      }
+    });
+  });
+
+  function bulkUnlock(dryRun, success) {
+    var form = $("#bulkUnlock");
+    var data = {
+      ids: $(".js-lock-select:checked").map(function() {
+        return $(this).val();
+      }).get(),
+      repo: form.find("[name=repo]").val(),
+      pull_num: parseInt(form.find("[name=pull]").val(), 10) || 0,
+      workspace: form.find("[name=workspace]").val(),
+      older_than_days: parseInt(form.find("[name=older_than_days]").val(), 10) || 0,
+      dry_run: dryRun
+    };
+    $.ajax({
+      url: "{{ .CleanedBasePath }}/locks/unlock",
+      type: "POST",
+      contentType: "application/json",
+      data: JSON.stringify(data),
+      success: success,
+      error: function(xhr) {
+        alert(xhr.responseText);
+      }
+    });
+  }
+
+  $("#previewUnlock").click(function() {
+    bulkUnlock(true, function(response) {
+      alert(response);
+    });
+  });
+
+  $("#bulkUnlockSubmit").click(function() {
+    // Always show what's going to be unlocked before unlocking it.
+    bulkUnlock(true, function(preview) {
+      if (!confirm(preview + "\nUnlock and discard these plans?")) {
+        return;
+      }
+      bulkUnlock(false, function() {
+        window.location.replace("{{ .CleanedBasePath }}/?discard=true");
+      });
+    });
+  });
+
+  $("#findClosedPulls").click(function() {
+    var btn = $(this);
+    btn.prop("disabled", true).val("Checking...");
+    $.getJSON("{{ .CleanedBasePath }}/pulls/closed", function(closed) {
+      $.each(closed, function(i, pull) {
+        $(".pull-row").filter(function() {
+          var row = $(this);
+          return row.data("hostname") === pull.hostname && row.data("repo") === pull.repo && row.data("num") === pull.num;
+        }).find(".pull-closed").show();
+      });
+      btn.val(closed.length + " closed pull requests found");
+    }).fail(function() {
+      btn.prop("disabled", false).val("Find Closed Pull Requests");
+    });
+  });
+
+  $(".js-remove-pull").click(function() {
+    var row = $(this).closest(".pull-row");
+    $.ajax({
+      url: "{{ .CleanedBasePath }}/pulls?" + $.param({hostname: row.data("hostname"), repo: row.data("repo"), num: row.data("num")}),
+      type: "DELETE",
+      success: function() {
+        row.remove();
+      }
+    });
+  });
+</script>
 </body>
 </html>
 `))
@@ -226,3 +448,84 @@ v{{ .AtlantisVersion }}
 </body>
 </html>
 `))
+
+// JobDetailData holds the fields needed to display the output of a job.
+type JobDetailData struct {
+	JobID        string
+	RepoFullName string
+	PullNum      int
+	Command      string
+	Username     string
+	Status       string
+	StartTime    time.Time
+	// Live is true if the output should be streamed. Otherwise Output is the
+	// job's full output.
+	Live            bool
+	Output          string
+	AtlantisVersion string
+	// CleanedBasePath is the path Atlantis is accessible at externally. If
+	// not using a path-based proxy, this will be an empty string. Never ends
+	// in a '/' (hence "cleaned").
+	CleanedBasePath string
+}
+
+var jobTemplate = template.Must(template.New("job.html.tmpl").Parse(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>atlantis</title>
+  <meta name="description" content="">
+  <meta name="author" content="">
+  <meta name="viewport" content="width=device-width, initial-scale=1">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/normalize.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/skeleton.css">
+  <link rel="stylesheet" href="{{ .CleanedBasePath }}/static/css/custom.css">
+  <link rel="icon" type="image/png" href="{{ .CleanedBasePath }}/static/images/atlantis-icon.png">
+  <style>
+    #output { max-height: 70vh; overflow: auto; white-space: pre-wrap; }
+  </style>
+</head>
+<body>
+  <div class="container">
+    <section class="header">
+    <a title="atlantis" href="{{ .CleanedBasePath }}/"><img src="{{ .CleanedBasePath }}/static/images/atlantis-icon.png"/></a>
+    <p class="title-heading">atlantis</p>
+    <p class="title-heading"><strong>{{.RepoFullName}} #{{.PullNum}}</strong> <code>{{.Command}}</code></p>
+    </section>
+    <div class="navbar-spacer"></div>
+    <br>
+    <section>
+      <h6><code>Status</code>: <strong id="status">{{.Status}}</strong></h6>
+      {{ if .Username }}<h6><code>Triggered By</code>: <strong>{{.Username}}</strong></h6>{{ end }}
+      <h6><code>Started</code>: <strong>{{.StartTime.Format "02-01-2006 15:04:05"}}</strong></h6>
+      <pre id="output"><code>{{.Output}}</code></pre>
+    </section>
+  </div>
+<footer>
+v{{ .AtlantisVersion }}
+</footer>
+{{ if .Live }}
+<script>
+  var output = document.querySelector("#output");
+  var code = output.querySelector("code");
+  var statusEl = document.querySelector("#status");
+  var source = new EventSource("{{ .CleanedBasePath }}/jobs/{{ .JobID }}/stream");
+
+  source.onmessage = function(event) {
+    // Only scroll if the user hasn't scrolled up to read earlier output.
+    var atBottom = output.scrollTop + output.clientHeight >= output.scrollHeight - 5;
+    code.appendChild(document.createTextNode(event.data + "\n"));
+    if (atBottom) {
+      output.scrollTop = output.scrollHeight;
+    }
+  };
+  source.addEventListener("done", function() {
+    source.close();
+    statusEl.textContent = "finished";
+  });
+</script>
+{{ end }}
+</body>
+</html>
+`))